@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"strings"
@@ -14,15 +15,24 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/klinux/velero-dashboard/internal/account"
 	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
 	"github.com/klinux/velero-dashboard/internal/config"
+	"github.com/klinux/velero-dashboard/internal/drill"
 	"github.com/klinux/velero-dashboard/internal/handler"
 	"github.com/klinux/velero-dashboard/internal/middleware"
+	"github.com/klinux/velero-dashboard/internal/migration"
 	"github.com/klinux/velero-dashboard/internal/notification"
+	"github.com/klinux/velero-dashboard/internal/session"
+	"github.com/klinux/velero-dashboard/internal/token"
 	"github.com/klinux/velero-dashboard/internal/ws"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func main() {
@@ -36,9 +46,15 @@ func main() {
 
 	// Initialize cluster store (SQLite or Kubernetes ConfigMap+Secrets)
 	storeConfig := cluster.StoreConfig{
-		StorageType:   cfg.Cluster.StorageType,
-		DBPath:        cfg.Cluster.DBPath,
-		EncryptionKey: cfg.Cluster.EncryptionKey,
+		StorageType: cfg.Cluster.StorageType,
+		DBPath:      cfg.Cluster.DBPath,
+		Keyring: cluster.KeyringConfig{
+			Key:        cfg.Cluster.EncryptionKey,
+			Keys:       cfg.Cluster.EncryptionKeys,
+			KeyFile:    cfg.Cluster.EncryptionKeyFile,
+			ActiveKey:  cfg.Cluster.EncryptionActiveKey,
+			Production: cfg.Production(),
+		},
 		Namespace:     cfg.Cluster.Namespace,
 		ConfigMapName: cfg.Cluster.ConfigMapName,
 	}
@@ -105,15 +121,122 @@ func main() {
 		}
 	}()
 
-	handlers := handler.NewHandlers(clusterMgr, hub, notifMgr, zapLogger)
+	// Reconcile webhooks defined by labelled Secrets/ConfigMaps (GitOps mode)
+	go func() {
+		if err := notifMgr.StartReconciliation(ctx); err != nil && ctx.Err() == nil {
+			zapLogger.Warn("Webhook reconciliation failed", zap.Error(err))
+		}
+	}()
+
+	// Initialize migration jobs (same storage type) and resume in-flight ones
+	migrationStore, err := migration.NewStore(migration.StoreConfig{
+		StorageType: cfg.Cluster.StorageType,
+		DBPath:      cfg.Cluster.DBPath,
+		Namespace:   cfg.Cluster.Namespace,
+	}, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Failed to create migration store", zap.Error(err))
+	}
+	migrationRunner := migration.NewRunner(migrationStore, func(id string) (migration.Client, error) {
+		client, err := clusterMgr.GetClient(id)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}, hub, zapLogger)
+	if err := migrationRunner.Start(ctx); err != nil {
+		zapLogger.Error("Failed to resume migration jobs", zap.Error(err))
+	}
+
+	// Initialize restore drills (same storage type) and schedule them
+	drillStore, err := drill.NewStore(drill.StoreConfig{
+		StorageType: cfg.Cluster.StorageType,
+		DBPath:      cfg.Cluster.DBPath,
+		Namespace:   cfg.Cluster.Namespace,
+	}, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Failed to create drill store", zap.Error(err))
+	}
+	drillRunner := drill.NewRunner(drillStore, func(id string) (drill.Client, error) {
+		client, err := clusterMgr.GetClient(id)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}, notification.NewAdapter(notifMgr), hub, zapLogger)
+	if err := drillRunner.Start(ctx); err != nil {
+		zapLogger.Error("Failed to schedule restore drills", zap.Error(err))
+	}
+
+	// Initialize API tokens (same storage type)
+	tokenStore, err := token.NewStore(token.StoreConfig{
+		StorageType: cfg.Cluster.StorageType,
+		DBPath:      cfg.Cluster.DBPath,
+		Namespace:   cfg.Cluster.Namespace,
+	}, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Failed to create token store", zap.Error(err))
+	}
+	tokens := token.NewManager(tokenStore, zapLogger)
+
+	// Initialize login sessions (same storage type); expired ones are pruned
+	sessionStore, err := session.NewStore(session.StoreConfig{
+		StorageType: cfg.Cluster.StorageType,
+		DBPath:      cfg.Cluster.DBPath,
+		Namespace:   cfg.Cluster.Namespace,
+	}, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Failed to create session store", zap.Error(err))
+	}
+	sessions := session.NewManager(sessionStore, cfg.Auth.RefreshExpiration, zapLogger)
+	sessions.Start(ctx)
+
+	// Initialize local users of basic auth mode (same storage type)
+	userStore, err := account.NewStore(account.StoreConfig{
+		StorageType: cfg.Cluster.StorageType,
+		DBPath:      cfg.Cluster.DBPath,
+		Namespace:   cfg.Cluster.Namespace,
+	}, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Failed to create user store", zap.Error(err))
+	}
+	users := account.NewManager(userStore, account.PasswordPolicy{MinLength: cfg.Auth.PasswordMinLength}, zapLogger)
+
+	handlers := handler.NewHandlers(clusterMgr, hub, notifMgr, migrationRunner, drillRunner, tokens, sessions, users, zapLogger)
 
 	// Initialize auth provider
 	jwtMgr := auth.NewJWTManager(cfg.Auth.JWTSecret, cfg.Auth.JWTExpiration)
-	authProvider, err := initAuthProvider(cfg.Auth, jwtMgr, zapLogger)
+	jwtMgr.SetAPITokens(tokens)
+	jwtMgr.SetSessions(sessions)
+	authProvider, err := initAuthProvider(ctx, cfg, jwtMgr, users, zapLogger)
 	if err != nil {
 		zapLogger.Fatal("Failed to initialize auth provider", zap.Error(err))
 	}
 	zapLogger.Info("Auth mode configured", zap.String("mode", authProvider.Mode()))
+	// Personal tokens follow their owner's current role where the provider
+	// can look users up
+	if resolver, ok := authProvider.(auth.UserResolver); ok {
+		tokens.SetUsers(resolver)
+	}
+
+	// Reconcile users defined by labelled Secrets (GitOps mode)
+	if authProvider.Mode() == "basic" {
+		go func() {
+			if err := users.StartReconciliation(ctx); err != nil && ctx.Err() == nil {
+				zapLogger.Warn("User reconciliation failed", zap.Error(err))
+			}
+		}()
+	}
+
+	// Role bindings grant roles on specific clusters and namespaces
+	bindings, err := auth.LoadRoleBindings(cfg.Auth.RoleBindingsFile)
+	if err != nil {
+		zapLogger.Fatal("Failed to load role bindings", zap.Error(err))
+	}
+	authorizer := auth.NewAuthorizer(bindings)
+	if len(bindings) > 0 {
+		zapLogger.Info("Role bindings loaded", zap.Int("count", len(bindings)))
+	}
 
 	app := fiber.New(fiber.Config{
 		AppName:      "Velero Dashboard API",
@@ -154,14 +277,34 @@ func main() {
 		return c.JSON(fiber.Map{"mode": authProvider.Mode()})
 	})
 
+	// The global limiter skips failed requests; credential checks get one
+	// that counts them: 10 failures per 15 minutes per IP
+	failureLimiter := middleware.NewFailureLimiter(10, 15*time.Minute)
+	app.Post("/api/auth/login", failureLimiter)
+	app.Post("/api/auth/password", failureLimiter)
+
 	// Provider-specific public routes (login, callback, etc.)
 	authProvider.SetupRoutes(app.Group("/api"))
 
 	// ── Protected routes ────────────────────────────────────────
 
-	api := app.Group("/api", authProvider.Middleware())
+	api := app.Group("/api", authProvider.Middleware(), authorizer.Middleware())
+
+	// Viewer-level routes (any authenticated user). Handlers only return
+	// what the user's role and role bindings grant.
+	api.Get("/auth/access", authorizer.Access)
+	api.Get("/clusters", handlers.Cluster.List)
+	api.Get("/clusters/access", handlers.Cluster.Access)
+
+	// API tokens: users manage their own, global admins everyone's
+	api.Get("/tokens", handlers.Token.List)
+	api.Post("/tokens", handlers.Token.Create)
+	api.Delete("/tokens/:id", handlers.Token.Revoke)
+
+	// Login sessions: users see and end their own, global admins everyone's
+	api.Get("/sessions", handlers.Session.List)
+	api.Delete("/sessions/:id", handlers.Session.Revoke)
 
-	// Viewer-level routes (any authenticated user)
 	api.Get("/dashboard/stats", handlers.Dashboard.Stats)
 
 	api.Get("/backups", handlers.Backup.List)
@@ -174,6 +317,13 @@ func main() {
 	api.Get("/restores/:name/logs", handlers.Restore.Logs)
 	api.Get("/restores/:name", handlers.Restore.Get)
 
+	api.Get("/migrations", handlers.Migration.List)
+	api.Get("/migrations/:id", handlers.Migration.Get)
+
+	api.Get("/drills", handlers.Drill.List)
+	api.Get("/drills/runs", handlers.Drill.AllRuns)
+	api.Get("/drills/:id/runs", handlers.Drill.Runs)
+
 	api.Get("/schedules", handlers.Schedule.List)
 	api.Get("/schedules/:name", handlers.Schedule.Get)
 
@@ -181,24 +331,46 @@ func main() {
 	api.Get("/settings/snapshot-locations", handlers.Settings.SnapshotLocations)
 	api.Get("/settings/server-info", handlers.Settings.ServerInfo)
 
-	// Operator-level routes (operator + admin)
-	operator := api.Group("", auth.RequireRole(auth.RoleOperator))
+	// Operator-level routes (operator + admin, globally or through a role
+	// binding; handlers check the cluster and namespaces)
+	operator := api.Group("", auth.RequireAnyRole(auth.RoleOperator))
 	operator.Post("/backups", handlers.Backup.Create)
 	operator.Delete("/backups/:name", handlers.Backup.Delete)
 	operator.Post("/restores", handlers.Restore.Create)
 	operator.Delete("/restores/:name", handlers.Restore.Delete)
 	operator.Post("/restores/cross-cluster", handlers.CrossCluster.CreateCrossClusterRestore)
+	operator.Post("/migrations", handlers.Migration.Create)
+	operator.Post("/migrations/:id/cancel", handlers.Migration.Cancel)
+	operator.Post("/drills", handlers.Drill.Create)
+	operator.Put("/drills/:id", handlers.Drill.Update)
+	operator.Delete("/drills/:id", handlers.Drill.Delete)
+	operator.Post("/drills/:id/run", handlers.Drill.Run)
 	operator.Post("/schedules", handlers.Schedule.Create)
 	operator.Patch("/schedules/:name", handlers.Schedule.Update)
 	operator.Delete("/schedules/:name", handlers.Schedule.Delete)
 
-	// Admin-level routes (admin only)
+	// Storage locations (admin on the whole cluster, globally or through a
+	// role binding). Registered before the admin group, whose middleware
+	// would otherwise run first.
+	clusterAdmin := api.Group("", auth.RequireAnyRole(auth.RoleAdmin))
+	clusterAdmin.Post("/settings/backup-locations", handlers.Settings.CreateBackupLocation)
+	clusterAdmin.Patch("/settings/backup-locations/:name", handlers.Settings.UpdateBackupLocation)
+	clusterAdmin.Delete("/settings/backup-locations/:name", handlers.Settings.DeleteBackupLocation)
+	clusterAdmin.Post("/settings/snapshot-locations", handlers.Settings.CreateSnapshotLocation)
+	clusterAdmin.Patch("/settings/snapshot-locations/:name", handlers.Settings.UpdateSnapshotLocation)
+	clusterAdmin.Delete("/settings/snapshot-locations/:name", handlers.Settings.DeleteSnapshotLocation)
+
+	// Admin-level routes (global admin only)
 	admin := api.Group("", auth.RequireRole(auth.RoleAdmin))
 
 	// Cluster management (admin only)
-	admin.Get("/clusters", handlers.Cluster.List)
+	admin.Get("/clusters/groups", handlers.Cluster.Groups)
+	admin.Get("/clusters/encryption", handlers.Cluster.EncryptionStatus)
+	admin.Post("/clusters/encryption/reencrypt", handlers.Cluster.Reencrypt)
 	admin.Get("/clusters/:id", handlers.Cluster.Get)
 	admin.Post("/clusters", handlers.Cluster.Create)
+	admin.Post("/clusters/import/contexts", handlers.Cluster.ImportContexts)
+	admin.Post("/clusters/import", handlers.Cluster.Import)
 	admin.Patch("/clusters/:id", handlers.Cluster.Update)
 	admin.Delete("/clusters/:id", handlers.Cluster.Delete)
 
@@ -209,13 +381,21 @@ func main() {
 	admin.Delete("/notifications/webhooks/:id", handlers.Notification.DeleteWebhook)
 	admin.Post("/notifications/webhooks/:id/test", handlers.Notification.TestWebhook)
 
-	// Storage locations
-	admin.Post("/settings/backup-locations", handlers.Settings.CreateBackupLocation)
-	admin.Patch("/settings/backup-locations/:name", handlers.Settings.UpdateBackupLocation)
-	admin.Delete("/settings/backup-locations/:name", handlers.Settings.DeleteBackupLocation)
-	admin.Post("/settings/snapshot-locations", handlers.Settings.CreateSnapshotLocation)
-	admin.Patch("/settings/snapshot-locations/:name", handlers.Settings.UpdateSnapshotLocation)
-	admin.Delete("/settings/snapshot-locations/:name", handlers.Settings.DeleteSnapshotLocation)
+	// Offboarding: end a user's sessions and revoke their personal tokens
+	admin.Delete("/users/:username/sessions", handlers.Session.RevokeUser)
+
+	// Local users of basic auth mode
+	if authProvider.Mode() == "basic" {
+		admin.Get("/users", handlers.User.List)
+		admin.Post("/users", handlers.User.Create)
+		admin.Patch("/users/:username", handlers.User.Update)
+		admin.Delete("/users/:username", handlers.User.Delete)
+		admin.Post("/users/:username/password", handlers.User.ResetPassword)
+	}
+
+	// Configuration backup and restore
+	admin.Post("/bundle/export", handlers.Bundle.Export)
+	admin.Post("/bundle/import", handlers.Bundle.Import)
 
 	// ── WebSocket ───────────────────────────────────────────────
 
@@ -225,7 +405,9 @@ func main() {
 		}
 		return fiber.ErrUpgradeRequired
 	})
-	app.Get("/ws", websocket.New(handlers.WS.Handle))
+	app.Get("/ws", authProvider.Middleware(), authorizer.Middleware(), websocket.New(handlers.WS.Handle, websocket.Config{
+		Subprotocols: []string{auth.WebSocketProtocol},
+	}))
 
 	// ── Graceful shutdown ───────────────────────────────────────
 
@@ -237,6 +419,11 @@ func main() {
 		cancel()
 		clusterMgr.Shutdown() // Stop all cluster connections and informers
 		_ = notifStore.Close()
+		_ = migrationStore.Close()
+		_ = drillStore.Close()
+		_ = tokens.Close()
+		_ = sessions.Close()
+		_ = users.Close()
 		if err := app.Shutdown(); err != nil {
 			zapLogger.Error("Shutdown error", zap.Error(err))
 		}
@@ -251,13 +438,31 @@ func main() {
 	}
 }
 
-func initAuthProvider(cfg config.AuthConfig, jwtMgr *auth.JWTManager, logger *zap.Logger) (auth.AuthProvider, error) {
+func initAuthProvider(ctx context.Context, appCfg *config.Config, jwtMgr *auth.JWTManager, users *account.Manager, logger *zap.Logger) (auth.AuthProvider, error) {
+	cfg := appCfg.Auth
 	switch cfg.Mode {
 	case "basic":
 		if cfg.JWTSecret == "" {
 			logger.Warn("JWT_SECRET not set for basic auth — using auto-generated secret (sessions won't survive restarts)")
 		}
-		return auth.NewBasicProvider(cfg.Users, jwtMgr, logger)
+		provider, err := auth.NewBasicProvider(cfg.Users, jwtMgr, logger)
+		if err != nil {
+			return nil, err
+		}
+		// AUTH_USERS only seeds the user store; users are managed through
+		// the API from then on.
+		seeded, err := users.Seed(ctx, auth.ParseUsers(cfg.Users))
+		if err != nil {
+			return nil, err
+		}
+		if seeded > 0 {
+			logger.Info("Seeded users from AUTH_USERS", zap.Int("count", seeded))
+		}
+		if existing, err := users.List(ctx); err == nil && len(existing) == 0 {
+			logger.Warn("AUTH_MODE=basic but no users exist — set AUTH_USERS to seed an admin")
+		}
+		provider.SetUsers(users)
+		return provider, nil
 
 	case "oidc":
 		if cfg.JWTSecret == "" {
@@ -275,11 +480,81 @@ func initAuthProvider(cfg config.AuthConfig, jwtMgr *auth.JWTManager, logger *za
 			FrontendURL:    cfg.FrontendURL,
 		}, jwtMgr, logger)
 
+	case "kubernetes":
+		if cfg.JWTSecret == "" {
+			logger.Warn("JWT_SECRET not set for kubernetes auth — using auto-generated secret (sessions won't survive restarts)")
+		}
+		client, err := managementClient(appCfg.Kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		resource := schema.ParseGroupResource(cfg.KubernetesResource)
+		return auth.NewKubernetesProvider(auth.KubernetesConfig{
+			RoleSource:     cfg.KubernetesRoleSource,
+			AdminGroups:    splitTrim(cfg.KubernetesAdminGroups),
+			OperatorGroups: splitTrim(cfg.KubernetesOperatorGroups),
+			DefaultRole:    cfg.KubernetesDefaultRole,
+			Audiences:      splitTrim(cfg.KubernetesAudiences),
+			AccessGroup:    resource.Group,
+			AccessResource: resource.Resource,
+			CacheTTL:       cfg.KubernetesCacheTTL,
+		}, client, jwtMgr, logger)
+
+	case "proxy":
+		return auth.NewProxyProvider(auth.ProxyConfig{
+			TrustedCIDRs:   splitTrim(cfg.ProxyTrustedCIDRs),
+			UserHeader:     cfg.ProxyUserHeader,
+			EmailHeader:    cfg.ProxyEmailHeader,
+			GroupsHeader:   cfg.ProxyGroupsHeader,
+			AdminGroups:    splitTrim(cfg.ProxyAdminGroups),
+			OperatorGroups: splitTrim(cfg.ProxyOperatorGroups),
+			DefaultRole:    cfg.ProxyDefaultRole,
+		}, jwtMgr, logger)
+
+	case "ldap":
+		return auth.NewLDAPProvider(auth.LDAPConfig{
+			URL:                cfg.LDAPURL,
+			StartTLS:           cfg.LDAPStartTLS,
+			CAFile:             cfg.LDAPCAFile,
+			InsecureSkipVerify: cfg.LDAPInsecureSkipVerify,
+			Timeout:            cfg.LDAPTimeout,
+			BindDN:             cfg.LDAPBindDN,
+			BindPassword:       cfg.LDAPBindPassword,
+			UserDNTemplate:     cfg.LDAPUserDNTemplate,
+			UserBaseDN:         cfg.LDAPUserBaseDN,
+			UserFilter:         cfg.LDAPUserFilter,
+			UsernameAttribute:  cfg.LDAPUsernameAttribute,
+			EmailAttribute:     cfg.LDAPEmailAttribute,
+			GroupBaseDN:        cfg.LDAPGroupBaseDN,
+			GroupFilter:        cfg.LDAPGroupFilter,
+			GroupNameAttribute: cfg.LDAPGroupNameAttribute,
+			NestedGroups:       cfg.LDAPNestedGroups,
+			AdminGroups:        splitTrim(cfg.LDAPAdminGroups),
+			OperatorGroups:     splitTrim(cfg.LDAPOperatorGroups),
+			DefaultRole:        cfg.LDAPDefaultRole,
+		}, jwtMgr, logger)
+
 	default:
 		return auth.NewNoneProvider(), nil
 	}
 }
 
+// managementClient connects to the cluster of KUBECONFIG, or the one the
+// dashboard runs in.
+func managementClient(kubeconfig string) (kubernetes.Interface, error) {
+	var restCfg *rest.Config
+	var err error
+	if kubeconfig != "" {
+		restCfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		restCfg, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get management cluster config: %w", err)
+	}
+	return kubernetes.NewForConfig(restCfg)
+}
+
 func splitTrim(s string) []string {
 	parts := strings.Split(s, ",")
 	result := make([]string, 0, len(parts))
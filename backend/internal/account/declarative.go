@@ -0,0 +1,240 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// definitionSelector selects Secrets holding user definitions. Each Secret
+// defines one user with the keys:
+//
+//	username      login name (defaults to the Secret name)
+//	passwordHash  bcrypt hash of the password
+//	role          none, viewer, operator or admin (defaults to viewer)
+//	email         optional email address
+//	disabled      "true" to disable
+const definitionSelector = "app.kubernetes.io/name=velero-dashboard,app.kubernetes.io/component=user-definition"
+
+// definitionResyncInterval re-reads definitions periodically, in case a
+// watch event was missed.
+const definitionResyncInterval = 5 * time.Minute
+
+// parseDefinition reads a user definition from a Secret's data.
+func parseDefinition(name string, data map[string]string) (*User, error) {
+	u := &User{
+		Username:     strings.TrimSpace(data["username"]),
+		Email:        strings.TrimSpace(data["email"]),
+		Role:         strings.TrimSpace(data["role"]),
+		Disabled:     strings.TrimSpace(data["disabled"]) == "true",
+		Source:       SourceDeclarative,
+		Definition:   "Secret/" + name,
+		PasswordHash: strings.TrimSpace(data["passwordHash"]),
+	}
+	if u.Username == "" {
+		u.Username = name
+	}
+	if err := validateUsername(u.Username); err != nil {
+		return nil, err
+	}
+	if u.Role == "" {
+		u.Role = auth.RoleViewer
+	}
+	if err := validateRole(u.Role); err != nil {
+		return nil, err
+	}
+	if u.PasswordHash == "" {
+		return nil, fmt.Errorf("passwordHash is required")
+	}
+	if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+		return nil, fmt.Errorf("passwordHash is not a bcrypt hash: %w", err)
+	}
+	return u, nil
+}
+
+// StartReconciliation keeps users declared by labelled Secrets in sync. It
+// is only available with Kubernetes storage and blocks until ctx is done.
+func (m *Manager) StartReconciliation(ctx context.Context) error {
+	k8sStore, ok := m.store.(*jsonstore.K8sStore[User])
+	if !ok {
+		m.logger.Info("User definitions not available (not using Kubernetes storage)")
+		return nil
+	}
+
+	m.logger.Info("Starting user reconciliation (watching for user definitions)")
+	return m.watchDefinitions(ctx, k8sStore.Clientset(), k8sStore.Namespace())
+}
+
+// watchDefinitions watches labelled Secrets and reconciles the users they
+// define into the store. Declared users are read-only through the API.
+func (m *Manager) watchDefinitions(ctx context.Context, clientset kubernetes.Interface, namespace string) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		watcher, err := clientset.CoreV1().Secrets(namespace).Watch(ctx, metav1.ListOptions{LabelSelector: definitionSelector})
+		if err != nil {
+			m.logger.Error("Failed to watch user definition Secrets", zap.Error(err))
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		// Initial reconciliation on watch start
+		m.reconcileDefinitions(ctx, clientset, namespace)
+
+		m.logger.Info("Started watching user definitions",
+			zap.String("namespace", namespace),
+			zap.String("labelSelector", definitionSelector))
+
+		func() {
+			defer watcher.Stop()
+			ticker := time.NewTicker(definitionResyncInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					m.reconcileDefinitions(ctx, clientset, namespace)
+				case event, ok := <-watcher.ResultChan():
+					if !ok {
+						m.logger.Warn("User definition watch channel closed, restarting")
+						return
+					}
+					switch event.Type {
+					case watch.Added, watch.Deleted, watch.Modified:
+						m.reconcileDefinitions(ctx, clientset, namespace)
+					}
+				}
+			}
+		}()
+
+		// Brief pause before reconnecting
+		time.Sleep(time.Second)
+	}
+}
+
+// reconcileDefinitions makes the declarative users in the store match the
+// definitions. Users created through the API or AUTH_USERS are never taken
+// over. Returns true if changes were made.
+func (m *Manager) reconcileDefinitions(ctx context.Context, clientset kubernetes.Interface, namespace string) bool {
+	defs, invalid, err := m.loadDefinitions(ctx, clientset, namespace)
+	if err != nil {
+		m.logger.Error("Failed to load user definitions", zap.Error(err))
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users, err := m.store.List(ctx)
+	if err != nil {
+		m.logger.Error("Failed to list users for reconciliation", zap.Error(err))
+		return false
+	}
+	existing := make(map[string]*User, len(users))
+	for _, u := range users {
+		existing[u.Username] = u
+	}
+
+	changed := false
+	now := m.now()
+	for username, def := range defs {
+		u := existing[username]
+		switch {
+		case u == nil:
+			def.CreatedAt, def.UpdatedAt, def.PasswordChangedAt = now, now, &now
+			if err := m.store.Create(ctx, def); err != nil {
+				m.logger.Error("Failed to create declared user", zap.String("username", username), zap.Error(err))
+				continue
+			}
+		case !u.managed():
+			m.logger.Warn("User definition names an existing user not managed declaratively, skipping",
+				zap.String("source", def.Definition), zap.String("username", username))
+			continue
+		case u.Definition == def.Definition && u.Email == def.Email && u.Role == def.Role &&
+			u.Disabled == def.Disabled && u.PasswordHash == def.PasswordHash:
+			continue
+		default:
+			if u.PasswordHash != def.PasswordHash {
+				u.PasswordHash = def.PasswordHash
+				u.PasswordChangedAt = &now
+			}
+			u.Email, u.Role, u.Disabled, u.Definition = def.Email, def.Role, def.Disabled, def.Definition
+			u.UpdatedAt = now
+			if err := m.store.Update(ctx, u); err != nil {
+				m.logger.Error("Failed to update declared user", zap.String("username", username), zap.Error(err))
+				continue
+			}
+		}
+		changed = true
+	}
+
+	for _, u := range users {
+		if u.managed() && defs[u.Username] == nil && !invalid[u.Definition] {
+			if err := m.store.Delete(ctx, u.Username); err != nil {
+				m.logger.Error("Failed to delete declared user", zap.String("username", u.Username), zap.Error(err))
+				continue
+			}
+			changed = true
+		}
+	}
+
+	if changed {
+		m.logger.Info("User definitions reconciled", zap.Int("managed", len(defs)))
+	}
+	return changed
+}
+
+// loadDefinitions reads all valid user definitions, keyed by username.
+// Invalid definitions are logged and reported separately by source, so that
+// a typo leaves the previously reconciled user in place instead of removing
+// it. When two Secrets declare the same username, the first by name wins.
+func (m *Manager) loadDefinitions(ctx context.Context, clientset kubernetes.Interface, namespace string) (map[string]*User, map[string]bool, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{LabelSelector: definitionSelector})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list Secrets: %w", err)
+	}
+	items := secrets.Items
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	defs := make(map[string]*User)
+	invalid := make(map[string]bool)
+	for i := range items {
+		source := "Secret/" + items[i].Name
+		def, err := parseDefinition(items[i].Name, secretStringData(&items[i]))
+		if err == nil && defs[def.Username] != nil {
+			err = fmt.Errorf("username %q is already declared by %s", def.Username, defs[def.Username].Definition)
+		}
+		if err != nil {
+			m.logger.Warn("Invalid user definition, skipping", zap.String("source", source), zap.Error(err))
+			invalid[source] = true
+			continue
+		}
+		defs[def.Username] = def
+	}
+	return defs, invalid, nil
+}
+
+func secretStringData(secret *corev1.Secret) map[string]string {
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	return data
+}
@@ -0,0 +1,131 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var definitionLabels = map[string]string{
+	"app.kubernetes.io/name":      "velero-dashboard",
+	"app.kubernetes.io/component": "user-definition",
+}
+
+func TestParseDefinition(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("declared-password"), bcrypt.MinCost)
+
+	def, err := parseDefinition("ops", map[string]string{"passwordHash": string(hash) + "\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Username != "ops" || def.Role != auth.RoleViewer || def.Source != SourceDeclarative || def.Definition != "Secret/ops" {
+		t.Errorf("unexpected defaults: %+v", def)
+	}
+
+	invalid := map[string]map[string]string{
+		"bad username": {"username": "ops team", "passwordHash": string(hash)},
+		"bad role":     {"role": "owner", "passwordHash": string(hash)},
+		"no hash":      {"role": "admin"},
+		"plain text":   {"passwordHash": "declared-password"},
+	}
+	for name, data := range invalid {
+		if _, err := parseDefinition("ops", data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestReconcileDefinitions(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	cs := fake.NewSimpleClientset()
+	secrets := cs.CoreV1().Secrets("velero")
+	hash, _ := bcrypt.GenerateFromPassword([]byte("declared-password"), bcrypt.MinCost)
+
+	if _, err := m.Create(ctx, CreateRequest{Username: "admin", Role: auth.RoleAdmin, Password: "initial-password"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]map[string]string{
+		"ops":      {"username": "ops@example.com", "passwordHash": string(hash), "role": "operator"},
+		"takeover": {"username": "admin", "passwordHash": string(hash), "role": "viewer"},
+	} {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: definitionLabels}}
+		secret.Data = map[string][]byte{}
+		for k, v := range data {
+			secret.Data[k] = []byte(v)
+		}
+		if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !m.reconcileDefinitions(ctx, cs, "velero") {
+		t.Fatal("expected reconciliation to change the store")
+	}
+	if m.reconcileDefinitions(ctx, cs, "velero") {
+		t.Error("second reconciliation should be a no-op")
+	}
+
+	ops, err := m.Get(ctx, "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ops.Role != auth.RoleOperator || ops.Definition != "Secret/ops" || ops.MustChangePassword {
+		t.Errorf("unexpected declared user: %+v", ops)
+	}
+	if _, err := m.Authenticate(ctx, "ops@example.com", "declared-password"); err != nil {
+		t.Errorf("declared user can't sign in: %v", err)
+	}
+	// Users created otherwise are never taken over
+	if admin, _ := m.Get(ctx, "admin"); admin.Role != auth.RoleAdmin || admin.Source != SourceAPI {
+		t.Errorf("API user was taken over: %+v", admin)
+	}
+
+	// Declared users are read-only through the API
+	role := auth.RoleAdmin
+	if _, err := m.Update(ctx, "ops@example.com", UpdateRequest{Role: &role}); !errors.Is(err, ErrManaged) {
+		t.Errorf("expected ErrManaged on update, got %v", err)
+	}
+	if _, err := m.ResetPassword(ctx, "ops@example.com", "another-password"); !errors.Is(err, ErrManaged) {
+		t.Errorf("expected ErrManaged on password reset, got %v", err)
+	}
+	if _, err := m.ChangePassword(ctx, "ops@example.com", "declared-password", "another-password"); !errors.Is(err, auth.ErrPasswordPolicy) {
+		t.Errorf("expected a policy error on password change, got %v", err)
+	}
+	if err := m.Delete(ctx, "ops@example.com"); !errors.Is(err, ErrManaged) {
+		t.Errorf("expected ErrManaged on delete, got %v", err)
+	}
+
+	// Editing the definition updates the user
+	secret, _ := secrets.Get(ctx, "ops", metav1.GetOptions{})
+	secret.Data["disabled"] = []byte("true")
+	_, _ = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	m.reconcileDefinitions(ctx, cs, "velero")
+	if ops, _ := m.Get(ctx, "ops@example.com"); !ops.Disabled {
+		t.Error("definition change not applied")
+	}
+
+	// A broken definition keeps the previously reconciled user
+	secret.Data["role"] = []byte("owner")
+	_, _ = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	m.reconcileDefinitions(ctx, cs, "velero")
+	if ops, err := m.Get(ctx, "ops@example.com"); err != nil || ops.Role != auth.RoleOperator {
+		t.Errorf("invalid definition should leave the user untouched: %+v %v", ops, err)
+	}
+
+	// Removing the definition removes the user
+	_ = secrets.Delete(ctx, "ops", metav1.DeleteOptions{})
+	m.reconcileDefinitions(ctx, cs, "velero")
+	if _, err := m.Get(ctx, "ops@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := m.Get(ctx, "admin"); err != nil {
+		t.Errorf("API user removed: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Manager creates, authenticates and administers local users. It implements
+// auth.LocalUsers.
+type Manager struct {
+	store  Store
+	policy PasswordPolicy
+	logger *zap.Logger
+	now    func() time.Time
+	cost   int
+
+	// mu serializes changes, so the last admin can't be demoted or deleted
+	// by two requests at once.
+	mu sync.Mutex
+	// seeded holds the usernames AUTH_USERS lists. They can't be deleted,
+	// since the next Seed would bring them back.
+	seeded map[string]bool
+
+	dummyOnce sync.Once
+	dummyHash []byte
+}
+
+// NewManager creates a user manager enforcing policy on new passwords.
+func NewManager(store Store, policy PasswordPolicy, logger *zap.Logger) *Manager {
+	return &Manager{
+		store:  store,
+		policy: policy,
+		logger: logger,
+		now:    time.Now,
+		cost:   bcrypt.DefaultCost,
+	}
+}
+
+// Seed creates the users of AUTH_USERS that don't exist yet. Existing users
+// are left alone, so changes made through the API survive restarts. Seeded
+// users can be disabled but not deleted while AUTH_USERS lists them.
+func (m *Manager) Seed(ctx context.Context, users map[string]auth.LocalUser) (int, error) {
+	names := make([]string, 0, len(users))
+	seeded := make(map[string]bool, len(users))
+	for name := range users {
+		names = append(names, name)
+		seeded[users[name].Username] = true
+	}
+	sort.Strings(names)
+
+	m.mu.Lock()
+	m.seeded = seeded
+	m.mu.Unlock()
+
+	created := 0
+	now := m.now()
+	for _, name := range names {
+		seed := users[name]
+		if err := validateUsername(seed.Username); err != nil {
+			m.logger.Warn("Skipping AUTH_USERS entry", zap.String("username", seed.Username), zap.Error(err))
+			continue
+		}
+		err := m.store.Create(ctx, &User{
+			Username:     seed.Username,
+			Email:        seed.Email,
+			Role:         seed.Role,
+			Source:       SourceSeed,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+			PasswordHash: seed.PasswordHash,
+		})
+		if errors.Is(err, ErrExists) {
+			continue
+		}
+		if err != nil {
+			return created, fmt.Errorf("failed to seed user %s: %w", seed.Username, err)
+		}
+		created++
+	}
+	return created, nil
+}
+
+// Authenticate returns an enabled user whose password matches.
+func (m *Manager) Authenticate(ctx context.Context, username, password string) (*auth.LocalUser, error) {
+	u, err := m.verify(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return u.local(), nil
+}
+
+// verify checks a user's password. Unknown users cost a bcrypt comparison
+// too, so response times don't reveal which usernames exist.
+func (m *Manager) verify(ctx context.Context, username, password string) (*User, error) {
+	u, err := m.store.Get(ctx, username)
+	if errors.Is(err, ErrNotFound) {
+		_ = bcrypt.CompareHashAndPassword(m.dummy(), []byte(password))
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil || u.Disabled {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+func (m *Manager) dummy() []byte {
+	m.dummyOnce.Do(func() {
+		m.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("velero-dashboard"), m.cost)
+	})
+	return m.dummyHash
+}
+
+// Lookup returns an enabled user.
+func (m *Manager) Lookup(ctx context.Context, username string) (*auth.LocalUser, error) {
+	u, err := m.store.Get(ctx, username)
+	if errors.Is(err, ErrNotFound) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Disabled {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return u.local(), nil
+}
+
+// ChangePassword replaces a user's password after checking the current one.
+func (m *Manager) ChangePassword(ctx context.Context, username, current, next string) (*auth.LocalUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.verify(ctx, username, current)
+	if err != nil {
+		return nil, err
+	}
+	if u.managed() {
+		return nil, fmt.Errorf("%w: the password is managed declaratively (%s)", auth.ErrPasswordPolicy, u.Definition)
+	}
+	if current == next {
+		return nil, fmt.Errorf("%w: choose a password different from the current one", auth.ErrPasswordPolicy)
+	}
+	if err := m.setPassword(u, next); err != nil {
+		return nil, err
+	}
+	u.MustChangePassword = false
+	if err := m.store.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return u.local(), nil
+}
+
+// Get returns a user.
+func (m *Manager) Get(ctx context.Context, username string) (*User, error) {
+	return m.store.Get(ctx, username)
+}
+
+// List returns all users.
+func (m *Manager) List(ctx context.Context) ([]*User, error) {
+	return m.store.List(ctx)
+}
+
+// Create adds a user with a password chosen by an admin, which the user must
+// change on first login unless req says otherwise.
+func (m *Manager) Create(ctx context.Context, req CreateRequest) (*User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	now := m.now()
+	u := &User{
+		Username:           req.Username,
+		Email:              req.Email,
+		Role:               req.Role,
+		MustChangePassword: req.MustChangePassword == nil || *req.MustChangePassword,
+		Source:             SourceAPI,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := m.setPassword(u, req.Password); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.store.Create(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Update changes a user's email, role or disabled flag. Declarative users
+// are refused with ErrManaged, as are password resets and deletes.
+func (m *Manager) Update(ctx context.Context, username string, req UpdateRequest) (*User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.store.Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if u.managed() {
+		return nil, ErrManaged
+	}
+	wasAdmin := u.enabledAdmin()
+	if req.Email != nil {
+		u.Email = *req.Email
+	}
+	if req.Role != nil {
+		u.Role = *req.Role
+	}
+	if req.Disabled != nil {
+		u.Disabled = *req.Disabled
+	}
+	if wasAdmin && !u.enabledAdmin() {
+		if err := m.keepAdmin(ctx, username); err != nil {
+			return nil, err
+		}
+	}
+	u.UpdatedAt = m.now()
+	if err := m.store.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// ResetPassword sets a password chosen by an admin, which the user must
+// change on their next login.
+func (m *Manager) ResetPassword(ctx context.Context, username, password string) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.store.Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if u.managed() {
+		return nil, ErrManaged
+	}
+	if err := m.setPassword(u, password); err != nil {
+		return nil, err
+	}
+	u.MustChangePassword = true
+	if err := m.store.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Delete removes a user. Users AUTH_USERS lists are refused with ErrSeeded,
+// declarative users with ErrManaged.
+func (m *Manager) Delete(ctx context.Context, username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, err := m.store.Get(ctx, username)
+	if err != nil {
+		return err
+	}
+	if u.managed() {
+		return ErrManaged
+	}
+	if m.seeded[u.Username] {
+		return ErrSeeded
+	}
+	if u.enabledAdmin() {
+		if err := m.keepAdmin(ctx, username); err != nil {
+			return err
+		}
+	}
+	return m.store.Delete(ctx, username)
+}
+
+// keepAdmin returns ErrLastAdmin unless an enabled admin other than username
+// exists.
+func (m *Manager) keepAdmin(ctx context.Context, username string) error {
+	users, err := m.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Username != username && u.enabledAdmin() {
+			return nil
+		}
+	}
+	return ErrLastAdmin
+}
+
+// setPassword checks password against the policy and stores its hash in u.
+func (m *Manager) setPassword(u *User, password string) error {
+	if err := m.policy.Check(u.Username, password); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), m.cost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	now := m.now()
+	u.PasswordHash = string(hash)
+	u.PasswordChangedAt = &now
+	u.UpdatedAt = now
+	return nil
+}
+
+// Close closes the underlying store.
+func (m *Manager) Close() error {
+	return m.store.Close()
+}
@@ -0,0 +1,192 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "users.db"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	m := NewManager(store, PasswordPolicy{MinLength: 12}, zap.NewNop())
+	m.cost = bcrypt.MinCost
+	return m
+}
+
+func TestManagerSeed(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("seeded-password"), bcrypt.MinCost)
+
+	seed := auth.ParseUsers("admin:" + string(hash) + ":admin,bad user:" + string(hash) + ":viewer")
+	n, err := m.Seed(ctx, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("seeded %d users, want 1", n)
+	}
+	user, err := m.Authenticate(ctx, "admin", "seeded-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != auth.RoleAdmin || user.MustChangePassword {
+		t.Errorf("seeded user = %+v", user)
+	}
+
+	// Seeding again must not undo changes made through the API.
+	if _, err := m.Update(ctx, "admin", UpdateRequest{Email: ptr("admin@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Seed(ctx, seed); err != nil || n != 0 {
+		t.Fatalf("reseed = %d, %v", n, err)
+	}
+	if u, _ := m.Get(ctx, "admin"); u.Email != "admin@example.com" || u.Source != SourceSeed {
+		t.Errorf("user after reseed = %+v", u)
+	}
+}
+
+func TestManagerDeleteSeeded(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("seeded-password"), bcrypt.MinCost)
+
+	seed := auth.ParseUsers("admin:" + string(hash) + ":admin,ops:" + string(hash) + ":operator")
+	if _, err := m.Seed(ctx, seed); err != nil {
+		t.Fatal(err)
+	}
+
+	// A user AUTH_USERS still lists would come back on the next restart.
+	if err := m.Delete(ctx, "ops"); !errors.Is(err, ErrSeeded) {
+		t.Fatalf("expected ErrSeeded, got %v", err)
+	}
+	if _, err := m.Update(ctx, "ops", UpdateRequest{Disabled: ptr(true)}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Seed(ctx, seed); err != nil || n != 0 {
+		t.Fatalf("reseed = %d, %v", n, err)
+	}
+	if u, _ := m.Get(ctx, "ops"); !u.Disabled {
+		t.Errorf("disabled seed user re-enabled by reseed: %+v", u)
+	}
+
+	// Once removed from AUTH_USERS it can be deleted for good.
+	seed = auth.ParseUsers("admin:" + string(hash) + ":admin")
+	if _, err := m.Seed(ctx, seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Delete(ctx, "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Seed(ctx, seed); err != nil || n != 0 {
+		t.Fatalf("reseed = %d, %v", n, err)
+	}
+	if _, err := m.Get(ctx, "ops"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted user came back after reseed: %v", err)
+	}
+}
+
+func TestManagerForcedPasswordChange(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+
+	if _, err := m.Create(ctx, CreateRequest{Username: "bob", Role: auth.RoleViewer, Password: "short"}); !errors.Is(err, auth.ErrPasswordPolicy) {
+		t.Errorf("expected ErrPasswordPolicy for a short password, got %v", err)
+	}
+	if _, err := m.Create(ctx, CreateRequest{Username: "bob", Role: auth.RoleViewer, Password: "bob-is-the-best"}); !errors.Is(err, auth.ErrPasswordPolicy) {
+		t.Errorf("expected ErrPasswordPolicy for a password containing the username, got %v", err)
+	}
+	created, err := m.Create(ctx, CreateRequest{Username: "bob", Role: auth.RoleViewer, Password: "temporary-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.MustChangePassword || created.Public().PasswordHash != "" {
+		t.Errorf("created = %+v", created)
+	}
+	if _, err := m.Create(ctx, CreateRequest{Username: "bob", Role: auth.RoleViewer, Password: "temporary-secret"}); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists, got %v", err)
+	}
+
+	user, err := m.Authenticate(ctx, "bob", "temporary-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.MustChangePassword {
+		t.Error("admin-chosen passwords must be changed on first login")
+	}
+
+	if _, err := m.ChangePassword(ctx, "bob", "wrong-password", "a-better-secret"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := m.ChangePassword(ctx, "bob", "temporary-secret", "temporary-secret"); !errors.Is(err, auth.ErrPasswordPolicy) {
+		t.Errorf("expected ErrPasswordPolicy for an unchanged password, got %v", err)
+	}
+	user, err = m.ChangePassword(ctx, "bob", "temporary-secret", "a-better-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.MustChangePassword {
+		t.Error("changing the password must clear MustChangePassword")
+	}
+	if _, err := m.Authenticate(ctx, "bob", "temporary-secret"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("old password must stop working, got %v", err)
+	}
+
+	if _, err := m.ResetPassword(ctx, "bob", "another-temporary"); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := m.Lookup(ctx, "bob"); user == nil || !user.MustChangePassword {
+		t.Errorf("reset must force a password change, got %+v", user)
+	}
+
+	if _, err := m.Update(ctx, "bob", UpdateRequest{Disabled: ptr(true)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Authenticate(ctx, "bob", "another-temporary"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("disabled users must not authenticate, got %v", err)
+	}
+	if _, err := m.Lookup(ctx, "bob"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("disabled users must not be looked up, got %v", err)
+	}
+}
+
+func TestManagerLastAdmin(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	noChange := false
+	for _, name := range []string{"alice", "carol"} {
+		if _, err := m.Create(ctx, CreateRequest{Username: name, Role: auth.RoleAdmin, Password: "correct-horse-battery", MustChangePassword: &noChange}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := m.Update(ctx, "alice", UpdateRequest{Role: ptr(auth.RoleViewer)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Update(ctx, "carol", UpdateRequest{Disabled: ptr(true)}); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected ErrLastAdmin when disabling the last admin, got %v", err)
+	}
+	if err := m.Delete(ctx, "carol"); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected ErrLastAdmin when deleting the last admin, got %v", err)
+	}
+	if err := m.Delete(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Delete(ctx, "alice"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,128 @@
+package account
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Store persists users. Create returns ErrExists for a taken username.
+type Store interface {
+	Create(ctx context.Context, u *User) error
+	Get(ctx context.Context, username string) (*User, error)
+	List(ctx context.Context) ([]*User, error)
+	Update(ctx context.Context, u *User) error
+	Delete(ctx context.Context, username string) error
+	Close() error
+}
+
+// StoreConfig holds configuration for creating a user store.
+type StoreConfig struct {
+	StorageType string // "auto", "kubernetes", "sqlite"
+	DBPath      string // For SQLite
+	Namespace   string // For Kubernetes
+}
+
+// NewStore creates a user store based on the storage type.
+func NewStore(cfg StoreConfig, logger *zap.Logger) (Store, error) {
+	storageType := cfg.StorageType
+	if storageType == "" || storageType == "auto" {
+		if isInCluster() {
+			storageType = "kubernetes"
+		} else {
+			storageType = "sqlite"
+		}
+	}
+
+	switch storageType {
+	case "kubernetes":
+		return NewK8sStore(cfg.Namespace, logger)
+	case "sqlite":
+		dbPath := cfg.DBPath
+		if dbPath == "" {
+			dbPath = "./users.db"
+		}
+		return NewSQLiteStore(dbPath, logger)
+	default:
+		return nil, fmt.Errorf("unknown user storage type: %s", storageType)
+	}
+}
+
+// records stores each user in its own Secret, or as a row of the
+// users table.
+var records = jsonstore.Config[User]{
+	Kind:        "user",
+	Key:         func(u *User) string { return u.Username },
+	Less:        func(a, b *User) bool { return a.Username < b.Username },
+	ErrNotFound: ErrNotFound,
+	ErrExists:   ErrExists,
+
+	Component: "user",
+	Prefix:    "velero-dashboard-user-",
+	Name:      hashUsername,
+	Secret:    true,
+
+	Schema:     "users",
+	Migrations: sqliteMigrations,
+	Table:      "users",
+	KeyColumn:  "username",
+	CreatedAt:  func(u *User) time.Time { return u.CreatedAt },
+}
+
+// sqliteMigrations is the user store schema history. Never edit an
+// applied migration; append a new one instead.
+var sqliteMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create users",
+		Up: migrate.Exec(
+			`CREATE TABLE IF NOT EXISTS users (
+				username TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`,
+		),
+	},
+}
+
+// NewK8sStore creates a store keeping users in Secrets.
+func NewK8sStore(namespace string, logger *zap.Logger) (Store, error) {
+	store, err := jsonstore.NewK8sStore(namespace, records, logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func newK8sStore(clientset kubernetes.Interface, namespace string, logger *zap.Logger) *jsonstore.K8sStore[User] {
+	return jsonstore.NewK8sStoreForClient(clientset, namespace, records, logger)
+}
+
+// NewSQLiteStore creates a store keeping users in SQLite.
+func NewSQLiteStore(dbPath string, logger *zap.Logger) (Store, error) {
+	store, err := jsonstore.NewSQLiteStore(dbPath, records, logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func isInCluster() bool {
+	_, err := rest.InClusterConfig()
+	return err == nil
+}
+
+// hashUsername names user Secrets after a hash of the username, since
+// usernames may contain characters Secret names can't.
+func hashUsername(username string) string {
+	sum := sha256.Sum256([]byte(username))
+	return hex.EncodeToString(sum[:16])
+}
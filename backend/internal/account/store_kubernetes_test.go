@@ -0,0 +1,58 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newK8sStore(fake.NewSimpleClientset(), "velero", zap.NewNop())
+
+	now := time.Now()
+	alice := &User{Username: "alice@example.com", Role: "admin", PasswordHash: "hash", CreatedAt: now}
+	bob := &User{Username: "Bob", Role: "viewer", PasswordHash: "hash", CreatedAt: now}
+	for _, u := range []*User{bob, alice} {
+		if err := store.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Create(ctx, alice); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists, got %v", err)
+	}
+
+	alice.Disabled = true
+	if err := store.Update(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Disabled || got.Role != "admin" {
+		t.Errorf("user = %+v", got)
+	}
+
+	users, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Username != "Bob" {
+		t.Errorf("users = %+v", users)
+	}
+
+	if err := store.Delete(ctx, "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "alice@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Update(ctx, alice); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+)
+
+var (
+	// ErrNotFound is returned when a user doesn't exist.
+	ErrNotFound = errors.New("user not found")
+	// ErrExists is returned when creating a user whose name is taken.
+	ErrExists = errors.New("user already exists")
+	// ErrLastAdmin is returned for changes that would leave no enabled
+	// admin.
+	ErrLastAdmin = errors.New("at least one enabled admin is required")
+	// ErrSeeded is returned when deleting a user AUTH_USERS still lists,
+	// which the next restart would create again.
+	ErrSeeded = errors.New("user is listed in AUTH_USERS: disable it, or remove it from AUTH_USERS and restart before deleting it")
+	// ErrManaged is returned when changing a user defined declaratively.
+	ErrManaged = errors.New("user is managed declaratively and read-only")
+)
+
+// Source tells where a user was created.
+type Source string
+
+const (
+	// SourceSeed users were imported from AUTH_USERS on startup.
+	SourceSeed Source = "seed"
+	// SourceAPI users were created through the API.
+	SourceAPI Source = "api"
+	// SourceDeclarative users are defined by labelled Secrets and are
+	// read-only through the API.
+	SourceDeclarative Source = "declarative"
+)
+
+// User is a local user of basic auth mode. Only a bcrypt hash of the
+// password is stored.
+type User struct {
+	Username           string     `json:"username"`
+	Email              string     `json:"email,omitempty"`
+	Role               string     `json:"role"`
+	Disabled           bool       `json:"disabled"`
+	MustChangePassword bool       `json:"mustChangePassword"`
+	Source             Source     `json:"source"`
+	Definition         string     `json:"definition,omitempty"` // Kind/name of the defining object, for declarative users
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+	PasswordChangedAt  *time.Time `json:"passwordChangedAt,omitempty"`
+	PasswordHash       string     `json:"passwordHash,omitempty"`
+}
+
+// Public returns the user without its password hash, as the API lists it.
+func (u *User) Public() *User {
+	cp := *u
+	cp.PasswordHash = ""
+	return &cp
+}
+
+// local returns the user as the auth package sees it.
+func (u *User) local() *auth.LocalUser {
+	return &auth.LocalUser{
+		Username:           u.Username,
+		Email:              u.Email,
+		PasswordHash:       u.PasswordHash,
+		Role:               u.Role,
+		MustChangePassword: u.MustChangePassword,
+	}
+}
+
+// managed reports whether the user is defined declaratively.
+func (u *User) managed() bool {
+	return u.Source == SourceDeclarative
+}
+
+// enabledAdmin reports whether the user counts towards the last admin.
+func (u *User) enabledAdmin() bool {
+	return u.Role == auth.RoleAdmin && !u.Disabled
+}
+
+// CreateRequest is the payload for creating a user. The password is
+// temporary: the user must change it on first login unless
+// MustChangePassword is explicitly false.
+type CreateRequest struct {
+	Username           string `json:"username"`
+	Email              string `json:"email,omitempty"`
+	Role               string `json:"role"`
+	Password           string `json:"password"`
+	MustChangePassword *bool  `json:"mustChangePassword,omitempty"`
+}
+
+// Validate checks the request, except for the password policy.
+func (r *CreateRequest) Validate() error {
+	if err := validateUsername(r.Username); err != nil {
+		return err
+	}
+	return validateRole(r.Role)
+}
+
+// UpdateRequest changes a user. Nil fields are left alone.
+type UpdateRequest struct {
+	Email    *string `json:"email,omitempty"`
+	Role     *string `json:"role,omitempty"`
+	Disabled *bool   `json:"disabled,omitempty"`
+}
+
+// Validate checks the request.
+func (r *UpdateRequest) Validate() error {
+	if r.Role != nil {
+		return validateRole(*r.Role)
+	}
+	return nil
+}
+
+func validateUsername(username string) error {
+	if username == "" {
+		return errors.New("username is required")
+	}
+	if utf8.RuneCountInString(username) > 64 {
+		return errors.New("username must be at most 64 characters")
+	}
+	// ":" would let a user pose as a service token ("token:<name>") in
+	// role bindings, "," and ":" couldn't be written back to AUTH_USERS.
+	if strings.ContainsAny(username, ":,/ \t\r\n") {
+		return errors.New("username must not contain spaces, slashes, colons or commas")
+	}
+	return nil
+}
+
+func validateRole(role string) error {
+	if role != auth.RoleViewer && role != auth.RoleOperator && role != auth.RoleAdmin && role != auth.RoleNone {
+		return errors.New("role must be none, viewer, operator or admin")
+	}
+	return nil
+}
+
+// PasswordPolicy is what new passwords must satisfy. Length matters more
+// than composition, so only a minimum length is configurable.
+type PasswordPolicy struct {
+	MinLength int
+}
+
+// maxPasswordBytes is the most bcrypt hashes; longer passwords would be
+// silently truncated.
+const maxPasswordBytes = 72
+
+// Check returns an error wrapping auth.ErrPasswordPolicy when password is
+// not acceptable for username.
+func (p PasswordPolicy) Check(username, password string) error {
+	switch {
+	case utf8.RuneCountInString(password) < p.MinLength:
+		return fmt.Errorf("%w: use at least %d characters", auth.ErrPasswordPolicy, p.MinLength)
+	case len(password) > maxPasswordBytes:
+		return fmt.Errorf("%w: use at most %d bytes", auth.ErrPasswordPolicy, maxPasswordBytes)
+	case strings.Contains(strings.ToLower(password), strings.ToLower(username)):
+		return fmt.Errorf("%w: must not contain the username", auth.ErrPasswordPolicy)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// APITokenPrefix starts every long-lived API token, which tells them apart
+// from session JWTs.
+const APITokenPrefix = "vdt_"
+
+// APITokenVerifier resolves an API token to the user it acts as. It returns
+// an error for unknown, revoked and expired tokens.
+type APITokenVerifier interface {
+	Verify(ctx context.Context, token string) (*UserInfo, error)
+}
+
+// UserResolver re-reads a user outside of a login. Personal API tokens use
+// it to act with their owner's current role and groups rather than those
+// the owner had when creating the token. It returns an error for users that
+// were removed or disabled.
+type UserResolver interface {
+	ResolveUser(ctx context.Context, user UserInfo) (UserInfo, error)
+}
+
+// IsAPIToken reports whether token looks like an API token rather than a
+// JWT.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// SetAPITokens makes RequireAuth accept API tokens alongside JWTs.
+func (m *JWTManager) SetAPITokens(v APITokenVerifier) {
+	m.apiTokens = v
+}
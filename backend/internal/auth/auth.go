@@ -1,18 +1,61 @@
 package auth
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 const (
 	RoleViewer   = "viewer"
 	RoleOperator = "operator"
 	RoleAdmin    = "admin"
+
+	// RoleNone grants nothing globally; the user only gets what their role
+	// bindings grant.
+	RoleNone = "none"
 )
 
 // UserInfo represents an authenticated user.
 type UserInfo struct {
-	Username string `json:"username"`
-	Email    string `json:"email,omitempty"`
-	Role     string `json:"role"`
+	Username string   `json:"username"`
+	Email    string   `json:"email,omitempty"`
+	Role     string   `json:"role"`
+	Groups   []string `json:"groups,omitempty"`
+
+	// UID and Extra carry a Kubernetes identity's remaining attributes, so
+	// that RBAC re-checks see the same user as the original token review.
+	UID   string              `json:"uid,omitempty"`
+	Extra map[string][]string `json:"extra,omitempty"`
+
+	// Set when the request carries an API token: the token's ID, the
+	// clusters it is limited to (empty for all), the highest role it may
+	// exercise, whatever the user's role and role bindings grant, and when
+	// it expires (zero for never).
+	TokenID        string    `json:"tokenId,omitempty"`
+	Clusters       []string  `json:"clusters,omitempty"`
+	RoleLimit      string    `json:"-"`
+	TokenExpiresAt time.Time `json:"-"`
+
+	// SessionID is the browser session the request belongs to, if any.
+	SessionID string `json:"-"`
+}
+
+// GlobalRole returns the role the user holds on every cluster. Tokens
+// limited to some clusters hold none.
+func (u *UserInfo) GlobalRole() string {
+	if len(u.Clusters) > 0 {
+		return RoleNone
+	}
+	return u.Role
+}
+
+// limitRole caps role at the user's RoleLimit, if any.
+func (u *UserInfo) limitRole(role string) string {
+	if u.RoleLimit != "" && roleLevels[role] > roleLevels[u.RoleLimit] {
+		return u.RoleLimit
+	}
+	return role
 }
 
 // AuthProvider is the interface for all auth backends.
@@ -22,12 +65,21 @@ type AuthProvider interface {
 	Middleware() fiber.Handler
 }
 
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
 // RoleHierarchy returns true if userRole >= requiredRole.
 func RoleHierarchy(userRole, requiredRole string) bool {
-	levels := map[string]int{
-		RoleViewer:   1,
-		RoleOperator: 2,
-		RoleAdmin:    3,
+	return roleLevels[userRole] >= roleLevels[requiredRole]
+}
+
+// higherRole returns the more privileged of two roles.
+func higherRole(a, b string) string {
+	if roleLevels[b] > roleLevels[a] {
+		return b
 	}
-	return levels[userRole] >= levels[requiredRole]
+	return a
 }
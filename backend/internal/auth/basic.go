@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,26 +10,58 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-type localUser struct {
+var (
+	// ErrInvalidCredentials is returned for unknown users, wrong passwords
+	// and disabled users alike.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrPasswordPolicy is wrapped by errors describing why a new password
+	// was rejected.
+	ErrPasswordPolicy = errors.New("password does not meet the policy")
+	// ErrPasswordChangeRequired is returned on login when the user must pick
+	// a new password first.
+	ErrPasswordChangeRequired = errors.New("password change required")
+)
+
+// LocalUser is a basic-mode user.
+type LocalUser struct {
 	Username     string
+	Email        string
 	PasswordHash string
 	Role         string
+
+	// MustChangePassword is set for passwords chosen by an admin. Such users
+	// can't log in until they change it.
+	MustChangePassword bool
 }
 
-// BasicProvider handles username/password authentication via env-configured users.
+// LocalUsers looks up and authenticates basic-mode users.
+type LocalUsers interface {
+	// Authenticate returns the user once password matches, or
+	// ErrInvalidCredentials.
+	Authenticate(ctx context.Context, username, password string) (*LocalUser, error)
+	// Lookup returns an enabled user, or ErrInvalidCredentials.
+	Lookup(ctx context.Context, username string) (*LocalUser, error)
+	// ChangePassword replaces a user's password after checking the current
+	// one, and clears MustChangePassword.
+	ChangePassword(ctx context.Context, username, current, next string) (*LocalUser, error)
+}
+
+// BasicProvider handles username/password authentication. Users come from
+// AUTH_USERS, or from a user store once SetUsers was called.
 type BasicProvider struct {
-	users  map[string]localUser
+	users  LocalUsers
 	jwtMgr *JWTManager
 	logger *zap.Logger
 }
 
 // NewBasicProvider creates a basic auth provider. usersEnv format: "user1:bcrypt_hash:role,user2:bcrypt_hash:role"
 func NewBasicProvider(usersEnv string, jwtMgr *JWTManager, logger *zap.Logger) (*BasicProvider, error) {
-	users := ParseUsers(usersEnv)
-	if len(users) == 0 {
-		logger.Warn("AUTH_MODE=basic but no valid users configured in AUTH_USERS")
-	}
-	return &BasicProvider{users: users, jwtMgr: jwtMgr, logger: logger}, nil
+	return &BasicProvider{users: staticUsers(ParseUsers(usersEnv)), jwtMgr: jwtMgr, logger: logger}, nil
+}
+
+// SetUsers replaces the AUTH_USERS users with a user store.
+func (p *BasicProvider) SetUsers(users LocalUsers) {
+	p.users = users
 }
 
 func (p *BasicProvider) Mode() string {
@@ -36,7 +70,9 @@ func (p *BasicProvider) Mode() string {
 
 func (p *BasicProvider) SetupRoutes(router fiber.Router) {
 	router.Post("/auth/login", p.login)
+	router.Post("/auth/password", p.changePassword)
 	router.Get("/auth/me", RequireAuth(p.jwtMgr, p.logger), p.me)
+	sessionRoutes(router, p.jwtMgr, p.reload, p.logger)
 }
 
 func (p *BasicProvider) Middleware() fiber.Handler {
@@ -56,18 +92,64 @@ func (p *BasicProvider) login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "username and password are required"})
 	}
 
-	user, ok := p.users[req.Username]
-	if !ok {
-		p.logger.Debug("Login attempt for unknown user", zap.String("username", req.Username))
+	user, err := p.users.Authenticate(c.Context(), req.Username, req.Password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		p.logger.Debug("Failed login", zap.String("username", req.Username))
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid credentials"})
 	}
+	if err != nil {
+		p.logger.Error("Failed to authenticate user", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to authenticate"})
+	}
+	if user.MustChangePassword {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":                  ErrPasswordChangeRequired.Error(),
+			"passwordChangeRequired": true,
+		})
+	}
+	return p.startSession(c, user)
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		p.logger.Debug("Failed password check", zap.String("username", req.Username))
+// changePassword lets users change their password, proving who they are
+// with the current one. That also works before the first login, when an
+// admin-chosen password must be replaced. All other sessions of the user end
+// and a new one starts.
+func (p *BasicProvider) changePassword(c *fiber.Ctx) error {
+	var req struct {
+		Username        string `json:"username"`
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Username == "" || req.CurrentPassword == "" || req.NewPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "username, currentPassword and newPassword are required"})
+	}
+
+	user, err := p.users.ChangePassword(c.Context(), req.Username, req.CurrentPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid credentials"})
+	case errors.Is(err, ErrPasswordPolicy):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		p.logger.Error("Failed to change password", zap.String("username", req.Username), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to change password"})
 	}
+	p.logger.Info("Password changed", zap.String("username", user.Username))
 
-	token, err := p.jwtMgr.Generate(UserInfo{Username: user.Username, Role: user.Role})
+	if p.jwtMgr.sessions != nil {
+		if _, err := p.jwtMgr.sessions.RevokeUser(c.Context(), user.Username); err != nil {
+			p.logger.Warn("Failed to end sessions after password change", zap.String("username", user.Username), zap.Error(err))
+		}
+	}
+	return p.startSession(c, user)
+}
+
+// startSession responds with an access token for user.
+func (p *BasicProvider) startSession(c *fiber.Ctx, user *LocalUser) error {
+	token, err := p.jwtMgr.login(c, UserInfo{Username: user.Username, Email: user.Email, Role: user.Role})
 	if err != nil {
 		p.logger.Error("Failed to generate JWT", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate token"})
@@ -80,6 +162,28 @@ func (p *BasicProvider) login(c *fiber.Ctx) error {
 	})
 }
 
+// reload re-reads a session's user, so removed, disabled and reset users
+// can't refresh their sessions and role changes apply on the next refresh.
+func (p *BasicProvider) reload(info UserInfo) (UserInfo, error) {
+	return p.ResolveUser(context.Background(), info)
+}
+
+// ResolveUser re-reads a local user. Users with a pending password change
+// are rejected until they chose a new password, so an admin's reset also
+// stops their personal API tokens.
+func (p *BasicProvider) ResolveUser(ctx context.Context, info UserInfo) (UserInfo, error) {
+	user, err := p.users.Lookup(ctx, info.Username)
+	if err != nil {
+		return UserInfo{}, err
+	}
+	if user.MustChangePassword {
+		return UserInfo{}, ErrPasswordChangeRequired
+	}
+	info.Email = user.Email
+	info.Role = user.Role
+	return info, nil
+}
+
 func (p *BasicProvider) me(c *fiber.Ctx) error {
 	user := GetUser(c)
 	if user == nil {
@@ -88,9 +192,36 @@ func (p *BasicProvider) me(c *fiber.Ctx) error {
 	return c.JSON(user)
 }
 
+// staticUsers are the users of AUTH_USERS, when no user store is set.
+type staticUsers map[string]LocalUser
+
+func (s staticUsers) Authenticate(_ context.Context, username, password string) (*LocalUser, error) {
+	user, ok := s[username]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+func (s staticUsers) Lookup(_ context.Context, username string) (*LocalUser, error) {
+	user, ok := s[username]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+func (s staticUsers) ChangePassword(context.Context, string, string, string) (*LocalUser, error) {
+	return nil, errors.New("passwords from AUTH_USERS can't be changed")
+}
+
 // ParseUsers parses "user1:bcrypt_hash:role,user2:bcrypt_hash:role" into a map.
-func ParseUsers(env string) map[string]localUser {
-	users := make(map[string]localUser)
+// Role "none" leaves the user with only what their role bindings grant.
+func ParseUsers(env string) map[string]LocalUser {
+	users := make(map[string]LocalUser)
 	if env == "" {
 		return users
 	}
@@ -100,10 +231,10 @@ func ParseUsers(env string) map[string]localUser {
 			continue
 		}
 		username, hash, role := parts[0], parts[1], parts[2]
-		if role != RoleViewer && role != RoleOperator && role != RoleAdmin {
+		if role != RoleViewer && role != RoleOperator && role != RoleAdmin && role != RoleNone {
 			role = RoleViewer
 		}
-		users[username] = localUser{
+		users[username] = LocalUser{
 			Username:     username,
 			PasswordHash: hash,
 			Role:         role,
@@ -0,0 +1,367 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+const AuthorizerContextKey = "auth_authorizer"
+
+// RoleBinding grants a role on a set of clusters and, optionally, only on
+// some of their namespaces. Bindings add to a user's global role, they never
+// take anything away.
+type RoleBinding struct {
+	Name       string          `json:"name"`
+	Role       string          `json:"role"`
+	Subjects   Subjects        `json:"subjects"`
+	Clusters   ClusterSelector `json:"clusters"`
+	Namespaces []string        `json:"namespaces,omitempty"` // empty grants every namespace; globs such as "payments-*" are allowed
+
+	selector labels.Selector
+}
+
+// Subjects lists who a binding applies to. Users match the username or email,
+// groups match the OIDC group claim.
+type Subjects struct {
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// ClusterSelector picks the clusters of a binding by ID or by label selector
+// (e.g. "env=prod"). An empty selector matches every cluster.
+type ClusterSelector struct {
+	IDs      []string `json:"ids,omitempty"`
+	Selector string   `json:"selector,omitempty"`
+}
+
+type roleBindingsFile struct {
+	Bindings []RoleBinding `json:"bindings"`
+}
+
+// LoadRoleBindings reads role bindings from a YAML or JSON file. An empty
+// path means no bindings.
+func LoadRoleBindings(file string) ([]RoleBinding, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read role bindings: %w", err)
+	}
+	return ParseRoleBindings(data)
+}
+
+// ParseRoleBindings parses and validates a "bindings:" document.
+func ParseRoleBindings(data []byte) ([]RoleBinding, error) {
+	var f roleBindingsFile
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid role bindings: %w", err)
+	}
+
+	seen := make(map[string]bool, len(f.Bindings))
+	for i := range f.Bindings {
+		b := &f.Bindings[i]
+		if b.Name == "" {
+			return nil, fmt.Errorf("role binding %d: name is required", i)
+		}
+		if seen[b.Name] {
+			return nil, fmt.Errorf("role binding %q: duplicate name", b.Name)
+		}
+		seen[b.Name] = true
+
+		if b.Role != RoleViewer && b.Role != RoleOperator && b.Role != RoleAdmin {
+			return nil, fmt.Errorf("role binding %q: role must be viewer, operator or admin", b.Name)
+		}
+		if len(b.Subjects.Users) == 0 && len(b.Subjects.Groups) == 0 {
+			return nil, fmt.Errorf("role binding %q: at least one user or group is required", b.Name)
+		}
+		selector, err := labels.Parse(b.Clusters.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("role binding %q: invalid cluster selector: %w", b.Name, err)
+		}
+		b.selector = selector
+		for _, ns := range b.Namespaces {
+			if ns == "" || ns == "*" {
+				return nil, fmt.Errorf("role binding %q: omit namespaces to grant every namespace", b.Name)
+			}
+			if _, err := path.Match(ns, ""); err != nil {
+				return nil, fmt.Errorf("role binding %q: invalid namespace pattern %q", b.Name, ns)
+			}
+		}
+	}
+	return f.Bindings, nil
+}
+
+// matchesUser reports whether the binding applies to user.
+func (b *RoleBinding) matchesUser(user *UserInfo) bool {
+	for _, u := range b.Subjects.Users {
+		if u == user.Username || (user.Email != "" && strings.EqualFold(u, user.Email)) {
+			return true
+		}
+	}
+	for _, want := range b.Subjects.Groups {
+		for _, g := range user.Groups {
+			if strings.EqualFold(g, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesCluster reports whether the binding covers a cluster. Labels are
+// only fetched when the binding selects by label.
+func (b *RoleBinding) matchesCluster(id string, clusterLabels func() map[string]string) bool {
+	if len(b.Clusters.IDs) == 0 && b.Clusters.Selector == "" {
+		return true
+	}
+	for _, want := range b.Clusters.IDs {
+		if want == id {
+			return true
+		}
+	}
+	if b.Clusters.Selector == "" || clusterLabels == nil {
+		return false
+	}
+	return b.selector.Matches(labels.Set(clusterLabels()))
+}
+
+// Scope is what a user may do on one cluster.
+type Scope struct {
+	Role       string            // role on the cluster as a whole
+	Namespaces map[string]string // additional roles on namespaces matching a pattern
+}
+
+// Can reports whether the scope grants role on namespace. An empty
+// namespace asks for the cluster as a whole. A namespace that is itself a
+// pattern, as Velero accepts in includedNamespaces, is only granted by the
+// identical pattern since it may expand beyond any other.
+func (s Scope) Can(role, namespace string) bool {
+	if RoleHierarchy(s.Role, role) && roleLevels[s.Role] > 0 {
+		return true
+	}
+	if namespace == "" {
+		return false
+	}
+	if IsNamespacePattern(namespace) {
+		r, ok := s.Namespaces[namespace]
+		return ok && RoleHierarchy(r, role)
+	}
+	for pattern, r := range s.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok && RoleHierarchy(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAll reports whether the scope grants role on every namespace in
+// namespaces. Velero treats an empty list or "*" as every namespace, which
+// needs the role on the whole cluster.
+func (s Scope) CanAll(role string, namespaces []string) bool {
+	if len(namespaces) == 0 {
+		return s.Can(role, "")
+	}
+	for _, ns := range namespaces {
+		if ns == "*" {
+			ns = ""
+		}
+		if !s.Can(role, ns) {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects reports whether the scope grants role on at least one of
+// namespaces. An empty list or "*" covers every namespace and so intersects
+// any scope that grants role somewhere on the cluster.
+func (s Scope) Intersects(role string, namespaces []string) bool {
+	if len(namespaces) == 0 || slices.Contains(namespaces, "*") {
+		return s.Any(role)
+	}
+	for _, ns := range namespaces {
+		if s.Can(role, ns) {
+			return true
+		}
+		if !IsNamespacePattern(ns) {
+			continue
+		}
+		for pattern, r := range s.Namespaces {
+			if ok, _ := path.Match(ns, pattern); ok && RoleHierarchy(r, role) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsNamespacePattern reports whether namespace contains glob characters.
+func IsNamespacePattern(namespace string) bool {
+	return strings.ContainsAny(namespace, `*?[\`)
+}
+
+// Any reports whether the scope grants role somewhere on the cluster.
+func (s Scope) Any(role string) bool {
+	if s.Can(role, "") {
+		return true
+	}
+	for _, r := range s.Namespaces {
+		if RoleHierarchy(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespacePatterns returns the sorted namespace patterns the scope grants
+// role on. It is empty when the role is held on the whole cluster.
+func (s Scope) NamespacePatterns(role string) []string {
+	if s.Can(role, "") {
+		return nil
+	}
+	var patterns []string
+	for pattern, r := range s.Namespaces {
+		if RoleHierarchy(r, role) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// Authorizer resolves a user's global role and role bindings into what they
+// may do on a cluster. A nil Authorizer only applies global roles.
+type Authorizer struct {
+	bindings []RoleBinding
+}
+
+// NewAuthorizer creates an authorizer for the given role bindings.
+func NewAuthorizer(bindings []RoleBinding) *Authorizer {
+	return &Authorizer{bindings: bindings}
+}
+
+// Bindings returns the role bindings that apply to user.
+func (a *Authorizer) Bindings(user *UserInfo) []RoleBinding {
+	if a == nil || user == nil {
+		return nil
+	}
+	var matched []RoleBinding
+	for _, b := range a.bindings {
+		if b.matchesUser(user) {
+			matched = append(matched, b)
+		}
+	}
+	return matched
+}
+
+// MaxRole returns the highest role user holds anywhere, globally or through
+// a binding.
+func (a *Authorizer) MaxRole(user *UserInfo) string {
+	if user == nil {
+		return ""
+	}
+	role := user.Role
+	for _, b := range a.Bindings(user) {
+		role = higherRole(role, b.Role)
+	}
+	return user.limitRole(role)
+}
+
+// Scope returns what user may do on a cluster. clusterLabels is called at
+// most once, and only when a binding selects clusters by label.
+func (a *Authorizer) Scope(user *UserInfo, clusterID string, clusterLabels func() map[string]string) Scope {
+	if user == nil || (len(user.Clusters) > 0 && !slices.Contains(user.Clusters, clusterID)) {
+		return Scope{}
+	}
+	scope := Scope{Role: user.Role}
+	if user.Role == RoleAdmin {
+		return scope.limit(user)
+	}
+
+	var cached map[string]string
+	loaded := false
+	lazyLabels := func() map[string]string {
+		if !loaded && clusterLabels != nil {
+			cached, loaded = clusterLabels(), true
+		}
+		return cached
+	}
+
+	for _, b := range a.Bindings(user) {
+		if !b.matchesCluster(clusterID, lazyLabels) {
+			continue
+		}
+		if len(b.Namespaces) == 0 {
+			scope.Role = higherRole(scope.Role, b.Role)
+			continue
+		}
+		if scope.Namespaces == nil {
+			scope.Namespaces = make(map[string]string)
+		}
+		for _, ns := range b.Namespaces {
+			scope.Namespaces[ns] = higherRole(scope.Namespaces[ns], b.Role)
+		}
+	}
+	return scope.limit(user)
+}
+
+// limit caps every role in the scope at the user's RoleLimit.
+func (s Scope) limit(user *UserInfo) Scope {
+	if user.RoleLimit == "" {
+		return s
+	}
+	s.Role = user.limitRole(s.Role)
+	for ns, r := range s.Namespaces {
+		s.Namespaces[ns] = user.limitRole(r)
+	}
+	return s
+}
+
+// Middleware makes the authorizer available to handlers and RequireAnyRole.
+// It runs after the auth provider's middleware.
+func (a *Authorizer) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(AuthorizerContextKey, a)
+		return c.Next()
+	}
+}
+
+// Access returns the caller's effective role and the bindings that apply to
+// them, so the frontend can decide what to offer.
+func (a *Authorizer) Access(c *fiber.Ctx) error {
+	user := GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	bindings := a.Bindings(user)
+	if bindings == nil {
+		bindings = []RoleBinding{}
+	}
+	return c.JSON(fiber.Map{
+		"username":   user.Username,
+		"globalRole": user.Role,
+		"role":       a.MaxRole(user),
+		"bindings":   bindings,
+	})
+}
+
+// GetAuthorizer returns the authorizer installed by Authorizer.Middleware,
+// or nil.
+func GetAuthorizer(c *fiber.Ctx) *Authorizer {
+	a, _ := c.Locals(AuthorizerContextKey).(*Authorizer)
+	return a
+}
+
+// GetScope returns what the authenticated user may do on a cluster.
+func GetScope(c *fiber.Ctx, clusterID string, clusterLabels func() map[string]string) Scope {
+	return GetAuthorizer(c).Scope(GetUser(c), clusterID, clusterLabels)
+}
@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const paymentsBindings = `
+bindings:
+  - name: payments-prod
+    role: operator
+    subjects:
+      groups: [payments]
+    clusters:
+      selector: env=prod
+    namespaces: [payments, "payments-*"]
+  - name: sre
+    role: admin
+    subjects:
+      users: [sre@example.com]
+    clusters:
+      ids: [staging]
+`
+
+func TestParseRoleBindings(t *testing.T) {
+	bindings, err := ParseRoleBindings([]byte(paymentsBindings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bindings) != 2 {
+		t.Fatalf("expected 2 bindings, got %d", len(bindings))
+	}
+
+	invalid := map[string]string{
+		"missing name":   "bindings: [{role: viewer, subjects: {users: [a]}}]",
+		"duplicate name": "bindings: [{name: a, role: viewer, subjects: {users: [a]}}, {name: a, role: viewer, subjects: {users: [b]}}]",
+		"bad role":       "bindings: [{name: a, role: owner, subjects: {users: [a]}}]",
+		"none role":      "bindings: [{name: a, role: none, subjects: {users: [a]}}]",
+		"no subjects":    "bindings: [{name: a, role: viewer}]",
+		"bad selector":   "bindings: [{name: a, role: viewer, subjects: {users: [a]}, clusters: {selector: '=prod'}}]",
+		"star namespace": "bindings: [{name: a, role: viewer, subjects: {users: [a]}, namespaces: ['*']}]",
+		"bad pattern":    "bindings: [{name: a, role: viewer, subjects: {users: [a]}, namespaces: ['ns-[']}]",
+		"unknown field":  "bindings: [{name: a, role: viewer, subjects: {users: [a]}, cluster: {ids: [x]}}]",
+	}
+	for name, doc := range invalid {
+		if _, err := ParseRoleBindings([]byte(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAuthorizerScope(t *testing.T) {
+	bindings, err := ParseRoleBindings([]byte(paymentsBindings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authz := NewAuthorizer(bindings)
+	prod := func() map[string]string { return map[string]string{"env": "prod"} }
+	dev := func() map[string]string { return map[string]string{"env": "dev"} }
+
+	payments := &UserInfo{Username: "pat", Role: RoleNone, Groups: []string{"Payments"}}
+	scope := authz.Scope(payments, "prod-eu", prod)
+
+	if !scope.Can(RoleOperator, "payments") || !scope.Can(RoleOperator, "payments-api") {
+		t.Error("expected operator on payments namespaces in prod")
+	}
+	if scope.Can(RoleViewer, "billing") {
+		t.Error("expected no access to other namespaces")
+	}
+	if scope.Can(RoleViewer, "") || scope.CanAll(RoleViewer, nil) || scope.CanAll(RoleOperator, []string{"payments", "*"}) {
+		t.Error("expected no access to the whole cluster")
+	}
+	if !scope.CanAll(RoleOperator, []string{"payments", "payments-worker"}) {
+		t.Error("expected operator on all payments namespaces")
+	}
+	if scope.CanAll(RoleAdmin, []string{"payments"}) {
+		t.Error("binding must not grant admin")
+	}
+	if !scope.Any(RoleOperator) || scope.Any(RoleAdmin) {
+		t.Errorf("unexpected Any results for %+v", scope)
+	}
+	if got := scope.NamespacePatterns(RoleViewer); !slices.Equal(got, []string{"payments", "payments-*"}) {
+		t.Errorf("NamespacePatterns = %v", got)
+	}
+
+	if s := authz.Scope(payments, "dev", dev); s.Any(RoleViewer) {
+		t.Errorf("expected no access outside prod, got %+v", s)
+	}
+
+	// A global viewer keeps reading everything and gains operator on their namespaces.
+	viewer := &UserInfo{Username: "val", Role: RoleViewer, Groups: []string{"payments"}}
+	scope = authz.Scope(viewer, "prod-eu", prod)
+	if !scope.CanAll(RoleViewer, nil) || scope.Can(RoleOperator, "billing") || !scope.Can(RoleOperator, "payments") {
+		t.Errorf("unexpected scope for global viewer: %+v", scope)
+	}
+	if got := scope.NamespacePatterns(RoleViewer); got != nil {
+		t.Errorf("expected no patterns for a cluster-wide role, got %v", got)
+	}
+
+	sre := &UserInfo{Username: "sam", Email: "SRE@example.com", Role: RoleViewer}
+	if s := authz.Scope(sre, "staging", nil); !s.Can(RoleAdmin, "") {
+		t.Errorf("expected admin on staging by email, got %+v", s)
+	}
+	if s := authz.Scope(sre, "prod-eu", prod); s.Can(RoleAdmin, "") {
+		t.Errorf("expected no admin on prod, got %+v", s)
+	}
+}
+
+func TestAuthorizerScopeLabelsLookup(t *testing.T) {
+	bindings, err := ParseRoleBindings([]byte(paymentsBindings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authz := NewAuthorizer(bindings)
+	calls := 0
+	labelsFn := func() map[string]string {
+		calls++
+		return nil
+	}
+
+	authz.Scope(&UserInfo{Username: "sam", Email: "sre@example.com", Role: RoleViewer}, "staging", labelsFn)
+	if calls != 0 {
+		t.Errorf("labels looked up %d times for an ID-only binding", calls)
+	}
+	authz.Scope(&UserInfo{Username: "pat", Role: RoleNone, Groups: []string{"payments"}}, "prod-eu", labelsFn)
+	if calls != 1 {
+		t.Errorf("labels looked up %d times, want 1", calls)
+	}
+}
+
+func TestAuthorizerMaxRole(t *testing.T) {
+	bindings, err := ParseRoleBindings([]byte(paymentsBindings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authz := NewAuthorizer(bindings)
+
+	tests := []struct {
+		user *UserInfo
+		want string
+	}{
+		{&UserInfo{Username: "pat", Role: RoleNone, Groups: []string{"payments"}}, RoleOperator},
+		{&UserInfo{Username: "sam", Email: "sre@example.com", Role: RoleViewer}, RoleAdmin},
+		{&UserInfo{Username: "olga", Role: RoleOperator}, RoleOperator},
+		{&UserInfo{Username: "nobody", Role: RoleNone}, RoleNone},
+	}
+	for _, tt := range tests {
+		if got := authz.MaxRole(tt.user); got != tt.want {
+			t.Errorf("MaxRole(%s) = %q, want %q", tt.user.Username, got, tt.want)
+		}
+	}
+
+	var none *Authorizer
+	if got := none.MaxRole(&UserInfo{Username: "olga", Role: RoleOperator}); got != RoleOperator {
+		t.Errorf("nil authorizer MaxRole = %q", got)
+	}
+}
+
+func TestRequireAnyRole(t *testing.T) {
+	bindings, err := ParseRoleBindings([]byte(paymentsBindings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := map[string]*UserInfo{
+		"pat":    {Username: "pat", Role: RoleNone, Groups: []string{"payments"}},
+		"nobody": {Username: "nobody", Role: RoleViewer},
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(UserContextKey, users[c.Get("X-User")])
+		return c.Next()
+	}, NewAuthorizer(bindings).Middleware())
+	app.Post("/restores", RequireAnyRole(RoleOperator), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	for user, want := range map[string]int{
+		"pat":    fiber.StatusCreated,
+		"nobody": fiber.StatusForbidden,
+		"":       fiber.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("POST", "/restores", nil)
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("%q: status = %d, want %d", user, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestScopeNamespacePatterns(t *testing.T) {
+	scope := Scope{Role: RoleNone, Namespaces: map[string]string{"payments": RoleOperator, "payments-*": RoleOperator}}
+
+	tests := []struct {
+		namespace string
+		want      bool
+	}{
+		{"payments-api", true},
+		{"payments-*", true},  // the granted pattern itself
+		{"payments-?", false}, // a different pattern may reach other namespaces
+		{"pay*", false},
+		{"*", false},
+	}
+	for _, tt := range tests {
+		if got := scope.Can(RoleOperator, tt.namespace); got != tt.want {
+			t.Errorf("Can(%q) = %v, want %v", tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestScopeIntersects(t *testing.T) {
+	scope := Scope{Role: RoleNone, Namespaces: map[string]string{"payments": RoleViewer, "payments-*": RoleOperator}}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		want       bool
+	}{
+		{"whole cluster", nil, true},
+		{"star", []string{"*"}, true},
+		{"subset", []string{"payments"}, true},
+		{"mixed", []string{"billing", "payments-api"}, true},
+		{"pattern covering theirs", []string{"pay*"}, true},
+		{"others only", []string{"billing", "ledger"}, false},
+		{"other pattern", []string{"billing-*"}, false},
+	}
+	for _, tt := range tests {
+		if got := scope.Intersects(RoleViewer, tt.namespaces); got != tt.want {
+			t.Errorf("%s: Intersects = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if scope.Intersects(RoleOperator, []string{"payments", "billing"}) {
+		t.Error("expected no operator intersection on payments")
+	}
+	if (Scope{Role: RoleNone}).Intersects(RoleViewer, nil) {
+		t.Error("empty scope must not see whole-cluster backups")
+	}
+}
+
+func TestAuthorizerTokenLimits(t *testing.T) {
+	bindings, err := ParseRoleBindings([]byte(paymentsBindings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authz := NewAuthorizer(bindings)
+	prod := func() map[string]string { return map[string]string{"env": "prod"} }
+
+	// A viewer token of a payments operator, limited to one cluster
+	token := &UserInfo{Username: "pat", Role: RoleNone, Groups: []string{"payments"}, RoleLimit: RoleViewer, Clusters: []string{"prod-eu"}}
+	scope := authz.Scope(token, "prod-eu", prod)
+	if !scope.Can(RoleViewer, "payments") || scope.Can(RoleOperator, "payments") {
+		t.Errorf("expected viewer only, got %+v", scope)
+	}
+	if s := authz.Scope(token, "prod-us", prod); s.Any(RoleViewer) {
+		t.Errorf("expected nothing outside the token's clusters, got %+v", s)
+	}
+	if got := authz.MaxRole(token); got != RoleViewer {
+		t.Errorf("MaxRole = %q, want viewer", got)
+	}
+
+	admin := &UserInfo{Username: "root", Role: RoleAdmin, RoleLimit: RoleOperator}
+	if s := authz.Scope(admin, "any", nil); s.Can(RoleAdmin, "") || !s.Can(RoleOperator, "") {
+		t.Errorf("expected admin capped at operator, got %+v", s)
+	}
+}
@@ -11,15 +11,22 @@ import (
 // Claims represents the JWT payload.
 type Claims struct {
 	jwt.RegisteredClaims
-	Username string `json:"username"`
-	Email    string `json:"email,omitempty"`
-	Role     string `json:"role"`
+	Username string   `json:"username"`
+	Email    string   `json:"email,omitempty"`
+	Role     string   `json:"role"`
+	Groups   []string `json:"groups,omitempty"`
+
+	// SessionID names the server-side session the token belongs to, when
+	// sessions are enabled.
+	SessionID string `json:"sid,omitempty"`
 }
 
 // JWTManager handles JWT token generation and validation.
 type JWTManager struct {
 	secret     []byte
 	expiration time.Duration
+	apiTokens  APITokenVerifier
+	sessions   SessionStore
 }
 
 // NewJWTManager creates a new JWT manager. If secret is empty, generates a random one.
@@ -39,6 +46,10 @@ func NewJWTManager(secret string, expiration time.Duration) *JWTManager {
 
 // Generate creates a signed JWT for the given user.
 func (m *JWTManager) Generate(user UserInfo) (string, error) {
+	return m.generate(user, "")
+}
+
+func (m *JWTManager) generate(user UserInfo, sessionID string) (string, error) {
 	now := time.Now()
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -49,6 +60,9 @@ func (m *JWTManager) Generate(user UserInfo) (string, error) {
 		Username: user.Username,
 		Email:    user.Email,
 		Role:     user.Role,
+		Groups:   user.Groups,
+
+		SessionID: sessionID,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(m.secret)
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Role sources of the kubernetes provider.
+const (
+	// KubernetesRolesFromGroups maps the groups TokenReview reports to
+	// roles, like OIDC groups.
+	KubernetesRolesFromGroups = "groups"
+	// KubernetesRolesFromAccess asks SubjectAccessReview which role verbs
+	// the user may use on a virtual resource, so roles are granted with
+	// Kubernetes RBAC.
+	KubernetesRolesFromAccess = "access"
+)
+
+// maxReviewCache bounds the number of cached token reviews.
+const maxReviewCache = 1000
+
+var errNotAuthenticated = errors.New("token not authenticated")
+
+// KubernetesConfig holds the kubernetes provider configuration.
+type KubernetesConfig struct {
+	RoleSource     string
+	AdminGroups    []string
+	OperatorGroups []string
+	DefaultRole    string
+	// Audiences the token must be issued for; empty accepts the API
+	// server's own audience.
+	Audiences []string
+	// AccessGroup and AccessResource name the virtual resource whose verbs
+	// ("viewer", "operator", "admin") grant roles with RoleSource "access".
+	AccessGroup    string
+	AccessResource string
+	// CacheTTL is how long a reviewed token is trusted before it is reviewed
+	// again.
+	CacheTTL time.Duration
+}
+
+// KubernetesProvider authenticates Kubernetes bearer tokens, e.g. from
+// kubectl or ServiceAccounts, with the management cluster's TokenReview API.
+// Browsers exchange a token for a dashboard session at /auth/login.
+type KubernetesProvider struct {
+	client kubernetes.Interface
+	cfg    KubernetesConfig
+	jwtMgr *JWTManager
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	reviews map[string]reviewedToken // SHA-256 of the token -> review
+}
+
+type reviewedToken struct {
+	user    UserInfo
+	expires time.Time
+}
+
+// NewKubernetesProvider creates a kubernetes auth provider reviewing tokens
+// with client.
+func NewKubernetesProvider(cfg KubernetesConfig, client kubernetes.Interface, jwtMgr *JWTManager, logger *zap.Logger) (*KubernetesProvider, error) {
+	switch cfg.RoleSource {
+	case KubernetesRolesFromGroups:
+	case KubernetesRolesFromAccess:
+		if cfg.AccessResource == "" {
+			return nil, errors.New("a resource is required to derive roles from access reviews")
+		}
+	default:
+		return nil, fmt.Errorf("unknown role source %q, use %q or %q", cfg.RoleSource, KubernetesRolesFromGroups, KubernetesRolesFromAccess)
+	}
+	return &KubernetesProvider{
+		client:  client,
+		cfg:     cfg,
+		jwtMgr:  jwtMgr,
+		logger:  logger,
+		now:     time.Now,
+		reviews: make(map[string]reviewedToken),
+	}, nil
+}
+
+func (p *KubernetesProvider) Mode() string {
+	return "kubernetes"
+}
+
+func (p *KubernetesProvider) SetupRoutes(router fiber.Router) {
+	router.Post("/auth/login", p.login)
+	router.Get("/auth/me", p.Middleware(), p.me)
+	sessionRoutes(router, p.jwtMgr, p.reload, p.logger)
+}
+
+// Middleware accepts Kubernetes tokens as well as the dashboard's own JWTs
+// and API tokens.
+func (p *KubernetesProvider) Middleware() fiber.Handler {
+	requireJWT := RequireAuth(p.jwtMgr, p.logger)
+	return func(c *fiber.Ctx) error {
+		tokenStr, problem := requestToken(c)
+		if problem != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": problem})
+		}
+		if IsAPIToken(tokenStr) {
+			return requireJWT(c)
+		}
+		if _, err := p.jwtMgr.Validate(tokenStr); err == nil {
+			return requireJWT(c)
+		}
+
+		user, err := p.authenticate(c.Context(), tokenStr)
+		if errors.Is(err, errNotAuthenticated) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired token"})
+		}
+		if err != nil {
+			p.logger.Error("Kubernetes token review failed", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to authenticate"})
+		}
+		c.Locals(UserContextKey, user)
+		c.Locals(RecheckContextKey, Recheck(func(ctx context.Context) error {
+			_, err := p.authenticate(ctx, tokenStr)
+			return err
+		}))
+		return c.Next()
+	}
+}
+
+// login exchanges a Kubernetes token for a dashboard session, so the UI can
+// be used with e.g. the output of "kubectl create token".
+func (p *KubernetesProvider) login(c *fiber.Ctx) error {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "token is required"})
+	}
+
+	user, err := p.authenticate(c.Context(), req.Token)
+	if errors.Is(err, errNotAuthenticated) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid credentials"})
+	}
+	if err != nil {
+		p.logger.Error("Kubernetes token review failed", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to authenticate"})
+	}
+
+	token, err := p.jwtMgr.login(c, *user)
+	if err != nil {
+		p.logger.Error("Failed to generate JWT", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate token"})
+	}
+	return c.JSON(fiber.Map{
+		"token":    token,
+		"username": user.Username,
+		"role":     user.Role,
+	})
+}
+
+func (p *KubernetesProvider) me(c *fiber.Ctx) error {
+	user := GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	return c.JSON(user)
+}
+
+// reload re-derives a session's role, so RBAC and group mapping changes
+// apply on the next refresh.
+func (p *KubernetesProvider) reload(info UserInfo) (UserInfo, error) {
+	return p.ResolveUser(context.Background(), info)
+}
+
+// ResolveUser re-derives a user's role from their groups and RBAC.
+func (p *KubernetesProvider) ResolveUser(ctx context.Context, info UserInfo) (UserInfo, error) {
+	extra := make(map[string]authnv1.ExtraValue, len(info.Extra))
+	for k, v := range info.Extra {
+		extra[k] = authnv1.ExtraValue(v)
+	}
+	role, err := p.role(ctx, &authnv1.UserInfo{Username: info.Username, UID: info.UID, Groups: info.Groups, Extra: extra})
+	if err != nil {
+		return UserInfo{}, err
+	}
+	info.Role = role
+	return info, nil
+}
+
+// authenticate reviews a token, or returns the cached result of an earlier
+// review.
+func (p *KubernetesProvider) authenticate(ctx context.Context, token string) (*UserInfo, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	now := p.now()
+
+	p.mu.Lock()
+	cached, ok := p.reviews[key]
+	p.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		user := cached.user
+		return &user, nil
+	}
+
+	review, err := p.client.AuthenticationV1().TokenReviews().Create(ctx, &authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{Token: token, Audiences: p.cfg.Audiences},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		p.logger.Debug("Kubernetes token rejected", zap.String("reason", review.Status.Error))
+		return nil, errNotAuthenticated
+	}
+
+	role, err := p.role(ctx, &review.Status.User)
+	if err != nil {
+		return nil, err
+	}
+	user := UserInfo{
+		Username: review.Status.User.Username,
+		Role:     role,
+		Groups:   review.Status.User.Groups,
+		UID:      review.Status.User.UID,
+	}
+	if len(review.Status.User.Extra) > 0 {
+		user.Extra = make(map[string][]string, len(review.Status.User.Extra))
+		for k, v := range review.Status.User.Extra {
+			user.Extra[k] = v
+		}
+	}
+
+	p.mu.Lock()
+	if len(p.reviews) >= maxReviewCache {
+		for k, r := range p.reviews {
+			if !now.Before(r.expires) {
+				delete(p.reviews, k)
+			}
+		}
+		if len(p.reviews) >= maxReviewCache {
+			p.reviews = make(map[string]reviewedToken)
+		}
+	}
+	p.reviews[key] = reviewedToken{user: user, expires: now.Add(p.cfg.CacheTTL)}
+	p.mu.Unlock()
+	return &user, nil
+}
+
+// role derives the dashboard role of a Kubernetes user.
+func (p *KubernetesProvider) role(ctx context.Context, user *authnv1.UserInfo) (string, error) {
+	if p.cfg.RoleSource == KubernetesRolesFromGroups {
+		return groupRole(user.Groups, p.cfg.AdminGroups, p.cfg.OperatorGroups, p.cfg.DefaultRole), nil
+	}
+
+	extra := make(map[string]authzv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authzv1.ExtraValue(v)
+	}
+	for _, role := range []string{RoleAdmin, RoleOperator, RoleViewer} {
+		review, err := p.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authzv1.SubjectAccessReview{
+			Spec: authzv1.SubjectAccessReviewSpec{
+				User:   user.Username,
+				UID:    user.UID,
+				Groups: user.Groups,
+				Extra:  extra,
+				ResourceAttributes: &authzv1.ResourceAttributes{
+					Group:    p.cfg.AccessGroup,
+					Resource: p.cfg.AccessResource,
+					Verb:     role,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to review access: %w", err)
+		}
+		if review.Status.Allowed {
+			return role, nil
+		}
+	}
+	return p.cfg.DefaultRole, nil
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeReviews answers TokenReviews for "<username>" tokens listed in users
+// and SubjectAccessReviews from allowed (username -> verbs).
+func fakeReviews(users map[string][]string, allowed map[string][]string) (*fake.Clientset, *int) {
+	client := fake.NewSimpleClientset()
+	reviews := 0
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authnv1.TokenReview)
+		if groups, ok := users[review.Spec.Token]; ok {
+			review.Status = authnv1.TokenReviewStatus{
+				Authenticated: true,
+				User:          authnv1.UserInfo{Username: review.Spec.Token, Groups: groups},
+			}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authzv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		if attrs.Group == "velero-dashboard.io" && attrs.Resource == "roles" {
+			for _, verb := range allowed[review.Spec.User] {
+				if verb == attrs.Verb {
+					review.Status.Allowed = true
+				}
+			}
+		}
+		return true, review, nil
+	})
+	return client, &reviews
+}
+
+func kubernetesApp(t *testing.T, cfg KubernetesConfig, client *fake.Clientset) (*fiber.App, *JWTManager) {
+	t.Helper()
+	jwtMgr := NewJWTManager("test-secret-key", time.Minute)
+	provider, err := NewKubernetesProvider(cfg, client, jwtMgr, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	provider.SetupRoutes(app.Group("/api"))
+	app.Get("/api/whoami", provider.Middleware(), func(c *fiber.Ctx) error {
+		return c.SendString(GetUser(c).Username + ":" + GetUser(c).Role)
+	})
+	return app, jwtMgr
+}
+
+func whoami(t *testing.T, app *fiber.App, token string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/whoami", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestKubernetesAccessRoles(t *testing.T) {
+	client, reviews := fakeReviews(
+		map[string][]string{"system:serviceaccount:ops:backup": nil, "alice": nil, "bob": nil},
+		map[string][]string{"system:serviceaccount:ops:backup": {"operator", "viewer"}, "alice": {"admin"}},
+	)
+	app, jwtMgr := kubernetesApp(t, KubernetesConfig{
+		RoleSource:     KubernetesRolesFromAccess,
+		DefaultRole:    RoleNone,
+		AccessGroup:    "velero-dashboard.io",
+		AccessResource: "roles",
+		CacheTTL:       time.Minute,
+	}, client)
+
+	tests := map[string]string{
+		"system:serviceaccount:ops:backup": "system:serviceaccount:ops:backup:operator",
+		"alice":                            "alice:admin",
+		"bob":                              "bob:none",
+	}
+	for token, want := range tests {
+		if status, body := whoami(t, app, token); status != fiber.StatusOK || body != want {
+			t.Errorf("%s: status = %d, body = %q, want %q", token, status, body, want)
+		}
+	}
+	if status, _ := whoami(t, app, "forged"); status != fiber.StatusUnauthorized {
+		t.Errorf("unknown token status = %d", status)
+	}
+
+	before := *reviews
+	whoami(t, app, "alice")
+	if *reviews != before {
+		t.Error("reviewed tokens must be cached")
+	}
+
+	// Dashboard JWTs keep working alongside Kubernetes tokens.
+	own, _ := jwtMgr.Generate(UserInfo{Username: "carol", Role: RoleViewer})
+	if status, body := whoami(t, app, own); status != fiber.StatusOK || body != "carol:viewer" {
+		t.Errorf("dashboard JWT: status = %d, body = %q", status, body)
+	}
+}
+
+func TestKubernetesResolveUserKeepsIdentity(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authnv1.TokenReview)
+		review.Status = authnv1.TokenReviewStatus{
+			Authenticated: true,
+			User: authnv1.UserInfo{
+				Username: "alice",
+				UID:      "uid-1",
+				Groups:   []string{"ops"},
+				Extra:    map[string]authnv1.ExtraValue{"scopes": {"backup"}},
+			},
+		}
+		return true, review, nil
+	})
+	var specs []authzv1.SubjectAccessReviewSpec
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authzv1.SubjectAccessReview)
+		specs = append(specs, review.Spec)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+	provider, err := NewKubernetesProvider(KubernetesConfig{
+		RoleSource:     KubernetesRolesFromAccess,
+		DefaultRole:    RoleNone,
+		AccessGroup:    "velero-dashboard.io",
+		AccessResource: "roles",
+		CacheTTL:       time.Minute,
+	}, client, NewJWTManager("test-secret-key", time.Minute), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := provider.authenticate(context.Background(), "alice-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.ResolveUser(context.Background(), *user); err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("got %d access reviews, want 2", len(specs))
+	}
+	// The re-check must review the same identity as the token review.
+	for i, spec := range specs {
+		if spec.User != "alice" || spec.UID != "uid-1" || len(spec.Groups) != 1 || len(spec.Extra["scopes"]) != 1 || spec.Extra["scopes"][0] != "backup" {
+			t.Errorf("review %d: unexpected spec %+v", i, spec)
+		}
+	}
+}
+
+func TestKubernetesGroupRoles(t *testing.T) {
+	client, _ := fakeReviews(map[string][]string{
+		"alice": {"system:authenticated", "Velero-Admins"},
+		"bob":   {"system:authenticated"},
+	}, nil)
+	app, _ := kubernetesApp(t, KubernetesConfig{
+		RoleSource:  KubernetesRolesFromGroups,
+		AdminGroups: []string{"velero-admins"},
+		DefaultRole: RoleViewer,
+	}, client)
+
+	if status, body := whoami(t, app, "alice"); status != fiber.StatusOK || body != "alice:admin" {
+		t.Errorf("alice: status = %d, body = %q", status, body)
+	}
+	if status, body := whoami(t, app, "bob"); status != fiber.StatusOK || body != "bob:viewer" {
+		t.Errorf("bob: status = %d, body = %q", status, body)
+	}
+
+	req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"token":"alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("login status = %d", resp.StatusCode)
+	}
+	req = httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"token":"forged"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, _ := app.Test(req); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("login with an unknown token status = %d", resp.StatusCode)
+	}
+}
+
+func TestNewKubernetesProviderValidation(t *testing.T) {
+	jwtMgr := NewJWTManager("test-secret-key", time.Minute)
+	if _, err := NewKubernetesProvider(KubernetesConfig{RoleSource: "magic"}, fake.NewSimpleClientset(), jwtMgr, zap.NewNop()); err == nil {
+		t.Error("expected an error for an unknown role source")
+	}
+	if _, err := NewKubernetesProvider(KubernetesConfig{RoleSource: KubernetesRolesFromAccess}, fake.NewSimpleClientset(), jwtMgr, zap.NewNop()); err == nil {
+		t.Error("expected an error for access reviews without a resource")
+	}
+}
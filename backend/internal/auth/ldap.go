@@ -0,0 +1,390 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// maxLDAPGroups bounds the groups resolved for one user, so a huge or
+// cyclic hierarchy can't stall a login.
+const maxLDAPGroups = 500
+
+// errNoServiceAccount is returned when a user must be re-read from the
+// directory but there is no service account to search it with.
+var errNoServiceAccount = errors.New("the directory can't be searched without a bind DN; sign in again")
+
+// LDAPConfig holds the LDAP provider configuration.
+type LDAPConfig struct {
+	// URL of the directory, ldap://host:389 or ldaps://host:636.
+	URL string
+	// StartTLS upgrades ldap:// connections to TLS before binding.
+	StartTLS           bool
+	CAFile             string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+
+	// BindDN and BindPassword are a service account that looks up users and
+	// their groups. Without one, users bind with UserDNTemplate directly and
+	// look themselves up.
+	BindDN       string
+	BindPassword string
+	// UserDNTemplate turns a username into a bind DN, "%s" standing for the
+	// username, e.g. "uid=%s,ou=people,dc=example,dc=org", or
+	// "%s@corp.example.com" for Active Directory.
+	UserDNTemplate string
+	// UserBaseDN and UserFilter find a user's entry, "%s" standing for the
+	// username, e.g. "(sAMAccountName=%s)" for Active Directory.
+	UserBaseDN        string
+	UserFilter        string
+	UsernameAttribute string
+	EmailAttribute    string
+
+	// GroupBaseDN and GroupFilter find the groups an entry is a direct member
+	// of, "%s" standing for the entry's DN. No groups are looked up without a
+	// GroupBaseDN.
+	GroupBaseDN        string
+	GroupFilter        string
+	GroupNameAttribute string
+	// NestedGroups also resolves the groups of groups, as Active Directory
+	// nests them.
+	NestedGroups bool
+
+	// AdminGroups and OperatorGroups match group names or DNs.
+	AdminGroups    []string
+	OperatorGroups []string
+	DefaultRole    string
+}
+
+// LDAPProvider authenticates users with an LDAP directory, such as OpenLDAP
+// or Active Directory, and maps their groups to roles.
+type LDAPProvider struct {
+	cfg       LDAPConfig
+	tlsConfig *tls.Config
+	jwtMgr    *JWTManager
+	logger    *zap.Logger
+}
+
+// NewLDAPProvider creates an LDAP auth provider.
+func NewLDAPProvider(cfg LDAPConfig, jwtMgr *JWTManager, logger *zap.Logger) (*LDAPProvider, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid LDAP URL %q", cfg.URL)
+	}
+	switch u.Scheme {
+	case "ldap":
+		if !cfg.StartTLS {
+			logger.Warn("LDAP connections are not encrypted, passwords are sent in cleartext; use ldaps:// or StartTLS")
+		}
+	case "ldaps":
+		if cfg.StartTLS {
+			return nil, errors.New("StartTLS can't be used with ldaps:// URLs")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported LDAP URL scheme %q, use ldap:// or ldaps://", u.Scheme)
+	}
+	if cfg.BindDN == "" && cfg.UserDNTemplate == "" {
+		return nil, errors.New("either a bind DN or a user DN template is required")
+	}
+	if cfg.BindDN != "" && cfg.UserBaseDN == "" {
+		return nil, errors.New("a user base DN is required to search users")
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LDAP CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(uid=%s)"
+	}
+	if cfg.UsernameAttribute == "" {
+		cfg.UsernameAttribute = "uid"
+	}
+	if cfg.EmailAttribute == "" {
+		cfg.EmailAttribute = "mail"
+	}
+	if cfg.GroupFilter == "" {
+		cfg.GroupFilter = "(member=%s)"
+	}
+	if cfg.GroupNameAttribute == "" {
+		cfg.GroupNameAttribute = "cn"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &LDAPProvider{cfg: cfg, tlsConfig: tlsConfig, jwtMgr: jwtMgr, logger: logger}, nil
+}
+
+func (p *LDAPProvider) Mode() string {
+	return "ldap"
+}
+
+func (p *LDAPProvider) SetupRoutes(router fiber.Router) {
+	router.Post("/auth/login", p.login)
+	router.Get("/auth/me", RequireAuth(p.jwtMgr, p.logger), p.me)
+	sessionRoutes(router, p.jwtMgr, p.reload, p.logger)
+}
+
+func (p *LDAPProvider) Middleware() fiber.Handler {
+	return RequireAuth(p.jwtMgr, p.logger)
+}
+
+func (p *LDAPProvider) login(c *fiber.Ctx) error {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Username == "" || req.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "username and password are required"})
+	}
+
+	user, err := p.authenticate(req.Username, req.Password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		p.logger.Debug("Failed login", zap.String("username", req.Username))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid credentials"})
+	}
+	if err != nil {
+		p.logger.Error("Failed to authenticate user", zap.String("username", req.Username), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to authenticate"})
+	}
+
+	token, err := p.jwtMgr.login(c, *user)
+	if err != nil {
+		p.logger.Error("Failed to generate JWT", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate token"})
+	}
+	return c.JSON(fiber.Map{
+		"token":    token,
+		"username": user.Username,
+		"role":     user.Role,
+	})
+}
+
+func (p *LDAPProvider) me(c *fiber.Ctx) error {
+	user := GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	return c.JSON(user)
+}
+
+// reload re-reads a session's user with the service account, so removed
+// users can't refresh their sessions and group changes apply on the next
+// refresh. Without a service account the directory can't be searched, and
+// users must sign in again.
+func (p *LDAPProvider) reload(info UserInfo) (UserInfo, error) {
+	if p.cfg.BindDN == "" {
+		return UserInfo{}, errNoServiceAccount
+	}
+	conn, err := p.dial()
+	if err != nil {
+		return UserInfo{}, err
+	}
+	defer conn.Close()
+
+	if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+		return UserInfo{}, fmt.Errorf("failed to bind as %s: %w", p.cfg.BindDN, err)
+	}
+	entry, err := p.findUser(conn, info.Username, "")
+	if err != nil {
+		return UserInfo{}, err
+	}
+	user, err := p.userInfo(conn, entry, info.Username)
+	if err != nil {
+		return UserInfo{}, err
+	}
+	return *user, nil
+}
+
+// ResolveUser re-reads a user's email and groups from the directory. It
+// fails without a service account.
+func (p *LDAPProvider) ResolveUser(_ context.Context, info UserInfo) (UserInfo, error) {
+	return p.reload(info)
+}
+
+// authenticate checks a user's password by binding as them, and resolves
+// their groups.
+func (p *LDAPProvider) authenticate(username, password string) (*UserInfo, error) {
+	// An empty password would be an unauthenticated bind, which servers
+	// accept for any DN.
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var entry *ldap.Entry
+	if p.cfg.BindDN != "" {
+		if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind as %s: %w", p.cfg.BindDN, err)
+		}
+		if entry, err = p.findUser(conn, username, ""); err != nil {
+			return nil, err
+		}
+		if err := p.bind(conn, entry.DN, password); err != nil {
+			return nil, err
+		}
+		// Look up groups with the service account, which may see more
+		// than the user.
+		if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind as %s: %w", p.cfg.BindDN, err)
+		}
+	} else {
+		dn := strings.ReplaceAll(p.cfg.UserDNTemplate, "%s", ldap.EscapeDN(username))
+		if err := p.bind(conn, dn, password); err != nil {
+			return nil, err
+		}
+		if entry, err = p.findUser(conn, username, dn); err != nil {
+			return nil, err
+		}
+	}
+	return p.userInfo(conn, entry, username)
+}
+
+// dial connects to the directory, upgrading the connection with StartTLS if
+// configured.
+func (p *LDAPProvider) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(p.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: p.cfg.Timeout}),
+		ldap.DialWithTLSConfig(p.tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	conn.SetTimeout(p.cfg.Timeout)
+	if p.cfg.StartTLS {
+		if err := conn.StartTLS(p.tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// bind binds as dn, mapping rejected passwords to ErrInvalidCredentials.
+func (p *LDAPProvider) bind(conn *ldap.Conn, dn, password string) error {
+	err := conn.Bind(dn, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bind as %s: %w", dn, err)
+	}
+	return nil
+}
+
+// findUser returns the entry of username. Without a UserBaseDN, it reads
+// the entry at boundDN instead.
+func (p *LDAPProvider) findUser(conn *ldap.Conn, username, boundDN string) (*ldap.Entry, error) {
+	attrs := []string{p.cfg.UsernameAttribute, p.cfg.EmailAttribute}
+	req := ldap.NewSearchRequest(boundDN, ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+		1, p.timeLimit(), false, "(objectClass=*)", attrs, nil)
+	if p.cfg.UserBaseDN != "" {
+		filter := strings.ReplaceAll(p.cfg.UserFilter, "%s", ldap.EscapeFilter(username))
+		req = ldap.NewSearchRequest(p.cfg.UserBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+			2, p.timeLimit(), false, filter, attrs, nil)
+	}
+
+	res, err := conn.Search(req)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) || (err == nil && len(res.Entries) > 1) {
+		p.logger.Warn("LDAP user filter matches several entries", zap.String("username", username))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search user: %w", err)
+	}
+	if len(res.Entries) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	return res.Entries[0], nil
+}
+
+// userInfo builds the dashboard user of entry. The directory's spelling of
+// the username wins over the one typed at login.
+func (p *LDAPProvider) userInfo(conn *ldap.Conn, entry *ldap.Entry, username string) (*UserInfo, error) {
+	if name := entry.GetEqualFoldAttributeValue(p.cfg.UsernameAttribute); name != "" {
+		username = name
+	}
+	names, dns, err := p.groups(conn, entry.DN)
+	if err != nil {
+		return nil, err
+	}
+	return &UserInfo{
+		Username: username,
+		Email:    entry.GetEqualFoldAttributeValue(p.cfg.EmailAttribute),
+		Role:     groupRole(append(names, dns...), p.cfg.AdminGroups, p.cfg.OperatorGroups, p.cfg.DefaultRole),
+		Groups:   names,
+	}, nil
+}
+
+// groups returns the names and DNs of the groups dn is a member of. With
+// NestedGroups it walks up the hierarchy breadth-first, so a user in "ops"
+// nested in "velero-admins" gets both. Cycles are visited once.
+func (p *LDAPProvider) groups(conn *ldap.Conn, dn string) (names, dns []string, err error) {
+	if p.cfg.GroupBaseDN == "" {
+		return nil, nil, nil
+	}
+	seen := map[string]bool{strings.ToLower(dn): true}
+	queue := []string{dn}
+	for len(queue) > 0 && len(dns) < maxLDAPGroups {
+		member := queue[0]
+		queue = queue[1:]
+
+		filter := strings.ReplaceAll(p.cfg.GroupFilter, "%s", ldap.EscapeFilter(member))
+		res, err := conn.Search(ldap.NewSearchRequest(p.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+			0, p.timeLimit(), false, filter, []string{p.cfg.GroupNameAttribute}, nil))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to search groups: %w", err)
+		}
+		for _, group := range res.Entries {
+			key := strings.ToLower(group.DN)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			dns = append(dns, group.DN)
+			if name := group.GetEqualFoldAttributeValue(p.cfg.GroupNameAttribute); name != "" {
+				names = append(names, name)
+			}
+			if p.cfg.NestedGroups {
+				queue = append(queue, group.DN)
+			}
+		}
+	}
+	return names, dns, nil
+}
+
+// timeLimit is the server-side search time limit, in seconds.
+func (p *LDAPProvider) timeLimit() int {
+	return int(p.cfg.Timeout.Seconds())
+}
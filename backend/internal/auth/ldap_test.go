@@ -0,0 +1,427 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// testDirectory is a minimal in-process LDAP server: simple binds,
+// searches with and/or/not/equality/present filters, and StartTLS.
+type testDirectory struct {
+	entries   []testEntry
+	passwords map[string]string // DN -> password
+	tlsConfig *tls.Config
+	// requireTLS rejects binds on unencrypted connections.
+	requireTLS bool
+}
+
+type testEntry struct {
+	dn    string
+	attrs map[string][]string
+}
+
+// newTestDirectory has alice in "ops", which is nested in "velero-admins",
+// bob in "velero-operators", and a service account.
+func newTestDirectory(t *testing.T) (*testDirectory, string) {
+	t.Helper()
+	serverTLS, caFile := testCertificate(t)
+	return &testDirectory{
+		entries: []testEntry{
+			{"uid=alice,ou=people,dc=example,dc=org", map[string][]string{"uid": {"alice"}, "mail": {"alice@example.org"}}},
+			{"uid=bob,ou=people,dc=example,dc=org", map[string][]string{"uid": {"bob"}, "mail": {"bob@example.org"}}},
+			{"cn=ops,ou=groups,dc=example,dc=org", map[string][]string{
+				"cn": {"ops"}, "member": {"uid=alice,ou=people,dc=example,dc=org", "cn=velero-admins,ou=groups,dc=example,dc=org"},
+			}},
+			{"cn=velero-admins,ou=groups,dc=example,dc=org", map[string][]string{
+				"cn": {"velero-admins"}, "member": {"cn=ops,ou=groups,dc=example,dc=org"},
+			}},
+			{"cn=velero-operators,ou=groups,dc=example,dc=org", map[string][]string{
+				"cn": {"velero-operators"}, "member": {"uid=bob,ou=people,dc=example,dc=org"},
+			}},
+		},
+		passwords: map[string]string{
+			"uid=alice,ou=people,dc=example,dc=org":      "alice-secret",
+			"uid=bob,ou=people,dc=example,dc=org":        "bob-secret",
+			"cn=dashboard,ou=services,dc=example,dc=org": "service-secret",
+		},
+		tlsConfig: serverTLS,
+	}, caFile
+}
+
+// listen serves the directory on a local port and returns its URL.
+func (d *testDirectory) listen(t *testing.T, ldaps bool) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	scheme := "ldap"
+	if ldaps {
+		ln = tls.NewListener(ln, d.tlsConfig)
+		scheme = "ldaps"
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn, ldaps)
+		}
+	}()
+	return scheme + "://" + ln.Addr().String()
+}
+
+func (d *testDirectory) serve(conn net.Conn, secure bool) {
+	defer func() { _ = conn.Close() }()
+	bound := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			switch {
+			case d.requireTLS && !secure:
+				code = ldap.LDAPResultConfidentialityRequired
+			case password != "" && d.passwords[strings.ToLower(dn)] == password:
+				code, bound = ldap.LDAPResultSuccess, dn
+			}
+			d.respond(conn, id, ldap.ApplicationBindResponse, code)
+
+		case ldap.ApplicationSearchRequest:
+			if bound == "" {
+				d.respond(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights)
+				continue
+			}
+			base := strings.ToLower(op.Children[0].Data.String())
+			scope := op.Children[1].Value.(int64)
+			for _, e := range d.entries {
+				dn := strings.ToLower(e.dn)
+				inScope := dn == base || (scope != int64(ldap.ScopeBaseObject) && strings.HasSuffix(dn, ","+base))
+				if inScope && matchTestFilter(op.Children[6], e) {
+					d.sendEntry(conn, id, e)
+				}
+			}
+			d.respond(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)
+
+		case ldap.ApplicationExtendedRequest:
+			d.respond(conn, id, ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess)
+			tlsConn := tls.Server(conn, d.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (d *testDirectory) respond(conn net.Conn, id int64, tag ber.Tag, code uint16) {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	d.send(conn, id, op)
+}
+
+func (d *testDirectory) sendEntry(conn net.Conn, id int64, e testEntry) {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""))
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, values := range e.attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		attr.AppendChild(set)
+		attrs.AppendChild(attr)
+	}
+	op.AppendChild(attrs)
+	d.send(conn, id, op)
+}
+
+func (d *testDirectory) send(conn net.Conn, id int64, op *ber.Packet) {
+	msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	msg.AppendChild(op)
+	_, _ = conn.Write(msg.Bytes())
+}
+
+func matchTestFilter(f *ber.Packet, e testEntry) bool {
+	switch f.Tag {
+	case ldap.FilterAnd:
+		for _, c := range f.Children {
+			if !matchTestFilter(c, e) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, c := range f.Children {
+			if matchTestFilter(c, e) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchTestFilter(f.Children[0], e)
+	case ldap.FilterPresent:
+		attr := f.Data.String()
+		return strings.EqualFold(attr, "objectClass") || len(testAttr(e, attr)) > 0
+	case ldap.FilterEqualityMatch:
+		want := f.Children[1].Data.String()
+		for _, v := range testAttr(e, f.Children[0].Data.String()) {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func testAttr(e testEntry, name string) []string {
+	for k, v := range e.attrs {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// testCertificate returns a server TLS config for 127.0.0.1 and the path of
+// its CA certificate.
+func testCertificate(t *testing.T) (*tls.Config, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap.test"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}, caFile
+}
+
+func searchBindConfig(url, caFile string) LDAPConfig {
+	return LDAPConfig{
+		URL:            url,
+		StartTLS:       true,
+		CAFile:         caFile,
+		BindDN:         "cn=dashboard,ou=services,dc=example,dc=org",
+		BindPassword:   "service-secret",
+		UserBaseDN:     "ou=people,dc=example,dc=org",
+		GroupBaseDN:    "ou=groups,dc=example,dc=org",
+		NestedGroups:   true,
+		AdminGroups:    []string{"velero-admins"},
+		OperatorGroups: []string{"cn=velero-operators,ou=groups,dc=example,dc=org"},
+		DefaultRole:    RoleViewer,
+	}
+}
+
+func TestLDAPSearchAndBind(t *testing.T) {
+	dir, caFile := newTestDirectory(t)
+	dir.requireTLS = true
+	url := dir.listen(t, false)
+
+	p, err := NewLDAPProvider(searchBindConfig(url, caFile), nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice, err := p.authenticate("ALICE", "alice-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Username != "alice" || alice.Email != "alice@example.org" || alice.Role != RoleAdmin {
+		t.Errorf("alice = %+v, want admin through the nested velero-admins group", alice)
+	}
+	if strings.Join(alice.Groups, ",") != "ops,velero-admins" {
+		t.Errorf("alice's groups = %v", alice.Groups)
+	}
+
+	// Groups also match by DN.
+	if bob, err := p.authenticate("bob", "bob-secret"); err != nil || bob.Role != RoleOperator {
+		t.Errorf("bob = %+v, %v, want operator", bob, err)
+	}
+
+	for _, tc := range []struct{ username, password string }{
+		{"alice", "wrong-password"},
+		{"alice", ""},
+		{"carol", "alice-secret"},
+		{"*", "alice-secret"},
+		{"alice)(uid=*", "alice-secret"},
+	} {
+		if _, err := p.authenticate(tc.username, tc.password); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("authenticate(%q, %q) = %v, want ErrInvalidCredentials", tc.username, tc.password, err)
+		}
+	}
+
+	cfg := searchBindConfig(url, caFile)
+	cfg.NestedGroups = false
+	flat, err := NewLDAPProvider(cfg, nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice, err := flat.authenticate("alice", "alice-secret"); err != nil || alice.Role != RoleViewer {
+		t.Errorf("alice without nested groups = %+v, %v, want viewer", alice, err)
+	}
+
+	// Removed users can't refresh their sessions.
+	if info, err := p.reload(UserInfo{Username: "bob", Role: RoleViewer}); err != nil || info.Role != RoleOperator {
+		t.Errorf("reload(bob) = %+v, %v", info, err)
+	}
+	if _, err := p.reload(UserInfo{Username: "carol"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("reload(carol) = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestLDAPDirectBind(t *testing.T) {
+	dir, caFile := newTestDirectory(t)
+	url := dir.listen(t, true)
+
+	p, err := NewLDAPProvider(LDAPConfig{
+		URL:            url,
+		CAFile:         caFile,
+		UserDNTemplate: "uid=%s,ou=people,dc=example,dc=org",
+		GroupBaseDN:    "ou=groups,dc=example,dc=org",
+		OperatorGroups: []string{"velero-operators"},
+		DefaultRole:    RoleViewer,
+	}, nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := p.authenticate("bob", "bob-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Username != "bob" || bob.Role != RoleOperator {
+		t.Errorf("bob = %+v", bob)
+	}
+	if _, err := p.authenticate("bob", "alice-secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+	// Without a service account sessions can't be refreshed: the directory
+	// can't confirm bob still exists.
+	if _, err := p.reload(*bob); !errors.Is(err, errNoServiceAccount) {
+		t.Errorf("reload without a bind DN = %v, want errNoServiceAccount", err)
+	}
+	// DN special characters can't change which entry is bound.
+	if _, err := p.authenticate("bob,ou=people", "bob-secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for an injected DN, got %v", err)
+	}
+
+	// Without the CA, the server's certificate isn't trusted.
+	untrusted, err := NewLDAPProvider(LDAPConfig{URL: url, UserDNTemplate: "uid=%s,ou=people,dc=example,dc=org"}, nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := untrusted.authenticate("bob", "bob-secret"); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected a TLS error, got %v", err)
+	}
+}
+
+func TestLDAPLogin(t *testing.T) {
+	dir, caFile := newTestDirectory(t)
+	jwtMgr := NewJWTManager("test-secret-key", time.Minute)
+	p, err := NewLDAPProvider(searchBindConfig(dir.listen(t, false), caFile), jwtMgr, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	p.SetupRoutes(app.Group("/api"))
+
+	login := func(body string) (int, map[string]string) {
+		req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, 5000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	status, out := login(`{"username":"alice","password":"alice-secret"}`)
+	if status != fiber.StatusOK || out["role"] != RoleAdmin {
+		t.Fatalf("login = %d %v", status, out)
+	}
+	claims, err := jwtMgr.Validate(out["token"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Username != "alice" || claims.Role != RoleAdmin || claims.Email != "alice@example.org" {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	if status, _ := login(`{"username":"alice","password":"nope"}`); status != fiber.StatusUnauthorized {
+		t.Errorf("wrong password = %d, want 401", status)
+	}
+	if status, _ := login(`{"username":"alice","password":""}`); status != fiber.StatusBadRequest {
+		t.Errorf("empty password = %d, want 400", status)
+	}
+}
+
+func TestNewLDAPProviderValidation(t *testing.T) {
+	for name, cfg := range map[string]LDAPConfig{
+		"no url":          {UserDNTemplate: "uid=%s"},
+		"bad scheme":      {URL: "http://ldap.example.org", UserDNTemplate: "uid=%s"},
+		"ldaps starttls":  {URL: "ldaps://ldap.example.org", StartTLS: true, UserDNTemplate: "uid=%s"},
+		"no bind method":  {URL: "ldap://ldap.example.org"},
+		"search, no base": {URL: "ldap://ldap.example.org", BindDN: "cn=svc", BindPassword: "x"},
+		"missing ca file": {URL: "ldaps://ldap.example.org", UserDNTemplate: "uid=%s", CAFile: "/nonexistent/ca.pem"},
+	} {
+		if _, err := NewLDAPProvider(cfg, nil, zap.NewNop()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -1,29 +1,65 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 )
 
-const UserContextKey = "auth_user"
+const (
+	UserContextKey    = "auth_user"
+	ExpiresContextKey = "auth_expires"
+	RecheckContextKey = "auth_recheck"
+)
+
+// Recheck re-validates the credentials a request was authenticated with, so
+// long-lived connections can notice revoked tokens and sessions.
+type Recheck func(ctx context.Context) error
+
+// errSessionRevoked is returned by a Recheck when the session has ended.
+var errSessionRevoked = errors.New("session revoked")
 
-// RequireAuth creates middleware that validates a JWT from the Authorization header.
+// WebSocketProtocol is the subprotocol negotiated on /ws. Browsers can't set
+// headers on a WebSocket handshake, so clients offer it together with a
+// "bearer.<token>" protocol carrying their JWT.
+const WebSocketProtocol = "velero-dashboard"
+
+const bearerProtocolPrefix = "bearer."
+
+// RequireAuth creates middleware that validates a JWT, or an API token once
+// SetAPITokens was called, from the Authorization header, or on WebSocket
+// upgrades from the subprotocol or token query parameter. Once SetSessions
+// was called, JWTs must also belong to a session that hasn't been revoked.
 func RequireAuth(jwtMgr *JWTManager, logger *zap.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
+		tokenStr, problem := requestToken(c)
+		if problem != "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "missing authorization header",
+				"error": problem,
 			})
 		}
 
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenStr == authHeader {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "invalid authorization format, expected Bearer token",
-			})
+		if IsAPIToken(tokenStr) && jwtMgr.apiTokens != nil {
+			user, err := jwtMgr.apiTokens.Verify(c.Context(), tokenStr)
+			if err != nil {
+				logger.Debug("API token validation failed", zap.Error(err))
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "invalid or expired token",
+				})
+			}
+			c.Locals(UserContextKey, user)
+			if !user.TokenExpiresAt.IsZero() {
+				c.Locals(ExpiresContextKey, user.TokenExpiresAt)
+			}
+			c.Locals(RecheckContextKey, Recheck(func(ctx context.Context) error {
+				_, err := jwtMgr.apiTokens.Verify(ctx, tokenStr)
+				return err
+			}))
+			return c.Next()
 		}
 
 		claims, err := jwtMgr.Validate(tokenStr)
@@ -34,16 +70,65 @@ func RequireAuth(jwtMgr *JWTManager, logger *zap.Logger) fiber.Handler {
 			})
 		}
 
+		if jwtMgr.sessions != nil && (claims.SessionID == "" || !jwtMgr.sessions.Active(c.Context(), claims.SessionID)) {
+			logger.Debug("JWT session revoked", zap.String("username", claims.Username), zap.String("session", claims.SessionID))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "session revoked",
+			})
+		}
+
 		c.Locals(UserContextKey, &UserInfo{
-			Username: claims.Username,
-			Email:    claims.Email,
-			Role:     claims.Role,
+			Username:  claims.Username,
+			Email:     claims.Email,
+			Role:      claims.Role,
+			Groups:    claims.Groups,
+			SessionID: claims.SessionID,
 		})
+		if claims.ExpiresAt != nil {
+			c.Locals(ExpiresContextKey, claims.ExpiresAt.Time)
+		}
+		if jwtMgr.sessions != nil {
+			c.Locals(RecheckContextKey, Recheck(func(ctx context.Context) error {
+				if !jwtMgr.sessions.Active(ctx, claims.SessionID) {
+					return errSessionRevoked
+				}
+				return nil
+			}))
+		}
 		return c.Next()
 	}
 }
 
-// RequireRole checks that the authenticated user has at least the required role.
+// requestToken returns the JWT of a request, or why there is none.
+func requestToken(c *fiber.Ctx) (string, string) {
+	authHeader := c.Get("Authorization")
+	if authHeader == "" && isWebSocketUpgrade(c) {
+		for _, protocol := range strings.Split(c.Get("Sec-WebSocket-Protocol"), ",") {
+			if token, ok := strings.CutPrefix(strings.TrimSpace(protocol), bearerProtocolPrefix); ok && token != "" {
+				return token, ""
+			}
+		}
+		if token := c.Query("token"); token != "" {
+			return token, ""
+		}
+	}
+	if authHeader == "" {
+		return "", "missing authorization header"
+	}
+
+	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenStr == authHeader {
+		return "", "invalid authorization format, expected Bearer token"
+	}
+	return tokenStr, ""
+}
+
+func isWebSocketUpgrade(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket")
+}
+
+// RequireRole checks that the authenticated user has at least the required
+// role on every cluster.
 func RequireRole(requiredRole string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user := GetUser(c)
@@ -52,11 +137,33 @@ func RequireRole(requiredRole string) fiber.Handler {
 				"error": "not authenticated",
 			})
 		}
-		if !RoleHierarchy(user.Role, requiredRole) {
+		if role := user.GlobalRole(); !RoleHierarchy(role, requiredRole) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error":    "insufficient permissions",
 				"required": requiredRole,
-				"current":  user.Role,
+				"current":  role,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// RequireAnyRole checks that the authenticated user holds the required role
+// globally or through a role binding on some cluster. Handlers behind it must
+// check the cluster and namespaces a request acts on with GetScope.
+func RequireAnyRole(requiredRole string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := GetUser(c)
+		if user == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "not authenticated",
+			})
+		}
+		if role := GetAuthorizer(c).MaxRole(user); !RoleHierarchy(role, requiredRole) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":    "insufficient permissions",
+				"required": requiredRole,
+				"current":  role,
 			})
 		}
 		return c.Next()
@@ -68,3 +175,17 @@ func GetUser(c *fiber.Ctx) *UserInfo {
 	user, _ := c.Locals(UserContextKey).(*UserInfo)
 	return user
 }
+
+// GetRecheck returns how to re-validate the request's credentials, or nil
+// when they can't be revoked before they expire.
+func GetRecheck(c *fiber.Ctx) Recheck {
+	recheck, _ := c.Locals(RecheckContextKey).(Recheck)
+	return recheck
+}
+
+// GetExpiry returns when the request's token expires, or the zero time when
+// it doesn't (e.g. with auth disabled).
+func GetExpiry(c *fiber.Ctx) time.Time {
+	expires, _ := c.Locals(ExpiresContextKey).(time.Time)
+	return expires
+}
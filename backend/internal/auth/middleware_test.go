@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+func TestRequireAuthTokenSources(t *testing.T) {
+	jwtMgr := NewJWTManager("test-secret-key", time.Hour)
+	token, err := jwtMgr.Generate(UserInfo{Username: "alice", Role: RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/ws", RequireAuth(jwtMgr, zap.NewNop()), func(c *fiber.Ctx) error {
+		if GetExpiry(c).Before(time.Now()) {
+			t.Errorf("expiry = %v", GetExpiry(c))
+		}
+		return c.SendString(GetUser(c).Username)
+	})
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    int
+	}{
+		{"header", "/ws", map[string]string{"Authorization": "Bearer " + token}, fiber.StatusOK},
+		{"subprotocol", "/ws", map[string]string{
+			"Upgrade":                "websocket",
+			"Sec-WebSocket-Protocol": WebSocketProtocol + ", bearer." + token,
+		}, fiber.StatusOK},
+		{"query on upgrade", "/ws?token=" + token, map[string]string{"Upgrade": "websocket"}, fiber.StatusOK},
+		{"query without upgrade", "/ws?token=" + token, nil, fiber.StatusUnauthorized},
+		{"subprotocol without upgrade", "/ws", map[string]string{"Sec-WebSocket-Protocol": "bearer." + token}, fiber.StatusUnauthorized},
+		{"no token", "/ws", map[string]string{"Upgrade": "websocket", "Sec-WebSocket-Protocol": WebSocketProtocol}, fiber.StatusUnauthorized},
+		{"invalid token", "/ws?token=garbage", map[string]string{"Upgrade": "websocket"}, fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+type staticTokens map[string]*UserInfo
+
+func (s staticTokens) Verify(_ context.Context, token string) (*UserInfo, error) {
+	if user, ok := s[token]; ok {
+		return user, nil
+	}
+	return nil, errors.New("unknown token")
+}
+
+func TestRequireAuthAPITokens(t *testing.T) {
+	jwtMgr := NewJWTManager("test-secret-key", time.Hour)
+	jwtMgr.SetAPITokens(staticTokens{
+		APITokenPrefix + "ci.secret": {Username: "token:ci", Role: RoleOperator, RoleLimit: RoleOperator, Clusters: []string{"prod"}},
+	})
+
+	app := fiber.New()
+	api := app.Group("", RequireAuth(jwtMgr, zap.NewNop()))
+	api.Get("/me", func(c *fiber.Ctx) error { return c.SendString(GetUser(c).Username) })
+	api.Get("/admin", RequireRole(RoleOperator), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		name   string
+		target string
+		token  string
+		want   int
+	}{
+		{"api token", "/me", APITokenPrefix + "ci.secret", fiber.StatusOK},
+		{"unknown api token", "/me", APITokenPrefix + "ci.wrong", fiber.StatusUnauthorized},
+		{"cluster-scoped token on global route", "/admin", APITokenPrefix + "ci.secret", fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireAuthRecheck(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	tokens := staticTokens{
+		APITokenPrefix + "ci.secret": {Username: "token:ci", Role: RoleViewer, TokenExpiresAt: expires},
+	}
+	sessions := &memorySessions{sessions: map[string]*IssuedSession{"s1": {ID: "s1"}}}
+	jwtMgr := NewJWTManager("test-secret-key", time.Hour)
+	jwtMgr.SetAPITokens(tokens)
+	jwtMgr.SetSessions(sessions)
+	jwt, err := jwtMgr.generate(UserInfo{Username: "alice", Role: RoleViewer}, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recheck Recheck
+	var expiry time.Time
+	app := fiber.New()
+	app.Get("/ws", RequireAuth(jwtMgr, zap.NewNop()), func(c *fiber.Ctx) error {
+		recheck, expiry = GetRecheck(c), GetExpiry(c)
+		return c.SendStatus(fiber.StatusOK)
+	})
+	open := func(token string) {
+		t.Helper()
+		recheck = nil
+		req := httptest.NewRequest("GET", "/ws", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if resp, err := app.Test(req); err != nil || resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request failed: %v %v", resp, err)
+		}
+		if recheck == nil {
+			t.Fatal("no recheck set")
+		}
+		if err := recheck(context.Background()); err != nil {
+			t.Fatalf("recheck failed before revocation: %v", err)
+		}
+	}
+
+	// API tokens expire with the token and stop once revoked
+	open(APITokenPrefix + "ci.secret")
+	if !expiry.Equal(expires) {
+		t.Errorf("expiry = %v, want %v", expiry, expires)
+	}
+	delete(tokens, APITokenPrefix+"ci.secret")
+	if err := recheck(context.Background()); err == nil {
+		t.Error("recheck passed for a revoked API token")
+	}
+
+	// JWTs stop once their session is revoked
+	open(jwt)
+	_ = sessions.Revoke(context.Background(), "s1")
+	if err := recheck(context.Background()); err == nil {
+		t.Error("recheck passed for a revoked session")
+	}
+}
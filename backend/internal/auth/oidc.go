@@ -3,8 +3,9 @@ package auth
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
-	"net/url"
+	"fmt"
 	"strings"
 	"time"
 
@@ -27,6 +28,11 @@ type OIDCConfig struct {
 	FrontendURL    string
 }
 
+const (
+	oidcFlowCookie     = "oidc_flow"
+	oidcFlowCookiePath = "/api/auth/oidc"
+)
+
 // OIDCProvider handles OIDC authentication.
 type OIDCProvider struct {
 	provider     *oidc.Provider
@@ -80,50 +86,68 @@ func (p *OIDCProvider) SetupRoutes(router fiber.Router) {
 	router.Get("/auth/oidc/login", p.login)
 	router.Get("/auth/oidc/callback", p.callback)
 	router.Get("/auth/me", RequireAuth(p.jwtMgr, p.logger), p.me)
+	sessionRoutes(router, p.jwtMgr, nil, p.logger)
 }
 
 func (p *OIDCProvider) Middleware() fiber.Handler {
 	return RequireAuth(p.jwtMgr, p.logger)
 }
 
+// login starts the authorization code flow. The state, nonce and PKCE
+// verifier of the flow are kept in an HttpOnly cookie until the callback.
 func (p *OIDCProvider) login(c *fiber.Ctx) error {
-	state := generateState()
+	flow := oidcFlow{state: generateState(), nonce: generateState(), verifier: oauth2.GenerateVerifier()}
 
 	c.Cookie(&fiber.Cookie{
-		Name:     "oidc_state",
-		Value:    state,
+		Name:     oidcFlowCookie,
+		Value:    flow.String(),
+		Path:     oidcFlowCookiePath,
 		MaxAge:   300, // 5 minutes
 		HTTPOnly: true,
-		SameSite: "Lax",
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
 	})
 
-	authURL := p.oauth2Config.AuthCodeURL(state)
+	authURL := p.oauth2Config.AuthCodeURL(flow.state, oidc.Nonce(flow.nonce), oauth2.S256ChallengeOption(flow.verifier))
 	return c.Redirect(authURL, fiber.StatusTemporaryRedirect)
 }
 
+// callback completes the flow. The session is handed to the frontend in
+// the HttpOnly refresh cookie, never in the URL: the frontend's callback
+// page trades it for an access token at /auth/refresh.
 func (p *OIDCProvider) callback(c *fiber.Ctx) error {
-	code := c.Query("code")
-	state := c.Query("state")
+	flow, ok := parseOIDCFlow(c.Cookies(oidcFlowCookie))
+	// The flow is single-use, whatever the outcome.
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    "",
+		Path:     oidcFlowCookiePath,
+		MaxAge:   -1,
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
 
+	if idpErr := c.Query("error"); idpErr != "" {
+		p.logger.Warn("OIDC login failed at the identity provider",
+			zap.String("error", idpErr), zap.String("description", c.Query("error_description")))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "login failed at the identity provider"})
+	}
+
+	code := c.Query("code")
 	if code == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing code parameter"})
 	}
-
-	expectedState := c.Cookies("oidc_state")
-	if expectedState != "" && state != expectedState {
+	if !ok || subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(flow.state)) != 1 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid state parameter"})
 	}
+	if p.jwtMgr.sessions == nil {
+		p.logger.Error("OIDC login needs login sessions to hand over the session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "login sessions are not enabled"})
+	}
 
-	// Clear state cookie
-	c.Cookie(&fiber.Cookie{
-		Name:     "oidc_state",
-		Value:    "",
-		MaxAge:   -1,
-		HTTPOnly: true,
-	})
-
-	ctx := context.Background()
-	oauth2Token, err := p.oauth2Config.Exchange(ctx, code)
+	ctx := c.Context()
+	oauth2Token, err := p.oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(flow.verifier))
 	if err != nil {
 		p.logger.Error("OIDC token exchange failed", zap.Error(err))
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "token exchange failed"})
@@ -139,6 +163,10 @@ func (p *OIDCProvider) callback(c *fiber.Ctx) error {
 		p.logger.Error("OIDC token verification failed", zap.Error(err))
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "token verification failed"})
 	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(flow.nonce)) != 1 {
+		p.logger.Warn("OIDC ID token nonce mismatch")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "token verification failed"})
+	}
 
 	var claims map[string]interface{}
 	if err := idToken.Claims(&claims); err != nil {
@@ -151,17 +179,40 @@ func (p *OIDCProvider) callback(c *fiber.Ctx) error {
 		name = email
 	}
 
-	role := p.resolveRole(claims)
-
-	token, err := p.jwtMgr.Generate(UserInfo{Username: name, Email: email, Role: role})
+	groups, err := p.groups(ctx, idToken, claims, oauth2Token)
 	if err != nil {
-		p.logger.Error("Failed to generate JWT", zap.Error(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate token"})
+		p.logger.Error("Failed to fetch OIDC groups", zap.Error(err))
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "failed to fetch groups"})
+	}
+	role := groupRole(groups, p.cfg.AdminGroups, p.cfg.OperatorGroups, p.cfg.DefaultRole)
+
+	if _, err := p.jwtMgr.login(c, UserInfo{Username: name, Email: email, Role: role, Groups: groups}); err != nil {
+		p.logger.Error("Failed to start session", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start session"})
+	}
+	return c.Redirect(p.cfg.FrontendURL+"/auth/callback", fiber.StatusTemporaryRedirect)
+}
+
+// groups returns the user's groups from the ID token or, when the ID token
+// leaves them out, from the userinfo endpoint. Azure AD, for one, omits
+// groups from ID tokens of users in too many of them ("overage").
+func (p *OIDCProvider) groups(ctx context.Context, idToken *oidc.IDToken, claims map[string]interface{}, token *oauth2.Token) ([]string, error) {
+	if _, ok := claims[p.cfg.RoleClaim]; ok || p.provider.UserInfoEndpoint() == "" {
+		return extractGroups(claims, p.cfg.RoleClaim), nil
 	}
 
-	// Redirect to frontend callback with token
-	frontendCallback := p.cfg.FrontendURL + "/auth/callback?token=" + url.QueryEscape(token)
-	return c.Redirect(frontendCallback, fiber.StatusTemporaryRedirect)
+	info, err := p.provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+	if err != nil {
+		return nil, err
+	}
+	if info.Subject != idToken.Subject {
+		return nil, fmt.Errorf("userinfo subject %q doesn't match the ID token's", info.Subject)
+	}
+	var infoClaims map[string]interface{}
+	if err := info.Claims(&infoClaims); err != nil {
+		return nil, err
+	}
+	return extractGroups(infoClaims, p.cfg.RoleClaim), nil
 }
 
 func (p *OIDCProvider) me(c *fiber.Ctx) error {
@@ -172,24 +223,24 @@ func (p *OIDCProvider) me(c *fiber.Ctx) error {
 	return c.JSON(user)
 }
 
-func (p *OIDCProvider) resolveRole(claims map[string]interface{}) string {
-	groups := extractGroups(claims, p.cfg.RoleClaim)
-
+// groupRole maps a user's groups to admin or operator, case-insensitively,
+// and to defaultRole when none matches.
+func groupRole(groups, adminGroups, operatorGroups []string, defaultRole string) string {
 	for _, g := range groups {
-		for _, admin := range p.cfg.AdminGroups {
+		for _, admin := range adminGroups {
 			if strings.EqualFold(g, admin) {
 				return RoleAdmin
 			}
 		}
 	}
 	for _, g := range groups {
-		for _, op := range p.cfg.OperatorGroups {
+		for _, op := range operatorGroups {
 			if strings.EqualFold(g, op) {
 				return RoleOperator
 			}
 		}
 	}
-	return p.cfg.DefaultRole
+	return defaultRole
 }
 
 func extractGroups(claims map[string]interface{}, claimKey string) []string {
@@ -212,6 +263,27 @@ func extractGroups(claims map[string]interface{}, claimKey string) []string {
 	return nil
 }
 
+// oidcFlow is the state of a login in progress.
+type oidcFlow struct {
+	state    string
+	nonce    string
+	verifier string
+}
+
+// String encodes the flow for the flow cookie. None of the parts contains
+// a dot.
+func (f oidcFlow) String() string {
+	return f.state + "." + f.nonce + "." + f.verifier
+}
+
+func parseOIDCFlow(v string) (oidcFlow, bool) {
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return oidcFlow{}, false
+	}
+	return oidcFlow{state: parts[0], nonce: parts[1], verifier: parts[2]}, true
+}
+
 func generateState() string {
 	b := make([]byte, 16)
 	if _, err := rand.Read(b); err != nil {
@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// mockIdP is a minimal OIDC provider. Tests register the authorization
+// codes it accepts, as if the user had signed in.
+type mockIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu       sync.Mutex
+	codes    map[string]mockGrant
+	userinfo map[string]map[string]interface{} // access token -> claims
+}
+
+type mockGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+	userinfo  map[string]interface{}
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, codes: map[string]mockGrant{}, userinfo: map[string]map[string]interface{}{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"userinfo_endpoint":                     idp.URL + "/userinfo",
+			"jwks_uri":                              idp.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "test", "alg": "RS256", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		claims, ok := idp.userinfo[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		idp.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(claims)
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// token redeems a code once, checking the PKCE verifier against the
+// challenge of the authorization request.
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := jwt.MapClaims{"iss": idp.URL, "aud": "dashboard", "sub": "user-1", "exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix()}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "test"
+	idToken, err := tok.SignedString(idp.key)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	accessToken := generateState()
+	if grant.userinfo != nil {
+		idp.mu.Lock()
+		idp.userinfo[accessToken] = grant.userinfo
+		idp.mu.Unlock()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": accessToken, "token_type": "Bearer", "expires_in": 3600, "id_token": idToken,
+	})
+}
+
+// oidcLogin is a login in progress: the authorization request and the
+// flow cookie set by /auth/oidc/login.
+type oidcLogin struct {
+	query  url.Values
+	cookie string
+}
+
+type oidcTestApp struct {
+	app    *fiber.App
+	idp    *mockIdP
+	jwtMgr *JWTManager
+}
+
+func newOIDCTestApp(t *testing.T) *oidcTestApp {
+	t.Helper()
+	idp := newMockIdP(t)
+	jwtMgr := NewJWTManager("test-secret-key", time.Minute)
+	jwtMgr.SetSessions(&memorySessions{sessions: map[string]*IssuedSession{}})
+	p, err := NewOIDCProvider(OIDCConfig{
+		Issuer:         idp.URL,
+		ClientID:       "dashboard",
+		ClientSecret:   "client-secret",
+		RedirectURL:    "http://dashboard.test/api/auth/oidc/callback",
+		RoleClaim:      "groups",
+		AdminGroups:    []string{"velero-admins"},
+		OperatorGroups: []string{"velero-operators"},
+		DefaultRole:    RoleViewer,
+		FrontendURL:    "http://dashboard.test",
+	}, jwtMgr, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	p.SetupRoutes(app.Group("/api"))
+	return &oidcTestApp{app: app, idp: idp, jwtMgr: jwtMgr}
+}
+
+func (a *oidcTestApp) do(t *testing.T, method, target, cookie string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	resp, err := a.app.Test(req, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func (a *oidcTestApp) start(t *testing.T) oidcLogin {
+	t.Helper()
+	resp := a.do(t, "GET", "/api/auth/oidc/login", "")
+	if resp.StatusCode != fiber.StatusTemporaryRedirect {
+		t.Fatalf("login = %d", resp.StatusCode)
+	}
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cookie string
+	for _, c := range resp.Cookies() {
+		if c.Name == oidcFlowCookie {
+			if !c.HttpOnly || c.Path != oidcFlowCookiePath {
+				t.Errorf("flow cookie = %+v", c)
+			}
+			cookie = c.Name + "=" + c.Value
+		}
+	}
+	q := authURL.Query()
+	if q.Get("state") == "" || q.Get("nonce") == "" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization request = %v", q)
+	}
+	return oidcLogin{query: q, cookie: cookie}
+}
+
+// authorize registers a code for login, as the IdP does once the user signed
+// in.
+func (a *oidcTestApp) authorize(login oidcLogin, claims jwt.MapClaims, userinfo map[string]interface{}) string {
+	code := generateState()
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = login.query.Get("nonce")
+	}
+	a.idp.mu.Lock()
+	a.idp.codes[code] = mockGrant{challenge: login.query.Get("code_challenge"), claims: claims, userinfo: userinfo}
+	a.idp.mu.Unlock()
+	return code
+}
+
+func (a *oidcTestApp) callback(t *testing.T, code, state, cookie string) *http.Response {
+	t.Helper()
+	return a.do(t, "GET", "/api/auth/oidc/callback?code="+code+"&state="+url.QueryEscape(state), cookie)
+}
+
+// session trades the refresh cookie set by a successful callback for the
+// session's claims, like the frontend's callback page.
+func (a *oidcTestApp) session(t *testing.T, resp *http.Response) *Claims {
+	t.Helper()
+	var refresh string
+	for _, c := range resp.Cookies() {
+		if c.Name == RefreshCookie {
+			refresh = c.Name + "=" + c.Value
+		}
+	}
+	if refresh == "" {
+		t.Fatal("callback set no refresh cookie")
+	}
+	out := a.do(t, "POST", "/api/auth/refresh", refresh)
+	var body map[string]string
+	_ = json.NewDecoder(out.Body).Decode(&body)
+	claims, err := a.jwtMgr.Validate(body["token"])
+	if err != nil {
+		t.Fatalf("refresh = %d %v: %v", out.StatusCode, body, err)
+	}
+	return claims
+}
+
+func TestOIDCLogin(t *testing.T) {
+	a := newOIDCTestApp(t)
+	login := a.start(t)
+	code := a.authorize(login, jwt.MapClaims{"email": "alice@example.org", "name": "alice", "groups": []string{"velero-operators"}}, nil)
+
+	resp := a.callback(t, code, login.query.Get("state"), login.cookie)
+	if resp.StatusCode != fiber.StatusTemporaryRedirect {
+		t.Fatalf("callback = %d", resp.StatusCode)
+	}
+	if loc := resp.Header.Get("Location"); loc != "http://dashboard.test/auth/callback" {
+		t.Errorf("callback redirects to %q, want no token in the URL", loc)
+	}
+	claims := a.session(t, resp)
+	if claims.Username != "alice" || claims.Email != "alice@example.org" || claims.Role != RoleOperator {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// Codes and flows are single-use.
+	if resp := a.callback(t, code, login.query.Get("state"), login.cookie); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("replayed code = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestOIDCCallbackRejects(t *testing.T) {
+	a := newOIDCTestApp(t)
+	claims := func() jwt.MapClaims { return jwt.MapClaims{"email": "alice@example.org"} }
+
+	t.Run("missing state cookie", func(t *testing.T) {
+		login := a.start(t)
+		code := a.authorize(login, claims(), nil)
+		if resp := a.callback(t, code, login.query.Get("state"), ""); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("status = %d, want 400", resp.StatusCode)
+		}
+	})
+
+	t.Run("wrong state", func(t *testing.T) {
+		login := a.start(t)
+		code := a.authorize(login, claims(), nil)
+		if resp := a.callback(t, code, "forged", login.cookie); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("status = %d, want 400", resp.StatusCode)
+		}
+	})
+
+	t.Run("wrong PKCE verifier", func(t *testing.T) {
+		login := a.start(t)
+		code := a.authorize(login, claims(), nil)
+		flow, _ := parseOIDCFlow(strings.TrimPrefix(login.cookie, oidcFlowCookie+"="))
+		flow.verifier = "stolen-code-without-the-verifier-of-this-browser-0000"
+		if resp := a.callback(t, code, flow.state, oidcFlowCookie+"="+flow.String()); resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", resp.StatusCode)
+		}
+	})
+
+	t.Run("wrong nonce", func(t *testing.T) {
+		login := a.start(t)
+		c := claims()
+		c["nonce"] = "replayed-id-token"
+		code := a.authorize(login, c, nil)
+		resp := a.callback(t, code, login.query.Get("state"), login.cookie)
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", resp.StatusCode)
+		}
+		for _, c := range resp.Cookies() {
+			if c.Name == RefreshCookie && c.Value != "" {
+				t.Error("a session was started")
+			}
+		}
+	})
+
+	t.Run("identity provider error", func(t *testing.T) {
+		login := a.start(t)
+		resp := a.do(t, "GET", "/api/auth/oidc/callback?error=access_denied&state="+login.query.Get("state"), login.cookie)
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", resp.StatusCode)
+		}
+	})
+}
+
+func TestOIDCGroupOverage(t *testing.T) {
+	a := newOIDCTestApp(t)
+
+	// Groups missing from the ID token come from userinfo.
+	login := a.start(t)
+	code := a.authorize(login,
+		jwt.MapClaims{
+			"email":          "bob@example.org",
+			"_claim_names":   map[string]string{"groups": "src1"},
+			"_claim_sources": map[string]interface{}{"src1": map[string]string{"endpoint": "https://graph.example.org/getMemberObjects"}},
+		},
+		map[string]interface{}{"sub": "user-1", "groups": []string{"velero-admins"}})
+	resp := a.callback(t, code, login.query.Get("state"), login.cookie)
+	if resp.StatusCode != fiber.StatusTemporaryRedirect {
+		t.Fatalf("callback = %d", resp.StatusCode)
+	}
+	if claims := a.session(t, resp); claims.Role != RoleAdmin || strings.Join(claims.Groups, ",") != "velero-admins" {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// Userinfo about someone else is not trusted.
+	login = a.start(t)
+	code = a.authorize(login, jwt.MapClaims{"email": "bob@example.org"},
+		map[string]interface{}{"sub": "user-2", "groups": []string{"velero-admins"}})
+	if resp := a.callback(t, code, login.query.Get("state"), login.cookie); resp.StatusCode != fiber.StatusBadGateway {
+		t.Errorf("mismatched userinfo subject = %d, want 502", resp.StatusCode)
+	}
+}
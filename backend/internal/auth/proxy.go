@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// ProxyConfig holds the trusted reverse-proxy provider configuration.
+type ProxyConfig struct {
+	// TrustedCIDRs are the addresses of the proxies allowed to assert
+	// identities. Bare IPs are accepted too.
+	TrustedCIDRs   []string
+	UserHeader     string
+	EmailHeader    string
+	GroupsHeader   string
+	AdminGroups    []string
+	OperatorGroups []string
+	DefaultRole    string
+}
+
+// ProxyProvider trusts the identity headers set by an authenticating reverse
+// proxy, such as oauth2-proxy, but only on connections from trusted proxy
+// addresses. Other clients may still use API tokens.
+type ProxyProvider struct {
+	trusted []netip.Prefix
+	cfg     ProxyConfig
+	jwtMgr  *JWTManager
+	logger  *zap.Logger
+}
+
+// NewProxyProvider creates a reverse-proxy auth provider.
+func NewProxyProvider(cfg ProxyConfig, jwtMgr *JWTManager, logger *zap.Logger) (*ProxyProvider, error) {
+	if len(cfg.TrustedCIDRs) == 0 {
+		return nil, errors.New("at least one trusted proxy CIDR is required")
+	}
+	trusted := make([]netip.Prefix, 0, len(cfg.TrustedCIDRs))
+	for _, cidr := range cfg.TrustedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid trusted proxy CIDR %q: %w", cidr, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	if cfg.UserHeader == "" {
+		cfg.UserHeader = "X-Forwarded-User"
+	}
+	if cfg.EmailHeader == "" {
+		cfg.EmailHeader = "X-Forwarded-Email"
+	}
+	if cfg.GroupsHeader == "" {
+		cfg.GroupsHeader = "X-Forwarded-Groups"
+	}
+	return &ProxyProvider{trusted: trusted, cfg: cfg, jwtMgr: jwtMgr, logger: logger}, nil
+}
+
+func (p *ProxyProvider) Mode() string {
+	return "proxy"
+}
+
+func (p *ProxyProvider) SetupRoutes(router fiber.Router) {
+	router.Get("/auth/me", p.Middleware(), p.me)
+}
+
+// Middleware authenticates requests by the proxy's headers. Requests from
+// other addresses carrying those headers are rejected as spoofed; requests
+// without them fall back to API tokens.
+func (p *ProxyProvider) Middleware() fiber.Handler {
+	requireToken := RequireAuth(p.jwtMgr, p.logger)
+	return func(c *fiber.Ctx) error {
+		username := strings.TrimSpace(c.Get(p.cfg.UserHeader))
+		email := strings.TrimSpace(c.Get(p.cfg.EmailHeader))
+		groupsHeader := c.Get(p.cfg.GroupsHeader)
+		asserted := username != "" || email != "" || groupsHeader != ""
+
+		if !p.trustedPeer(c) {
+			if asserted {
+				p.logger.Warn("Rejected identity headers from an untrusted address",
+					zap.String("remote", c.Context().RemoteIP().String()), zap.String("path", c.Path()))
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "untrusted proxy"})
+			}
+			return requireToken(c)
+		}
+
+		if username == "" {
+			username = email
+		}
+		if username == "" {
+			return requireToken(c)
+		}
+
+		groups := splitHeaderList(groupsHeader)
+		c.Locals(UserContextKey, &UserInfo{
+			Username: username,
+			Email:    email,
+			Role:     groupRole(groups, p.cfg.AdminGroups, p.cfg.OperatorGroups, p.cfg.DefaultRole),
+			Groups:   groups,
+		})
+		return c.Next()
+	}
+}
+
+// trustedPeer reports whether the connection comes from a trusted proxy. It
+// looks at the TCP peer, never at X-Forwarded-For, which clients control.
+func (p *ProxyProvider) trustedPeer(c *fiber.Ctx) bool {
+	addr, ok := netip.AddrFromSlice(c.Context().RemoteIP())
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ProxyProvider) me(c *fiber.Ctx) error {
+	user := GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "not authenticated"})
+	}
+	return c.JSON(user)
+}
+
+// splitHeaderList splits a comma-separated header value, dropping empty
+// entries.
+func splitHeaderList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
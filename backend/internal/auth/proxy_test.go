@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// app.Test connections come from 0.0.0.0.
+func proxyApp(t *testing.T, trusted ...string) *fiber.App {
+	t.Helper()
+	provider, err := NewProxyProvider(ProxyConfig{
+		TrustedCIDRs:   trusted,
+		AdminGroups:    []string{"velero-admins"},
+		OperatorGroups: []string{"velero-operators"},
+		DefaultRole:    RoleViewer,
+	}, NewJWTManager("test-secret-key", time.Minute), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	app.Get("/whoami", provider.Middleware(), func(c *fiber.Ctx) error {
+		user := GetUser(c)
+		return c.SendString(user.Username + ":" + user.Email + ":" + user.Role)
+	})
+	return app
+}
+
+func proxyRequest(t *testing.T, app *fiber.App, headers map[string]string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/whoami", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestProxyTrustedHeaders(t *testing.T) {
+	app := proxyApp(t, "10.0.0.0/8", "0.0.0.0")
+
+	tests := []struct {
+		headers map[string]string
+		want    string
+	}{
+		{map[string]string{"X-Forwarded-User": "alice", "X-Forwarded-Email": "alice@example.com", "X-Forwarded-Groups": "dev, Velero-Admins"}, "alice:alice@example.com:admin"},
+		{map[string]string{"X-Forwarded-User": "bob", "X-Forwarded-Groups": "velero-operators"}, "bob::operator"},
+		{map[string]string{"X-Forwarded-Email": "carol@example.com"}, "carol@example.com:carol@example.com:viewer"},
+	}
+	for _, tt := range tests {
+		if status, body := proxyRequest(t, app, tt.headers); status != fiber.StatusOK || body != tt.want {
+			t.Errorf("headers %v: status = %d, body = %q, want %q", tt.headers, status, body, tt.want)
+		}
+	}
+	if status, _ := proxyRequest(t, app, nil); status != fiber.StatusUnauthorized {
+		t.Errorf("request without identity status = %d", status)
+	}
+}
+
+func TestProxyRejectsSpoofedHeaders(t *testing.T) {
+	app := proxyApp(t, "10.0.0.0/8")
+
+	status, body := proxyRequest(t, app, map[string]string{
+		"X-Forwarded-User":   "alice",
+		"X-Forwarded-Groups": "velero-admins",
+		"X-Forwarded-For":    "10.0.0.1",
+	})
+	if status != fiber.StatusUnauthorized {
+		t.Errorf("spoofed headers: status = %d, body = %q", status, body)
+	}
+	if status, _ := proxyRequest(t, app, map[string]string{"X-Forwarded-Groups": "velero-admins"}); status != fiber.StatusUnauthorized {
+		t.Errorf("spoofed groups: status = %d", status)
+	}
+}
+
+func TestNewProxyProviderValidation(t *testing.T) {
+	jwtMgr := NewJWTManager("test-secret-key", time.Minute)
+	if _, err := NewProxyProvider(ProxyConfig{}, jwtMgr, zap.NewNop()); err == nil {
+		t.Error("expected an error without trusted CIDRs")
+	}
+	if _, err := NewProxyProvider(ProxyConfig{TrustedCIDRs: []string{"not-a-cidr"}}, jwtMgr, zap.NewNop()); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
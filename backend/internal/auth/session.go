@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// RefreshCookie holds the refresh token of a browser session. It is only
+// sent to the refresh and logout endpoints.
+const (
+	RefreshCookie     = "velero_refresh"
+	refreshCookiePath = "/api/auth"
+)
+
+// SessionStore keeps the server-side state of browser sessions: who logged
+// in, and the hash of their current refresh token.
+type SessionStore interface {
+	// Create starts a session for user.
+	Create(ctx context.Context, user UserInfo, client SessionClient) (*IssuedSession, error)
+	// Refresh rotates a refresh token. reload, when set, may update the
+	// user or reject them, which ends the session.
+	Refresh(ctx context.Context, refreshToken string, reload UserReloader) (*IssuedSession, error)
+	// Active reports whether a session still exists.
+	Active(ctx context.Context, id string) bool
+	// Revoke ends a session.
+	Revoke(ctx context.Context, id string) error
+	// RevokeUser ends every session of a user.
+	RevokeUser(ctx context.Context, username string) (int, error)
+	// Logout ends the session a refresh token belongs to.
+	Logout(ctx context.Context, refreshToken string) error
+}
+
+// SessionClient describes where a session was started from.
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+// IssuedSession is a session together with its new refresh token.
+type IssuedSession struct {
+	ID           string
+	User         UserInfo
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// UserReloader re-reads a user on refresh, so removed users lose access and
+// role changes apply without a new login.
+type UserReloader func(UserInfo) (UserInfo, error)
+
+// SetSessions makes logins start server-side sessions. Access tokens then
+// carry the session's ID, and RequireAuth rejects them once it is revoked.
+func (m *JWTManager) SetSessions(s SessionStore) {
+	m.sessions = s
+}
+
+// login issues an access token for user and, with sessions enabled, sets the
+// refresh token cookie.
+func (m *JWTManager) login(c *fiber.Ctx, user UserInfo) (string, error) {
+	if m.sessions == nil {
+		return m.Generate(user)
+	}
+	issued, err := m.sessions.Create(c.Context(), user, SessionClient{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	})
+	if err != nil {
+		return "", err
+	}
+	setRefreshCookie(c, issued)
+	return m.generate(user, issued.ID)
+}
+
+// sessionRoutes registers the refresh and logout endpoints shared by the
+// login providers.
+func sessionRoutes(router fiber.Router, m *JWTManager, reload UserReloader, logger *zap.Logger) {
+	router.Post("/auth/refresh", func(c *fiber.Ctx) error {
+		refreshToken := c.Cookies(RefreshCookie)
+		if m.sessions == nil || refreshToken == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "no session"})
+		}
+		issued, err := m.sessions.Refresh(c.Context(), refreshToken, reload)
+		if err != nil {
+			logger.Debug("Session refresh failed", zap.Error(err))
+			clearRefreshCookie(c)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired session"})
+		}
+		token, err := m.generate(issued.User, issued.ID)
+		if err != nil {
+			logger.Error("Failed to generate JWT", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate token"})
+		}
+		setRefreshCookie(c, issued)
+		return c.JSON(fiber.Map{
+			"token":    token,
+			"username": issued.User.Username,
+			"role":     issued.User.Role,
+		})
+	})
+
+	// Logout ends the session of the refresh cookie and of the bearer token,
+	// whichever the client still has. It succeeds even when neither is
+	// valid any more.
+	router.Post("/auth/logout", func(c *fiber.Ctx) error {
+		if m.sessions != nil {
+			if refreshToken := c.Cookies(RefreshCookie); refreshToken != "" {
+				if err := m.sessions.Logout(c.Context(), refreshToken); err != nil {
+					logger.Debug("Logout by refresh token failed", zap.Error(err))
+				}
+			}
+			if tokenStr, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer "); ok && !IsAPIToken(tokenStr) {
+				if claims, err := m.Validate(tokenStr); err == nil && claims.SessionID != "" {
+					if err := m.sessions.Revoke(c.Context(), claims.SessionID); err != nil {
+						logger.Debug("Logout by access token failed", zap.Error(err))
+					}
+				}
+			}
+		}
+		clearRefreshCookie(c)
+		return c.JSON(fiber.Map{"message": "logged out"})
+	})
+}
+
+func setRefreshCookie(c *fiber.Ctx, issued *IssuedSession) {
+	c.Cookie(&fiber.Cookie{
+		Name:     RefreshCookie,
+		Value:    issued.RefreshToken,
+		Path:     refreshCookiePath,
+		Expires:  issued.ExpiresAt,
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+}
+
+func clearRefreshCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     RefreshCookie,
+		Value:    "",
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+}
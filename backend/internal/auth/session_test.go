@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memorySessions is a SessionStore whose refresh tokens are "<id>.<n>".
+type memorySessions struct {
+	sessions map[string]*IssuedSession
+	next     int
+}
+
+func (m *memorySessions) Create(_ context.Context, user UserInfo, _ SessionClient) (*IssuedSession, error) {
+	m.next++
+	id := string(rune('a' + m.next))
+	s := &IssuedSession{ID: id, User: user, ExpiresAt: time.Now().Add(time.Hour)}
+	m.sessions[id] = s
+	return m.rotate(s), nil
+}
+
+func (m *memorySessions) Refresh(_ context.Context, token string, reload UserReloader) (*IssuedSession, error) {
+	id, _, _ := strings.Cut(token, ".")
+	s, ok := m.sessions[id]
+	if !ok || s.RefreshToken != token {
+		return nil, errors.New("invalid")
+	}
+	if reload != nil {
+		user, err := reload(s.User)
+		if err != nil {
+			delete(m.sessions, id)
+			return nil, err
+		}
+		s.User = user
+	}
+	return m.rotate(s), nil
+}
+
+func (m *memorySessions) rotate(s *IssuedSession) *IssuedSession {
+	m.next++
+	s.RefreshToken = s.ID + "." + string(rune('a'+m.next))
+	cp := *s
+	return &cp
+}
+
+func (m *memorySessions) Active(_ context.Context, id string) bool {
+	_, ok := m.sessions[id]
+	return ok
+}
+
+func (m *memorySessions) Revoke(_ context.Context, id string) error {
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *memorySessions) RevokeUser(_ context.Context, username string) (int, error) {
+	n := 0
+	for id, s := range m.sessions {
+		if s.User.Username == username {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memorySessions) Logout(_ context.Context, token string) error {
+	id, _, _ := strings.Cut(token, ".")
+	if s, ok := m.sessions[id]; ok && s.RefreshToken == token {
+		delete(m.sessions, id)
+	}
+	return nil
+}
+
+func TestBasicSessions(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtMgr := NewJWTManager("test-secret-key", time.Minute)
+	sessions := &memorySessions{sessions: map[string]*IssuedSession{}}
+	jwtMgr.SetSessions(sessions)
+	provider, err := NewBasicProvider("alice:"+string(hash)+":admin", jwtMgr, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	provider.SetupRoutes(app.Group("/api"))
+	app.Get("/api/whoami", provider.Middleware(), func(c *fiber.Ctx) error {
+		return c.SendString(GetUser(c).Role)
+	})
+
+	do := func(method, path, token, cookie, body string) (*http.Response, map[string]string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: RefreshCookie, Value: cookie})
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+	refreshCookie := func(resp *http.Response) string {
+		t.Helper()
+		for _, c := range resp.Cookies() {
+			if c.Name == RefreshCookie {
+				if !c.HttpOnly || c.Path != "/api/auth" {
+					t.Errorf("refresh cookie must be HttpOnly and scoped to /api/auth: %+v", c)
+				}
+				return c.Value
+			}
+		}
+		t.Fatal("no refresh cookie set")
+		return ""
+	}
+
+	resp, body := do("POST", "/api/auth/login", "", "", `{"username":"alice","password":"secret"}`)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("login status = %d", resp.StatusCode)
+	}
+	access, cookie := body["token"], refreshCookie(resp)
+	claims, err := jwtMgr.Validate(access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SessionID == "" || !sessions.Active(context.Background(), claims.SessionID) {
+		t.Fatalf("access token must name an active session, got %q", claims.SessionID)
+	}
+
+	if resp, _ := do("GET", "/api/whoami", access, "", ""); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("whoami status = %d", resp.StatusCode)
+	}
+	legacy, _ := jwtMgr.Generate(UserInfo{Username: "alice", Role: RoleAdmin})
+	if resp, _ := do("GET", "/api/whoami", legacy, "", ""); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("tokens without a session must be rejected, status = %d", resp.StatusCode)
+	}
+
+	resp, body = do("POST", "/api/auth/refresh", "", cookie, "")
+	if resp.StatusCode != fiber.StatusOK || body["token"] == "" || body["role"] != RoleAdmin {
+		t.Fatalf("refresh status = %d, body = %v", resp.StatusCode, body)
+	}
+	if next := refreshCookie(resp); next == cookie {
+		t.Error("refresh must rotate the refresh token")
+	} else {
+		cookie = next
+	}
+	if resp, _ := do("POST", "/api/auth/refresh", "", "", ""); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("refresh without cookie status = %d", resp.StatusCode)
+	}
+
+	resp, _ = do("POST", "/api/auth/logout", access, cookie, "")
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("logout status = %d", resp.StatusCode)
+	}
+	if resp, _ := do("GET", "/api/whoami", access, "", ""); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("access token must be rejected after logout, status = %d", resp.StatusCode)
+	}
+	if resp, _ := do("POST", "/api/auth/refresh", "", cookie, ""); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("refresh after logout status = %d", resp.StatusCode)
+	}
+
+	// Users removed from AUTH_USERS can't refresh.
+	resp, _ = do("POST", "/api/auth/login", "", "", `{"username":"alice","password":"secret"}`)
+	cookie = refreshCookie(resp)
+	delete(provider.users.(staticUsers), "alice")
+	if resp, _ := do("POST", "/api/auth/refresh", "", cookie, ""); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("refresh of a removed user status = %d", resp.StatusCode)
+	}
+}
+
+// resettableUsers are staticUsers whose passwords can be changed.
+type resettableUsers struct{ staticUsers }
+
+func (r resettableUsers) ChangePassword(ctx context.Context, username, current, next string) (*LocalUser, error) {
+	user, err := r.Authenticate(ctx, username, current)
+	if err != nil {
+		return nil, err
+	}
+	if len(next) < 8 {
+		return nil, ErrPasswordPolicy
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte(next), bcrypt.MinCost)
+	user.PasswordHash, user.MustChangePassword = string(hash), false
+	r.staticUsers[username] = *user
+	return user, nil
+}
+
+func TestBasicForcedPasswordChange(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("temporary"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtMgr := NewJWTManager("test-secret-key", time.Minute)
+	sessions := &memorySessions{sessions: map[string]*IssuedSession{}}
+	jwtMgr.SetSessions(sessions)
+	provider, err := NewBasicProvider("", jwtMgr, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.SetUsers(resettableUsers{staticUsers{"bob": {
+		Username: "bob", PasswordHash: string(hash), Role: RoleViewer, MustChangePassword: true,
+	}}})
+
+	app := fiber.New()
+	provider.SetupRoutes(app.Group("/api"))
+	post := func(path, body string) (int, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	status, body := post("/api/auth/login", `{"username":"bob","password":"temporary"}`)
+	if status != fiber.StatusForbidden || body["passwordChangeRequired"] != true {
+		t.Fatalf("login with a temporary password: status = %d, body = %v", status, body)
+	}
+	if len(sessions.sessions) != 0 {
+		t.Error("no session may start before the password is changed")
+	}
+	// Personal API tokens of bob stop working until then too
+	if _, err := provider.ResolveUser(context.Background(), UserInfo{Username: "bob"}); !errors.Is(err, ErrPasswordChangeRequired) {
+		t.Errorf("ResolveUser with a pending password change: %v", err)
+	}
+
+	if status, _ := post("/api/auth/password", `{"username":"bob","currentPassword":"wrong","newPassword":"brand-new-secret"}`); status != fiber.StatusUnauthorized {
+		t.Errorf("change with a wrong password status = %d", status)
+	}
+	if status, _ := post("/api/auth/password", `{"username":"bob","currentPassword":"temporary","newPassword":"short"}`); status != fiber.StatusBadRequest {
+		t.Errorf("change to a weak password status = %d", status)
+	}
+	status, body = post("/api/auth/password", `{"username":"bob","currentPassword":"temporary","newPassword":"brand-new-secret"}`)
+	if status != fiber.StatusOK || body["token"] == "" || body["role"] != RoleViewer {
+		t.Fatalf("change password: status = %d, body = %v", status, body)
+	}
+
+	if status, _ := post("/api/auth/login", `{"username":"bob","password":"brand-new-secret"}`); status != fiber.StatusOK {
+		t.Errorf("login with the new password status = %d", status)
+	}
+	if _, err := provider.ResolveUser(context.Background(), UserInfo{Username: "bob"}); err != nil {
+		t.Errorf("ResolveUser after the password change: %v", err)
+	}
+}
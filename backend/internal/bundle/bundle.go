@@ -0,0 +1,176 @@
+// Package bundle exports and imports the dashboard's own state as a
+// passphrase-protected file. Bundles only use the storage interfaces, so a
+// bundle exported from one backend can be imported into another.
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// Format identifies bundle files.
+	Format = "velero-dashboard-bundle"
+	// Version is the envelope and contents version written by Seal.
+	Version = 1
+	// MinPassphraseLength is the shortest passphrase Seal accepts.
+	MinPassphraseLength = 12
+)
+
+// Argon2id parameters used for new bundles, and upper bounds accepted when
+// opening one, so a crafted bundle can't make the server allocate gigabytes.
+const (
+	kdfTime      = 3
+	kdfMemory    = 64 * 1024 // KiB
+	kdfThreads   = 4
+	kdfMaxTime   = 10
+	kdfMaxMemory = 256 * 1024
+	saltSize     = 16
+	keySize      = 32
+)
+
+// ErrPassphrase is returned when a bundle can't be decrypted, either because
+// the passphrase is wrong or the file was modified.
+var ErrPassphrase = errors.New("wrong passphrase or corrupted bundle")
+
+// Contents is the decrypted state carried by a bundle.
+type Contents struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Clusters   []Cluster `json:"clusters"`
+	Webhooks   []Webhook `json:"webhooks"`
+}
+
+// Cluster is an exported cluster including its kubeconfig.
+type Cluster struct {
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace"`
+	IsDefault  bool              `json:"isDefault"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Kubeconfig string            `json:"kubeconfig"`
+}
+
+// Webhook is an exported webhook including its URL.
+type Webhook struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+}
+
+// Envelope is the serialized form of a bundle. Only the KDF parameters are
+// readable without the passphrase.
+type Envelope struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"createdAt"`
+	KDF        KDF       `json:"kdf"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// KDF describes how the encryption key was derived from the passphrase.
+type KDF struct {
+	Name    string `json:"name"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// Seal encrypts contents with a key derived from passphrase.
+func Seal(contents *Contents, passphrase string) ([]byte, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+	}
+
+	plaintext, err := json.Marshal(contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+
+	kdf := KDF{Name: "argon2id", Salt: make([]byte, saltSize), Time: kdfTime, Memory: kdfMemory, Threads: kdfThreads}
+	if _, err := rand.Read(kdf.Salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newAEAD(passphrase, kdf)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	env := Envelope{
+		Format:     Format,
+		Version:    Version,
+		CreatedAt:  contents.ExportedAt,
+		KDF:        kdf,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, additionalData(Version)),
+	}
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Open decrypts a bundle produced by Seal.
+func Open(data []byte, passphrase string) (*Contents, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("not a bundle file: %w", err)
+	}
+	if env.Format != Format {
+		return nil, fmt.Errorf("not a bundle file: format %q", env.Format)
+	}
+	if env.Version < 1 || env.Version > Version {
+		return nil, fmt.Errorf("unsupported bundle version %d", env.Version)
+	}
+	if env.KDF.Name != "argon2id" {
+		return nil, fmt.Errorf("unsupported key derivation %q", env.KDF.Name)
+	}
+	if env.KDF.Time == 0 || env.KDF.Time > kdfMaxTime || env.KDF.Memory == 0 ||
+		env.KDF.Memory > kdfMaxMemory || env.KDF.Threads == 0 || len(env.KDF.Salt) < saltSize {
+		return nil, fmt.Errorf("invalid key derivation parameters")
+	}
+
+	gcm, err := newAEAD(passphrase, env.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, ErrPassphrase
+	}
+	plaintext, err := gcm.Open(nil, env.Nonce, env.Ciphertext, additionalData(env.Version))
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+
+	var contents Contents
+	if err := json.Unmarshal(plaintext, &contents); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle: %w", err)
+	}
+	return &contents, nil
+}
+
+func newAEAD(passphrase string, kdf KDF) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, keySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the ciphertext to the envelope version, so a bundle
+// can't be replayed under a different version's parsing rules.
+func additionalData(version int) []byte {
+	return []byte(fmt.Sprintf("%s/v%d", Format, version))
+}
@@ -0,0 +1,125 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+func testContents() *Contents {
+	return &Contents{
+		Version:    Version,
+		ExportedAt: time.Now().UTC().Truncate(time.Second),
+		Clusters: []Cluster{
+			{Name: "prod", Namespace: "velero", IsDefault: true, Labels: map[string]string{"env": "prod"}, Kubeconfig: "apiVersion: v1\nkind: Config\n"},
+		},
+		Webhooks: []Webhook{
+			{Name: "ops", Type: "slack", URL: "https://hooks.slack.com/services/T/B/X", Events: []string{"backup_failed"}, Enabled: true},
+		},
+	}
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	data, err := Seal(testContents(), testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Open(data, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Clusters) != 1 || got.Clusters[0].Kubeconfig != "apiVersion: v1\nkind: Config\n" {
+		t.Errorf("clusters = %+v", got.Clusters)
+	}
+	if len(got.Webhooks) != 1 || got.Webhooks[0].URL != "https://hooks.slack.com/services/T/B/X" {
+		t.Errorf("webhooks = %+v", got.Webhooks)
+	}
+}
+
+func TestSealDoesNotLeakSecrets(t *testing.T) {
+	data, err := Seal(testContents(), testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hooks.slack.com", "kind: Config", "prod"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("bundle contains %q in clear text", secret)
+		}
+	}
+}
+
+func TestSealRejectsShortPassphrase(t *testing.T) {
+	if _, err := Seal(testContents(), "short"); err == nil {
+		t.Fatal("expected error for short passphrase")
+	}
+}
+
+func TestOpenWrongPassphrase(t *testing.T) {
+	data, err := Seal(testContents(), testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(data, "incorrect horse battery"); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("err = %v, want ErrPassphrase", err)
+	}
+}
+
+func TestOpenTampered(t *testing.T) {
+	data, err := Seal(testContents(), testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	env.Ciphertext[0] ^= 0xff
+	tampered, _ := json.Marshal(env)
+
+	if _, err := Open(tampered, testPassphrase); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("err = %v, want ErrPassphrase", err)
+	}
+}
+
+func TestOpenRejectsInvalidEnvelopes(t *testing.T) {
+	data, err := Seal(testContents(), testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(env *Envelope)
+	}{
+		{"wrong format", func(env *Envelope) { env.Format = "something-else" }},
+		{"newer version", func(env *Envelope) { env.Version = Version + 1 }},
+		{"unknown kdf", func(env *Envelope) { env.KDF.Name = "pbkdf2" }},
+		{"excessive memory", func(env *Envelope) { env.KDF.Memory = 4 * 1024 * 1024 }},
+		{"excessive time", func(env *Envelope) { env.KDF.Time = 1000 }},
+		{"short salt", func(env *Envelope) { env.KDF.Salt = env.KDF.Salt[:4] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var env Envelope
+			if err := json.Unmarshal(data, &env); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(&env)
+			modified, _ := json.Marshal(env)
+
+			_, err := Open(modified, testPassphrase)
+			if err == nil || errors.Is(err, ErrPassphrase) {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+		})
+	}
+
+	if _, err := Open([]byte("not json"), testPassphrase); err == nil {
+		t.Fatal("expected error for garbage input")
+	}
+}
@@ -0,0 +1,246 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/notification"
+)
+
+// Import actions reported per item.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionSkipped = "skipped"
+	ActionFailed  = "failed"
+)
+
+// ItemResult reports what happened to one bundle entry on import.
+type ItemResult struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ImportReport summarizes an import.
+type ImportReport struct {
+	Clusters []ItemResult `json:"clusters"`
+	Webhooks []ItemResult `json:"webhooks"`
+
+	// Changed lists the clusters created or updated, which need (re)connecting
+	Changed []*cluster.Cluster `json:"-"`
+}
+
+// Export reads the state of both stores. Declaratively managed webhooks are
+// left out since their definitions live outside the dashboard.
+func Export(ctx context.Context, clusters cluster.Store, webhooks notification.Store) (*Contents, error) {
+	contents := &Contents{
+		Version:    Version,
+		ExportedAt: time.Now().UTC(),
+		Clusters:   []Cluster{},
+		Webhooks:   []Webhook{},
+	}
+
+	list, err := clusters.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	for _, summary := range list {
+		// List doesn't carry kubeconfigs
+		c, err := clusters.Get(ctx, summary.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cluster %s: %w", summary.Name, err)
+		}
+		contents.Clusters = append(contents.Clusters, Cluster{
+			Name:       c.Name,
+			Namespace:  c.Namespace,
+			IsDefault:  c.IsDefault,
+			Labels:     c.Labels,
+			Kubeconfig: string(c.KubeconfigRaw),
+		})
+	}
+
+	hooks, err := webhooks.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	for _, wh := range hooks {
+		if wh.Managed {
+			continue
+		}
+		events := make([]string, len(wh.Events))
+		for i, e := range wh.Events {
+			events[i] = string(e)
+		}
+		contents.Webhooks = append(contents.Webhooks, Webhook{
+			Name:    wh.Name,
+			Type:    string(wh.Type),
+			URL:     wh.URL,
+			Events:  events,
+			Enabled: wh.Enabled,
+		})
+	}
+
+	return contents, nil
+}
+
+// Import writes the bundle contents into the stores. Clusters are matched by
+// name and webhooks by name and type; existing entries are skipped unless
+// overwrite is set. Failures of single entries are reported, not returned.
+func Import(ctx context.Context, contents *Contents, clusters cluster.Store, webhooks notification.Store, overwrite bool) (*ImportReport, error) {
+	report := &ImportReport{Clusters: []ItemResult{}, Webhooks: []ItemResult{}}
+	if err := importClusters(ctx, contents.Clusters, clusters, overwrite, report); err != nil {
+		return nil, err
+	}
+	if err := importWebhooks(ctx, contents.Webhooks, webhooks, overwrite, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func importClusters(ctx context.Context, entries []Cluster, store cluster.Store, overwrite bool, report *ImportReport) error {
+	existing, err := store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list clusters: %w", err)
+	}
+	idByName := make(map[string]string, len(existing))
+	hasDefault := false
+	for _, c := range existing {
+		idByName[c.Name] = c.ID
+		hasDefault = hasDefault || c.IsDefault
+	}
+
+	for _, entry := range entries {
+		result := ItemResult{Name: entry.Name}
+		// Don't take the default away from a populated target unless asked to
+		setDefault := entry.IsDefault && (overwrite || !hasDefault)
+		labelErr := cluster.ValidateLabels(entry.Labels)
+
+		switch id := idByName[entry.Name]; {
+		case entry.Name == "" || entry.Kubeconfig == "":
+			result.Action, result.Reason = ActionFailed, "name and kubeconfig are required"
+		case labelErr != nil:
+			result.Action, result.Reason = ActionFailed, labelErr.Error()
+		case id != "" && !overwrite:
+			result.Action, result.Reason = ActionSkipped, "a cluster with this name exists"
+		case id != "":
+			labels := entry.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			req := cluster.UpdateClusterRequest{
+				Kubeconfig: &entry.Kubeconfig,
+				Namespace:  &entry.Namespace,
+				Labels:     labels,
+			}
+			if setDefault {
+				req.SetAsDefault = &setDefault
+			}
+			if err := store.Update(ctx, id, req); err != nil {
+				result.Action, result.Reason = ActionFailed, err.Error()
+				break
+			}
+			updated, err := store.Get(ctx, id)
+			if err != nil {
+				result.Action, result.Reason = ActionFailed, err.Error()
+				break
+			}
+			result.Action = ActionUpdated
+			report.Changed = append(report.Changed, updated)
+		default:
+			created, err := store.Create(ctx, cluster.CreateClusterRequest{
+				Name:         entry.Name,
+				Namespace:    entry.Namespace,
+				SetAsDefault: setDefault,
+				Labels:       entry.Labels,
+				Kubeconfig:   entry.Kubeconfig,
+			})
+			if err != nil {
+				result.Action, result.Reason = ActionFailed, err.Error()
+				break
+			}
+			result.Action = ActionCreated
+			idByName[created.Name] = created.ID
+			report.Changed = append(report.Changed, created)
+		}
+
+		if setDefault && (result.Action == ActionCreated || result.Action == ActionUpdated) {
+			hasDefault = true
+		}
+		report.Clusters = append(report.Clusters, result)
+	}
+	return nil
+}
+
+func importWebhooks(ctx context.Context, entries []Webhook, store notification.Store, overwrite bool, report *ImportReport) error {
+	existing, err := store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	type key struct{ name, typ string }
+	byKey := make(map[key]*notification.WebhookConfig, len(existing))
+	for _, wh := range existing {
+		byKey[key{wh.Name, string(wh.Type)}] = wh
+	}
+
+	for _, entry := range entries {
+		result := ItemResult{Name: entry.Name}
+		typ := notification.WebhookType(entry.Type)
+		events, eventsErr := parseEvents(entry.Events)
+
+		switch current := byKey[key{entry.Name, entry.Type}]; {
+		case entry.Name == "" || entry.URL == "" || !typ.Valid():
+			result.Action, result.Reason = ActionFailed, "name, url and a valid type are required"
+		case eventsErr != nil:
+			result.Action, result.Reason = ActionFailed, eventsErr.Error()
+		case current != nil && current.Managed:
+			result.Action, result.Reason = ActionSkipped, "a declaratively managed webhook with this name exists"
+		case current != nil && !overwrite:
+			result.Action, result.Reason = ActionSkipped, "a webhook with this name exists"
+		case current != nil:
+			err := store.Update(ctx, current.ID, notification.UpdateWebhookRequest{
+				URL:     &entry.URL,
+				Events:  events,
+				Enabled: &entry.Enabled,
+			})
+			if err != nil {
+				result.Action, result.Reason = ActionFailed, err.Error()
+				break
+			}
+			result.Action = ActionUpdated
+		default:
+			created, err := store.Create(ctx, notification.CreateWebhookRequest{
+				Name:    entry.Name,
+				Type:    typ,
+				URL:     entry.URL,
+				Events:  events,
+				Enabled: entry.Enabled,
+			})
+			if err != nil {
+				result.Action, result.Reason = ActionFailed, err.Error()
+				break
+			}
+			result.Action = ActionCreated
+			byKey[key{created.Name, string(created.Type)}] = created
+		}
+
+		report.Webhooks = append(report.Webhooks, result)
+	}
+	return nil
+}
+
+func parseEvents(raw []string) ([]notification.EventType, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("at least one event is required")
+	}
+	events := make([]notification.EventType, len(raw))
+	for i, r := range raw {
+		events[i] = notification.EventType(r)
+		if !events[i].Valid() {
+			return nil, fmt.Errorf("invalid event %q", r)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,202 @@
+package bundle
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/notification"
+	"go.uber.org/zap"
+)
+
+const testKubeconfig = "apiVersion: v1\nkind: Config\nclusters: []\n"
+
+type testStores struct {
+	clusters *cluster.SQLiteStore
+	webhooks *notification.SQLiteStore
+}
+
+// newTestStores opens both stores on one database file, as the server does.
+func newTestStores(t *testing.T, key string) testStores {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clusters.db")
+	clusters, err := cluster.NewSQLiteStore(path, key, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks, err := notification.NewSQLiteStore(path, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = webhooks.Close()
+		_ = clusters.Close()
+	})
+	return testStores{clusters: clusters, webhooks: webhooks}
+}
+
+func seed(t *testing.T, s testStores) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := s.clusters.Create(ctx, cluster.CreateClusterRequest{
+		Name: "prod", Namespace: "velero", SetAsDefault: true,
+		Labels: map[string]string{"env": "prod"}, Kubeconfig: testKubeconfig,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.clusters.Create(ctx, cluster.CreateClusterRequest{
+		Name: "staging", Namespace: "backup", Kubeconfig: testKubeconfig,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.webhooks.Create(ctx, notification.CreateWebhookRequest{
+		Name: "ops", Type: notification.WebhookSlack, URL: "https://hooks.slack.com/services/T/B/X",
+		Events: []notification.EventType{notification.EventBackupFailed}, Enabled: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportIntoEmptyStores(t *testing.T) {
+	ctx := context.Background()
+	source := newTestStores(t, "source-key-32-bytes-long-padding")
+	seed(t, source)
+
+	contents, err := Export(ctx, source.clusters, source.webhooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Seal(contents, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A different encryption key: kubeconfigs are re-encrypted on import
+	target := newTestStores(t, "target-key-32-bytes-long-padding")
+	opened, err := Open(data, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Import(ctx, opened, target.clusters, target.webhooks, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range append(report.Clusters, report.Webhooks...) {
+		if r.Action != ActionCreated {
+			t.Errorf("%s: action = %s (%s), want created", r.Name, r.Action, r.Reason)
+		}
+	}
+	if len(report.Changed) != 2 {
+		t.Errorf("changed = %d, want 2", len(report.Changed))
+	}
+
+	def, err := target.clusters.GetDefault(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Name != "prod" || def.Namespace != "velero" || def.Labels["env"] != "prod" {
+		t.Errorf("default cluster = %+v", def)
+	}
+	if string(def.KubeconfigRaw) != testKubeconfig {
+		t.Errorf("kubeconfig = %q", def.KubeconfigRaw)
+	}
+
+	hooks, err := target.webhooks.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 1 || hooks[0].URL != "https://hooks.slack.com/services/T/B/X" || !hooks[0].Enabled {
+		t.Errorf("webhooks = %+v", hooks)
+	}
+}
+
+func TestImportSkipsExistingUnlessOverwrite(t *testing.T) {
+	ctx := context.Background()
+	target := newTestStores(t, "target-key-32-bytes-long-padding")
+	seed(t, target)
+
+	contents := &Contents{
+		Version: Version,
+		Clusters: []Cluster{
+			{Name: "prod", Namespace: "velero-new", IsDefault: true, Kubeconfig: "apiVersion: v1\nkind: Config\n"},
+			{Name: "dr", Namespace: "velero", IsDefault: true, Kubeconfig: testKubeconfig},
+		},
+		Webhooks: []Webhook{
+			{Name: "ops", Type: "slack", URL: "https://hooks.slack.com/services/T/B/NEW", Events: []string{"restore_failed"}, Enabled: false},
+		},
+	}
+
+	report, err := Import(ctx, contents, target.clusters, target.webhooks, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Clusters[0].Action != ActionSkipped || report.Clusters[1].Action != ActionCreated {
+		t.Errorf("clusters = %+v", report.Clusters)
+	}
+	if report.Webhooks[0].Action != ActionSkipped {
+		t.Errorf("webhooks = %+v", report.Webhooks)
+	}
+	// The existing default is kept when merging
+	if def, _ := target.clusters.GetDefault(ctx); def == nil || def.Name != "prod" || def.Namespace != "velero" {
+		t.Errorf("default cluster = %+v", def)
+	}
+
+	report, err = Import(ctx, contents, target.clusters, target.webhooks, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Clusters[0].Action != ActionUpdated || report.Webhooks[0].Action != ActionUpdated {
+		t.Errorf("report = %+v", report)
+	}
+	def, err := target.clusters.GetDefault(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Name != "dr" {
+		t.Errorf("default = %s, want dr", def.Name)
+	}
+
+	list, _ := target.clusters.List(ctx)
+	for _, c := range list {
+		if c.Name == "prod" && (c.Namespace != "velero-new" || len(c.Labels) != 0) {
+			t.Errorf("prod not overwritten: %+v", c)
+		}
+	}
+	hooks, _ := target.webhooks.List(ctx)
+	if len(hooks) != 1 || hooks[0].URL != "https://hooks.slack.com/services/T/B/NEW" || hooks[0].Enabled {
+		t.Errorf("webhooks = %+v", hooks)
+	}
+}
+
+func TestImportReportsInvalidEntries(t *testing.T) {
+	ctx := context.Background()
+	target := newTestStores(t, "target-key-32-bytes-long-padding")
+
+	contents := &Contents{
+		Version: Version,
+		Clusters: []Cluster{
+			{Name: "no-kubeconfig"},
+			{Name: "bad-labels", Kubeconfig: testKubeconfig, Labels: map[string]string{"bad key!": "x"}},
+		},
+		Webhooks: []Webhook{
+			{Name: "bad-type", Type: "pager", URL: "https://example.com", Events: []string{"backup_failed"}},
+			{Name: "bad-event", Type: "webhook", URL: "https://example.com", Events: []string{"nope"}},
+		},
+	}
+	report, err := Import(ctx, contents, target.clusters, target.webhooks, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range append(report.Clusters, report.Webhooks...) {
+		if r.Action != ActionFailed || r.Reason == "" {
+			t.Errorf("%s: action = %s (%s), want failed", r.Name, r.Action, r.Reason)
+		}
+	}
+	if list, _ := target.clusters.List(ctx); len(list) != 0 {
+		t.Errorf("clusters created from invalid entries: %d", len(list))
+	}
+}
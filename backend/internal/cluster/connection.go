@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// ConnectionState is the lifecycle state of a managed cluster connection.
+type ConnectionState string
+
+const (
+	StateConnecting   ConnectionState = "connecting"
+	StateConnected    ConnectionState = "connected"
+	StateDegraded     ConnectionState = "degraded"
+	StateDisconnected ConnectionState = "disconnected"
+)
+
+const (
+	// maxDegradedChecks is the number of consecutive failed health checks
+	// after which a degraded cluster is torn down and reconnected.
+	maxDegradedChecks = 3
+
+	reconnectInitialDelay = 5 * time.Second
+	reconnectMaxDelay     = 5 * time.Minute
+
+	// permissionCheckInterval is how often connected clusters re-run the
+	// RBAC preflight; access reviews are too many to run on every check.
+	permissionCheckInterval = 10 * time.Minute
+
+	// metadataRefreshInterval is how often versions, provider and node
+	// count are re-collected; they rarely change.
+	metadataRefreshInterval = 10 * time.Minute
+)
+
+// StatusChange is broadcast over WebSocket when a cluster changes state.
+type StatusChange struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Status         ConnectionState `json:"status"`
+	PreviousStatus ConnectionState `json:"previousStatus,omitempty"`
+	StatusMessage  string          `json:"statusMessage,omitempty"`
+	Timestamp      time.Time       `json:"timestamp"`
+}
+
+// backoff computes exponential reconnect delays with jitter.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+	attempt int
+}
+
+func newBackoff() *backoff {
+	return &backoff{initial: reconnectInitialDelay, max: reconnectMaxDelay}
+}
+
+// Next returns the delay before the next attempt and advances the sequence.
+// Delays double on each call up to max, with up to 20% random jitter so that
+// many clusters failing together don't retry in lockstep.
+func (b *backoff) Next() time.Duration {
+	delay := b.initial << b.attempt
+	if delay <= 0 || delay > b.max {
+		delay = b.max
+	} else {
+		b.attempt++
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay)/5 + 1))
+	return delay - jitter
+}
+
+// Reset restarts the sequence after a successful connection.
+func (b *backoff) Reset() {
+	b.attempt = 0
+}
@@ -0,0 +1,43 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	b := &backoff{initial: time.Second, max: 8 * time.Second}
+
+	expected := []time.Duration{1, 2, 4, 8, 8, 8}
+	for i, want := range expected {
+		want *= time.Second
+		got := b.Next()
+		// Up to 20% jitter is subtracted
+		if got > want || got < want-want/5 {
+			t.Errorf("attempt %d: delay %v not within [%v, %v]", i, got, want-want/5, want)
+		}
+	}
+}
+
+func TestBackoffReset(t *testing.T) {
+	b := &backoff{initial: time.Second, max: time.Minute}
+	for i := 0; i < 5; i++ {
+		b.Next()
+	}
+
+	b.Reset()
+
+	if got := b.Next(); got > time.Second {
+		t.Errorf("Expected delay <= 1s after reset, got %v", got)
+	}
+}
+
+func TestNewBackoffDefaults(t *testing.T) {
+	b := newBackoff()
+	if b.initial != reconnectInitialDelay {
+		t.Errorf("Expected initial %v, got %v", reconnectInitialDelay, b.initial)
+	}
+	if b.max != reconnectMaxDelay {
+		t.Errorf("Expected max %v, got %v", reconnectMaxDelay, b.max)
+	}
+}
@@ -0,0 +1,329 @@
+package cluster
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// defaultKeyID names the key given as a bare CLUSTER_ENCRYPTION_KEY.
+const defaultKeyID = "default"
+
+// envelopeMagic prefixes ciphertexts written by the keyring. Rows without
+// it predate key rotation and are read with the legacy key.
+var envelopeMagic = []byte("vdk1")
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// KeyringConfig describes where encryption keys come from.
+type KeyringConfig struct {
+	Key        string // single key, raw 32 bytes or base64; its ID is "default"
+	Keys       string // "id:base64key,id2:base64key"
+	KeyFile    string // mounted file with one "id:base64key" (or a bare key) per line
+	ActiveKey  string // ID used for new ciphertexts; required with several keys
+	Production bool   // refuse missing or malformed keys instead of warning
+}
+
+// Keyring encrypts data with envelope encryption: every ciphertext gets a
+// fresh data key, which is wrapped by the active key-encryption key. The
+// key ID is stored with the ciphertext so that older keys keep working
+// until rows are re-encrypted.
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+	legacy cipher.AEAD // rows written before the keyring, if any
+}
+
+// NewKeyring loads keys from the configured sources.
+func NewKeyring(cfg KeyringConfig, logger *zap.Logger) (*Keyring, error) {
+	raw := make(map[string][]byte)
+	kr := &Keyring{keys: make(map[string]cipher.AEAD)}
+
+	if cfg.Key != "" {
+		key, err := decodeKey(cfg.Key)
+		if err != nil {
+			if cfg.Production {
+				return nil, fmt.Errorf("CLUSTER_ENCRYPTION_KEY: %w", err)
+			}
+			logger.Warn("CLUSTER_ENCRYPTION_KEY is not 32 bytes; padding it. This is refused in production", zap.Error(err))
+			key = legacyKeyBytes(cfg.Key)
+		}
+		raw[defaultKeyID] = key
+
+		// Before the keyring, the key string was padded or truncated to
+		// 32 bytes as-is, even when it was base64
+		legacy, err := newAEAD(legacyKeyBytes(cfg.Key))
+		if err != nil {
+			return nil, err
+		}
+		kr.legacy = legacy
+	}
+
+	if cfg.Keys != "" {
+		if err := parseKeyList(strings.Split(cfg.Keys, ","), raw); err != nil {
+			return nil, fmt.Errorf("CLUSTER_ENCRYPTION_KEYS: %w", err)
+		}
+	}
+
+	if cfg.KeyFile != "" {
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		if err := parseKeyList(strings.Split(string(data), "\n"), raw); err != nil {
+			return nil, fmt.Errorf("key file %s: %w", cfg.KeyFile, err)
+		}
+	}
+
+	if len(raw) == 0 {
+		if cfg.Production {
+			return nil, fmt.Errorf("no encryption key configured; set CLUSTER_ENCRYPTION_KEY, CLUSTER_ENCRYPTION_KEYS or CLUSTER_ENCRYPTION_KEY_FILE")
+		}
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		raw[defaultKeyID] = key
+		logger.Warn("No encryption key provided, generated random key. Clusters won't persist across restarts!")
+	}
+
+	for id, key := range raw {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		kr.keys[id] = aead
+	}
+
+	kr.active = cfg.ActiveKey
+	if kr.active == "" {
+		if len(raw) > 1 {
+			return nil, fmt.Errorf("CLUSTER_ENCRYPTION_ACTIVE_KEY is required when several keys are configured (have %s)", strings.Join(kr.KeyIDs(), ", "))
+		}
+		for id := range raw {
+			kr.active = id
+		}
+	}
+	if _, ok := kr.keys[kr.active]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not configured", kr.active)
+	}
+
+	logger.Info("Encryption keyring loaded",
+		zap.String("activeKey", kr.active),
+		zap.Strings("keys", kr.KeyIDs()))
+
+	return kr, nil
+}
+
+// parseKeyList adds "id:base64key" entries to keys. A bare entry without an
+// ID is the default key.
+func parseKeyList(entries []string, keys map[string][]byte) error {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		id, value, found := strings.Cut(entry, ":")
+		if !found {
+			id, value = defaultKeyID, entry
+		}
+		id = strings.TrimSpace(id)
+		if !keyIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid key ID %q", id)
+		}
+		if _, dup := keys[id]; dup {
+			return fmt.Errorf("duplicate key ID %q", id)
+		}
+		key, err := decodeKey(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return nil
+}
+
+// decodeKey accepts a base64 encoded or raw 32-byte key.
+func decodeKey(s string) ([]byte, error) {
+	if decoded, err := base64.StdEncoding.DecodeString(s); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	if len(s) == 32 {
+		return []byte(s), nil
+	}
+	return nil, fmt.Errorf("key must be 32 bytes, raw or base64 encoded (got %d characters)", len(s))
+}
+
+// legacyKeyBytes reproduces the pre-keyring key derivation.
+func legacyKeyBytes(key string) []byte {
+	if len(key) == 32 {
+		return []byte(key)
+	}
+	return []byte(fmt.Sprintf("%032s", key)[:32])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ActiveKey returns the ID used for new ciphertexts.
+func (k *Keyring) ActiveKey() string {
+	return k.active
+}
+
+// KeyIDs returns the configured key IDs, sorted.
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt seals plaintext under a fresh data key wrapped by the active key.
+//
+// Layout: magic | len(keyID) | keyID | len(wrappedDEK) | wrappedDEK | nonce | sealed
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(data, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return k.envelope(k.active, dek, sealed)
+}
+
+// Decrypt opens a ciphertext produced by Encrypt or by the legacy cipher.
+func (k *Keyring) Decrypt(ciphertext []byte) ([]byte, error) {
+	_, dek, sealed, err := k.open(ciphertext)
+	if err == nil {
+		data, err := newAEAD(dek)
+		if err != nil {
+			return nil, err
+		}
+		return unseal(data, sealed, nil)
+	}
+
+	// A legacy ciphertext can start with the magic by chance, so fall back
+	// whenever the envelope doesn't open
+	if k.legacy != nil {
+		if plaintext, legacyErr := unseal(k.legacy, ciphertext, nil); legacyErr == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, err
+}
+
+// Rewrap re-encrypts a ciphertext's data key with the active key. Legacy
+// ciphertexts are fully re-encrypted. It reports whether anything changed.
+func (k *Keyring) Rewrap(ciphertext []byte) ([]byte, bool, error) {
+	keyID, dek, sealed, err := k.open(ciphertext)
+	if err != nil {
+		plaintext, err := k.Decrypt(ciphertext)
+		if err != nil {
+			return nil, false, err
+		}
+		rewrapped, err := k.Encrypt(plaintext)
+		return rewrapped, err == nil, err
+	}
+	if keyID == k.active {
+		return ciphertext, false, nil
+	}
+	rewrapped, err := k.envelope(k.active, dek, sealed)
+	return rewrapped, err == nil, err
+}
+
+// KeyID returns the ID of the key that wrapped a ciphertext, or "" for
+// legacy ciphertexts.
+func (k *Keyring) KeyID(ciphertext []byte) string {
+	keyID, _, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return ""
+	}
+	return keyID
+}
+
+func (k *Keyring) envelope(keyID string, dek, sealed []byte) ([]byte, error) {
+	wrapped, err := seal(k.keys[keyID], dek, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(envelopeMagic)
+	buf.WriteByte(byte(len(keyID)))
+	buf.WriteString(keyID)
+	buf.WriteByte(byte(len(wrapped)))
+	buf.Write(wrapped)
+	buf.Write(sealed)
+	return buf.Bytes(), nil
+}
+
+// open parses an envelope and unwraps its data key.
+func (k *Keyring) open(ciphertext []byte) (keyID string, dek, sealed []byte, err error) {
+	keyID, rest, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return keyID, nil, nil, fmt.Errorf("encryption key %q is not configured", keyID)
+	}
+	if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+		return keyID, nil, nil, fmt.Errorf("ciphertext too short")
+	}
+	wrapped, sealed := rest[1:1+int(rest[0])], rest[1+int(rest[0]):]
+	dek, err = unseal(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return keyID, nil, nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return keyID, dek, sealed, nil
+}
+
+func parseEnvelope(ciphertext []byte) (string, []byte, error) {
+	if !bytes.HasPrefix(ciphertext, envelopeMagic) {
+		return "", nil, fmt.Errorf("not an envelope ciphertext")
+	}
+	rest := ciphertext[len(envelopeMagic):]
+	if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+		return "", nil, fmt.Errorf("ciphertext too short")
+	}
+	return string(rest[1 : 1+int(rest[0])]), rest[1+int(rest[0]):], nil
+}
+
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func unseal(aead cipher.AEAD, ciphertext, additional []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestKeyringRoundtrip(t *testing.T) {
+	kr, err := NewKeyring(KeyringConfig{Key: testKey(1)}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.ActiveKey() != "default" {
+		t.Errorf("expected default active key, got %q", kr.ActiveKey())
+	}
+
+	ct, err := kr.Encrypt([]byte("kubeconfig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.KeyID(ct) != "default" {
+		t.Errorf("expected key ID default, got %q", kr.KeyID(ct))
+	}
+	pt, err := kr.Decrypt(ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pt) != "kubeconfig" {
+		t.Errorf("roundtrip mismatch: %q", pt)
+	}
+}
+
+func TestKeyringDecryptsLegacyCiphertext(t *testing.T) {
+	key := "test-key-32-bytes-long-padding!!"
+	legacy, err := newAEAD(legacyKeyBytes(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := seal(legacy, []byte("old-row"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := NewKeyring(KeyringConfig{Key: key}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.KeyID(ct) != "" {
+		t.Errorf("legacy ciphertext should have no key ID")
+	}
+	pt, err := kr.Decrypt(ct)
+	if err != nil {
+		t.Fatalf("legacy decrypt failed: %v", err)
+	}
+	if string(pt) != "old-row" {
+		t.Errorf("unexpected plaintext %q", pt)
+	}
+
+	rewrapped, changed, err := kr.Rewrap(ct)
+	if err != nil || !changed {
+		t.Fatalf("Rewrap legacy: changed=%v err=%v", changed, err)
+	}
+	if kr.KeyID(rewrapped) != "default" {
+		t.Errorf("rewrapped ciphertext should use the default key")
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldRing, err := NewKeyring(KeyringConfig{Keys: "v1:" + testKey(1)}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, _ := oldRing.Encrypt([]byte("secret"))
+
+	both, err := NewKeyring(KeyringConfig{
+		Keys:      "v1:" + testKey(1) + ",v2:" + testKey(2),
+		ActiveKey: "v2",
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := both.Decrypt(ct); err != nil || string(pt) != "secret" {
+		t.Fatalf("old ciphertext unreadable after adding key: %q %v", pt, err)
+	}
+
+	rewrapped, changed, err := both.Rewrap(ct)
+	if err != nil || !changed {
+		t.Fatalf("Rewrap: changed=%v err=%v", changed, err)
+	}
+	if both.KeyID(rewrapped) != "v2" {
+		t.Errorf("expected v2, got %q", both.KeyID(rewrapped))
+	}
+	if _, changed, _ := both.Rewrap(rewrapped); changed {
+		t.Error("rewrapping with the active key should be a no-op")
+	}
+
+	newRing, err := NewKeyring(KeyringConfig{Keys: "v2:" + testKey(2)}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt, err := newRing.Decrypt(rewrapped); err != nil || string(pt) != "secret" {
+		t.Fatalf("rewrapped ciphertext unreadable without old key: %q %v", pt, err)
+	}
+	if _, err := newRing.Decrypt(ct); err == nil || !strings.Contains(err.Error(), `"v1"`) {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestKeyringProductionRefusesWeakKeys(t *testing.T) {
+	if _, err := NewKeyring(KeyringConfig{Production: true}, zap.NewNop()); err == nil {
+		t.Error("expected error for missing key in production")
+	}
+	if _, err := NewKeyring(KeyringConfig{Key: "short", Production: true}, zap.NewNop()); err == nil {
+		t.Error("expected error for short key in production")
+	}
+	if _, err := NewKeyring(KeyringConfig{Key: "short"}, zap.NewNop()); err != nil {
+		t.Errorf("short key should only warn in development: %v", err)
+	}
+}
+
+func TestKeyringRequiresActiveKey(t *testing.T) {
+	_, err := NewKeyring(KeyringConfig{Keys: "a:" + testKey(1) + ",b:" + testKey(2)}, zap.NewNop())
+	if err == nil {
+		t.Error("expected error without active key")
+	}
+	_, err = NewKeyring(KeyringConfig{Keys: "a:" + testKey(1), ActiveKey: "b"}, zap.NewNop())
+	if err == nil {
+		t.Error("expected error for unknown active key")
+	}
+	_, err = NewKeyring(KeyringConfig{Keys: "a:" + testKey(1) + ",a:" + testKey(2), ActiveKey: "a"}, zap.NewNop())
+	if err == nil {
+		t.Error("expected error for duplicate key ID")
+	}
+}
+
+func TestKeyringKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	content := "# rotated 2024-01\nold:" + testKey(1) + "\n\nnew:" + testKey(2) + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := NewKeyring(KeyringConfig{KeyFile: path, ActiveKey: "new", Production: true}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(kr.KeyIDs(), ","); got != "new,old" {
+		t.Errorf("unexpected key IDs %q", got)
+	}
+
+	if _, err := NewKeyring(KeyringConfig{KeyFile: filepath.Join(t.TempDir(), "missing")}, zap.NewNop()); err == nil {
+		t.Error("expected error for missing key file")
+	}
+}
@@ -3,6 +3,11 @@ package cluster
 import (
 	"encoding/base64"
 	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 // TokenToKubeconfig generates a kubeconfig YAML from token-based auth
@@ -43,3 +48,78 @@ users:
 
 	return kubeconfig
 }
+
+// KubeconfigContext describes one context of an uploaded kubeconfig.
+type KubeconfigContext struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Server    string `json:"server"`
+	Namespace string `json:"namespace,omitempty"`
+	Current   bool   `json:"current"`
+}
+
+// LoadKubeconfig parses raw kubeconfig YAML, also accepting it base64 encoded.
+func LoadKubeconfig(data string) (*clientcmdapi.Config, error) {
+	data = strings.TrimSpace(data)
+	if decoded, err := base64.StdEncoding.DecodeString(data); err == nil {
+		data = string(decoded)
+	}
+
+	cfg, err := clientcmd.Load([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	if len(cfg.Contexts) == 0 {
+		return nil, fmt.Errorf("kubeconfig has no contexts")
+	}
+	return cfg, nil
+}
+
+// ListKubeconfigContexts returns the contexts of a kubeconfig sorted by name.
+func ListKubeconfigContexts(cfg *clientcmdapi.Config) []KubeconfigContext {
+	contexts := make([]KubeconfigContext, 0, len(cfg.Contexts))
+	for name, ctx := range cfg.Contexts {
+		kc := KubeconfigContext{
+			Name:      name,
+			Cluster:   ctx.Cluster,
+			User:      ctx.AuthInfo,
+			Namespace: ctx.Namespace,
+			Current:   name == cfg.CurrentContext,
+		}
+		if cl, ok := cfg.Clusters[ctx.Cluster]; ok {
+			kc.Server = cl.Server
+		}
+		contexts = append(contexts, kc)
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts
+}
+
+// MinimizeKubeconfig returns a standalone kubeconfig that holds only the
+// given context together with its cluster and user, so that each imported
+// cluster stores no credentials for the others.
+func MinimizeKubeconfig(cfg *clientcmdapi.Config, contextName string) ([]byte, error) {
+	if _, ok := cfg.Contexts[contextName]; !ok {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	minimal := cfg.DeepCopy()
+	minimal.CurrentContext = contextName
+	if err := clientcmdapi.MinifyConfig(minimal); err != nil {
+		return nil, fmt.Errorf("context %q: %w", contextName, err)
+	}
+
+	// File references can't be resolved on the server
+	cl := minimal.Clusters[minimal.Contexts[contextName].Cluster]
+	user := minimal.AuthInfos[minimal.Contexts[contextName].AuthInfo]
+	if cl.CertificateAuthority != "" || user.ClientCertificate != "" || user.ClientKey != "" || user.TokenFile != "" {
+		return nil, fmt.Errorf("context %q references local files; embed the credentials with 'kubectl config view --flatten'", contextName)
+	}
+
+	data, err := clientcmd.Write(*minimal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize kubeconfig for context %q: %w", contextName, err)
+	}
+	return data, nil
+}
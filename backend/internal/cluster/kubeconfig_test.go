@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"strings"
 	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func TestTokenToKubeconfigBasic(t *testing.T) {
@@ -95,3 +97,127 @@ func TestTokenToKubeconfigIsValidYAML(t *testing.T) {
 		t.Error("Missing users section")
 	}
 }
+
+const multiContextKubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com:6443
+    certificate-authority-data: Y2EtZGF0YQ==
+- name: staging-cluster
+  cluster:
+    server: https://staging.example.com:6443
+- name: local-cluster
+  cluster:
+    server: https://local.example.com:6443
+    certificate-authority: /home/me/.kube/ca.crt
+contexts:
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: prod-admin
+    namespace: apps
+- name: staging
+  context:
+    cluster: staging-cluster
+    user: staging-admin
+- name: local
+  context:
+    cluster: local-cluster
+    user: staging-admin
+users:
+- name: prod-admin
+  user:
+    token: prod-token
+- name: staging-admin
+  user:
+    token: staging-token
+`
+
+func TestListKubeconfigContexts(t *testing.T) {
+	cfg, err := LoadKubeconfig(multiContextKubeconfig)
+	if err != nil {
+		t.Fatalf("LoadKubeconfig failed: %v", err)
+	}
+
+	contexts := ListKubeconfigContexts(cfg)
+	if len(contexts) != 3 {
+		t.Fatalf("Expected 3 contexts, got %d", len(contexts))
+	}
+	if contexts[0].Name != "local" || contexts[1].Name != "prod" || contexts[2].Name != "staging" {
+		t.Errorf("Expected contexts sorted by name, got %v", contexts)
+	}
+
+	prod := contexts[1]
+	if prod.Server != "https://prod.example.com:6443" || prod.User != "prod-admin" || prod.Namespace != "apps" {
+		t.Errorf("Unexpected prod context: %+v", prod)
+	}
+	if prod.Current || !contexts[2].Current {
+		t.Error("Expected only staging to be current")
+	}
+}
+
+func TestLoadKubeconfigBase64(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(multiContextKubeconfig))
+	cfg, err := LoadKubeconfig(encoded)
+	if err != nil {
+		t.Fatalf("LoadKubeconfig failed: %v", err)
+	}
+	if len(cfg.Contexts) != 3 {
+		t.Errorf("Expected 3 contexts, got %d", len(cfg.Contexts))
+	}
+}
+
+func TestLoadKubeconfigInvalid(t *testing.T) {
+	if _, err := LoadKubeconfig("not: [valid"); err == nil {
+		t.Error("Expected error for invalid kubeconfig")
+	}
+	if _, err := LoadKubeconfig("apiVersion: v1\nkind: Config\n"); err == nil {
+		t.Error("Expected error for kubeconfig without contexts")
+	}
+}
+
+func TestMinimizeKubeconfig(t *testing.T) {
+	cfg, _ := LoadKubeconfig(multiContextKubeconfig)
+
+	data, err := MinimizeKubeconfig(cfg, "prod")
+	if err != nil {
+		t.Fatalf("MinimizeKubeconfig failed: %v", err)
+	}
+
+	minimal, err := clientcmd.Load(data)
+	if err != nil {
+		t.Fatalf("Minimized kubeconfig doesn't parse: %v", err)
+	}
+	if minimal.CurrentContext != "prod" {
+		t.Errorf("Expected current-context prod, got %q", minimal.CurrentContext)
+	}
+	if len(minimal.Contexts) != 1 || len(minimal.Clusters) != 1 || len(minimal.AuthInfos) != 1 {
+		t.Errorf("Expected exactly one context, cluster and user, got %d/%d/%d",
+			len(minimal.Contexts), len(minimal.Clusters), len(minimal.AuthInfos))
+	}
+	if minimal.AuthInfos["prod-admin"] == nil || minimal.AuthInfos["prod-admin"].Token != "prod-token" {
+		t.Error("Expected prod-admin credentials to be kept")
+	}
+	if strings.Contains(string(data), "staging-token") {
+		t.Error("Minimized kubeconfig leaks credentials of other contexts")
+	}
+
+	// The source config must not be modified
+	if len(cfg.Contexts) != 3 {
+		t.Error("MinimizeKubeconfig modified its input")
+	}
+}
+
+func TestMinimizeKubeconfigErrors(t *testing.T) {
+	cfg, _ := LoadKubeconfig(multiContextKubeconfig)
+
+	if _, err := MinimizeKubeconfig(cfg, "missing"); err == nil {
+		t.Error("Expected error for unknown context")
+	}
+	if _, err := MinimizeKubeconfig(cfg, "local"); err == nil {
+		t.Error("Expected error for context referencing local files")
+	}
+}
@@ -3,20 +3,33 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/klinux/velero-dashboard/internal/k8s"
+	"github.com/klinux/velero-dashboard/internal/metrics"
 	"github.com/klinux/velero-dashboard/internal/ws"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
-// ManagedCluster wraps a cluster with its client and informer
+// ManagedCluster wraps a cluster with its client and informer.
+// Client and InformerMgr are nil while the cluster is not connected.
 type ManagedCluster struct {
 	Cluster     *Cluster
 	Client      *k8s.Client
 	InformerMgr *k8s.InformerManager
 	CancelFunc  context.CancelFunc
+	State       ConnectionState
+
+	ctx          context.Context // parent context for informers and reconnects
+	failures     int             // consecutive failed probes (health checks or connect attempts)
+	downNotified bool            // a cluster_disconnected notification is outstanding
+	backoff      *backoff
+	retryTimer   *time.Timer
+	permsChecked time.Time // last RBAC preflight
+	mdCollected  time.Time // last metadata collection
 }
 
 // Manager manages all cluster connections and informers
@@ -72,7 +85,7 @@ func (m *Manager) Start(ctx context.Context) error {
 		}
 
 		if err := m.AddCluster(ctx, cluster); err != nil {
-			m.logger.Error("Failed to connect to cluster",
+			m.logger.Error("Failed to connect to cluster, will retry in background",
 				zap.String("name", cluster.Name),
 				zap.Error(err))
 			// Continue with other clusters even if one fails
@@ -84,21 +97,44 @@ func (m *Manager) Start(ctx context.Context) error {
 	go m.healthCheckLoop(ctx)
 
 	m.logger.Info("Cluster manager started",
-		zap.Int("connected", len(m.clusters)),
+		zap.Int("connected", m.connectedCount()),
 		zap.Int("total", len(summaries)))
 
 	return nil
 }
 
-// AddCluster adds and connects to a new cluster
+// AddCluster registers a cluster and attempts to connect to it. If the
+// first attempt fails the cluster stays registered in the disconnected
+// state and is retried with exponential backoff; the error is still
+// returned so callers can log it.
 func (m *Manager) AddCluster(ctx context.Context, cluster *Cluster) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Check if already connected
 	if _, exists := m.clusters[cluster.ID]; exists {
-		return fmt.Errorf("cluster already connected")
+		m.mu.Unlock()
+		return fmt.Errorf("cluster already registered")
 	}
+	mc := &ManagedCluster{
+		Cluster: cluster,
+		ctx:     ctx,
+		backoff: newBackoff(),
+	}
+	m.clusters[cluster.ID] = mc
+	m.mu.Unlock()
+
+	m.setState(mc, StateConnecting, "")
+
+	if err := m.connect(mc); err != nil {
+		m.recordFailure(mc)
+		m.setState(mc, StateDisconnected, err.Error())
+		m.scheduleReconnect(mc)
+		return err
+	}
+	return nil
+}
+
+// connect creates a client, verifies connectivity and starts informers.
+func (m *Manager) connect(mc *ManagedCluster) error {
+	cluster := mc.Cluster
 
 	// Create K8s client from kubeconfig
 	client, err := k8s.NewClientFromKubeconfig(
@@ -107,35 +143,57 @@ func (m *Manager) AddCluster(ctx context.Context, cluster *Cluster) error {
 		m.logger,
 	)
 	if err != nil {
-		_ = m.store.UpdateStatus(ctx, cluster.ID, "error", err.Error())
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
+	// Verify the Velero CRDs before touching them so a missing install
+	// reports clearly instead of as a failed list
+	caps, err := client.DetectCapabilities()
+	if err != nil {
+		return err
+	}
+
 	// Test connection
-	testCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	testCtx, cancel := context.WithTimeout(mc.ctx, 10*time.Second)
 	defer cancel()
 
 	if err := client.TestConnection(testCtx); err != nil {
-		_ = m.store.UpdateStatus(ctx, cluster.ID, "error", err.Error())
 		return fmt.Errorf("connection test failed: %w", err)
 	}
 
+	perms := m.checkPermissions(mc.ctx, client, cluster.Name)
+
 	// Start informers for this cluster
-	clusterCtx, clusterCancel := context.WithCancel(ctx)
+	clusterCtx, clusterCancel := context.WithCancel(mc.ctx)
 	informerMgr := k8s.NewInformerManager(client, m.hub, cluster.ID, cluster.Name, m.logger)
 	if m.notifier != nil {
 		informerMgr.SetNotifier(m.notifier)
 	}
-	go informerMgr.Start(clusterCtx)
 
-	m.clusters[cluster.ID] = &ManagedCluster{
-		Cluster:     cluster,
-		Client:      client,
-		InformerMgr: informerMgr,
-		CancelFunc:  clusterCancel,
+	m.mu.Lock()
+	if m.clusters[cluster.ID] != mc {
+		// Removed or replaced while we were connecting
+		m.mu.Unlock()
+		clusterCancel()
+		return fmt.Errorf("cluster removed during connect")
+	}
+	mc.Client = client
+	mc.Cluster.Capabilities = caps
+	mc.Cluster.Permissions = perms
+	if perms != nil {
+		mc.permsChecked = time.Now()
 	}
+	mc.InformerMgr = informerMgr
+	mc.CancelFunc = clusterCancel
+	mc.failures = 0
+	mc.backoff.Reset()
+	state, message := mc.healthyState()
+	m.mu.Unlock()
 
-	_ = m.store.UpdateStatus(ctx, cluster.ID, "connected", "")
+	go informerMgr.Start(clusterCtx)
+	go m.collectMetadata(clusterCtx, mc, client)
+
+	m.setState(mc, state, message)
 	m.logger.Info("Cluster connected",
 		zap.String("id", cluster.ID),
 		zap.String("name", cluster.Name),
@@ -144,8 +202,163 @@ func (m *Manager) AddCluster(ctx context.Context, cluster *Cluster) error {
 	return nil
 }
 
+// disconnect stops informers and drops the client. Caller must hold m.mu.
+func (mc *ManagedCluster) disconnect() {
+	if mc.CancelFunc != nil {
+		mc.CancelFunc()
+	}
+	mc.Client = nil
+	mc.InformerMgr = nil
+	mc.CancelFunc = nil
+}
+
+// scheduleReconnect arms a retry timer using the cluster's backoff.
+func (m *Manager) scheduleReconnect(mc *ManagedCluster) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.clusters[mc.Cluster.ID] != mc || mc.retryTimer != nil || mc.ctx.Err() != nil {
+		return
+	}
+
+	delay := mc.backoff.Next()
+	mc.retryTimer = time.AfterFunc(delay, func() { m.reconnect(mc) })
+
+	m.logger.Info("Scheduled cluster reconnect",
+		zap.String("id", mc.Cluster.ID),
+		zap.String("name", mc.Cluster.Name),
+		zap.Duration("delay", delay))
+}
+
+func (m *Manager) reconnect(mc *ManagedCluster) {
+	m.mu.Lock()
+	mc.retryTimer = nil
+	stale := m.clusters[mc.Cluster.ID] != mc || mc.ctx.Err() != nil
+	m.mu.Unlock()
+	if stale {
+		return
+	}
+
+	m.setState(mc, StateConnecting, "")
+	if err := m.connect(mc); err != nil {
+		m.logger.Warn("Cluster reconnect failed",
+			zap.String("id", mc.Cluster.ID),
+			zap.String("name", mc.Cluster.Name),
+			zap.Error(err))
+		m.recordFailure(mc)
+		m.setState(mc, StateDisconnected, err.Error())
+		m.scheduleReconnect(mc)
+	}
+}
+
+func (m *Manager) recordFailure(mc *ManagedCluster) {
+	m.mu.Lock()
+	mc.failures++
+	m.mu.Unlock()
+}
+
+// setState records a state transition in the store and broadcasts it when
+// the state actually changed.
+func (m *Manager) setState(mc *ManagedCluster, state ConnectionState, message string) {
+	m.mu.Lock()
+	if m.clusters[mc.Cluster.ID] != mc {
+		m.mu.Unlock()
+		return
+	}
+	prev, prevMessage := mc.State, mc.Cluster.StatusMessage
+	name := mc.Cluster.Name
+	mc.State = state
+	mc.Cluster.Status = string(state)
+	mc.Cluster.StatusMessage = message
+	m.mu.Unlock()
+
+	// Health checks report the same state every interval; only changes are
+	// written to the store
+	if prev != state || prevMessage != message {
+		_ = m.store.UpdateStatus(context.Background(), mc.Cluster.ID, string(state), message)
+	}
+	m.updateMetrics()
+	m.notifyStatus(mc, state, message)
+
+	if prev == state {
+		return
+	}
+
+	m.logger.Info("Cluster state changed",
+		zap.String("id", mc.Cluster.ID),
+		zap.String("name", name),
+		zap.String("from", string(prev)),
+		zap.String("to", string(state)),
+		zap.String("message", message))
+
+	if m.hub != nil {
+		m.hub.Broadcast(k8s.WSEvent{
+			Type:   "cluster",
+			Action: "status",
+			Resource: StatusChange{
+				ID:             mc.Cluster.ID,
+				Name:           name,
+				Status:         state,
+				PreviousStatus: prev,
+				StatusMessage:  message,
+				Timestamp:      time.Now(),
+			},
+			ClusterID: mc.Cluster.ID,
+		})
+	}
+}
+
+// notifyStatus dispatches cluster_disconnected once a cluster has failed
+// maxDegradedChecks consecutive probes, and cluster_recovered when such a
+// cluster reconnects. A single failed probe never produces a notification.
+func (m *Manager) notifyStatus(mc *ManagedCluster, state ConnectionState, message string) {
+	if m.notifier == nil {
+		return
+	}
+
+	m.mu.Lock()
+	var payload *k8s.NotificationPayload
+	switch {
+	case state == StateDisconnected && !mc.downNotified && mc.failures >= maxDegradedChecks:
+		mc.downNotified = true
+		payload = &k8s.NotificationPayload{
+			EventType: "cluster_disconnected",
+			Title:     "Cluster Disconnected",
+			Message:   fmt.Sprintf("Cluster \"%s\" is unreachable after %d attempts: %s", mc.Cluster.Name, mc.failures, message),
+		}
+	case mc.downNotified && (state == StateConnected || (state == StateDegraded && mc.failures == 0)):
+		mc.downNotified = false
+		payload = &k8s.NotificationPayload{
+			EventType: "cluster_recovered",
+			Title:     "Cluster Recovered",
+			Message:   fmt.Sprintf("Cluster \"%s\" is connected again", mc.Cluster.Name),
+		}
+	}
+	if payload != nil {
+		payload.ClusterID = mc.Cluster.ID
+		payload.ClusterName = mc.Cluster.Name
+		payload.Resource = mc.Cluster.ToSummary()
+	}
+	m.mu.Unlock()
+
+	if payload != nil {
+		go m.notifier.Dispatch(context.Background(), *payload)
+	}
+}
+
+// updateMetrics refreshes the cluster connection gauges.
+func (m *Manager) updateMetrics() {
+	m.mu.RLock()
+	total := len(m.clusters)
+	m.mu.RUnlock()
+
+	metrics.ClustersConnected.Set(float64(m.connectedCount()))
+	metrics.ClustersTotal.Set(float64(total))
+}
+
 // RemoveCluster disconnects and removes a cluster
 func (m *Manager) RemoveCluster(id string) error {
+	defer m.updateMetrics()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -154,8 +367,12 @@ func (m *Manager) RemoveCluster(id string) error {
 		return fmt.Errorf("cluster not found or not connected")
 	}
 
-	// Stop informers
-	mc.CancelFunc()
+	// Stop pending reconnects and informers
+	if mc.retryTimer != nil {
+		mc.retryTimer.Stop()
+		mc.retryTimer = nil
+	}
+	mc.disconnect()
 
 	// Remove from memory
 	delete(m.clusters, id)
@@ -173,7 +390,7 @@ func (m *Manager) GetClient(id string) (*k8s.Client, error) {
 	defer m.mu.RUnlock()
 
 	mc, exists := m.clusters[id]
-	if !exists {
+	if !exists || mc.Client == nil {
 		return nil, fmt.Errorf("cluster not found or not connected: %s", id)
 	}
 
@@ -191,7 +408,40 @@ func (m *Manager) GetDefaultClient(ctx context.Context) (*k8s.Client, error) {
 
 // ListClusters returns all cluster summaries
 func (m *Manager) ListClusters(ctx context.Context) ([]*ClusterSummary, error) {
-	return m.store.List(ctx)
+	summaries, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, s := range summaries {
+		m.applyRuntime(s)
+	}
+	return summaries, nil
+}
+
+// GetCluster returns a single cluster summary including runtime state
+func (m *Manager) GetCluster(ctx context.Context, id string) (*ClusterSummary, error) {
+	cluster, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	summary := cluster.ToSummary()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.applyRuntime(summary)
+	return summary, nil
+}
+
+// applyRuntime copies state that only lives in memory onto a stored
+// summary. Caller must hold m.mu.
+func (m *Manager) applyRuntime(s *ClusterSummary) {
+	if mc, ok := m.clusters[s.ID]; ok {
+		s.Capabilities = mc.Cluster.Capabilities
+		s.Permissions = mc.Cluster.Permissions
+	}
 }
 
 // GetAllClients returns all connected cluster clients with their IDs
@@ -201,11 +451,115 @@ func (m *Manager) GetAllClients() map[string]*k8s.Client {
 
 	clients := make(map[string]*k8s.Client, len(m.clusters))
 	for id, mc := range m.clusters {
-		clients[id] = mc.Client
+		if mc.Client != nil {
+			clients[id] = mc.Client
+		}
 	}
 	return clients
 }
 
+// SelectClients returns connected clients for a ?cluster= aggregation value:
+// "all" (or empty) selects every connected cluster and the ID of a registered
+// cluster selects that cluster. Anything else is parsed as a label selector
+// such as "env=prod,region in (eu,us)" or "!legacy", matched against the
+// clusters' in-memory labels.
+func (m *Manager) SelectClients(_ context.Context, selector string) (map[string]*k8s.Client, error) {
+	if selector == "" || selector == "all" {
+		return m.GetAllClients(), nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if mc, ok := m.clusters[selector]; ok {
+		clients := make(map[string]*k8s.Client, 1)
+		if mc.Client != nil {
+			clients[selector] = mc.Client
+		}
+		return clients, nil
+	}
+
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+	clients := make(map[string]*k8s.Client)
+	for id, mc := range m.clusters {
+		if mc.Client != nil && sel.Matches(labels.Set(mc.Cluster.Labels)) {
+			clients[id] = mc.Client
+		}
+	}
+	return clients, nil
+}
+
+// IsSelector reports whether a ?cluster= value selects clusters by label
+// rather than naming a registered cluster or "all". Besides the selectors
+// IsLabelSelector recognises, this includes bare keys such as "env".
+func (m *Manager) IsSelector(v string) bool {
+	if v == "" || v == "all" {
+		return false
+	}
+	if IsLabelSelector(v) {
+		return true
+	}
+	m.mu.RLock()
+	_, registered := m.clusters[v]
+	m.mu.RUnlock()
+	if registered {
+		return false
+	}
+	_, err := labels.Parse(v)
+	return err == nil
+}
+
+// Labels returns the in-memory labels of a registered cluster, so
+// permission checks that select clusters by label don't read the store.
+func (m *Manager) Labels(id string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if mc, exists := m.clusters[id]; exists {
+		return mc.Cluster.Labels
+	}
+	return nil
+}
+
+// SetLabels replaces the in-memory labels of a cluster once its stored
+// labels changed.
+func (m *Manager) SetLabels(id string, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mc, exists := m.clusters[id]; exists {
+		mc.Cluster.Labels = labels
+	}
+}
+
+// GetState returns the in-memory connection state of a cluster.
+func (m *Manager) GetState(id string) (ConnectionState, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	mc, exists := m.clusters[id]
+	if !exists {
+		return "", false
+	}
+	return mc.State, true
+}
+
+func (m *Manager) connectedCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := 0
+	for _, mc := range m.clusters {
+		if mc.Client != nil {
+			n++
+		}
+	}
+	return n
+}
+
 // GetStore returns the underlying store (needed by handler)
 func (m *Manager) GetStore() Store {
 	return m.store
@@ -227,8 +581,11 @@ func (m *Manager) healthCheckLoop(ctx context.Context) {
 func (m *Manager) performHealthChecks(ctx context.Context) {
 	m.mu.RLock()
 	clusterIDs := make([]string, 0, len(m.clusters))
-	for id := range m.clusters {
-		clusterIDs = append(clusterIDs, id)
+	for id, mc := range m.clusters {
+		// Disconnected clusters are handled by their reconnect timer
+		if mc.Client != nil {
+			clusterIDs = append(clusterIDs, id)
+		}
 	}
 	m.mu.RUnlock()
 
@@ -240,26 +597,150 @@ func (m *Manager) performHealthChecks(ctx context.Context) {
 func (m *Manager) healthCheckCluster(ctx context.Context, clusterID string) {
 	m.mu.RLock()
 	mc, exists := m.clusters[clusterID]
+	var client *k8s.Client
+	var name string
+	var permsChecked, mdCollected time.Time
+	if exists {
+		client = mc.Client
+		name = mc.Cluster.Name
+		permsChecked = mc.permsChecked
+		mdCollected = mc.mdCollected
+	}
 	m.mu.RUnlock()
 
-	if !exists {
+	if client == nil {
 		return
 	}
 
 	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if err := mc.Client.TestConnection(checkCtx); err != nil {
+	if err := client.TestConnection(checkCtx); err != nil {
 		m.logger.Warn("Health check failed",
 			zap.String("cluster", clusterID),
+			zap.String("name", name),
+			zap.Error(err))
+		metrics.HealthCheckFailures.WithLabelValues(name).Inc()
+
+		m.mu.Lock()
+		if mc.Client != client {
+			// Reconnected or removed while the check was in flight
+			m.mu.Unlock()
+			return
+		}
+		mc.failures++
+		failures := mc.failures
+		if failures >= maxDegradedChecks {
+			// Tear down informers; they are restarted on reconnect
+			mc.disconnect()
+		}
+		m.mu.Unlock()
+
+		if failures >= maxDegradedChecks {
+			m.setState(mc, StateDisconnected, err.Error())
+			m.scheduleReconnect(mc)
+		} else {
+			m.setState(mc, StateDegraded, err.Error())
+		}
+		return
+	}
+
+	var perms k8s.PermissionMatrix
+	if time.Since(permsChecked) >= permissionCheckInterval {
+		perms = m.checkPermissions(ctx, client, name)
+	}
+
+	m.mu.Lock()
+	if mc.Client != client {
+		m.mu.Unlock()
+		return
+	}
+	mc.failures = 0
+	if perms != nil {
+		mc.Cluster.Permissions = perms
+		mc.permsChecked = time.Now()
+	}
+	state, message := mc.healthyState()
+	m.mu.Unlock()
+	m.setState(mc, state, message)
+
+	if time.Since(mdCollected) >= metadataRefreshInterval {
+		m.collectMetadata(ctx, mc, client)
+	}
+}
+
+// collectMetadata refreshes and persists the cluster's version, provider
+// and node count.
+func (m *Manager) collectMetadata(ctx context.Context, mc *ManagedCluster, client *k8s.Client) {
+	collectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	info, err := client.CollectClusterInfo(collectCtx)
+	if err != nil {
+		m.logger.Warn("Failed to collect cluster metadata",
+			zap.String("name", mc.Cluster.Name),
+			zap.Error(err))
+		return
+	}
+	md := &Metadata{
+		KubernetesVersion: info.KubernetesVersion,
+		VeleroVersion:     info.VeleroVersion,
+		Provider:          info.Provider,
+		NodeCount:         info.NodeCount,
+		CollectedAt:       time.Now(),
+	}
+
+	m.mu.Lock()
+	if m.clusters[mc.Cluster.ID] != mc || mc.Client != client {
+		m.mu.Unlock()
+		return
+	}
+	mc.Cluster.Metadata = md
+	mc.mdCollected = md.CollectedAt
+	m.mu.Unlock()
+
+	if err := m.store.UpdateMetadata(context.Background(), mc.Cluster.ID, md); err != nil {
+		m.logger.Warn("Failed to persist cluster metadata",
 			zap.String("name", mc.Cluster.Name),
 			zap.Error(err))
-		_ = m.store.UpdateStatus(ctx, clusterID, "error", err.Error())
-	} else {
-		_ = m.store.UpdateStatus(ctx, clusterID, "connected", "")
 	}
 }
 
+// checkPermissions runs the RBAC preflight for a cluster. It returns nil if
+// the review itself fails, leaving the previous matrix in place.
+func (m *Manager) checkPermissions(ctx context.Context, client *k8s.Client, name string) k8s.PermissionMatrix {
+	reviewCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	perms, err := client.CheckPermissions(reviewCtx)
+	if err != nil {
+		m.logger.Warn("RBAC preflight failed",
+			zap.String("name", name),
+			zap.Error(err))
+		return nil
+	}
+	if missing := perms.Missing(); len(missing) > 0 {
+		m.logger.Warn("Cluster identity lacks permissions",
+			zap.String("name", name),
+			zap.Strings("missing", missing))
+	}
+	if missing := perms.MissingOptional(); len(missing) > 0 {
+		m.logger.Info("Cluster identity lacks permissions for some features",
+			zap.String("name", name),
+			zap.Strings("missing", missing))
+	}
+	return perms
+}
+
+// healthyState is the state of a reachable cluster: degraded while its
+// identity lacks permissions the dashboard needs. Caller must hold m.mu.
+func (mc *ManagedCluster) healthyState() (ConnectionState, string) {
+	if missing := mc.Cluster.Permissions.Missing(); len(missing) > 0 {
+		return StateDegraded, "missing permissions: " + strings.Join(missing, ", ")
+	}
+	return StateConnected, ""
+}
+
 // StartReconciliation starts watching for external cluster Secret changes (GitOps/declarative mode).
 // Only active when using Kubernetes storage backend.
 func (m *Manager) StartReconciliation(ctx context.Context) error {
@@ -298,9 +779,11 @@ func (m *Manager) reconcile(ctx context.Context) {
 	}
 	m.mu.RUnlock()
 
-	// Add new clusters (in store but not in memory)
+	// Add new clusters (in store but not in memory), refresh the labels of
+	// known ones
 	for _, summary := range summaries {
 		if memoryIDs[summary.ID] {
+			m.SetLabels(summary.ID, summary.Labels)
 			continue
 		}
 
@@ -353,7 +836,10 @@ func (m *Manager) Shutdown() {
 	}
 
 	for id, mc := range m.clusters {
-		mc.CancelFunc()
+		if mc.retryTimer != nil {
+			mc.retryTimer.Stop()
+		}
+		mc.disconnect()
 		m.logger.Info("Stopped cluster", zap.String("id", id), zap.String("name", mc.Cluster.Name))
 	}
 
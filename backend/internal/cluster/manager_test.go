@@ -0,0 +1,230 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"go.uber.org/zap"
+)
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (n *recordingNotifier) Dispatch(_ context.Context, p k8s.NotificationPayload) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, p.EventType)
+}
+
+func (n *recordingNotifier) waitFor(t *testing.T, count int) []string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		n.mu.Lock()
+		if len(n.events) >= count {
+			events := append([]string(nil), n.events...)
+			n.mu.Unlock()
+			return events
+		}
+		n.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.events...)
+}
+
+func newTestManager() (*Manager, *recordingNotifier) {
+	logger, _ := zap.NewDevelopment()
+	m := NewManager(nil, nil, logger)
+	n := &recordingNotifier{}
+	m.SetNotifier(n)
+	return m, n
+}
+
+func TestNotifyStatusDebouncesDisconnect(t *testing.T) {
+	m, n := newTestManager()
+	mc := &ManagedCluster{Cluster: &Cluster{ID: "c1", Name: "prod"}}
+
+	// Fewer failures than the threshold must not notify
+	for i := 1; i < maxDegradedChecks; i++ {
+		mc.failures = i
+		m.notifyStatus(mc, StateDisconnected, "timeout")
+	}
+	if events := n.waitFor(t, 1); len(events) != 0 {
+		t.Fatalf("Expected no notifications before threshold, got %v", events)
+	}
+
+	mc.failures = maxDegradedChecks
+	m.notifyStatus(mc, StateDisconnected, "timeout")
+	mc.failures++
+	m.notifyStatus(mc, StateDisconnected, "timeout")
+
+	events := n.waitFor(t, 1)
+	if len(events) != 1 || events[0] != "cluster_disconnected" {
+		t.Fatalf("Expected a single cluster_disconnected, got %v", events)
+	}
+
+	mc.failures = 0
+	m.notifyStatus(mc, StateConnected, "")
+	m.notifyStatus(mc, StateConnected, "")
+
+	events = n.waitFor(t, 2)
+	if len(events) != 2 || events[1] != "cluster_recovered" {
+		t.Fatalf("Expected cluster_recovered after disconnect, got %v", events)
+	}
+}
+
+func TestNotifyStatusNoRecoveryWithoutDisconnect(t *testing.T) {
+	m, n := newTestManager()
+	mc := &ManagedCluster{Cluster: &Cluster{ID: "c1", Name: "prod"}}
+
+	mc.failures = 1
+	m.notifyStatus(mc, StateDegraded, "timeout")
+	mc.failures = 0
+	m.notifyStatus(mc, StateConnected, "")
+
+	if events := n.waitFor(t, 1); len(events) != 0 {
+		t.Fatalf("Expected no notifications for a transient failure, got %v", events)
+	}
+}
+
+func TestHealthyStateMissingPermissions(t *testing.T) {
+	mc := &ManagedCluster{Cluster: &Cluster{ID: "c1"}}
+
+	// Unknown permissions (preflight not run or failed) don't degrade
+	if state, _ := mc.healthyState(); state != StateConnected {
+		t.Errorf("Expected connected without a permission matrix, got %s", state)
+	}
+
+	mc.Cluster.Permissions = k8s.PermissionMatrix{
+		"backups":              {"list": true, "create": true},
+		"deletebackuprequests": {"create": false},
+	}
+	state, message := mc.healthyState()
+	if state != StateDegraded {
+		t.Errorf("Expected degraded, got %s", state)
+	}
+	if message != "missing permissions: create deletebackuprequests" {
+		t.Errorf("Unexpected message %q", message)
+	}
+
+	mc.Cluster.Permissions["deletebackuprequests"]["create"] = true
+	if state, _ := mc.healthyState(); state != StateConnected {
+		t.Errorf("Expected connected once permissions are granted, got %s", state)
+	}
+}
+
+func TestNotifyStatusRecoveryWhenDegradedByPermissions(t *testing.T) {
+	m, n := newTestManager()
+	mc := &ManagedCluster{Cluster: &Cluster{ID: "c1", Name: "prod"}}
+
+	mc.failures = maxDegradedChecks
+	m.notifyStatus(mc, StateDisconnected, "timeout")
+	n.waitFor(t, 1)
+	mc.failures = 0
+	m.notifyStatus(mc, StateDegraded, "missing permissions: create downloadrequests")
+
+	events := n.waitFor(t, 2)
+	if len(events) != 2 || events[1] != "cluster_recovered" {
+		t.Fatalf("Expected cluster_recovered, got %v", events)
+	}
+}
+
+func TestLabelsFromMemory(t *testing.T) {
+	m, _ := newTestManager()
+	m.clusters["c1"] = &ManagedCluster{Cluster: &Cluster{ID: "c1", Labels: map[string]string{"env": "prod"}}}
+
+	if got := m.Labels("c1"); got["env"] != "prod" {
+		t.Errorf("labels = %v", got)
+	}
+	m.SetLabels("c1", map[string]string{"env": "staging"})
+	if got := m.Labels("c1"); got["env"] != "staging" {
+		t.Errorf("labels after update = %v", got)
+	}
+	m.SetLabels("missing", map[string]string{"env": "prod"})
+	if got := m.Labels("missing"); got != nil {
+		t.Errorf("unknown cluster labels = %v", got)
+	}
+}
+
+func TestSelectClients(t *testing.T) {
+	m, _ := newTestManager()
+	id := "6f1c2a3e-1b2c-4d5e-8f90-123456789abc"
+	m.clusters[id] = &ManagedCluster{Cluster: &Cluster{ID: id, Labels: map[string]string{"env": "prod"}}, Client: &k8s.Client{}}
+	m.clusters["c2"] = &ManagedCluster{Cluster: &Cluster{ID: "c2", Labels: map[string]string{"region": "eu"}}, Client: &k8s.Client{}}
+	m.clusters["c3"] = &ManagedCluster{Cluster: &Cluster{ID: "c3", Labels: map[string]string{"env": "dev"}}}
+
+	tests := []struct {
+		value    string
+		want     []string
+		selector bool
+	}{
+		{"all", []string{id, "c2"}, false},
+		{id, []string{id}, false},
+		{"c3", nil, false}, // registered but not connected
+		{"env", []string{id}, true},
+		{"!env", []string{"c2"}, true},
+		{"env=prod", []string{id}, true},
+	}
+	for _, tt := range tests {
+		clients, err := m.SelectClients(context.Background(), tt.value)
+		if err != nil {
+			t.Fatalf("SelectClients(%q): %v", tt.value, err)
+		}
+		var got []string
+		for id := range clients {
+			got = append(got, id)
+		}
+		sort.Strings(got)
+		sort.Strings(tt.want)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("SelectClients(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+		if sel := m.IsSelector(tt.value); sel != tt.selector {
+			t.Errorf("IsSelector(%q) = %v, want %v", tt.value, sel, tt.selector)
+		}
+	}
+
+	if _, err := m.SelectClients(context.Background(), "env in (prod"); err == nil {
+		t.Error("expected an error for a malformed selector")
+	}
+}
+
+// statusStore records status updates.
+type statusStore struct {
+	Store
+	updates []string
+}
+
+func (s *statusStore) UpdateStatus(_ context.Context, id, status, message string) error {
+	s.updates = append(s.updates, status+": "+message)
+	return nil
+}
+
+func TestSetStatePersistsChangesOnly(t *testing.T) {
+	m, _ := newTestManager()
+	store := &statusStore{}
+	m.store = store
+	mc := &ManagedCluster{Cluster: &Cluster{ID: "c1", Name: "prod"}, State: StateConnected}
+	m.clusters["c1"] = mc
+
+	m.setState(mc, StateConnected, "")
+	m.setState(mc, StateDegraded, "timeout")
+	m.setState(mc, StateDegraded, "timeout")
+	m.setState(mc, StateDegraded, "connection refused")
+	m.setState(mc, StateConnected, "")
+	m.setState(mc, StateConnected, "")
+
+	want := []string{"degraded: timeout", "degraded: connection refused", "connected: "}
+	if strings.Join(store.updates, ",") != strings.Join(want, ",") {
+		t.Errorf("updates = %q, want %q", store.updates, want)
+	}
+}
@@ -16,17 +16,35 @@ type Store interface {
 	Update(ctx context.Context, id string, req UpdateClusterRequest) error
 	Delete(ctx context.Context, id string) error
 	UpdateStatus(ctx context.Context, id, status, message string) error
+	UpdateMetadata(ctx context.Context, id string, md *Metadata) error
 	GetDefault(ctx context.Context) (*Cluster, error)
 	Close() error
 }
 
+// LegacyKeyID reports rows encrypted before the keyring existed.
+const LegacyKeyID = "legacy"
+
+// KeyUsage reports which encryption keys protect stored kubeconfigs.
+type KeyUsage struct {
+	ActiveKey string         `json:"activeKey"`
+	Keys      []string       `json:"keys"`
+	Rows      map[string]int `json:"rows"` // key ID (or "legacy") -> row count
+}
+
+// KeyRotator is implemented by stores that encrypt kubeconfigs themselves.
+// The Kubernetes store relies on Secret encryption instead.
+type KeyRotator interface {
+	KeyUsage(ctx context.Context) (*KeyUsage, error)
+	Reencrypt(ctx context.Context) (int, error)
+}
+
 // StoreConfig holds configuration for store creation
 type StoreConfig struct {
-	StorageType   string // "sqlite" or "kubernetes"
-	DBPath        string // SQLite database path
-	EncryptionKey string // AES encryption key (32 bytes)
-	Namespace     string // K8s namespace for ConfigMap/Secrets
-	ConfigMapName string // ConfigMap name for cluster metadata
+	StorageType   string        // "sqlite" or "kubernetes"
+	DBPath        string        // SQLite database path
+	Keyring       KeyringConfig // SQLite kubeconfig encryption keys
+	Namespace     string        // K8s namespace for ConfigMap/Secrets
+	ConfigMapName string        // ConfigMap name for cluster metadata
 }
 
 // NewStore creates a new store based on configuration
@@ -35,7 +53,7 @@ func NewStore(cfg StoreConfig, logger *zap.Logger) (Store, error) {
 	case "kubernetes":
 		return NewK8sStore(cfg.Namespace, cfg.ConfigMapName, logger)
 	case "sqlite":
-		return NewSQLiteStore(cfg.DBPath, cfg.EncryptionKey, logger)
+		return newSQLiteStoreFromConfig(cfg, logger)
 	case "auto", "":
 		// Auto-detect: use K8s if in-cluster, otherwise SQLite
 		if isInCluster() {
@@ -43,12 +61,20 @@ func NewStore(cfg StoreConfig, logger *zap.Logger) (Store, error) {
 			return NewK8sStore(cfg.Namespace, cfg.ConfigMapName, logger)
 		}
 		logger.Info("Auto-detected local environment, using SQLite storage")
-		return NewSQLiteStore(cfg.DBPath, cfg.EncryptionKey, logger)
+		return newSQLiteStoreFromConfig(cfg, logger)
 	default:
 		return nil, fmt.Errorf("unknown storage type: %s", cfg.StorageType)
 	}
 }
 
+func newSQLiteStoreFromConfig(cfg StoreConfig, logger *zap.Logger) (Store, error) {
+	keyring, err := NewKeyring(cfg.Keyring, logger)
+	if err != nil {
+		return nil, fmt.Errorf("encryption keyring: %w", err)
+	}
+	return NewSQLiteStoreWithKeyring(cfg.DBPath, keyring, logger)
+}
+
 // isInCluster checks if we're running inside a Kubernetes cluster
 func isInCluster() bool {
 	_, err := rest.InClusterConfig()
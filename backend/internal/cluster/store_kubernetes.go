@@ -3,21 +3,48 @@ package cluster
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 )
 
-// K8sStore implements Store interface using Kubernetes ConfigMap and Secrets
+const (
+	clustersDataKey = "clusters.json"
+	statusDataKey   = "status.json"
+)
+
+// conflictRetry bounds retries of ConfigMap writes that lost a
+// resourceVersion race. Health checks of every cluster write the status
+// ConfigMap concurrently, so allow more attempts than retry.DefaultRetry.
+var conflictRetry = wait.Backoff{
+	Steps:    10,
+	Duration: 10 * time.Millisecond,
+	Factor:   1.5,
+	Jitter:   0.5,
+}
+
+// errUnchanged lets a mutation skip the write when it changed nothing.
+var errUnchanged = errors.New("unchanged")
+
+// K8sStore implements Store interface using Kubernetes ConfigMap and Secrets.
+//
+// Cluster configuration lives in one ConfigMap and the frequently written
+// connection status in a second one ("<name>-status"), so that health
+// checks don't contend with admin edits or GitOps-managed configuration.
 type K8sStore struct {
-	clientset     *kubernetes.Clientset
+	clientset     kubernetes.Interface
 	namespace     string
 	configMapName string
 	logger        *zap.Logger
@@ -25,14 +52,26 @@ type K8sStore struct {
 
 // clusterMetadata represents cluster metadata stored in ConfigMap
 type clusterMetadata struct {
-	Name            string    `json:"name"`
-	Namespace       string    `json:"namespace"`
-	SecretRef       string    `json:"secretRef"`
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	SecretRef       string            `json:"secretRef"`
+	Status          string            `json:"status"`
+	StatusMessage   string            `json:"statusMessage,omitempty"`
+	IsDefault       bool              `json:"isDefault"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	LastHealthCheck time.Time         `json:"lastHealthCheck,omitempty"`
+	Metadata        *Metadata         `json:"metadata,omitempty"`
+}
+
+// clusterStatus represents the health check results stored in the status
+// ConfigMap. It takes precedence over the status fields of clusterMetadata,
+// which are only kept for data written before the split.
+type clusterStatus struct {
 	Status          string    `json:"status"`
 	StatusMessage   string    `json:"statusMessage,omitempty"`
-	IsDefault       bool      `json:"isDefault"`
-	CreatedAt       time.Time `json:"createdAt"`
 	LastHealthCheck time.Time `json:"lastHealthCheck,omitempty"`
+	Metadata        *Metadata `json:"metadata,omitempty"`
 }
 
 // NewK8sStore creates a new Kubernetes-based store
@@ -56,6 +95,10 @@ func NewK8sStore(namespace, configMapName string, logger *zap.Logger) (*K8sStore
 		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
+	return newK8sStore(context.Background(), clientset, namespace, configMapName, logger)
+}
+
+func newK8sStore(ctx context.Context, clientset kubernetes.Interface, namespace, configMapName string, logger *zap.Logger) (*K8sStore, error) {
 	store := &K8sStore{
 		clientset:     clientset,
 		namespace:     namespace,
@@ -63,8 +106,11 @@ func NewK8sStore(namespace, configMapName string, logger *zap.Logger) (*K8sStore
 		logger:        logger,
 	}
 
-	// Ensure ConfigMap exists
-	if err := store.ensureConfigMap(context.Background()); err != nil {
+	// Ensure ConfigMaps exist
+	if err := store.ensureConfigMap(ctx, store.configMapName, "cluster-config", clustersDataKey); err != nil {
+		return nil, err
+	}
+	if err := store.ensureConfigMap(ctx, store.statusConfigMapName(), "cluster-status", statusDataKey); err != nil {
 		return nil, err
 	}
 
@@ -75,78 +121,185 @@ func NewK8sStore(namespace, configMapName string, logger *zap.Logger) (*K8sStore
 	return store, nil
 }
 
-// ensureConfigMap creates ConfigMap if it doesn't exist
-func (s *K8sStore) ensureConfigMap(ctx context.Context) error {
-	_, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.configMapName, metav1.GetOptions{})
+func (s *K8sStore) statusConfigMapName() string {
+	return s.configMapName + "-status"
+}
+
+// ensureConfigMap creates a ConfigMap holding an empty JSON map if it doesn't exist
+func (s *K8sStore) ensureConfigMap(ctx context.Context, name, component, key string) error {
+	_, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, name, metav1.GetOptions{})
 	if err == nil {
 		return nil // Already exists
 	}
 
-	// Create ConfigMap with empty clusters map
 	cm := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      s.configMapName,
+			Name:      name,
 			Namespace: s.namespace,
 			Labels: map[string]string{
 				"app.kubernetes.io/name":       "velero-dashboard",
-				"app.kubernetes.io/component":  "cluster-config",
+				"app.kubernetes.io/component":  component,
 				"app.kubernetes.io/managed-by": "velero-dashboard",
 			},
 		},
 		Data: map[string]string{
-			"clusters.json": "{}",
+			key: "{}",
 		},
 	}
 
 	_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Create(ctx, cm, metav1.CreateOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to create ConfigMap: %w", err)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ConfigMap %s: %w", name, err)
 	}
 
-	s.logger.Info("Created ConfigMap for cluster storage")
+	s.logger.Info("Created ConfigMap for cluster storage", zap.String("configMap", name))
 	return nil
 }
 
-// loadMetadata loads all cluster metadata from ConfigMap
+// readJSONMap reads a JSON object stored under key in a ConfigMap.
+func readJSONMap[T any](cm *corev1.ConfigMap, key string) (map[string]*T, error) {
+	data := cm.Data[key]
+	entries := make(map[string]*T)
+	if data == "" {
+		return entries, nil
+	}
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return entries, nil
+}
+
+// mutateJSONMap applies fn to the JSON object stored under key in a
+// ConfigMap and writes it back. The write carries the resourceVersion of
+// the read, so a concurrent update makes it fail with a conflict; fn is
+// then re-applied to a fresh copy.
+func mutateJSONMap[T any](ctx context.Context, s *K8sStore, name, key string, fn func(map[string]*T) error) error {
+	configMaps := s.clientset.CoreV1().ConfigMaps(s.namespace)
+	err := retry.RetryOnConflict(conflictRetry, func() error {
+		cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get ConfigMap %s: %w", name, err)
+		}
+		entries, err := readJSONMap[T](cm, key)
+		if err != nil {
+			return err
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", key, err)
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = string(data)
+
+		// Returned unwrapped so RetryOnConflict can recognize conflicts
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+	return err
+}
+
+// loadMetadata loads all cluster metadata, with the latest status applied
 func (s *K8sStore) loadMetadata(ctx context.Context) (map[string]*clusterMetadata, error) {
 	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.configMapName, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ConfigMap: %w", err)
 	}
-
-	data := cm.Data["clusters.json"]
-	if data == "" {
-		return make(map[string]*clusterMetadata), nil
+	clusters, err := readJSONMap[clusterMetadata](cm, clustersDataKey)
+	if err != nil {
+		return nil, err
 	}
 
-	var clusters map[string]*clusterMetadata
-	if err := json.Unmarshal([]byte(data), &clusters); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal cluster metadata: %w", err)
+	statusCM, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.statusConfigMapName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return clusters, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status ConfigMap: %w", err)
+	}
+	statuses, err := readJSONMap[clusterStatus](statusCM, statusDataKey)
+	if err != nil {
+		// Status is rebuilt by the next health checks; don't hide clusters
+		s.logger.Warn("Ignoring unreadable cluster status", zap.Error(err))
+		return clusters, nil
 	}
 
+	for id, st := range statuses {
+		meta, ok := clusters[id]
+		if !ok {
+			continue
+		}
+		if st.Status != "" {
+			meta.Status = st.Status
+			meta.StatusMessage = st.StatusMessage
+			meta.LastHealthCheck = st.LastHealthCheck
+		}
+		if st.Metadata != nil {
+			meta.Metadata = st.Metadata
+		}
+	}
 	return clusters, nil
 }
 
-// saveMetadata saves all cluster metadata to ConfigMap
-func (s *K8sStore) saveMetadata(ctx context.Context, clusters map[string]*clusterMetadata) error {
-	data, err := json.MarshalIndent(clusters, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal cluster metadata: %w", err)
-	}
+// mutateMetadata applies fn to the cluster configuration and saves it
+func (s *K8sStore) mutateMetadata(ctx context.Context, fn func(clusters map[string]*clusterMetadata) error) error {
+	return mutateJSONMap(ctx, s, s.configMapName, clustersDataKey, fn)
+}
 
-	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.configMapName, metav1.GetOptions{})
+// mutateStatus applies fn to an existing cluster's status entry and saves it
+func (s *K8sStore) mutateStatus(ctx context.Context, id string, fn func(st *clusterStatus)) error {
+	clusters, err := s.loadMetadata(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get ConfigMap: %w", err)
+		return err
+	}
+	meta, exists := clusters[id]
+	if !exists {
+		return fmt.Errorf("cluster not found")
 	}
 
-	cm.Data["clusters.json"] = string(data)
+	return mutateJSONMap(ctx, s, s.statusConfigMapName(), statusDataKey, func(statuses map[string]*clusterStatus) error {
+		st, ok := statuses[id]
+		if !ok {
+			// Seed from the pre-split fields so nothing is lost
+			st = &clusterStatus{
+				Status:          meta.Status,
+				StatusMessage:   meta.StatusMessage,
+				LastHealthCheck: meta.LastHealthCheck,
+				Metadata:        meta.Metadata,
+			}
+			statuses[id] = st
+		}
+		fn(st)
+		return nil
+	})
+}
 
-	_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, metav1.UpdateOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to update ConfigMap: %w", err)
+// pruneStatus drops status entries of clusters that no longer exist
+func (s *K8sStore) pruneStatus(ctx context.Context, ids ...string) {
+	err := mutateJSONMap(ctx, s, s.statusConfigMapName(), statusDataKey, func(statuses map[string]*clusterStatus) error {
+		changed := false
+		for _, id := range ids {
+			if _, ok := statuses[id]; ok {
+				delete(statuses, id)
+				changed = true
+			}
+		}
+		if !changed {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		s.logger.Warn("Failed to prune cluster status", zap.Strings("ids", ids), zap.Error(err))
 	}
-
-	return nil
 }
 
 // Create adds a new cluster
@@ -154,26 +307,18 @@ func (s *K8sStore) Create(ctx context.Context, req CreateClusterRequest) (*Clust
 	id := uuid.New().String()
 	secretName := fmt.Sprintf("velero-dashboard-cluster-%s", id)
 
-	// Load existing metadata
+	// Check for duplicate name before creating the Secret; checked again
+	// when saving in case of a concurrent create
 	clusters, err := s.loadMetadata(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	// Check for duplicate name
 	for _, meta := range clusters {
 		if meta.Name == req.Name {
 			return nil, fmt.Errorf("cluster with name %s already exists", req.Name)
 		}
 	}
 
-	// If setting as default, clear other defaults
-	if req.SetAsDefault {
-		for _, meta := range clusters {
-			meta.IsDefault = false
-		}
-	}
-
 	// Create Secret with kubeconfig
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -199,17 +344,30 @@ func (s *K8sStore) Create(ctx context.Context, req CreateClusterRequest) (*Clust
 
 	// Add cluster metadata
 	now := time.Now()
-	clusters[id] = &clusterMetadata{
-		Name:      req.Name,
-		Namespace: req.Namespace,
-		SecretRef: secretName,
-		Status:    "pending",
-		IsDefault: req.SetAsDefault,
-		CreatedAt: now,
-	}
-
-	// Save metadata
-	if err := s.saveMetadata(ctx, clusters); err != nil {
+	err = s.mutateMetadata(ctx, func(clusters map[string]*clusterMetadata) error {
+		for _, meta := range clusters {
+			if meta.Name == req.Name {
+				return fmt.Errorf("cluster with name %s already exists", req.Name)
+			}
+		}
+		// If setting as default, clear other defaults
+		if req.SetAsDefault {
+			for _, meta := range clusters {
+				meta.IsDefault = false
+			}
+		}
+		clusters[id] = &clusterMetadata{
+			Name:      req.Name,
+			Namespace: req.Namespace,
+			SecretRef: secretName,
+			Status:    "pending",
+			IsDefault: req.SetAsDefault,
+			Labels:    req.Labels,
+			CreatedAt: now,
+		}
+		return nil
+	})
+	if err != nil {
 		// Cleanup secret on failure
 		_ = s.clientset.CoreV1().Secrets(s.namespace).Delete(ctx, secretName, metav1.DeleteOptions{})
 		return nil, err
@@ -222,6 +380,7 @@ func (s *K8sStore) Create(ctx context.Context, req CreateClusterRequest) (*Clust
 		Namespace:     req.Namespace,
 		Status:        "pending",
 		IsDefault:     req.SetAsDefault,
+		Labels:        req.Labels,
 		CreatedAt:     now,
 	}, nil
 }
@@ -257,8 +416,10 @@ func (s *K8sStore) Get(ctx context.Context, id string) (*Cluster, error) {
 		Status:          meta.Status,
 		StatusMessage:   meta.StatusMessage,
 		IsDefault:       meta.IsDefault,
+		Labels:          meta.Labels,
 		CreatedAt:       meta.CreatedAt,
 		LastHealthCheck: meta.LastHealthCheck,
+		Metadata:        meta.Metadata,
 	}, nil
 }
 
@@ -278,8 +439,10 @@ func (s *K8sStore) List(ctx context.Context) ([]*ClusterSummary, error) {
 			Status:          meta.Status,
 			StatusMessage:   meta.StatusMessage,
 			IsDefault:       meta.IsDefault,
+			Labels:          meta.Labels,
 			CreatedAt:       meta.CreatedAt,
 			LastHealthCheck: meta.LastHealthCheck,
+			Metadata:        meta.Metadata,
 		})
 	}
 
@@ -288,95 +451,101 @@ func (s *K8sStore) List(ctx context.Context) ([]*ClusterSummary, error) {
 
 // Update modifies cluster configuration
 func (s *K8sStore) Update(ctx context.Context, id string, req UpdateClusterRequest) error {
-	clusters, err := s.loadMetadata(ctx)
-	if err != nil {
-		return err
-	}
-
-	meta, exists := clusters[id]
-	if !exists {
-		return fmt.Errorf("cluster not found")
-	}
+	var secretRef string
+	err := s.mutateMetadata(ctx, func(clusters map[string]*clusterMetadata) error {
+		meta, exists := clusters[id]
+		if !exists {
+			return fmt.Errorf("cluster not found")
+		}
+		secretRef = meta.SecretRef
 
-	// If setting as default, clear other defaults
-	if req.SetAsDefault != nil && *req.SetAsDefault {
-		for _, m := range clusters {
-			m.IsDefault = false
+		// If setting as default, clear other defaults
+		if req.SetAsDefault != nil && *req.SetAsDefault {
+			for _, m := range clusters {
+				m.IsDefault = false
+			}
 		}
-	}
 
-	// Update metadata
-	if req.Name != nil {
-		meta.Name = *req.Name
-	}
-	if req.Namespace != nil {
-		meta.Namespace = *req.Namespace
-	}
-	if req.SetAsDefault != nil {
-		meta.IsDefault = *req.SetAsDefault
+		if req.Name != nil {
+			meta.Name = *req.Name
+		}
+		if req.Namespace != nil {
+			meta.Namespace = *req.Namespace
+		}
+		if req.SetAsDefault != nil {
+			meta.IsDefault = *req.SetAsDefault
+		}
+		if req.Labels != nil {
+			meta.Labels = req.Labels
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Update kubeconfig in Secret if provided
 	if req.Kubeconfig != nil {
-		secret, err := s.clientset.CoreV1().Secrets(s.namespace).Get(ctx, meta.SecretRef, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to get Secret: %w", err)
-		}
-
-		secret.StringData = map[string]string{
-			"kubeconfig": *req.Kubeconfig,
-		}
-
-		_, err = s.clientset.CoreV1().Secrets(s.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		err := retry.RetryOnConflict(conflictRetry, func() error {
+			secret, err := s.clientset.CoreV1().Secrets(s.namespace).Get(ctx, secretRef, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			secret.StringData = map[string]string{
+				"kubeconfig": *req.Kubeconfig,
+			}
+			_, err = s.clientset.CoreV1().Secrets(s.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("failed to update Secret: %w", err)
 		}
 	}
 
-	return s.saveMetadata(ctx, clusters)
+	return nil
 }
 
 // Delete removes a cluster
 func (s *K8sStore) Delete(ctx context.Context, id string) error {
-	clusters, err := s.loadMetadata(ctx)
+	var secretRef string
+	err := s.mutateMetadata(ctx, func(clusters map[string]*clusterMetadata) error {
+		meta, exists := clusters[id]
+		if !exists {
+			return fmt.Errorf("cluster not found")
+		}
+		secretRef = meta.SecretRef
+		delete(clusters, id)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	meta, exists := clusters[id]
-	if !exists {
-		return fmt.Errorf("cluster not found")
-	}
-
 	// Delete Secret
-	err = s.clientset.CoreV1().Secrets(s.namespace).Delete(ctx, meta.SecretRef, metav1.DeleteOptions{})
+	err = s.clientset.CoreV1().Secrets(s.namespace).Delete(ctx, secretRef, metav1.DeleteOptions{})
 	if err != nil {
-		s.logger.Warn("Failed to delete Secret", zap.String("secret", meta.SecretRef), zap.Error(err))
+		s.logger.Warn("Failed to delete Secret", zap.String("secret", secretRef), zap.Error(err))
 	}
 
-	// Remove from metadata
-	delete(clusters, id)
-
-	return s.saveMetadata(ctx, clusters)
+	s.pruneStatus(ctx, id)
+	return nil
 }
 
 // UpdateStatus updates cluster connection status
 func (s *K8sStore) UpdateStatus(ctx context.Context, id, status, message string) error {
-	clusters, err := s.loadMetadata(ctx)
-	if err != nil {
-		return err
-	}
-
-	meta, exists := clusters[id]
-	if !exists {
-		return fmt.Errorf("cluster not found")
-	}
-
-	meta.Status = status
-	meta.StatusMessage = message
-	meta.LastHealthCheck = time.Now()
+	now := time.Now()
+	return s.mutateStatus(ctx, id, func(st *clusterStatus) {
+		st.Status = status
+		st.StatusMessage = message
+		st.LastHealthCheck = now
+	})
+}
 
-	return s.saveMetadata(ctx, clusters)
+// UpdateMetadata records the metadata collected by health checks
+func (s *K8sStore) UpdateMetadata(ctx context.Context, id string, md *Metadata) error {
+	return s.mutateStatus(ctx, id, func(st *clusterStatus) {
+		st.Metadata = md
+	})
 }
 
 // GetDefault returns the default cluster
@@ -403,7 +572,8 @@ func (s *K8sStore) Close() error {
 // WatchSecrets watches for externally created/deleted Secrets and syncs to ConfigMap metadata.
 // This enables declarative/GitOps workflows where admins create Secrets via kubectl/Helm.
 // Secrets must have label: app.kubernetes.io/component=cluster-kubeconfig
-// and annotations: velero-dashboard/cluster-name, velero-dashboard/cluster-namespace
+// and annotations: velero-dashboard/cluster-name, velero-dashboard/cluster-namespace.
+// Optional annotation velero-dashboard/cluster-labels ("env=prod,region=eu") sets cluster labels.
 func (s *K8sStore) WatchSecrets(ctx context.Context, onChange func()) error {
 	labelSelector := "app.kubernetes.io/name=velero-dashboard,app.kubernetes.io/component=cluster-kubeconfig"
 
@@ -481,104 +651,147 @@ func (s *K8sStore) reconcileSecrets(ctx context.Context) bool {
 		return false
 	}
 
-	// Load current metadata
-	clusters, err := s.loadMetadata(ctx)
-	if err != nil {
-		s.logger.Error("Failed to load metadata for reconciliation", zap.Error(err))
-		return false
-	}
-
-	changed := false
-
 	// Build a set of Secret names that exist
 	existingSecrets := make(map[string]bool)
 	for _, secret := range secrets.Items {
 		existingSecrets[secret.Name] = true
 	}
 
-	// Build a reverse map: secretRef -> clusterID
-	secretToCluster := make(map[string]string)
-	for id, meta := range clusters {
-		secretToCluster[meta.SecretRef] = id
-	}
-
-	// Check for new Secrets not in ConfigMap
-	for _, secret := range secrets.Items {
-		if _, exists := secretToCluster[secret.Name]; exists {
-			continue // Already tracked
+	var removed []string
+	changed := false
+	err = s.mutateMetadata(ctx, func(clusters map[string]*clusterMetadata) error {
+		// Re-evaluated from scratch when a conflict forces a retry
+		removed = nil
+		changed = false
+
+		// Build a reverse map: secretRef -> clusterID
+		secretToCluster := make(map[string]string)
+		for id, meta := range clusters {
+			secretToCluster[meta.SecretRef] = id
 		}
 
-		// Check if Secret has kubeconfig data
-		if _, hasKubeconfig := secret.Data["kubeconfig"]; !hasKubeconfig {
-			continue
-		}
+		// Check for new Secrets not in ConfigMap
+		for _, secret := range secrets.Items {
+			if id, exists := secretToCluster[secret.Name]; exists {
+				// Already tracked: keep annotation-managed labels in sync
+				if raw, ok := secret.Annotations[clusterLabelsAnnotation]; ok {
+					secretLabels, err := parseLabelsAnnotation(raw)
+					if err != nil {
+						s.logger.Warn("Invalid cluster-labels annotation, ignoring",
+							zap.String("secret", secret.Name), zap.Error(err))
+					} else if !labels.Equals(secretLabels, clusters[id].Labels) {
+						clusters[id].Labels = secretLabels
+						changed = true
+					}
+				}
+				continue
+			}
 
-		// Extract cluster info from annotations
-		annotations := secret.Annotations
-		clusterName := annotations["velero-dashboard/cluster-name"]
-		if clusterName == "" {
-			// Check label as fallback
-			clusterName = secret.Labels["velero-dashboard/cluster-id"]
-			if clusterName == "" {
-				s.logger.Warn("Secret missing cluster-name annotation, skipping",
-					zap.String("secret", secret.Name))
+			// Check if Secret has kubeconfig data
+			if _, hasKubeconfig := secret.Data["kubeconfig"]; !hasKubeconfig {
 				continue
 			}
-		}
 
-		clusterNamespace := annotations["velero-dashboard/cluster-namespace"]
-		if clusterNamespace == "" {
-			clusterNamespace = "velero"
-		}
+			// Extract cluster info from annotations
+			annotations := secret.Annotations
+			clusterName := annotations["velero-dashboard/cluster-name"]
+			if clusterName == "" {
+				// Check label as fallback
+				clusterName = secret.Labels["velero-dashboard/cluster-id"]
+				if clusterName == "" {
+					s.logger.Warn("Secret missing cluster-name annotation, skipping",
+						zap.String("secret", secret.Name))
+					continue
+				}
+			}
 
-		isDefault := annotations["velero-dashboard/is-default"] == "true"
+			clusterNamespace := annotations["velero-dashboard/cluster-namespace"]
+			if clusterNamespace == "" {
+				clusterNamespace = "velero"
+			}
 
-		// Generate ID for the new cluster
-		id := uuid.New().String()
+			isDefault := annotations["velero-dashboard/is-default"] == "true"
 
-		// If setting as default, clear others
-		if isDefault {
-			for _, m := range clusters {
-				m.IsDefault = false
+			clusterLabels, err := parseLabelsAnnotation(annotations[clusterLabelsAnnotation])
+			if err != nil {
+				s.logger.Warn("Invalid cluster-labels annotation, ignoring",
+					zap.String("secret", secret.Name), zap.Error(err))
+				clusterLabels = nil
 			}
-		}
 
-		clusters[id] = &clusterMetadata{
-			Name:      clusterName,
-			Namespace: clusterNamespace,
-			SecretRef: secret.Name,
-			Status:    "pending",
-			IsDefault: isDefault,
-			CreatedAt: secret.CreationTimestamp.Time,
-		}
+			// Generate ID for the new cluster
+			id := uuid.New().String()
 
-		s.logger.Info("Discovered external cluster Secret",
-			zap.String("id", id),
-			zap.String("name", clusterName),
-			zap.String("secret", secret.Name))
-		changed = true
-	}
+			// If setting as default, clear others
+			if isDefault {
+				for _, m := range clusters {
+					m.IsDefault = false
+				}
+			}
 
-	// Check for clusters whose Secrets have been deleted
-	for id, meta := range clusters {
-		if !existingSecrets[meta.SecretRef] {
-			s.logger.Info("Cluster Secret deleted externally, removing from metadata",
+			clusters[id] = &clusterMetadata{
+				Name:      clusterName,
+				Namespace: clusterNamespace,
+				SecretRef: secret.Name,
+				Status:    "pending",
+				IsDefault: isDefault,
+				Labels:    clusterLabels,
+				CreatedAt: secret.CreationTimestamp.Time,
+			}
+
+			s.logger.Info("Discovered external cluster Secret",
 				zap.String("id", id),
-				zap.String("name", meta.Name),
-				zap.String("secret", meta.SecretRef))
-			delete(clusters, id)
+				zap.String("name", clusterName),
+				zap.String("secret", secret.Name))
 			changed = true
 		}
-	}
 
-	// Save if changes were made
-	if changed {
-		if err := s.saveMetadata(ctx, clusters); err != nil {
-			s.logger.Error("Failed to save reconciled metadata", zap.Error(err))
-			return false
+		// Check for clusters whose Secrets have been deleted
+		for id, meta := range clusters {
+			if !existingSecrets[meta.SecretRef] {
+				s.logger.Info("Cluster Secret deleted externally, removing from metadata",
+					zap.String("id", id),
+					zap.String("name", meta.Name),
+					zap.String("secret", meta.SecretRef))
+				delete(clusters, id)
+				removed = append(removed, id)
+				changed = true
+			}
+		}
+
+		if !changed {
+			return errUnchanged
 		}
-		s.logger.Info("Reconciliation complete", zap.Int("totalClusters", len(clusters)))
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to save reconciled metadata", zap.Error(err))
+		return false
+	}
+	if len(removed) > 0 {
+		s.pruneStatus(ctx, removed...)
+	}
+	if changed {
+		s.logger.Info("Reconciliation complete")
 	}
 
 	return changed
 }
+
+// clusterLabelsAnnotation holds cluster labels on declaratively managed Secrets.
+const clusterLabelsAnnotation = "velero-dashboard/cluster-labels"
+
+// parseLabelsAnnotation parses "k1=v1,k2=v2" into a label map.
+func parseLabelsAnnotation(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	set, err := labels.ConvertSelectorToLabelsMap(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateLabels(set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
@@ -0,0 +1,251 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newVersionedClientset returns a fake clientset whose ConfigMap and Secret
+// updates enforce resourceVersion preconditions like the API server does.
+// The fake tracker alone accepts stale writes, which would hide lost updates.
+func newVersionedClientset(objects ...runtime.Object) *fake.Clientset {
+	cs := fake.NewSimpleClientset(objects...)
+	for _, resource := range []string{"configmaps", "secrets"} {
+		cs.PrependReactor("update", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+			update := action.(k8stesting.UpdateAction)
+			obj := update.GetObject().(metav1.Object)
+			gvr := update.GetResource()
+
+			current, err := cs.Tracker().Get(gvr, update.GetNamespace(), obj.GetName())
+			if err != nil {
+				return true, nil, err
+			}
+			currentVersion := current.(metav1.Object).GetResourceVersion()
+			if obj.GetResourceVersion() != currentVersion {
+				return true, nil, apierrors.NewConflict(gvr.GroupResource(), obj.GetName(),
+					fmt.Errorf("resourceVersion %q is stale, current is %q", obj.GetResourceVersion(), currentVersion))
+			}
+
+			n, _ := strconv.Atoi(currentVersion)
+			next := update.GetObject().DeepCopyObject()
+			next.(metav1.Object).SetResourceVersion(strconv.Itoa(n + 1))
+			if err := cs.Tracker().Update(gvr, next, update.GetNamespace()); err != nil {
+				return true, nil, err
+			}
+			return true, next, nil
+		})
+	}
+	return cs
+}
+
+func newTestK8sStore(t *testing.T, cs *fake.Clientset) *K8sStore {
+	t.Helper()
+	store, err := newK8sStore(context.Background(), cs, "velero", "velero-dashboard-clusters", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestK8sStoreCreatesStatusConfigMap(t *testing.T) {
+	cs := newVersionedClientset()
+	newTestK8sStore(t, cs)
+
+	for _, name := range []string{"velero-dashboard-clusters", "velero-dashboard-clusters-status"} {
+		if _, err := cs.CoreV1().ConfigMaps("velero").Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+			t.Errorf("ConfigMap %s not created: %v", name, err)
+		}
+	}
+}
+
+func TestK8sStoreRetriesOnConflict(t *testing.T) {
+	cs := newVersionedClientset()
+	store := newTestK8sStore(t, cs)
+	ctx := context.Background()
+
+	a, err := store.Create(ctx, CreateClusterRequest{Name: "a", Kubeconfig: "kc", Namespace: "velero"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Interleave a competing write between the store's read and its update:
+	// just before the first update of the config ConfigMap lands, another
+	// replica adds cluster "b" and bumps the resourceVersion. The fake
+	// clientset holds its lock while reactors run, so write via the tracker.
+	interleaved := false
+	cs.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		update := action.(k8stesting.UpdateAction)
+		if interleaved || update.GetObject().(metav1.Object).GetName() != "velero-dashboard-clusters" {
+			return false, nil, nil
+		}
+		interleaved = true
+
+		obj, err := cs.Tracker().Get(update.GetResource(), "velero", "velero-dashboard-clusters")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cm := obj.(*corev1.ConfigMap).DeepCopy()
+		clusters, _ := readJSONMap[clusterMetadata](cm, clustersDataKey)
+		clusters["b"] = &clusterMetadata{Name: "b", Namespace: "velero", SecretRef: "secret-b"}
+		data, _ := json.Marshal(clusters)
+		cm.Data[clustersDataKey] = string(data)
+		n, _ := strconv.Atoi(cm.ResourceVersion)
+		cm.ResourceVersion = strconv.Itoa(n + 1)
+		if err := cs.Tracker().Update(update.GetResource(), cm, "velero"); err != nil {
+			t.Fatal(err)
+		}
+		return false, nil, nil
+	})
+
+	labels := map[string]string{"env": "prod"}
+	if err := store.Update(ctx, a.ID, UpdateClusterRequest{Labels: labels}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !interleaved {
+		t.Fatal("competing write was not interleaved")
+	}
+
+	clusters, err := store.loadMetadata(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := clusters["b"]; !ok || len(clusters) != 2 {
+		t.Fatalf("competing write lost: %v", clusters)
+	}
+	if clusters[a.ID].Labels["env"] != "prod" {
+		t.Errorf("update lost: labels %v", clusters[a.ID].Labels)
+	}
+}
+
+func TestK8sStoreConcurrentStatusUpdates(t *testing.T) {
+	cs := newVersionedClientset()
+	store := newTestK8sStore(t, cs)
+	ctx := context.Background()
+
+	const n = 6
+	ids := make([]string, n)
+	for i := range ids {
+		c, err := store.Create(ctx, CreateClusterRequest{Name: fmt.Sprintf("c%d", i), Kubeconfig: "kc", Namespace: "velero"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = c.ID
+	}
+
+	// Health checks of every cluster and an admin edit race each other
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i, id := range ids {
+		wg.Add(2)
+		go func(id string) {
+			defer wg.Done()
+			errs <- store.UpdateStatus(ctx, id, "connected", "ok")
+		}(id)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs <- store.Update(ctx, id, UpdateClusterRequest{Labels: map[string]string{"n": strconv.Itoa(i)}})
+		}(i, id)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent write failed: %v", err)
+		}
+	}
+
+	clusters, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]*ClusterSummary)
+	for _, c := range clusters {
+		byID[c.ID] = c
+	}
+	for i, id := range ids {
+		c := byID[id]
+		if c == nil {
+			t.Fatalf("cluster %s lost", id)
+		}
+		if c.Status != "connected" || c.LastHealthCheck.IsZero() {
+			t.Errorf("status update lost for %s: %q", c.Name, c.Status)
+		}
+		if c.Labels["n"] != strconv.Itoa(i) {
+			t.Errorf("label update lost for %s: %v", c.Name, c.Labels)
+		}
+	}
+}
+
+func TestK8sStoreStatusFallsBackToConfig(t *testing.T) {
+	// Data written before the status split keeps its status until the next
+	// health check writes the status ConfigMap
+	legacy := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "velero-dashboard-clusters", Namespace: "velero"},
+		Data: map[string]string{
+			clustersDataKey: `{"c1":{"name":"old","namespace":"velero","secretRef":"s1","status":"connected","statusMessage":"fine","isDefault":true,"metadata":{"kubernetesVersion":"v1.28.0","nodeCount":2}}}`,
+		},
+	}
+	cs := newVersionedClientset(legacy)
+	store := newTestK8sStore(t, cs)
+	ctx := context.Background()
+
+	clusters, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || clusters[0].Status != "connected" || clusters[0].Metadata.KubernetesVersion != "v1.28.0" {
+		t.Fatalf("legacy status not read: %+v", clusters)
+	}
+
+	if err := store.UpdateStatus(ctx, "c1", "degraded", "slow"); err != nil {
+		t.Fatal(err)
+	}
+	clusters, _ = store.List(ctx)
+	if clusters[0].Status != "degraded" || clusters[0].Metadata == nil || clusters[0].Metadata.NodeCount != 2 {
+		t.Errorf("status split lost data: %+v", clusters[0])
+	}
+
+	// The config ConfigMap is left alone by status writes
+	cm, _ := cs.CoreV1().ConfigMaps("velero").Get(ctx, "velero-dashboard-clusters", metav1.GetOptions{})
+	if cm.ResourceVersion != "" {
+		t.Errorf("config ConfigMap was rewritten by a status update (resourceVersion %q)", cm.ResourceVersion)
+	}
+}
+
+func TestK8sStoreDeletePrunesStatus(t *testing.T) {
+	cs := newVersionedClientset()
+	store := newTestK8sStore(t, cs)
+	ctx := context.Background()
+
+	c, err := store.Create(ctx, CreateClusterRequest{Name: "a", Kubeconfig: "kc", Namespace: "velero"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateStatus(ctx, c.ID, "connected", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	cm, _ := cs.CoreV1().ConfigMaps("velero").Get(ctx, "velero-dashboard-clusters-status", metav1.GetOptions{})
+	statuses, err := readJSONMap[clusterStatus](cm, statusDataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := statuses[c.ID]; ok {
+		t.Error("status entry not pruned on delete")
+	}
+}
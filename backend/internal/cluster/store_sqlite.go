@@ -2,29 +2,36 @@ package cluster
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"database/sql"
-	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/klinux/velero-dashboard/internal/migrate"
 	_ "github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
 )
 
 // SQLiteStore implements Store interface using SQLite
 type SQLiteStore struct {
-	db     *sql.DB
-	cipher cipher.AEAD
-	logger *zap.Logger
+	db      *sql.DB
+	keyring *Keyring
+	logger  *zap.Logger
 }
 
-// NewSQLiteStore creates a new SQLite-based store
+// NewSQLiteStore creates a new SQLite-based store encrypting with a single
+// key. An empty key generates a random one, so data won't survive restarts.
 func NewSQLiteStore(dbPath string, encryptionKey string, logger *zap.Logger) (*SQLiteStore, error) {
+	keyring, err := NewKeyring(KeyringConfig{Key: encryptionKey}, logger)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLiteStoreWithKeyring(dbPath, keyring, logger)
+}
+
+// NewSQLiteStoreWithKeyring creates a new SQLite-based store
+func NewSQLiteStoreWithKeyring(dbPath string, keyring *Keyring, logger *zap.Logger) (*SQLiteStore, error) {
 	if dbPath == "" {
 		dbPath = "./clusters.db"
 	}
@@ -34,89 +41,64 @@ func NewSQLiteStore(dbPath string, encryptionKey string, logger *zap.Logger) (*S
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Initialize schema
-	if err := initSQLiteSchema(db); err != nil {
+	if err := migrate.Apply(db, "clusters", sqliteMigrations, logger); err != nil {
 		_ = db.Close()
-		return nil, err
-	}
-
-	// Setup encryption
-	gcm, err := setupCipher(encryptionKey, logger)
-	if err != nil {
-		_ = db.Close()
-		return nil, err
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
 	logger.Info("SQLite store initialized", zap.String("path", dbPath))
 
 	return &SQLiteStore{
-		db:     db,
-		cipher: gcm,
-		logger: logger,
+		db:      db,
+		keyring: keyring,
+		logger:  logger,
 	}, nil
 }
 
-func initSQLiteSchema(db *sql.DB) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS clusters (
-		id TEXT PRIMARY KEY,
-		name TEXT UNIQUE NOT NULL,
-		kubeconfig_encrypted BLOB NOT NULL,
-		namespace TEXT NOT NULL,
-		status TEXT NOT NULL,
-		status_message TEXT,
-		is_default INTEGER NOT NULL DEFAULT 0,
-		created_at DATETIME NOT NULL,
-		last_health_check DATETIME
-	);
-	CREATE INDEX IF NOT EXISTS idx_clusters_default ON clusters(is_default);
-	CREATE INDEX IF NOT EXISTS idx_clusters_name ON clusters(name);
-	`
-	_, err := db.Exec(schema)
-	return err
-}
-
-func setupCipher(key string, logger *zap.Logger) (cipher.AEAD, error) {
-	// If key is empty, generate a random one (with warning)
-	if key == "" {
-		randomKey := make([]byte, 32)
-		if _, err := rand.Read(randomKey); err != nil {
-			return nil, err
-		}
-		key = base64.StdEncoding.EncodeToString(randomKey)
-		logger.Warn("No encryption key provided, generated random key. Clusters won't persist across restarts!")
-	}
-
-	// Ensure key is 32 bytes for AES-256
-	keyBytes := []byte(key)
-	if len(keyBytes) != 32 {
-		// Pad or truncate to 32 bytes
-		keyBytes = []byte(fmt.Sprintf("%032s", key)[:32])
-	}
-
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	return cipher.NewGCM(block)
+// sqliteMigrations is the cluster store schema history. Never edit an
+// applied migration; append a new one instead.
+var sqliteMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create clusters",
+		Up: migrate.Exec(`
+		CREATE TABLE IF NOT EXISTS clusters (
+			id TEXT PRIMARY KEY,
+			name TEXT UNIQUE NOT NULL,
+			kubeconfig_encrypted BLOB NOT NULL,
+			namespace TEXT NOT NULL,
+			status TEXT NOT NULL,
+			status_message TEXT,
+			is_default INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			last_health_check DATETIME
+		)`,
+			`CREATE INDEX IF NOT EXISTS idx_clusters_default ON clusters(is_default)`,
+			`CREATE INDEX IF NOT EXISTS idx_clusters_name ON clusters(name)`,
+		),
+	},
+	{
+		Version: 2,
+		Name:    "add cluster labels",
+		Up: func(tx *sql.Tx) error {
+			return migrate.AddColumn(tx, "clusters", "labels", "TEXT")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add cluster metadata",
+		Up: func(tx *sql.Tx) error {
+			return migrate.AddColumn(tx, "clusters", "metadata", "TEXT")
+		},
+	},
 }
 
 func (s *SQLiteStore) encrypt(plaintext []byte) ([]byte, error) {
-	nonce := make([]byte, s.cipher.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-	return s.cipher.Seal(nonce, nonce, plaintext, nil), nil
+	return s.keyring.Encrypt(plaintext)
 }
 
 func (s *SQLiteStore) decrypt(ciphertext []byte) ([]byte, error) {
-	nonceSize := s.cipher.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return nil, fmt.Errorf("ciphertext too short")
-	}
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	return s.cipher.Open(nil, nonce, ciphertext, nil)
+	return s.keyring.Decrypt(ciphertext)
 }
 
 // Create adds a new cluster
@@ -135,11 +117,16 @@ func (s *SQLiteStore) Create(ctx context.Context, req CreateClusterRequest) (*Cl
 		}
 	}
 
+	labelsJSON, err := marshalLabels(req.Labels)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO clusters (id, name, kubeconfig_encrypted, namespace, status, is_default, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, id, req.Name, encrypted, req.Namespace, "pending", boolToInt(req.SetAsDefault), now)
+		INSERT INTO clusters (id, name, kubeconfig_encrypted, namespace, status, is_default, created_at, labels)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, req.Name, encrypted, req.Namespace, "pending", boolToInt(req.SetAsDefault), now, labelsJSON)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert cluster: %w", err)
@@ -152,6 +139,7 @@ func (s *SQLiteStore) Create(ctx context.Context, req CreateClusterRequest) (*Cl
 		Namespace:     req.Namespace,
 		Status:        "pending",
 		IsDefault:     req.SetAsDefault,
+		Labels:        req.Labels,
 		CreatedAt:     now,
 	}, nil
 }
@@ -162,14 +150,14 @@ func (s *SQLiteStore) Get(ctx context.Context, id string) (*Cluster, error) {
 	var encrypted []byte
 	var isDefault int
 	var lastCheck sql.NullTime
-	var statusMsg sql.NullString
+	var statusMsg, labelsJSON, metadataJSON sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, name, kubeconfig_encrypted, namespace, status, status_message,
-		       is_default, created_at, last_health_check
+		       is_default, created_at, last_health_check, labels, metadata
 		FROM clusters WHERE id = ?
 	`, id).Scan(&c.ID, &c.Name, &encrypted, &c.Namespace, &c.Status,
-		&statusMsg, &isDefault, &c.CreatedAt, &lastCheck)
+		&statusMsg, &isDefault, &c.CreatedAt, &lastCheck, &labelsJSON, &metadataJSON)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("cluster not found")
@@ -185,6 +173,8 @@ func (s *SQLiteStore) Get(ctx context.Context, id string) (*Cluster, error) {
 	if statusMsg.Valid {
 		c.StatusMessage = statusMsg.String
 	}
+	c.Labels = unmarshalLabels(labelsJSON)
+	c.Metadata = unmarshalMetadata(metadataJSON)
 
 	kubeconfig, err := s.decrypt(encrypted)
 	if err != nil {
@@ -199,7 +189,7 @@ func (s *SQLiteStore) Get(ctx context.Context, id string) (*Cluster, error) {
 func (s *SQLiteStore) List(ctx context.Context) ([]*ClusterSummary, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, name, namespace, status, status_message, is_default,
-		       created_at, last_health_check
+		       created_at, last_health_check, labels, metadata
 		FROM clusters ORDER BY is_default DESC, name ASC
 	`)
 	if err != nil {
@@ -212,10 +202,10 @@ func (s *SQLiteStore) List(ctx context.Context) ([]*ClusterSummary, error) {
 		var c ClusterSummary
 		var isDefault int
 		var lastCheck sql.NullTime
-		var statusMsg sql.NullString
+		var statusMsg, labelsJSON, metadataJSON sql.NullString
 
 		err := rows.Scan(&c.ID, &c.Name, &c.Namespace, &c.Status,
-			&statusMsg, &isDefault, &c.CreatedAt, &lastCheck)
+			&statusMsg, &isDefault, &c.CreatedAt, &lastCheck, &labelsJSON, &metadataJSON)
 		if err != nil {
 			return nil, err
 		}
@@ -227,6 +217,8 @@ func (s *SQLiteStore) List(ctx context.Context) ([]*ClusterSummary, error) {
 		if statusMsg.Valid {
 			c.StatusMessage = statusMsg.String
 		}
+		c.Labels = unmarshalLabels(labelsJSON)
+		c.Metadata = unmarshalMetadata(metadataJSON)
 
 		clusters = append(clusters, &c)
 	}
@@ -274,6 +266,14 @@ func (s *SQLiteStore) Update(ctx context.Context, id string, req UpdateClusterRe
 		query += "is_default = ?, "
 		args = append(args, boolToInt(*req.SetAsDefault))
 	}
+	if req.Labels != nil {
+		labelsJSON, err := marshalLabels(req.Labels)
+		if err != nil {
+			return err
+		}
+		query += "labels = ?, "
+		args = append(args, labelsJSON)
+	}
 
 	// Remove trailing comma and add WHERE clause
 	query = query[:len(query)-2] + " WHERE id = ?"
@@ -317,6 +317,98 @@ func (s *SQLiteStore) UpdateStatus(ctx context.Context, id, status, message stri
 	return err
 }
 
+// UpdateMetadata records the metadata collected by health checks
+func (s *SQLiteStore) UpdateMetadata(ctx context.Context, id string, md *Metadata) error {
+	data, err := json.Marshal(md)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, "UPDATE clusters SET metadata = ? WHERE id = ?", string(data), id)
+	return err
+}
+
+// KeyUsage counts stored kubeconfigs per encryption key ID
+func (s *SQLiteStore) KeyUsage(ctx context.Context) (*KeyUsage, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT kubeconfig_encrypted FROM clusters")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	usage := &KeyUsage{
+		ActiveKey: s.keyring.ActiveKey(),
+		Keys:      s.keyring.KeyIDs(),
+		Rows:      make(map[string]int),
+	}
+	for rows.Next() {
+		var encrypted []byte
+		if err := rows.Scan(&encrypted); err != nil {
+			return nil, err
+		}
+		keyID := s.keyring.KeyID(encrypted)
+		if keyID == "" {
+			keyID = LegacyKeyID
+		}
+		usage.Rows[keyID]++
+	}
+	return usage, rows.Err()
+}
+
+// Reencrypt rewraps every kubeconfig with the active key in one
+// transaction and returns the number of rows changed.
+func (s *SQLiteStore) Reencrypt(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, kubeconfig_encrypted FROM clusters")
+	if err != nil {
+		return 0, err
+	}
+	type row struct {
+		id        string
+		encrypted []byte
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.encrypted); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		all = append(all, r)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, r := range all {
+		rewrapped, ok, err := s.keyring.Rewrap(r.encrypted)
+		if err != nil {
+			return 0, fmt.Errorf("cluster %s: %w", r.id, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE clusters SET kubeconfig_encrypted = ? WHERE id = ?", rewrapped, r.id); err != nil {
+			return 0, err
+		}
+		changed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	s.logger.Info("Re-encrypted kubeconfigs",
+		zap.String("activeKey", s.keyring.ActiveKey()),
+		zap.Int("rows", changed))
+	return changed, nil
+}
+
 // GetDefault returns the default cluster
 func (s *SQLiteStore) GetDefault(ctx context.Context) (*Cluster, error) {
 	var id string
@@ -342,3 +434,34 @@ func boolToInt(b bool) int {
 	}
 	return 0
 }
+
+func marshalLabels(l map[string]string) (sql.NullString, error) {
+	if len(l) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func unmarshalLabels(s sql.NullString) map[string]string {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	var l map[string]string
+	_ = json.Unmarshal([]byte(s.String), &l)
+	return l
+}
+
+func unmarshalMetadata(s sql.NullString) *Metadata {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	var md Metadata
+	if err := json.Unmarshal([]byte(s.String), &md); err != nil {
+		return nil
+	}
+	return &md
+}
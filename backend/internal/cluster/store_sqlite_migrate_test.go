@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	"go.uber.org/zap"
+)
+
+// newFixtureDB writes a database from a SQL fixture and returns its path.
+func newFixtureDB(t *testing.T, fixture string) string {
+	t.Helper()
+	schema, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "clusters.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("failed to load fixture %s: %v", fixture, err)
+	}
+	return path
+}
+
+func TestSQLiteStoreUpgradeFromUnversionedSchema(t *testing.T) {
+	path := newFixtureDB(t, "schema_unversioned.sql")
+
+	store, err := NewSQLiteStore(path, "test-key-32-bytes-long-padding!!", zap.NewNop())
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	version, err := migrate.Version(store.db, "clusters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(sqliteMigrations) {
+		t.Errorf("expected version %d, got %d", len(sqliteMigrations), version)
+	}
+
+	clusters, err := store.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster after upgrade, got %d", len(clusters))
+	}
+	c := clusters[0]
+	if c.Name != "production" || !c.IsDefault || c.Labels["env"] != "prod" {
+		t.Errorf("cluster not preserved: %+v", c)
+	}
+	if c.Metadata == nil || c.Metadata.KubernetesVersion != "v1.29.2" {
+		t.Errorf("metadata not preserved: %+v", c.Metadata)
+	}
+}
+
+func TestSQLiteStoreUpgradeFromInitialSchema(t *testing.T) {
+	path := newFixtureDB(t, "schema_initial.sql")
+
+	store, err := NewSQLiteStore(path, "test-key-32-bytes-long-padding!!", zap.NewNop())
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	labels := map[string]string{"tier": "gold"}
+	if err := store.Update(ctx, "c1", UpdateClusterRequest{Labels: labels}); err != nil {
+		t.Fatalf("labels column missing after upgrade: %v", err)
+	}
+	clusters, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || clusters[0].Labels["tier"] != "gold" {
+		t.Errorf("unexpected clusters after upgrade: %+v", clusters)
+	}
+}
+
+func TestSQLiteStoreRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clusters.db")
+
+	store, err := NewSQLiteStore(path, "test-key-32-bytes-long-padding!!", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.db.Exec(
+		"INSERT INTO schema_migrations (component, version, name, applied_at) VALUES ('clusters', 999, 'future', CURRENT_TIMESTAMP)",
+	); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	if _, err := NewSQLiteStore(path, "test-key-32-bytes-long-padding!!", zap.NewNop()); err == nil {
+		t.Error("expected startup to fail against a newer schema")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -378,3 +379,150 @@ func TestSQLiteStoreAutoGenerateKey(t *testing.T) {
 		t.Error("Kubeconfig mismatch with auto-generated key")
 	}
 }
+
+func TestSQLiteStoreLabels(t *testing.T) {
+	store, cleanup := newTestSQLiteStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	cluster, err := store.Create(ctx, CreateClusterRequest{
+		Name: "labelled", Kubeconfig: "kc", Namespace: "velero",
+		Labels: map[string]string{"env": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, _ := store.Get(ctx, cluster.ID)
+	if got.Labels["env"] != "prod" {
+		t.Errorf("Expected label env=prod, got %v", got.Labels)
+	}
+
+	list, _ := store.List(ctx)
+	if len(list) != 1 || list[0].Labels["env"] != "prod" {
+		t.Errorf("Expected labels in List, got %v", list)
+	}
+
+	// nil leaves labels untouched
+	newName := "renamed"
+	if err := store.Update(ctx, cluster.ID, UpdateClusterRequest{Name: &newName}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, _ = store.Get(ctx, cluster.ID)
+	if got.Labels["env"] != "prod" {
+		t.Errorf("Expected labels unchanged, got %v", got.Labels)
+	}
+
+	// an empty map clears them
+	if err := store.Update(ctx, cluster.ID, UpdateClusterRequest{Labels: map[string]string{}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, _ = store.Get(ctx, cluster.ID)
+	if len(got.Labels) != 0 {
+		t.Errorf("Expected labels cleared, got %v", got.Labels)
+	}
+}
+
+func TestSQLiteStoreUpdateMetadata(t *testing.T) {
+	store, cleanup := newTestSQLiteStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	cluster, _ := store.Create(ctx, CreateClusterRequest{
+		Name: "meta", Kubeconfig: "kc", Namespace: "velero",
+	})
+
+	got, _ := store.Get(ctx, cluster.ID)
+	if got.Metadata != nil {
+		t.Errorf("Expected no metadata before collection, got %+v", got.Metadata)
+	}
+
+	md := &Metadata{
+		KubernetesVersion: "v1.27.3",
+		VeleroVersion:     "v1.11.1",
+		Provider:          "aws",
+		NodeCount:         3,
+		CollectedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+	if err := store.UpdateMetadata(ctx, cluster.ID, md); err != nil {
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	}
+
+	got, _ = store.Get(ctx, cluster.ID)
+	if got.Metadata == nil || *got.Metadata != *md {
+		t.Errorf("Expected metadata %+v, got %+v", md, got.Metadata)
+	}
+
+	list, _ := store.List(ctx)
+	if len(list) != 1 || list[0].Metadata == nil || list[0].Metadata.VeleroVersion != "v1.11.1" {
+		t.Errorf("Expected metadata in List, got %+v", list[0].Metadata)
+	}
+}
+
+func TestSQLiteStoreReencrypt(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-clusters-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = tmpFile.Close()
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	ctx := context.Background()
+	logger := zap.NewNop()
+	open := func(cfg KeyringConfig) *SQLiteStore {
+		t.Helper()
+		kr, err := NewKeyring(cfg, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store, err := NewSQLiteStoreWithKeyring(tmpFile.Name(), kr, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+
+	store := open(KeyringConfig{Keys: "v1:" + testKey(1)})
+	created, err := store.Create(ctx, CreateClusterRequest{Name: "a", Kubeconfig: "kc-a", Namespace: "velero"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	store = open(KeyringConfig{Keys: "v1:" + testKey(1) + ",v2:" + testKey(2), ActiveKey: "v2"})
+	if _, err := store.Create(ctx, CreateClusterRequest{Name: "b", Kubeconfig: "kc-b", Namespace: "velero"}); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := store.KeyUsage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Rows["v1"] != 1 || usage.Rows["v2"] != 1 {
+		t.Errorf("unexpected usage before re-encrypt: %v", usage.Rows)
+	}
+
+	changed, err := store.Reencrypt(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 1 {
+		t.Errorf("expected 1 row re-encrypted, got %d", changed)
+	}
+	usage, _ = store.KeyUsage(ctx)
+	if usage.Rows["v2"] != 2 || usage.Rows["v1"] != 0 {
+		t.Errorf("unexpected usage after re-encrypt: %v", usage.Rows)
+	}
+	_ = store.Close()
+
+	store = open(KeyringConfig{Keys: "v2:" + testKey(2)})
+	defer func() { _ = store.Close() }()
+	got, err := store.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get after retiring old key failed: %v", err)
+	}
+	if string(got.KubeconfigRaw) != "kc-a" {
+		t.Errorf("unexpected kubeconfig %q", got.KubeconfigRaw)
+	}
+}
@@ -0,0 +1,17 @@
+-- Database as written by the first release, before labels and metadata.
+CREATE TABLE clusters (
+	id TEXT PRIMARY KEY,
+	name TEXT UNIQUE NOT NULL,
+	kubeconfig_encrypted BLOB NOT NULL,
+	namespace TEXT NOT NULL,
+	status TEXT NOT NULL,
+	status_message TEXT,
+	is_default INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL,
+	last_health_check DATETIME
+);
+CREATE INDEX idx_clusters_default ON clusters(is_default);
+CREATE INDEX idx_clusters_name ON clusters(name);
+
+INSERT INTO clusters (id, name, kubeconfig_encrypted, namespace, status, status_message, is_default, created_at)
+VALUES ('c1', 'production', X'00', 'velero', 'connected', '', 1, '2024-01-15 08:30:00');
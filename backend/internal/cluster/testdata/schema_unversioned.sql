@@ -0,0 +1,20 @@
+-- Database as written before versioned migrations: tables created with
+-- CREATE TABLE IF NOT EXISTS and later columns added on startup.
+CREATE TABLE clusters (
+	id TEXT PRIMARY KEY,
+	name TEXT UNIQUE NOT NULL,
+	kubeconfig_encrypted BLOB NOT NULL,
+	namespace TEXT NOT NULL,
+	status TEXT NOT NULL,
+	status_message TEXT,
+	is_default INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL,
+	last_health_check DATETIME,
+	labels TEXT,
+	metadata TEXT
+);
+CREATE INDEX idx_clusters_default ON clusters(is_default);
+CREATE INDEX idx_clusters_name ON clusters(name);
+
+INSERT INTO clusters (id, name, kubeconfig_encrypted, namespace, status, status_message, is_default, created_at, labels, metadata)
+VALUES ('c1', 'production', X'00', 'velero', 'connected', '', 1, '2024-05-01 10:00:00', '{"env":"prod"}', '{"kubernetesVersion":"v1.29.2","nodeCount":3}');
@@ -1,39 +1,67 @@
 package cluster
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Cluster represents a Kubernetes cluster configuration
 type Cluster struct {
-	ID              string    `json:"id"`
-	Name            string    `json:"name"`
-	KubeconfigRaw   []byte    `json:"-"` // Never expose in API
-	Namespace       string    `json:"namespace"`
-	Status          string    `json:"status"` // "connected", "disconnected", "error"
-	StatusMessage   string    `json:"statusMessage,omitempty"`
-	IsDefault       bool      `json:"isDefault"`
-	CreatedAt       time.Time `json:"createdAt"`
-	LastHealthCheck time.Time `json:"lastHealthCheck"`
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	KubeconfigRaw   []byte            `json:"-"` // Never expose in API
+	Namespace       string            `json:"namespace"`
+	Status          string            `json:"status"` // "pending", "connecting", "connected", "degraded", "disconnected"
+	StatusMessage   string            `json:"statusMessage,omitempty"`
+	IsDefault       bool              `json:"isDefault"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	LastHealthCheck time.Time         `json:"lastHealthCheck"`
+	Metadata        *Metadata         `json:"metadata,omitempty"`
+
+	// Capabilities and Permissions are discovered on connect and are not persisted
+	Capabilities *k8s.VeleroCapabilities `json:"capabilities,omitempty"`
+	Permissions  k8s.PermissionMatrix    `json:"permissions,omitempty"`
 }
 
 // ClusterSummary is returned to frontend (without kubeconfig)
 type ClusterSummary struct {
-	ID              string    `json:"id"`
-	Name            string    `json:"name"`
-	Namespace       string    `json:"namespace"`
-	Status          string    `json:"status"`
-	StatusMessage   string    `json:"statusMessage,omitempty"`
-	IsDefault       bool      `json:"isDefault"`
-	CreatedAt       time.Time `json:"createdAt"`
-	LastHealthCheck time.Time `json:"lastHealthCheck"`
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	Status          string            `json:"status"`
+	StatusMessage   string            `json:"statusMessage,omitempty"`
+	IsDefault       bool              `json:"isDefault"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	LastHealthCheck time.Time         `json:"lastHealthCheck"`
+	Metadata        *Metadata         `json:"metadata,omitempty"`
+
+	// Capabilities and Permissions are discovered on connect and are not persisted
+	Capabilities *k8s.VeleroCapabilities `json:"capabilities,omitempty"`
+	Permissions  k8s.PermissionMatrix    `json:"permissions,omitempty"`
+}
+
+// Metadata describes the cluster itself and is refreshed by health checks
+type Metadata struct {
+	KubernetesVersion string    `json:"kubernetesVersion,omitempty"` // e.g. v1.29.4
+	VeleroVersion     string    `json:"veleroVersion,omitempty"`     // image tag of the velero deployment
+	Provider          string    `json:"provider,omitempty"`          // providerID scheme: aws, gce, azure...
+	NodeCount         int       `json:"nodeCount"`
+	CollectedAt       time.Time `json:"collectedAt"`
 }
 
 // CreateClusterRequest for adding new cluster
 type CreateClusterRequest struct {
-	Name         string `json:"name"`
-	Namespace    string `json:"namespace"`
-	SetAsDefault bool   `json:"setAsDefault"`
+	Name         string            `json:"name"`
+	Namespace    string            `json:"namespace,omitempty"` // auto-detected when empty
+	SetAsDefault bool              `json:"setAsDefault"`
+	Labels       map[string]string `json:"labels,omitempty"` // e.g. env=prod, region=eu
 
 	// Auth Mode 1: Kubeconfig (traditional)
 	Kubeconfig string `json:"kubeconfig,omitempty"` // base64 encoded or raw YAML
@@ -47,10 +75,11 @@ type CreateClusterRequest struct {
 
 // UpdateClusterRequest for updating cluster
 type UpdateClusterRequest struct {
-	Name         *string `json:"name,omitempty"`
-	Kubeconfig   *string `json:"kubeconfig,omitempty"`
-	Namespace    *string `json:"namespace,omitempty"`
-	SetAsDefault *bool   `json:"setAsDefault,omitempty"`
+	Name         *string           `json:"name,omitempty"`
+	Kubeconfig   *string           `json:"kubeconfig,omitempty"`
+	Namespace    *string           `json:"namespace,omitempty"`
+	SetAsDefault *bool             `json:"setAsDefault,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"` // replaces all labels when set; {} clears them
 
 	// Token-based auth (alternative to kubeconfig)
 	APIServer       *string `json:"apiServer,omitempty"`
@@ -59,6 +88,33 @@ type UpdateClusterRequest struct {
 	InsecureSkipTLS *bool   `json:"insecureSkipTLS,omitempty"`
 }
 
+// ImportContextsRequest carries a kubeconfig whose contexts should be listed
+type ImportContextsRequest struct {
+	Kubeconfig string `json:"kubeconfig"` // base64 encoded or raw YAML
+}
+
+// ImportContext selects one kubeconfig context to register as a cluster
+type ImportContext struct {
+	Context      string            `json:"context"`
+	Name         string            `json:"name,omitempty"`      // defaults to the context name
+	Namespace    string            `json:"namespace,omitempty"` // auto-detected when empty
+	SetAsDefault bool              `json:"setAsDefault,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// ImportClustersRequest registers several contexts of one kubeconfig at once
+type ImportClustersRequest struct {
+	Kubeconfig string          `json:"kubeconfig"`
+	Contexts   []ImportContext `json:"contexts"`
+}
+
+// ImportResult reports the outcome of importing a single context
+type ImportResult struct {
+	Context string          `json:"context"`
+	Cluster *ClusterSummary `json:"cluster,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
 // ToSummary converts Cluster to ClusterSummary (without sensitive data)
 func (c *Cluster) ToSummary() *ClusterSummary {
 	return &ClusterSummary{
@@ -68,7 +124,100 @@ func (c *Cluster) ToSummary() *ClusterSummary {
 		Status:          c.Status,
 		StatusMessage:   c.StatusMessage,
 		IsDefault:       c.IsDefault,
+		Labels:          c.Labels,
 		CreatedAt:       c.CreatedAt,
 		LastHealthCheck: c.LastHealthCheck,
+		Metadata:        c.Metadata,
+		Capabilities:    c.Capabilities,
+		Permissions:     c.Permissions,
+	}
+}
+
+// ValidateLabels checks that cluster labels follow Kubernetes label syntax,
+// so they can be matched with standard label selectors.
+func ValidateLabels(l map[string]string) error {
+	for k, v := range l {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %q: %s", k, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("invalid label value %q for key %q: %s", v, k, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// IsLabelSelector reports whether a ?cluster= value is a label selector
+// with an operator (e.g. "env=prod,region!=us" or "!legacy") rather than a
+// cluster ID or "all". A bare key such as "env" looks like a cluster ID; see
+// Manager.IsSelector.
+func IsLabelSelector(v string) bool {
+	return strings.ContainsAny(v, "=!") || strings.Contains(v, " in ") || strings.Contains(v, " notin ")
+}
+
+// FilterBySelector returns the summaries whose labels match the selector.
+// An empty selector matches everything.
+func FilterBySelector(summaries []*ClusterSummary, selector string) ([]*ClusterSummary, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	filtered := make([]*ClusterSummary, 0, len(summaries))
+	for _, s := range summaries {
+		if sel.Matches(labels.Set(s.Labels)) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered, nil
+}
+
+// MetadataFilter selects clusters by collected metadata. Versions match on
+// their prefix, so "1.27" matches "v1.27.3" but not "v1.270.0".
+type MetadataFilter struct {
+	KubernetesVersion string
+	VeleroVersion     string
+	Provider          string
+}
+
+// Empty reports whether the filter matches everything.
+func (f MetadataFilter) Empty() bool {
+	return f == MetadataFilter{}
+}
+
+// Matches reports whether a cluster's metadata satisfies the filter.
+// Clusters without metadata only match an empty filter.
+func (f MetadataFilter) Matches(md *Metadata) bool {
+	if f.Empty() {
+		return true
+	}
+	if md == nil {
+		return false
+	}
+	return versionMatches(md.KubernetesVersion, f.KubernetesVersion) &&
+		versionMatches(md.VeleroVersion, f.VeleroVersion) &&
+		(f.Provider == "" || strings.EqualFold(md.Provider, f.Provider))
+}
+
+func versionMatches(version, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	version = strings.TrimPrefix(version, "v")
+	prefix = strings.TrimPrefix(prefix, "v")
+	return version == prefix || strings.HasPrefix(version, prefix+".") || strings.HasPrefix(version, prefix+"-") || strings.HasPrefix(version, prefix+"+")
+}
+
+// FilterByMetadata returns the summaries matching the filter.
+func FilterByMetadata(summaries []*ClusterSummary, f MetadataFilter) []*ClusterSummary {
+	if f.Empty() {
+		return summaries
+	}
+	filtered := make([]*ClusterSummary, 0, len(summaries))
+	for _, s := range summaries {
+		if f.Matches(s.Metadata) {
+			filtered = append(filtered, s)
+		}
 	}
+	return filtered
 }
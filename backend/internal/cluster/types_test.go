@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -58,3 +59,129 @@ func TestClusterToSummaryNoKubeconfig(t *testing.T) {
 		t.Errorf("Expected ID 'id', got %q", summary.ID)
 	}
 }
+
+func TestClusterToSummaryCopiesLabels(t *testing.T) {
+	c := &Cluster{ID: "id", Labels: map[string]string{"env": "prod"}}
+
+	summary := c.ToSummary()
+	if summary.Labels["env"] != "prod" {
+		t.Errorf("Expected label env=prod, got %v", summary.Labels)
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	valid := map[string]string{"env": "prod", "example.com/region": "eu-west-1", "tier": ""}
+	if err := ValidateLabels(valid); err != nil {
+		t.Errorf("Expected valid labels, got %v", err)
+	}
+
+	for _, l := range []map[string]string{
+		{"bad key": "x"},
+		{"env": "not valid!"},
+		{"": "x"},
+	} {
+		if err := ValidateLabels(l); err == nil {
+			t.Errorf("Expected error for %v", l)
+		}
+	}
+}
+
+func TestIsLabelSelector(t *testing.T) {
+	cases := map[string]bool{
+		"":                                     false,
+		"all":                                  false,
+		"6f1c2a3e-1b2c-4d5e-8f90-123456789abc": false,
+		"env=prod":                             true,
+		"env!=prod":                            true,
+		"!legacy":                              true,
+		"env in (prod,staging)":                true,
+		"env notin (dev)":                      true,
+	}
+	for v, want := range cases {
+		if got := IsLabelSelector(v); got != want {
+			t.Errorf("IsLabelSelector(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestFilterBySelector(t *testing.T) {
+	summaries := []*ClusterSummary{
+		{ID: "a", Labels: map[string]string{"env": "prod", "region": "eu"}},
+		{ID: "b", Labels: map[string]string{"env": "prod", "region": "us"}},
+		{ID: "c", Labels: map[string]string{"env": "dev"}},
+		{ID: "d"},
+	}
+
+	cases := map[string][]string{
+		"":                     {"a", "b", "c", "d"},
+		"env=prod":             {"a", "b"},
+		"env=prod,region!=us":  {"a"},
+		"env in (prod,dev)":    {"a", "b", "c"},
+		"!env":                 {"d"},
+		"region notin (eu,us)": {"c", "d"},
+	}
+	for selector, want := range cases {
+		got, err := FilterBySelector(summaries, selector)
+		if err != nil {
+			t.Fatalf("FilterBySelector(%q) failed: %v", selector, err)
+		}
+		ids := make([]string, 0, len(got))
+		for _, s := range got {
+			ids = append(ids, s.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(want, ",") {
+			t.Errorf("FilterBySelector(%q) = %v, want %v", selector, ids, want)
+		}
+	}
+
+	if _, err := FilterBySelector(summaries, "env in (prod"); err == nil {
+		t.Error("Expected error for malformed selector")
+	}
+}
+
+func TestParseLabelsAnnotation(t *testing.T) {
+	got, err := parseLabelsAnnotation("env=prod, region=eu")
+	if err != nil {
+		t.Fatalf("parseLabelsAnnotation failed: %v", err)
+	}
+	if got["env"] != "prod" || got["region"] != "eu" {
+		t.Errorf("Unexpected labels: %v", got)
+	}
+
+	if got, err := parseLabelsAnnotation(""); err != nil || got != nil {
+		t.Errorf("Expected nil labels for empty annotation, got %v, %v", got, err)
+	}
+	if _, err := parseLabelsAnnotation("env=not valid!"); err == nil {
+		t.Error("Expected error for invalid label value")
+	}
+}
+
+func TestFilterByMetadata(t *testing.T) {
+	summaries := []*ClusterSummary{
+		{ID: "a", Metadata: &Metadata{KubernetesVersion: "v1.27.3", VeleroVersion: "v1.11.1", Provider: "aws"}},
+		{ID: "b", Metadata: &Metadata{KubernetesVersion: "v1.28.1", VeleroVersion: "v1.11.0", Provider: "gce"}},
+		{ID: "c", Metadata: &Metadata{KubernetesVersion: "v1.27.10-eks-1", VeleroVersion: "v1.13.0", Provider: "aws"}},
+		{ID: "d"},
+	}
+
+	cases := []struct {
+		filter MetadataFilter
+		want   string
+	}{
+		{MetadataFilter{}, "a,b,c,d"},
+		{MetadataFilter{KubernetesVersion: "1.27"}, "a,c"},
+		{MetadataFilter{KubernetesVersion: "1.27", VeleroVersion: "1.11"}, "a"},
+		{MetadataFilter{VeleroVersion: "v1.11"}, "a,b"},
+		{MetadataFilter{Provider: "AWS"}, "a,c"},
+		{MetadataFilter{KubernetesVersion: "1.2"}, ""},
+	}
+	for _, tc := range cases {
+		ids := []string{}
+		for _, s := range FilterByMetadata(summaries, tc.filter) {
+			ids = append(ids, s.ID)
+		}
+		if got := strings.Join(ids, ","); got != tc.want {
+			t.Errorf("FilterByMetadata(%+v) = %q, want %q", tc.filter, got, tc.want)
+		}
+	}
+}
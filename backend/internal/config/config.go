@@ -8,6 +8,7 @@ import (
 )
 
 type Config struct {
+	Environment string // "production" refuses insecure defaults
 	Server     ServerConfig
 	Velero     VeleroConfig
 	Kubeconfig string // Deprecated - for migration only
@@ -30,18 +31,23 @@ type VeleroConfig struct {
 }
 
 type ClusterConfig struct {
-	StorageType   string // "sqlite", "kubernetes", or "auto"
-	DBPath        string // SQLite database path
-	EncryptionKey string // AES encryption key (32 bytes)
-	Namespace     string // K8s namespace for ConfigMap/Secrets
-	ConfigMapName string // ConfigMap name for cluster metadata
+	StorageType         string // "sqlite", "kubernetes", or "auto"
+	DBPath              string // SQLite database path
+	EncryptionKey       string // AES encryption key (32 bytes)
+	EncryptionKeys      string // additional keys: "id:base64key,..."
+	EncryptionKeyFile   string // mounted file with one "id:base64key" per line
+	EncryptionActiveKey string // key ID used for new ciphertexts
+	Namespace           string // K8s namespace for ConfigMap/Secrets
+	ConfigMapName       string // ConfigMap name for cluster metadata
 }
 
 type AuthConfig struct {
 	Mode              string
 	JWTSecret         string
-	JWTExpiration     time.Duration
-	Users             string // basic mode: "user:hash:role,..."
+	JWTExpiration     time.Duration // lifetime of access tokens
+	RefreshExpiration time.Duration // lifetime of a login session, however often it is refreshed
+	Users             string // basic mode: "user:hash:role,...", seeds the user store
+	PasswordMinLength int    // basic mode: minimum length of passwords set through the API
 	OIDCIssuer        string
 	OIDCClientID      string
 	OIDCClientSecret  string
@@ -50,10 +56,50 @@ type AuthConfig struct {
 	OIDCAdminGroups   string
 	OIDCOperatorGroups string
 	OIDCDefaultRole   string
+	KubernetesRoleSource     string        // kubernetes mode: "access" (SubjectAccessReview) or "groups"
+	KubernetesResource       string        // kubernetes mode: virtual resource whose verbs grant roles, "resource.group"
+	KubernetesAdminGroups    string
+	KubernetesOperatorGroups string
+	KubernetesDefaultRole    string
+	KubernetesAudiences      string        // kubernetes mode: audiences tokens must be issued for
+	KubernetesCacheTTL       time.Duration // kubernetes mode: how long a reviewed token is trusted
+	ProxyTrustedCIDRs        string        // proxy mode: addresses allowed to set identity headers
+	ProxyUserHeader          string
+	ProxyEmailHeader         string
+	ProxyGroupsHeader        string
+	ProxyAdminGroups         string
+	ProxyOperatorGroups      string
+	ProxyDefaultRole         string
+	LDAPURL                  string // ldap mode: ldap:// or ldaps:// URL of the directory
+	LDAPStartTLS             bool
+	LDAPCAFile               string
+	LDAPInsecureSkipVerify   bool
+	LDAPTimeout              time.Duration
+	LDAPBindDN               string // ldap mode: service account searching users and groups; without it sessions can't be refreshed
+	LDAPBindPassword         string
+	LDAPUserDNTemplate       string // ldap mode: bind DN of a username when there is no service account
+	LDAPUserBaseDN           string
+	LDAPUserFilter           string
+	LDAPUsernameAttribute    string
+	LDAPEmailAttribute       string
+	LDAPGroupBaseDN          string
+	LDAPGroupFilter          string
+	LDAPGroupNameAttribute   string
+	LDAPNestedGroups         bool
+	LDAPAdminGroups          string
+	LDAPOperatorGroups       string
+	LDAPDefaultRole          string
 	FrontendURL       string
+	RoleBindingsFile  string // YAML/JSON file with cluster- and namespace-scoped role bindings
+}
+
+// Production reports whether the server runs in production mode.
+func (c *Config) Production() bool {
+	return c.Environment == "production"
 }
 
 func LoadConfig() (*Config, error) {
+	viper.SetDefault("ENVIRONMENT", "development")
 	viper.SetDefault("SERVER_HOST", "0.0.0.0")
 	viper.SetDefault("SERVER_PORT", "8080")
 	viper.SetDefault("SERVER_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:3001")
@@ -64,14 +110,19 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("CLUSTER_STORAGE_TYPE", "auto")
 	viper.SetDefault("CLUSTER_DB_PATH", "./clusters.db")
 	viper.SetDefault("CLUSTER_ENCRYPTION_KEY", "")
+	viper.SetDefault("CLUSTER_ENCRYPTION_KEYS", "")
+	viper.SetDefault("CLUSTER_ENCRYPTION_KEY_FILE", "")
+	viper.SetDefault("CLUSTER_ENCRYPTION_ACTIVE_KEY", "")
 	viper.SetDefault("CLUSTER_K8S_NAMESPACE", "velero")
 	viper.SetDefault("CLUSTER_CONFIGMAP_NAME", "velero-dashboard-clusters")
 
 	// Auth defaults
 	viper.SetDefault("AUTH_MODE", "none")
 	viper.SetDefault("JWT_SECRET", "")
-	viper.SetDefault("JWT_EXPIRATION", "24h")
+	viper.SetDefault("JWT_EXPIRATION", "15m")
+	viper.SetDefault("JWT_REFRESH_EXPIRATION", "24h")
 	viper.SetDefault("AUTH_USERS", "")
+	viper.SetDefault("AUTH_PASSWORD_MIN_LENGTH", 12)
 	viper.SetDefault("OIDC_ISSUER", "")
 	viper.SetDefault("OIDC_CLIENT_ID", "")
 	viper.SetDefault("OIDC_CLIENT_SECRET", "")
@@ -80,17 +131,64 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("OIDC_ADMIN_GROUPS", "velero-admins")
 	viper.SetDefault("OIDC_OPERATOR_GROUPS", "velero-operators")
 	viper.SetDefault("OIDC_DEFAULT_ROLE", "viewer")
+	viper.SetDefault("KUBERNETES_AUTH_ROLE_SOURCE", "access")
+	viper.SetDefault("KUBERNETES_AUTH_RESOURCE", "roles.velero-dashboard.io")
+	viper.SetDefault("KUBERNETES_AUTH_ADMIN_GROUPS", "")
+	viper.SetDefault("KUBERNETES_AUTH_OPERATOR_GROUPS", "")
+	viper.SetDefault("KUBERNETES_AUTH_DEFAULT_ROLE", "none")
+	viper.SetDefault("KUBERNETES_AUTH_AUDIENCES", "")
+	viper.SetDefault("KUBERNETES_AUTH_CACHE_TTL", "1m")
+	viper.SetDefault("PROXY_TRUSTED_CIDRS", "")
+	viper.SetDefault("PROXY_USER_HEADER", "X-Forwarded-User")
+	viper.SetDefault("PROXY_EMAIL_HEADER", "X-Forwarded-Email")
+	viper.SetDefault("PROXY_GROUPS_HEADER", "X-Forwarded-Groups")
+	viper.SetDefault("PROXY_ADMIN_GROUPS", "velero-admins")
+	viper.SetDefault("PROXY_OPERATOR_GROUPS", "velero-operators")
+	viper.SetDefault("PROXY_DEFAULT_ROLE", "viewer")
+	viper.SetDefault("LDAP_URL", "")
+	viper.SetDefault("LDAP_START_TLS", false)
+	viper.SetDefault("LDAP_CA_FILE", "")
+	viper.SetDefault("LDAP_INSECURE_SKIP_VERIFY", false)
+	viper.SetDefault("LDAP_TIMEOUT", "10s")
+	viper.SetDefault("LDAP_BIND_DN", "")
+	viper.SetDefault("LDAP_BIND_PASSWORD", "")
+	viper.SetDefault("LDAP_USER_DN_TEMPLATE", "")
+	viper.SetDefault("LDAP_USER_BASE_DN", "")
+	viper.SetDefault("LDAP_USER_FILTER", "(uid=%s)")
+	viper.SetDefault("LDAP_USERNAME_ATTRIBUTE", "uid")
+	viper.SetDefault("LDAP_EMAIL_ATTRIBUTE", "mail")
+	viper.SetDefault("LDAP_GROUP_BASE_DN", "")
+	viper.SetDefault("LDAP_GROUP_FILTER", "(member=%s)")
+	viper.SetDefault("LDAP_GROUP_NAME_ATTRIBUTE", "cn")
+	viper.SetDefault("LDAP_NESTED_GROUPS", false)
+	viper.SetDefault("LDAP_ADMIN_GROUPS", "velero-admins")
+	viper.SetDefault("LDAP_OPERATOR_GROUPS", "velero-operators")
+	viper.SetDefault("LDAP_DEFAULT_ROLE", "viewer")
 	viper.SetDefault("FRONTEND_URL", "http://localhost:3001")
+	viper.SetDefault("AUTH_ROLE_BINDINGS_FILE", "")
 
 	viper.AutomaticEnv()
 	_ = viper.ReadInConfig()
 
 	expiration, err := time.ParseDuration(viper.GetString("JWT_EXPIRATION"))
 	if err != nil {
-		expiration = 24 * time.Hour
+		expiration = 15 * time.Minute
+	}
+	refreshExpiration, err := time.ParseDuration(viper.GetString("JWT_REFRESH_EXPIRATION"))
+	if err != nil {
+		refreshExpiration = 24 * time.Hour
+	}
+	kubernetesCacheTTL, err := time.ParseDuration(viper.GetString("KUBERNETES_AUTH_CACHE_TTL"))
+	if err != nil {
+		kubernetesCacheTTL = time.Minute
+	}
+	ldapTimeout, err := time.ParseDuration(viper.GetString("LDAP_TIMEOUT"))
+	if err != nil {
+		ldapTimeout = 10 * time.Second
 	}
 
 	return &Config{
+		Environment: viper.GetString("ENVIRONMENT"),
 		Server: ServerConfig{
 			Host:           viper.GetString("SERVER_HOST"),
 			Port:           viper.GetString("SERVER_PORT"),
@@ -101,17 +199,22 @@ func LoadConfig() (*Config, error) {
 		},
 		Kubeconfig: viper.GetString("KUBECONFIG"),
 		Cluster: ClusterConfig{
-			StorageType:   viper.GetString("CLUSTER_STORAGE_TYPE"),
-			DBPath:        viper.GetString("CLUSTER_DB_PATH"),
-			EncryptionKey: viper.GetString("CLUSTER_ENCRYPTION_KEY"),
-			Namespace:     viper.GetString("CLUSTER_K8S_NAMESPACE"),
-			ConfigMapName: viper.GetString("CLUSTER_CONFIGMAP_NAME"),
+			StorageType:         viper.GetString("CLUSTER_STORAGE_TYPE"),
+			DBPath:              viper.GetString("CLUSTER_DB_PATH"),
+			EncryptionKey:       viper.GetString("CLUSTER_ENCRYPTION_KEY"),
+			EncryptionKeys:      viper.GetString("CLUSTER_ENCRYPTION_KEYS"),
+			EncryptionKeyFile:   viper.GetString("CLUSTER_ENCRYPTION_KEY_FILE"),
+			EncryptionActiveKey: viper.GetString("CLUSTER_ENCRYPTION_ACTIVE_KEY"),
+			Namespace:           viper.GetString("CLUSTER_K8S_NAMESPACE"),
+			ConfigMapName:       viper.GetString("CLUSTER_CONFIGMAP_NAME"),
 		},
 		Auth: AuthConfig{
 			Mode:              viper.GetString("AUTH_MODE"),
 			JWTSecret:         viper.GetString("JWT_SECRET"),
 			JWTExpiration:     expiration,
+			RefreshExpiration: refreshExpiration,
 			Users:             viper.GetString("AUTH_USERS"),
+			PasswordMinLength: viper.GetInt("AUTH_PASSWORD_MIN_LENGTH"),
 			OIDCIssuer:        viper.GetString("OIDC_ISSUER"),
 			OIDCClientID:      viper.GetString("OIDC_CLIENT_ID"),
 			OIDCClientSecret:  viper.GetString("OIDC_CLIENT_SECRET"),
@@ -120,7 +223,41 @@ func LoadConfig() (*Config, error) {
 			OIDCAdminGroups:   viper.GetString("OIDC_ADMIN_GROUPS"),
 			OIDCOperatorGroups: viper.GetString("OIDC_OPERATOR_GROUPS"),
 			OIDCDefaultRole:   viper.GetString("OIDC_DEFAULT_ROLE"),
+			KubernetesRoleSource:     viper.GetString("KUBERNETES_AUTH_ROLE_SOURCE"),
+			KubernetesResource:       viper.GetString("KUBERNETES_AUTH_RESOURCE"),
+			KubernetesAdminGroups:    viper.GetString("KUBERNETES_AUTH_ADMIN_GROUPS"),
+			KubernetesOperatorGroups: viper.GetString("KUBERNETES_AUTH_OPERATOR_GROUPS"),
+			KubernetesDefaultRole:    viper.GetString("KUBERNETES_AUTH_DEFAULT_ROLE"),
+			KubernetesAudiences:      viper.GetString("KUBERNETES_AUTH_AUDIENCES"),
+			KubernetesCacheTTL:       kubernetesCacheTTL,
+			ProxyTrustedCIDRs:        viper.GetString("PROXY_TRUSTED_CIDRS"),
+			ProxyUserHeader:          viper.GetString("PROXY_USER_HEADER"),
+			ProxyEmailHeader:         viper.GetString("PROXY_EMAIL_HEADER"),
+			ProxyGroupsHeader:        viper.GetString("PROXY_GROUPS_HEADER"),
+			ProxyAdminGroups:         viper.GetString("PROXY_ADMIN_GROUPS"),
+			ProxyOperatorGroups:      viper.GetString("PROXY_OPERATOR_GROUPS"),
+			ProxyDefaultRole:         viper.GetString("PROXY_DEFAULT_ROLE"),
+			LDAPURL:                  viper.GetString("LDAP_URL"),
+			LDAPStartTLS:             viper.GetBool("LDAP_START_TLS"),
+			LDAPCAFile:               viper.GetString("LDAP_CA_FILE"),
+			LDAPInsecureSkipVerify:   viper.GetBool("LDAP_INSECURE_SKIP_VERIFY"),
+			LDAPTimeout:              ldapTimeout,
+			LDAPBindDN:               viper.GetString("LDAP_BIND_DN"),
+			LDAPBindPassword:         viper.GetString("LDAP_BIND_PASSWORD"),
+			LDAPUserDNTemplate:       viper.GetString("LDAP_USER_DN_TEMPLATE"),
+			LDAPUserBaseDN:           viper.GetString("LDAP_USER_BASE_DN"),
+			LDAPUserFilter:           viper.GetString("LDAP_USER_FILTER"),
+			LDAPUsernameAttribute:    viper.GetString("LDAP_USERNAME_ATTRIBUTE"),
+			LDAPEmailAttribute:       viper.GetString("LDAP_EMAIL_ATTRIBUTE"),
+			LDAPGroupBaseDN:          viper.GetString("LDAP_GROUP_BASE_DN"),
+			LDAPGroupFilter:          viper.GetString("LDAP_GROUP_FILTER"),
+			LDAPGroupNameAttribute:   viper.GetString("LDAP_GROUP_NAME_ATTRIBUTE"),
+			LDAPNestedGroups:         viper.GetBool("LDAP_NESTED_GROUPS"),
+			LDAPAdminGroups:          viper.GetString("LDAP_ADMIN_GROUPS"),
+			LDAPOperatorGroups:       viper.GetString("LDAP_OPERATOR_GROUPS"),
+			LDAPDefaultRole:          viper.GetString("LDAP_DEFAULT_ROLE"),
 			FrontendURL:       viper.GetString("FRONTEND_URL"),
+			RoleBindingsFile:  viper.GetString("AUTH_ROLE_BINDINGS_FILE"),
 		},
 	}, nil
 }
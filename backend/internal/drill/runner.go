@@ -0,0 +1,640 @@
+// Package drill runs scheduled restore drills: the latest backup of a Velero
+// schedule is restored into scratch namespaces, checked against success
+// criteria, and the namespaces are deleted again. Every run is recorded so
+// that restores can be shown to work.
+package drill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Client is the part of the Velero client used by drills.
+type Client interface {
+	ListBackups(ctx context.Context) ([]k8s.BackupResponse, error)
+	GetBackup(ctx context.Context, name string) (*k8s.BackupResponse, error)
+	CreateRestore(ctx context.Context, req k8s.CreateRestoreRequest) (*k8s.RestoreResponse, error)
+	GetRestore(ctx context.Context, name string) (*k8s.RestoreResponse, error)
+	GetPodReadiness(ctx context.Context, namespaces []string) (*k8s.PodReadiness, error)
+	PrepareScratchNamespace(ctx context.Context, name, drillID, runID string) error
+	DeleteScratchNamespace(ctx context.Context, name, drillID string) error
+}
+
+// ClientFunc returns the client of a connected cluster.
+type ClientFunc func(clusterID string) (Client, error)
+
+// Broadcaster streams run progress to WebSocket clients.
+type Broadcaster interface {
+	Broadcast(event interface{})
+}
+
+const (
+	defaultPollInterval = 5 * time.Second
+	namespaceTimeout    = 5 * time.Minute
+	cleanupTimeout      = 2 * time.Minute
+	saveTimeout         = 10 * time.Second
+)
+
+// Runner schedules drills and executes their runs.
+type Runner struct {
+	store        Store
+	clients      ClientFunc
+	notifier     k8s.EventNotifier
+	hub          Broadcaster
+	logger       *zap.Logger
+	pollInterval time.Duration
+	cron         *cron.Cron
+
+	mu      sync.Mutex
+	ctx     context.Context
+	entries map[string]cron.EntryID
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// NewRunner creates a drill runner. Call Start to schedule the stored drills.
+func NewRunner(store Store, clients ClientFunc, notifier k8s.EventNotifier, hub Broadcaster, logger *zap.Logger) *Runner {
+	return &Runner{
+		store:        store,
+		clients:      clients,
+		notifier:     notifier,
+		hub:          hub,
+		logger:       logger,
+		pollInterval: defaultPollInterval,
+		cron:         cron.New(cron.WithLocation(time.UTC)),
+		entries:      make(map[string]cron.EntryID),
+		running:      make(map[string]bool),
+	}
+}
+
+// Store returns the drill store.
+func (r *Runner) Store() Store {
+	return r.store
+}
+
+// Start schedules the enabled drills. Runs interrupted by the previous
+// shutdown are marked failed and their scratch namespaces removed, since a
+// half-checked restore proves nothing.
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+
+	runs, err := r.store.ListRuns(ctx, "", 0)
+	if err != nil {
+		return fmt.Errorf("failed to list drill runs: %w", err)
+	}
+	for _, run := range runs {
+		if run.Phase != RunRunning {
+			continue
+		}
+		run := run
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			d, err := r.store.GetDrill(ctx, run.DrillID)
+			if err == nil {
+				r.cleanup(d, run)
+			}
+			r.complete(run, "interrupted by a dashboard restart")
+		}()
+	}
+
+	drills, err := r.store.ListDrills(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list drills: %w", err)
+	}
+	for _, d := range drills {
+		r.Schedule(d)
+	}
+
+	r.cron.Start()
+	go func() {
+		<-ctx.Done()
+		<-r.cron.Stop().Done()
+	}()
+	return nil
+}
+
+// Wait blocks until all runs have returned.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Create saves a new drill and schedules it.
+func (r *Runner) Create(ctx context.Context, req DrillRequest, createdBy string) (*Drill, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	d := &Drill{
+		ID:        uuid.New().String(),
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	req.apply(d)
+	if err := r.store.CreateDrill(ctx, d); err != nil {
+		return nil, err
+	}
+	r.Schedule(d)
+	return d, nil
+}
+
+// Update replaces a drill's settings and reschedules it. A run in progress
+// finishes with the settings it started with.
+func (r *Runner) Update(ctx context.Context, id string, req DrillRequest) (*Drill, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	d, err := r.store.GetDrill(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req.apply(d)
+	d.UpdatedAt = time.Now().UTC()
+	if err := r.store.UpdateDrill(ctx, d); err != nil {
+		return nil, err
+	}
+	r.Schedule(d)
+	return d, nil
+}
+
+// Delete unschedules and removes a drill. Its run history is kept.
+func (r *Runner) Delete(ctx context.Context, id string) error {
+	if r.Running(id) {
+		return ErrRunning
+	}
+	if err := r.store.DeleteDrill(ctx, id); err != nil {
+		return err
+	}
+	r.Unschedule(id)
+	return nil
+}
+
+// Schedule adds or replaces the cron entry of a drill.
+func (r *Runner) Schedule(d *Drill) {
+	r.Unschedule(d.ID)
+	if !d.Enabled {
+		return
+	}
+
+	id := d.ID
+	entry, err := r.cron.AddFunc(d.Cron, func() {
+		r.mu.Lock()
+		ctx := r.ctx
+		r.mu.Unlock()
+		if _, err := r.start(ctx, id, TriggerSchedule, ""); err != nil && !errors.Is(err, ErrRunning) {
+			r.logger.Error("Failed to start scheduled drill", zap.String("drill", id), zap.Error(err))
+		}
+	})
+	if err != nil {
+		r.logger.Error("Failed to schedule drill", zap.String("drill", id), zap.String("cron", d.Cron), zap.Error(err))
+		return
+	}
+
+	r.mu.Lock()
+	r.entries[id] = entry
+	r.mu.Unlock()
+}
+
+// Unschedule removes the cron entry of a drill.
+func (r *Runner) Unschedule(id string) {
+	r.mu.Lock()
+	entry, ok := r.entries[id]
+	delete(r.entries, id)
+	r.mu.Unlock()
+	if ok {
+		r.cron.Remove(entry)
+	}
+}
+
+// NextRun returns when a drill is next scheduled, or nil if it isn't.
+func (r *Runner) NextRun(id string) *time.Time {
+	r.mu.Lock()
+	entry, ok := r.entries[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	next := r.cron.Entry(entry).Next
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// Running reports whether a drill has a run in progress.
+func (r *Runner) Running(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running[id]
+}
+
+// Trigger starts a run of a drill now.
+func (r *Runner) Trigger(ctx context.Context, id, triggeredBy string) (*Run, error) {
+	return r.start(ctx, id, TriggerManual, triggeredBy)
+}
+
+func (r *Runner) start(ctx context.Context, id string, trigger Trigger, triggeredBy string) (*Run, error) {
+	d, err := r.store.GetDrill(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.ctx == nil {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("drill runner is not started")
+	}
+	if r.running[id] {
+		r.mu.Unlock()
+		return nil, ErrRunning
+	}
+	r.running[id] = true
+	runCtx := r.ctx
+	r.mu.Unlock()
+
+	runID := uuid.New().String()
+	run := &Run{
+		ID:              runID,
+		DrillID:         d.ID,
+		DrillName:       d.Name,
+		SourceClusterID: d.SourceClusterID,
+		TargetClusterID: d.TargetClusterID,
+		Trigger:         trigger,
+		TriggeredBy:     triggeredBy,
+		Phase:           RunRunning,
+		Checks:          []Check{},
+		StartedAt:       time.Now().UTC(),
+	}
+	if err := r.store.CreateRun(ctx, run); err != nil {
+		r.release(id)
+		return nil, err
+	}
+	r.logger.Info("Drill run started",
+		zap.String("drill", d.Name),
+		zap.String("run", run.ID),
+		zap.String("trigger", string(trigger)))
+	r.broadcast(run)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.release(id)
+		r.run(runCtx, d, run.clone())
+	}()
+	return run, nil
+}
+
+func (r *Runner) release(id string) {
+	r.mu.Lock()
+	delete(r.running, id)
+	r.mu.Unlock()
+}
+
+func (r *Runner) run(ctx context.Context, d *Drill, run *Run) {
+	restore, err := r.restore(ctx, d, run)
+	if err == nil {
+		r.evaluate(ctx, d, run, restore)
+	}
+
+	// Scratch namespaces go even when the drill failed or is interrupted
+	r.cleanup(d, run)
+
+	switch {
+	case ctx.Err() != nil:
+		r.complete(run, "interrupted by a dashboard shutdown")
+	case err != nil:
+		r.complete(run, err.Error())
+	default:
+		r.complete(run, "")
+	}
+}
+
+// restore restores the schedule's latest backup into the scratch namespaces
+// and waits for the restore to finish.
+func (r *Runner) restore(ctx context.Context, d *Drill, run *Run) (*k8s.RestoreResponse, error) {
+	src, err := r.clients(d.SourceClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("source cluster: %w", err)
+	}
+	tgt, err := r.clients(d.TargetClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("target cluster: %w", err)
+	}
+
+	backup, err := latestBackup(ctx, src, d.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	run.BackupName = backup.Name
+	r.progress(run, fmt.Sprintf("restoring backup %s", backup.Name))
+
+	// The target only sees the backup once its storage location has synced it
+	if d.TargetClusterID != d.SourceClusterID {
+		err := r.poll(ctx, timeout(d.SyncTimeout, DefaultSyncTimeout), "the target cluster to sync the backup", func(ctx context.Context) (bool, error) {
+			synced, err := tgt.GetBackup(ctx, backup.Name)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return false, err
+			}
+			return err == nil && synced.Phase == "Completed", nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ns := range d.ScratchNamespaces() {
+		err := r.poll(ctx, namespaceTimeout, "scratch namespace "+ns, func(ctx context.Context) (bool, error) {
+			err := tgt.PrepareScratchNamespace(ctx, ns, d.ID, run.ID)
+			if errors.Is(err, k8s.ErrNamespaceTerminating) {
+				return false, nil
+			}
+			return err == nil, err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	run.RestoreName = "drill-" + shortID(run.ID)
+	r.save(run)
+	_, err = tgt.CreateRestore(ctx, k8s.CreateRestoreRequest{
+		Name:               run.RestoreName,
+		BackupName:         backup.Name,
+		IncludedNamespaces: d.SourceNamespaces(),
+		NamespaceMapping:   d.NamespaceMapping,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result *k8s.RestoreResponse
+	err = r.poll(ctx, timeout(d.RestoreTimeout, DefaultRestoreTimeout), "the restore to finish", func(ctx context.Context) (bool, error) {
+		restore, err := tgt.GetRestore(ctx, run.RestoreName)
+		if err != nil {
+			return false, err
+		}
+		switch restore.Phase {
+		case "Completed", "PartiallyFailed", "Failed", "FailedValidation":
+			result = restore
+			return true, nil
+		}
+		r.progress(run, fmt.Sprintf("restore %s: %d/%d items", phaseOr(restore.Phase, "New"), restore.ItemsRestored, restore.TotalItems))
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	run.ItemsRestored = result.ItemsRestored
+	run.Errors = result.Errors
+	run.Warnings = result.Warnings
+	return result, nil
+}
+
+// evaluate records a check for each success criterion.
+func (r *Runner) evaluate(ctx context.Context, d *Drill, run *Run, restore *k8s.RestoreResponse) {
+	c := d.Criteria
+
+	switch restore.Phase {
+	case "Completed":
+		run.Checks = append(run.Checks, Check{Name: "restore", Passed: true, Message: "restore completed"})
+	case "PartiallyFailed":
+		run.Checks = append(run.Checks, Check{Name: "restore", Passed: c.AllowErrors, Message: "restore partially failed"})
+	default:
+		run.Checks = append(run.Checks, Check{Name: "restore", Passed: false, Message: "restore " + restore.Phase})
+	}
+
+	if !c.AllowErrors {
+		run.Checks = append(run.Checks, Check{
+			Name:    "errors",
+			Passed:  restore.Errors == 0,
+			Message: fmt.Sprintf("%d errors, %d warnings", restore.Errors, restore.Warnings),
+		})
+	}
+
+	if c.MinItems > 0 {
+		run.Checks = append(run.Checks, Check{
+			Name:    "items",
+			Passed:  restore.ItemsRestored >= c.MinItems,
+			Message: fmt.Sprintf("%d items restored, at least %d expected", restore.ItemsRestored, c.MinItems),
+		})
+	}
+
+	if c.RequirePodsReady {
+		run.Checks = append(run.Checks, r.checkPods(ctx, d, run))
+	}
+	r.save(run)
+}
+
+func (r *Runner) checkPods(ctx context.Context, d *Drill, run *Run) Check {
+	tgt, err := r.clients(d.TargetClusterID)
+	if err != nil {
+		return Check{Name: "pods-ready", Message: "target cluster: " + err.Error()}
+	}
+
+	d0 := timeout(d.Criteria.ReadyTimeout, DefaultReadyTimeout)
+	r.progress(run, "waiting for restored pods to become ready")
+	var last *k8s.PodReadiness
+	err = r.poll(ctx, d0, "pods to become ready", func(ctx context.Context) (bool, error) {
+		readiness, err := tgt.GetPodReadiness(ctx, d.ScratchNamespaces())
+		if err != nil {
+			return false, err
+		}
+		last = readiness
+		return readiness.AllReady(), nil
+	})
+	if err != nil {
+		msg := err.Error()
+		if last != nil {
+			msg = fmt.Sprintf("%d/%d pods ready after %s; not ready: %s", last.Ready, last.Total, d0, summarize(last.NotReady))
+		}
+		return Check{Name: "pods-ready", Message: msg}
+	}
+	if last.Total == 0 {
+		return Check{Name: "pods-ready", Passed: true, Message: "no pods were restored"}
+	}
+	return Check{Name: "pods-ready", Passed: true, Message: fmt.Sprintf("%d/%d pods ready", last.Ready, last.Total)}
+}
+
+// cleanup deletes the run's scratch namespaces with its own context, so it
+// also runs when the drill was interrupted.
+func (r *Runner) cleanup(d *Drill, run *Run) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	tgt, err := r.clients(d.TargetClusterID)
+	if err != nil {
+		run.Cleanup = "scratch namespaces not deleted: target cluster: " + err.Error()
+		return
+	}
+	var failed []string
+	for _, ns := range d.ScratchNamespaces() {
+		if err := tgt.DeleteScratchNamespace(ctx, ns, d.ID); err != nil {
+			r.logger.Warn("Failed to delete scratch namespace", zap.String("namespace", ns), zap.Error(err))
+			failed = append(failed, ns)
+		}
+	}
+	if len(failed) > 0 {
+		run.Cleanup = "failed to delete scratch namespaces: " + strings.Join(failed, ", ")
+		return
+	}
+	run.Cleanup = "deleted scratch namespaces " + strings.Join(d.ScratchNamespaces(), ", ")
+}
+
+// complete records the outcome of a run and notifies on failure. An empty
+// message means the restore itself went through and the checks decide.
+func (r *Runner) complete(run *Run, message string) {
+	now := time.Now().UTC()
+	run.CompletedAt = &now
+	run.Phase = RunPassed
+	if message != "" || len(run.Checks) == 0 {
+		run.Phase = RunFailed
+	}
+	var failed []string
+	for _, c := range run.Checks {
+		if !c.Passed {
+			run.Phase = RunFailed
+			failed = append(failed, c.Name+": "+c.Message)
+		}
+	}
+	switch {
+	case message != "":
+		run.Message = message
+	case len(failed) > 0:
+		run.Message = strings.Join(failed, "; ")
+	default:
+		run.Message = fmt.Sprintf("all %d checks passed", len(run.Checks))
+	}
+	r.save(run)
+
+	r.logger.Info("Drill run finished",
+		zap.String("drill", run.DrillName),
+		zap.String("run", run.ID),
+		zap.String("phase", string(run.Phase)),
+		zap.String("message", run.Message))
+
+	if run.Phase == RunFailed && r.notifier != nil {
+		r.notifier.Dispatch(context.Background(), k8s.NotificationPayload{
+			EventType: "drill_failed",
+			Title:     "Restore drill failed",
+			Message:   fmt.Sprintf("Restore drill %q failed: %s", run.DrillName, run.Message),
+			ClusterID: run.TargetClusterID,
+			Resource:  run.clone(),
+		})
+	}
+}
+
+// latestBackup returns the newest completed backup created by a schedule.
+func latestBackup(ctx context.Context, c Client, schedule string) (*k8s.BackupResponse, error) {
+	backups, err := c.ListBackups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups on the source cluster: %w", err)
+	}
+	var candidates []k8s.BackupResponse
+	for _, b := range backups {
+		if b.Phase == "Completed" && b.Labels[scheduleLabel] == schedule {
+			candidates = append(candidates, b)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no completed backup of schedule %s on the source cluster", schedule)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return backupTime(candidates[i]).After(backupTime(candidates[j]))
+	})
+	return &candidates[0], nil
+}
+
+func backupTime(b k8s.BackupResponse) time.Time {
+	for _, t := range []*time.Time{b.Completed, b.Started, b.Created} {
+		if t != nil {
+			return *t
+		}
+	}
+	return time.Time{}
+}
+
+// poll runs condition until it reports done, returns an error, or d elapses.
+func (r *Runner) poll(ctx context.Context, d time.Duration, what string, condition wait.ConditionWithContextFunc) error {
+	err := wait.PollUntilContextTimeout(ctx, r.pollInterval, d, true, condition)
+	if err != nil && wait.Interrupted(err) && ctx.Err() == nil {
+		return fmt.Errorf("timed out after %s waiting for %s", d, what)
+	}
+	return err
+}
+
+// progress records a run message, saving only when it changed.
+func (r *Runner) progress(run *Run, message string) {
+	if run.Message == message {
+		return
+	}
+	run.Message = message
+	r.save(run)
+}
+
+// save persists the run and streams it to WebSocket clients.
+func (r *Runner) save(run *Run) {
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+	if err := r.store.UpdateRun(ctx, run); err != nil {
+		r.logger.Error("Failed to save drill run", zap.String("id", run.ID), zap.Error(err))
+	}
+	r.broadcast(run)
+}
+
+func (r *Runner) broadcast(run *Run) {
+	if r.hub == nil {
+		return
+	}
+	r.hub.Broadcast(k8s.WSEvent{
+		Type:      "drill",
+		Action:    "modified",
+		Resource:  run.clone(),
+		ClusterID: run.TargetClusterID,
+	})
+}
+
+func (run *Run) clone() *Run {
+	c := *run
+	c.Checks = append([]Check{}, run.Checks...)
+	return &c
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func phaseOr(phase, def string) string {
+	if phase == "" {
+		return def
+	}
+	return phase
+}
+
+// summarize lists the first few names, so messages stay readable.
+func summarize(names []string) string {
+	const max = 3
+	if len(names) <= max {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:max], ", "), len(names)-max)
+}
@@ -0,0 +1,447 @@
+package drill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeCluster simulates Velero on one cluster. Restores complete on the
+// first read after creation unless restorePhase says otherwise.
+type fakeCluster struct {
+	mu           sync.Mutex
+	backups      []k8s.BackupResponse
+	restores     map[string]*k8s.RestoreResponse
+	restoreReqs  []k8s.CreateRestoreRequest
+	restorePhase string // final restore phase, "Completed" by default
+	podsReady    bool
+	namespaces   map[string]string // scratch namespace -> drill ID
+	deleted      []string
+	syncFrom     *fakeCluster // backups of this cluster are visible here
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{
+		restores:   make(map[string]*k8s.RestoreResponse),
+		namespaces: make(map[string]string),
+		podsReady:  true,
+	}
+}
+
+func (f *fakeCluster) addBackup(name, schedule, phase string, completed time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backups = append(f.backups, k8s.BackupResponse{
+		Name:      name,
+		Phase:     phase,
+		Labels:    map[string]string{scheduleLabel: schedule},
+		Completed: &completed,
+	})
+}
+
+func (f *fakeCluster) ListBackups(_ context.Context) ([]k8s.BackupResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]k8s.BackupResponse(nil), f.backups...), nil
+}
+
+func (f *fakeCluster) GetBackup(ctx context.Context, name string) (*k8s.BackupResponse, error) {
+	backups, _ := f.ListBackups(ctx)
+	if f.syncFrom != nil {
+		backups, _ = f.syncFrom.ListBackups(ctx)
+	}
+	for i := range backups {
+		if backups[i].Name == name {
+			return &backups[i], nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "velero.io", Resource: "backups"}, name)
+}
+
+func (f *fakeCluster) CreateRestore(_ context.Context, req k8s.CreateRestoreRequest) (*k8s.RestoreResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restoreReqs = append(f.restoreReqs, req)
+	r := &k8s.RestoreResponse{Name: req.Name, BackupName: req.BackupName, Phase: "InProgress"}
+	f.restores[req.Name] = r
+	return r, nil
+}
+
+func (f *fakeCluster) GetRestore(_ context.Context, name string) (*k8s.RestoreResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.restores[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: "velero.io", Resource: "restores"}, name)
+	}
+	out := *r
+	if r.Phase == "InProgress" && f.restorePhase != "InProgress" {
+		r.Phase = "Completed"
+		if f.restorePhase != "" {
+			r.Phase = f.restorePhase
+			r.Errors = 3
+		}
+		r.ItemsRestored = 42
+	}
+	return &out, nil
+}
+
+func (f *fakeCluster) GetPodReadiness(_ context.Context, namespaces []string) (*k8s.PodReadiness, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.podsReady {
+		return &k8s.PodReadiness{Total: 2, Ready: 2}, nil
+	}
+	return &k8s.PodReadiness{Total: 2, Ready: 1, NotReady: []string{namespaces[0] + "/web-1"}}, nil
+}
+
+func (f *fakeCluster) PrepareScratchNamespace(_ context.Context, name, drillID, _ string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if owner, ok := f.namespaces[name]; ok && owner != drillID {
+		return k8s.ErrNamespaceInUse
+	}
+	f.namespaces[name] = drillID
+	return nil
+}
+
+func (f *fakeCluster) DeleteScratchNamespace(_ context.Context, name, drillID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	owner, ok := f.namespaces[name]
+	if !ok {
+		return nil
+	}
+	if owner != drillID {
+		return k8s.ErrNamespaceInUse
+	}
+	delete(f.namespaces, name)
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func (f *fakeCluster) scratch() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]string, len(f.namespaces))
+	for k, v := range f.namespaces {
+		out[k] = v
+	}
+	return out
+}
+
+type recorder struct {
+	mu            sync.Mutex
+	events        []k8s.WSEvent
+	notifications []k8s.NotificationPayload
+}
+
+func (r *recorder) Broadcast(event interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event.(k8s.WSEvent))
+}
+
+func (r *recorder) Dispatch(_ context.Context, payload k8s.NotificationPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, payload)
+}
+
+func (r *recorder) last() *Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1].Resource.(*Run)
+}
+
+func (r *recorder) notified() []k8s.NotificationPayload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]k8s.NotificationPayload(nil), r.notifications...)
+}
+
+type testEnv struct {
+	source, target *fakeCluster
+	store          Store
+	hub            *recorder
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "drills.db"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	source := newFakeCluster()
+	target := newFakeCluster()
+	target.syncFrom = source
+	now := time.Now()
+	source.addBackup("daily-old", "daily", "Completed", now.Add(-48*time.Hour))
+	source.addBackup("daily-new", "daily", "Completed", now.Add(-time.Hour))
+	source.addBackup("daily-failed", "daily", "Failed", now)
+	source.addBackup("weekly-new", "weekly", "Completed", now)
+	return &testEnv{source: source, target: target, store: store, hub: &recorder{}}
+}
+
+func (e *testEnv) runner(t *testing.T, ctx context.Context) *Runner {
+	t.Helper()
+	clients := func(id string) (Client, error) {
+		switch id {
+		case "src":
+			return e.source, nil
+		case "tgt":
+			return e.target, nil
+		}
+		return nil, fmt.Errorf("cluster not found or not connected")
+	}
+	r := NewRunner(e.store, clients, e.hub, e.hub, zap.NewNop())
+	r.pollInterval = time.Millisecond
+	if err := r.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func testRequest() DrillRequest {
+	return DrillRequest{
+		Name:             "shop",
+		SourceClusterID:  "src",
+		Schedule:         "daily",
+		TargetClusterID:  "tgt",
+		NamespaceMapping: map[string]string{"shop": "shop-drill"},
+		Cron:             "0 3 * * 0",
+		Criteria:         Criteria{MinItems: 10, RequirePodsReady: true},
+	}
+}
+
+func runDrill(t *testing.T, r *Runner, req DrillRequest) *Run {
+	t.Helper()
+	d, err := r.Create(context.Background(), req, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := r.Trigger(context.Background(), d.ID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	runs, err := r.Store().ListRuns(context.Background(), d.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].ID != run.ID {
+		t.Fatalf("runs = %+v", runs)
+	}
+	return runs[0]
+}
+
+func TestRunnerPassesDrill(t *testing.T) {
+	env := newTestEnv(t)
+	r := env.runner(t, context.Background())
+
+	run := runDrill(t, r, testRequest())
+	if run.Phase != RunPassed {
+		t.Fatalf("phase = %s (%s) checks = %+v", run.Phase, run.Message, run.Checks)
+	}
+	if run.BackupName != "daily-new" || run.ItemsRestored != 42 || run.Trigger != TriggerManual || run.CompletedAt == nil {
+		t.Errorf("run = %+v", run)
+	}
+	if len(run.Checks) != 4 {
+		t.Errorf("checks = %+v", run.Checks)
+	}
+
+	if len(env.target.restoreReqs) != 1 {
+		t.Fatalf("restores = %d, want 1", len(env.target.restoreReqs))
+	}
+	req := env.target.restoreReqs[0]
+	if req.Name != run.RestoreName || req.BackupName != "daily-new" ||
+		len(req.IncludedNamespaces) != 1 || req.IncludedNamespaces[0] != "shop" || req.NamespaceMapping["shop"] != "shop-drill" {
+		t.Errorf("restore request = %+v", req)
+	}
+	if len(env.target.scratch()) != 0 || len(env.target.deleted) != 1 || !strings.Contains(run.Cleanup, "shop-drill") {
+		t.Errorf("scratch namespaces not cleaned up: %v %q", env.target.scratch(), run.Cleanup)
+	}
+	if last := env.hub.last(); last.Phase != RunPassed || last.ID != run.ID {
+		t.Errorf("last broadcast = %s %s", last.ID, last.Phase)
+	}
+	if n := env.hub.notified(); len(n) != 0 {
+		t.Errorf("notified on success: %+v", n)
+	}
+}
+
+func TestRunnerFailsCriteria(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.restorePhase = "PartiallyFailed"
+	env.target.podsReady = false
+	r := env.runner(t, context.Background())
+
+	req := testRequest()
+	req.Criteria = Criteria{MinItems: 100, RequirePodsReady: true, ReadyTimeout: "20ms"}
+	run := runDrill(t, r, req)
+	if run.Phase != RunFailed {
+		t.Fatalf("phase = %s", run.Phase)
+	}
+	failed := map[string]bool{}
+	for _, c := range run.Checks {
+		if !c.Passed {
+			failed[c.Name] = true
+		}
+	}
+	for _, name := range []string{"restore", "errors", "items", "pods-ready"} {
+		if !failed[name] {
+			t.Errorf("check %s passed: %+v", name, run.Checks)
+		}
+	}
+	if !strings.Contains(run.Message, "shop-drill/web-1") {
+		t.Errorf("message = %q", run.Message)
+	}
+	if len(env.target.scratch()) != 0 {
+		t.Errorf("scratch namespaces left behind: %v", env.target.scratch())
+	}
+
+	n := env.hub.notified()
+	if len(n) != 1 || n[0].EventType != "drill_failed" || n[0].ClusterID != "tgt" {
+		t.Errorf("notifications = %+v", n)
+	}
+}
+
+func TestRunnerAllowsErrors(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.restorePhase = "PartiallyFailed"
+	r := env.runner(t, context.Background())
+
+	req := testRequest()
+	req.Criteria.AllowErrors = true
+	if run := runDrill(t, r, req); run.Phase != RunPassed {
+		t.Fatalf("phase = %s (%s)", run.Phase, run.Message)
+	}
+}
+
+func TestRunnerFailsWithoutBackup(t *testing.T) {
+	env := newTestEnv(t)
+	r := env.runner(t, context.Background())
+
+	req := testRequest()
+	req.Schedule = "hourly"
+	run := runDrill(t, r, req)
+	if run.Phase != RunFailed || !strings.Contains(run.Message, "no completed backup") {
+		t.Fatalf("run = %s %q", run.Phase, run.Message)
+	}
+	if len(env.target.restoreReqs) != 0 {
+		t.Errorf("restore created without a backup")
+	}
+}
+
+func TestRunnerRefusesForeignNamespace(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.namespaces["shop-drill"] = "someone-else"
+	r := env.runner(t, context.Background())
+
+	run := runDrill(t, r, testRequest())
+	if run.Phase != RunFailed ||
+		!strings.Contains(run.Message, k8s.ErrNamespaceInUse.Error()) {
+		t.Fatalf("run = %s %q", run.Phase, run.Message)
+	}
+	if env.target.scratch()["shop-drill"] != "someone-else" {
+		t.Errorf("foreign namespace was touched")
+	}
+}
+
+func TestRunnerRejectsConcurrentRunAndCleansUpOnShutdown(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.restorePhase = "InProgress"
+	ctx, cancel := context.WithCancel(context.Background())
+	r := env.runner(t, ctx)
+
+	d, err := r.Create(context.Background(), testRequest(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Trigger(context.Background(), d.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Trigger(context.Background(), d.ID, ""); !errors.Is(err, ErrRunning) {
+		t.Errorf("second trigger: err = %v", err)
+	}
+	if err := r.Delete(context.Background(), d.ID); !errors.Is(err, ErrRunning) {
+		t.Errorf("delete while running: err = %v", err)
+	}
+
+	cancel()
+	r.Wait()
+	runs, _ := env.store.ListRuns(context.Background(), d.ID, 0)
+	if len(runs) != 1 || runs[0].Phase != RunFailed || !strings.Contains(runs[0].Message, "interrupted") {
+		t.Fatalf("runs = %+v", runs)
+	}
+	if len(env.target.scratch()) != 0 {
+		t.Errorf("scratch namespaces left behind: %v", env.target.scratch())
+	}
+}
+
+func TestRunnerFailsInterruptedRunsOnStart(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	d := &Drill{ID: "d1", Name: "shop", TargetClusterID: "tgt", NamespaceMapping: map[string]string{"shop": "shop-drill"}}
+	if err := env.store.CreateDrill(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.store.CreateRun(ctx, &Run{ID: "r1", DrillID: "d1", Phase: RunRunning, StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	env.target.namespaces["shop-drill"] = "d1"
+
+	r := env.runner(t, ctx)
+	r.Wait()
+
+	runs, _ := env.store.ListRuns(ctx, "d1", 0)
+	if runs[0].Phase != RunFailed || !strings.Contains(runs[0].Message, "restart") {
+		t.Errorf("run = %s %q", runs[0].Phase, runs[0].Message)
+	}
+	if len(env.target.scratch()) != 0 {
+		t.Errorf("scratch namespaces left behind: %v", env.target.scratch())
+	}
+}
+
+func TestRunnerSchedules(t *testing.T) {
+	env := newTestEnv(t)
+	r := env.runner(t, context.Background())
+
+	req := testRequest()
+	req.Enabled = true
+	d, err := r.Create(context.Background(), req, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := r.NextRun(d.ID)
+	if next == nil || next.Weekday() != time.Sunday || next.Hour() != 3 {
+		t.Fatalf("next run = %v", next)
+	}
+
+	req.Enabled = false
+	if _, err := r.Update(context.Background(), d.ID, req); err != nil {
+		t.Fatal(err)
+	}
+	if next := r.NextRun(d.ID); next != nil {
+		t.Errorf("disabled drill scheduled at %v", next)
+	}
+
+	if err := r.Delete(context.Background(), d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Update(context.Background(), d.ID, req); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update deleted drill: err = %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package drill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+)
+
+// maxRunsPerDrill bounds the run history kept for each drill.
+const maxRunsPerDrill = 100
+
+// Store persists drills and the results of their runs.
+type Store interface {
+	CreateDrill(ctx context.Context, d *Drill) error
+	GetDrill(ctx context.Context, id string) (*Drill, error)
+	ListDrills(ctx context.Context) ([]*Drill, error)
+	UpdateDrill(ctx context.Context, d *Drill) error
+	DeleteDrill(ctx context.Context, id string) error
+
+	// CreateRun records a run, pruning the drill's oldest runs beyond
+	// maxRunsPerDrill.
+	CreateRun(ctx context.Context, run *Run) error
+	UpdateRun(ctx context.Context, run *Run) error
+	// ListRuns returns runs newest first. An empty drillID lists the runs of
+	// all drills; a limit of 0 returns every run.
+	ListRuns(ctx context.Context, drillID string, limit int) ([]*Run, error)
+	Close() error
+}
+
+// StoreConfig holds configuration for creating a drill store.
+type StoreConfig struct {
+	StorageType string // "auto", "kubernetes", "sqlite"
+	DBPath      string // For SQLite
+	Namespace   string // For Kubernetes
+}
+
+// NewStore creates a drill store based on the storage type.
+func NewStore(cfg StoreConfig, logger *zap.Logger) (Store, error) {
+	storageType := cfg.StorageType
+	if storageType == "" || storageType == "auto" {
+		if isInCluster() {
+			storageType = "kubernetes"
+		} else {
+			storageType = "sqlite"
+		}
+	}
+
+	switch storageType {
+	case "kubernetes":
+		return NewK8sStore(cfg.Namespace, logger)
+	case "sqlite":
+		dbPath := cfg.DBPath
+		if dbPath == "" {
+			dbPath = "./drills.db"
+		}
+		return NewSQLiteStore(dbPath, logger)
+	default:
+		return nil, fmt.Errorf("unknown drill storage type: %s", storageType)
+	}
+}
+
+// drillRecords stores each drill in its own ConfigMap, or as a row of the
+// drills table.
+var drillRecords = jsonstore.Config[Drill]{
+	Kind:        "drill",
+	Key:         func(d *Drill) string { return d.ID },
+	Less:        func(a, b *Drill) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	ErrNotFound: ErrNotFound,
+
+	Component: "restore-drill",
+	Prefix:    "velero-dashboard-drill-",
+
+	Schema:     "restore-drills",
+	Migrations: sqliteMigrations,
+	Table:      "drills",
+	KeyColumn:  "id",
+	CreatedAt:  func(d *Drill) time.Time { return d.CreatedAt },
+}
+
+// runRecords stores each run in its own ConfigMap labelled with its drill,
+// or as a row of the drill_runs table. Runs are listed newest first.
+var runRecords = jsonstore.Config[Run]{
+	Kind:        "drill run",
+	Key:         func(r *Run) string { return r.ID },
+	Less:        func(a, b *Run) bool { return a.StartedAt.After(b.StartedAt) },
+	ErrNotFound: ErrNotFound,
+	Index:       func(r *Run) string { return r.DrillID },
+
+	Component:  "restore-drill-run",
+	Prefix:     "velero-dashboard-drill-run-",
+	IndexLabel: "velero-dashboard/drill-id",
+
+	Schema:      "restore-drills",
+	Migrations:  sqliteMigrations,
+	Table:       "drill_runs",
+	KeyColumn:   "id",
+	IndexColumn: "drill_id",
+	CreatedAt:   func(r *Run) time.Time { return r.StartedAt },
+	TimeColumn:  "started_at",
+}
+
+// records is a jsonstore table of T.
+type records[T any] interface {
+	Create(ctx context.Context, record *T) error
+	Get(ctx context.Context, key string) (*T, error)
+	List(ctx context.Context) ([]*T, error)
+	ListBy(ctx context.Context, value string) ([]*T, error)
+	Update(ctx context.Context, record *T) error
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
+
+// recordStore implements Store on a table of drills and a table of runs.
+type recordStore struct {
+	drills records[Drill]
+	runs   records[Run]
+	logger *zap.Logger
+}
+
+func (s *recordStore) CreateDrill(ctx context.Context, d *Drill) error {
+	return s.drills.Create(ctx, d)
+}
+
+func (s *recordStore) GetDrill(ctx context.Context, id string) (*Drill, error) {
+	return s.drills.Get(ctx, id)
+}
+
+func (s *recordStore) ListDrills(ctx context.Context) ([]*Drill, error) {
+	return s.drills.List(ctx)
+}
+
+func (s *recordStore) UpdateDrill(ctx context.Context, d *Drill) error {
+	return s.drills.Update(ctx, d)
+}
+
+// DeleteDrill removes the drill. Its runs are kept as evidence.
+func (s *recordStore) DeleteDrill(ctx context.Context, id string) error {
+	return s.drills.Delete(ctx, id)
+}
+
+func (s *recordStore) CreateRun(ctx context.Context, run *Run) error {
+	if err := s.runs.Create(ctx, run); err != nil {
+		return err
+	}
+
+	runs, err := s.runs.ListBy(ctx, run.DrillID)
+	if err != nil {
+		s.logger.Warn("Failed to prune drill runs", zap.String("drill", run.DrillID), zap.Error(err))
+		return nil
+	}
+	for i := maxRunsPerDrill; i < len(runs); i++ {
+		if err := s.runs.Delete(ctx, runs[i].ID); err != nil && !errors.Is(err, ErrNotFound) {
+			s.logger.Warn("Failed to prune drill run", zap.String("run", runs[i].ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *recordStore) UpdateRun(ctx context.Context, run *Run) error {
+	return s.runs.Update(ctx, run)
+}
+
+func (s *recordStore) ListRuns(ctx context.Context, drillID string, limit int) ([]*Run, error) {
+	var runs []*Run
+	var err error
+	if drillID == "" {
+		runs, err = s.runs.List(ctx)
+	} else {
+		runs, err = s.runs.ListBy(ctx, drillID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (s *recordStore) Close() error {
+	return errors.Join(s.drills.Close(), s.runs.Close())
+}
+
+func isInCluster() bool {
+	_, err := rest.InClusterConfig()
+	return err == nil
+}
@@ -0,0 +1,34 @@
+package drill
+
+import (
+	"fmt"
+
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// NewK8sStore creates a store keeping each drill and each run in its own
+// ConfigMap.
+func NewK8sStore(namespace string, logger *zap.Logger) (Store, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return newK8sStore(clientset, namespace, logger), nil
+}
+
+func newK8sStore(clientset kubernetes.Interface, namespace string, logger *zap.Logger) Store {
+	return &recordStore{
+		drills: jsonstore.NewK8sStoreForClient(clientset, namespace, drillRecords, logger),
+		runs:   jsonstore.NewK8sStoreForClient(clientset, namespace, runRecords, logger),
+		logger: logger,
+	}
+}
@@ -0,0 +1,72 @@
+package drill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newK8sStore(fake.NewSimpleClientset(), "velero", zap.NewNop())
+
+	d := &Drill{ID: "one", Name: "shop", Cron: "@daily", NamespaceMapping: map[string]string{"shop": "shop-drill"}, CreatedAt: time.Now()}
+	if err := store.CreateDrill(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	d.Enabled = true
+	if err := store.UpdateDrill(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetDrill(ctx, "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Enabled || got.NamespaceMapping["shop"] != "shop-drill" {
+		t.Errorf("drill = %+v", got)
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		run := &Run{ID: fmt.Sprintf("run-%d", i), DrillID: "one", Phase: RunPassed, StartedAt: start.Add(time.Duration(i) * time.Minute)}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.CreateRun(ctx, &Run{ID: "other", DrillID: "two", StartedAt: start}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateRun(ctx, &Run{ID: "run-2", DrillID: "one", Phase: RunFailed, StartedAt: start.Add(2 * time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := store.ListRuns(ctx, "one", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != "run-2" || runs[0].Phase != RunFailed || runs[1].ID != "run-1" {
+		t.Errorf("runs = %+v", runs)
+	}
+	if all, _ := store.ListRuns(ctx, "", 0); len(all) != 4 {
+		t.Errorf("all runs = %d, want 4", len(all))
+	}
+
+	// Runs outlive their drill
+	if err := store.DeleteDrill(ctx, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetDrill(ctx, "one"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetDrill after delete: err = %v", err)
+	}
+	if runs, _ := store.ListRuns(ctx, "one", 0); len(runs) != 3 {
+		t.Errorf("runs after delete = %d, want 3", len(runs))
+	}
+	if err := store.UpdateRun(ctx, &Run{ID: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateRun missing: err = %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package drill
+
+import (
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	"go.uber.org/zap"
+)
+
+// sqliteMigrations is the drill store schema history. Never edit an applied
+// migration; append a new one instead.
+var sqliteMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create drills and runs",
+		Up: migrate.Exec(
+			`CREATE TABLE IF NOT EXISTS drills (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS drill_runs (
+				id TEXT PRIMARY KEY,
+				drill_id TEXT NOT NULL,
+				data TEXT NOT NULL,
+				started_at TEXT NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_drill_runs_drill ON drill_runs (drill_id, started_at)`,
+		),
+	},
+}
+
+// NewSQLiteStore creates a store keeping drills and runs in SQLite, as JSON
+// alongside the columns needed for lookups and ordering.
+func NewSQLiteStore(dbPath string, logger *zap.Logger) (Store, error) {
+	drills, err := jsonstore.NewSQLiteStore(dbPath, drillRecords, logger)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := jsonstore.NewSQLiteStore(dbPath, runRecords, logger)
+	if err != nil {
+		_ = drills.Close()
+		return nil, err
+	}
+	return &recordStore{drills: drills, runs: runs, logger: logger}, nil
+}
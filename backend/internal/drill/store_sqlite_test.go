@@ -0,0 +1,35 @@
+package drill
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestSQLiteStorePrunesRuns(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "drills.db"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	start := time.Now()
+	for i := 0; i < maxRunsPerDrill+5; i++ {
+		run := &Run{ID: fmt.Sprintf("run-%03d", i), DrillID: "one", StartedAt: start.Add(time.Duration(i) * time.Second)}
+		if err := store.CreateRun(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, err := store.ListRuns(ctx, "one", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != maxRunsPerDrill || runs[len(runs)-1].ID != "run-005" {
+		t.Errorf("kept %d runs, oldest %s", len(runs), runs[len(runs)-1].ID)
+	}
+}
@@ -0,0 +1,216 @@
+package drill
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RunPhase is the state of a drill run.
+type RunPhase string
+
+const (
+	RunRunning RunPhase = "Running"
+	RunPassed  RunPhase = "Passed"
+	RunFailed  RunPhase = "Failed"
+)
+
+// Trigger records why a run started.
+type Trigger string
+
+const (
+	TriggerSchedule Trigger = "schedule"
+	TriggerManual   Trigger = "manual"
+)
+
+// Default timeouts, used when a drill doesn't set its own.
+const (
+	DefaultSyncTimeout    = 15 * time.Minute
+	DefaultRestoreTimeout = time.Hour
+	DefaultReadyTimeout   = 10 * time.Minute
+)
+
+// scheduleLabel is set by Velero on backups created by a Schedule.
+const scheduleLabel = "velero.io/schedule-name"
+
+var (
+	// ErrNotFound is returned when a drill doesn't exist.
+	ErrNotFound = errors.New("drill not found")
+	// ErrRunning is returned when starting a drill that is already running.
+	ErrRunning = errors.New("drill is already running")
+)
+
+// Criteria decide whether a restore counts as a successful drill.
+type Criteria struct {
+	MinItems         int64  `json:"minItems,omitempty"`     // Minimum number of restored items
+	AllowErrors      bool   `json:"allowErrors,omitempty"`  // Pass despite restore errors
+	RequirePodsReady bool   `json:"requirePodsReady"`       // All restored pods must become Ready
+	ReadyTimeout     string `json:"readyTimeout,omitempty"` // How long pods may take, e.g. "10m"
+}
+
+// Drill restores the latest backup of a schedule into scratch namespaces on
+// a cron, checks the result and deletes the namespaces again.
+type Drill struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	SourceClusterID string `json:"sourceClusterId"`
+	Schedule        string `json:"schedule"` // Velero schedule on the source cluster
+	TargetClusterID string `json:"targetClusterId"`
+	// NamespaceMapping maps each backed-up namespace to restore to the
+	// scratch namespace it is restored into.
+	NamespaceMapping map[string]string `json:"namespaceMapping"`
+	Cron             string            `json:"cron"`
+	Enabled          bool              `json:"enabled"`
+	Criteria         Criteria          `json:"criteria"`
+	SyncTimeout      string            `json:"syncTimeout,omitempty"`
+	RestoreTimeout   string            `json:"restoreTimeout,omitempty"`
+	CreatedBy        string            `json:"createdBy,omitempty"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	UpdatedAt        time.Time         `json:"updatedAt"`
+}
+
+// SourceNamespaces returns the namespaces restored from the backup.
+func (d *Drill) SourceNamespaces() []string {
+	namespaces := make([]string, 0, len(d.NamespaceMapping))
+	for ns := range d.NamespaceMapping {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// ScratchNamespaces returns the namespaces the drill restores into.
+func (d *Drill) ScratchNamespaces() []string {
+	namespaces := make([]string, 0, len(d.NamespaceMapping))
+	for _, ns := range d.SourceNamespaces() {
+		namespaces = append(namespaces, d.NamespaceMapping[ns])
+	}
+	return namespaces
+}
+
+// Check is the outcome of one success criterion.
+type Check struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// Run is the recorded result of executing a drill once.
+type Run struct {
+	ID              string     `json:"id"`
+	DrillID         string     `json:"drillId"`
+	DrillName       string     `json:"drillName"`
+	SourceClusterID string     `json:"sourceClusterId"`
+	TargetClusterID string     `json:"targetClusterId"`
+	Trigger         Trigger    `json:"trigger"`
+	TriggeredBy     string     `json:"triggeredBy,omitempty"`
+	Phase           RunPhase   `json:"phase"`
+	Message         string     `json:"message,omitempty"`
+	BackupName      string     `json:"backupName,omitempty"`
+	RestoreName     string     `json:"restoreName,omitempty"`
+	ItemsRestored   int64      `json:"itemsRestored"`
+	Errors          int64      `json:"errors"`
+	Warnings        int64      `json:"warnings"`
+	Checks          []Check    `json:"checks"`
+	Cleanup         string     `json:"cleanup,omitempty"` // Outcome of deleting the scratch namespaces
+	StartedAt       time.Time  `json:"startedAt"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+}
+
+// DrillRequest is the payload for creating or replacing a drill.
+type DrillRequest struct {
+	Name             string            `json:"name"`
+	SourceClusterID  string            `json:"sourceClusterId"`
+	Schedule         string            `json:"schedule"`
+	TargetClusterID  string            `json:"targetClusterId"`
+	NamespaceMapping map[string]string `json:"namespaceMapping"`
+	Cron             string            `json:"cron"`
+	Enabled          bool              `json:"enabled"`
+	Criteria         Criteria          `json:"criteria"`
+	SyncTimeout      string            `json:"syncTimeout,omitempty"`
+	RestoreTimeout   string            `json:"restoreTimeout,omitempty"`
+}
+
+// Validate checks the request before a drill is saved.
+func (r *DrillRequest) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.SourceClusterID == "" || r.TargetClusterID == "" {
+		return fmt.Errorf("sourceClusterId and targetClusterId are required")
+	}
+	if r.Schedule == "" {
+		return fmt.Errorf("schedule is required")
+	}
+	if _, err := cron.ParseStandard(r.Cron); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	// Restoring outside scratch namespaces could overwrite real workloads, and
+	// scratch namespaces are deleted afterwards, so every namespace is mapped
+	if len(r.NamespaceMapping) == 0 {
+		return fmt.Errorf("at least one namespace mapping is required")
+	}
+	scratch := make(map[string]bool, len(r.NamespaceMapping))
+	for from, to := range r.NamespaceMapping {
+		if errs := validation.IsDNS1123Label(from); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q: %s", from, errs[0])
+		}
+		if errs := validation.IsDNS1123Label(to); len(errs) > 0 {
+			return fmt.Errorf("invalid scratch namespace %q: %s", to, errs[0])
+		}
+		if scratch[to] {
+			return fmt.Errorf("scratch namespace %s is used more than once", to)
+		}
+		scratch[to] = true
+	}
+	for from := range r.NamespaceMapping {
+		if scratch[from] {
+			return fmt.Errorf("namespace %s is both restored and used as a scratch namespace", from)
+		}
+	}
+
+	if r.Criteria.MinItems < 0 {
+		return fmt.Errorf("minItems must not be negative")
+	}
+	for _, d := range []struct{ name, value string }{
+		{"syncTimeout", r.SyncTimeout},
+		{"restoreTimeout", r.RestoreTimeout},
+		{"readyTimeout", r.Criteria.ReadyTimeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v <= 0 {
+			return fmt.Errorf("%s must be a positive duration such as 30m", d.name)
+		}
+	}
+	return nil
+}
+
+// apply copies the request onto d.
+func (r *DrillRequest) apply(d *Drill) {
+	d.Name = r.Name
+	d.SourceClusterID = r.SourceClusterID
+	d.Schedule = r.Schedule
+	d.TargetClusterID = r.TargetClusterID
+	d.NamespaceMapping = r.NamespaceMapping
+	d.Cron = r.Cron
+	d.Enabled = r.Enabled
+	d.Criteria = r.Criteria
+	d.SyncTimeout = r.SyncTimeout
+	d.RestoreTimeout = r.RestoreTimeout
+}
+
+// timeout returns the parsed duration or def when unset. Values are
+// validated when the drill is saved.
+func timeout(value string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
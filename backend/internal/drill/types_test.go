@@ -0,0 +1,65 @@
+package drill
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDrillRequestValidate(t *testing.T) {
+	valid := func() DrillRequest {
+		return DrillRequest{
+			Name:             "shop",
+			SourceClusterID:  "a",
+			Schedule:         "daily",
+			TargetClusterID:  "b",
+			NamespaceMapping: map[string]string{"shop": "shop-drill"},
+			Cron:             "0 3 * * 0",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *DrillRequest)
+		wantErr bool
+	}{
+		{"valid", func(r *DrillRequest) {}, false},
+		{"same cluster", func(r *DrillRequest) { r.TargetClusterID = "a" }, false},
+		{"missing name", func(r *DrillRequest) { r.Name = "" }, true},
+		{"missing target", func(r *DrillRequest) { r.TargetClusterID = "" }, true},
+		{"missing schedule", func(r *DrillRequest) { r.Schedule = "" }, true},
+		{"invalid cron", func(r *DrillRequest) { r.Cron = "weekly" }, true},
+		{"descriptor", func(r *DrillRequest) { r.Cron = "@weekly" }, false},
+		{"no mapping", func(r *DrillRequest) { r.NamespaceMapping = nil }, true},
+		{"invalid scratch", func(r *DrillRequest) { r.NamespaceMapping = map[string]string{"shop": "Shop_1"} }, true},
+		{"shared scratch", func(r *DrillRequest) {
+			r.NamespaceMapping = map[string]string{"shop": "drill", "cart": "drill"}
+		}, true},
+		{"overlap", func(r *DrillRequest) {
+			r.NamespaceMapping = map[string]string{"shop": "cart", "cart": "cart-drill"}
+		}, true},
+		{"restore in place", func(r *DrillRequest) { r.NamespaceMapping = map[string]string{"shop": "shop"} }, true},
+		{"negative items", func(r *DrillRequest) { r.Criteria.MinItems = -1 }, true},
+		{"timeouts", func(r *DrillRequest) { r.SyncTimeout = "5m"; r.Criteria.ReadyTimeout = "2m" }, false},
+		{"invalid timeout", func(r *DrillRequest) { r.RestoreTimeout = "soon" }, true},
+		{"zero timeout", func(r *DrillRequest) { r.Criteria.ReadyTimeout = "0s" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(&r)
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDrillNamespaces(t *testing.T) {
+	d := &Drill{NamespaceMapping: map[string]string{"shop": "drill-shop", "cart": "drill-cart"}}
+	if got := d.SourceNamespaces(); !reflect.DeepEqual(got, []string{"cart", "shop"}) {
+		t.Errorf("SourceNamespaces() = %v", got)
+	}
+	if got := d.ScratchNamespaces(); !reflect.DeepEqual(got, []string{"drill-cart", "drill-shop"}) {
+		t.Errorf("ScratchNamespaces() = %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// errNoClusterAccess is returned when the caller's roles and role bindings
+// grant nothing on the requested cluster.
+var errNoClusterAccess = errors.New("insufficient permissions on this cluster")
+
+// scopedClient is a cluster client together with what the caller may do on
+// that cluster.
+type scopedClient struct {
+	*k8s.Client
+	clusterID string
+	scope     auth.Scope
+}
+
+// resolveClient returns the client of the ?cluster= parameter, or of the
+// default cluster, once the caller holds role somewhere on it. Handlers then
+// check the namespaces a request touches against client.scope.
+func resolveClient(c *fiber.Ctx, mgr *cluster.Manager, role string) (*scopedClient, error) {
+	clusterID, err := requestedCluster(c, mgr)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := clusterScope(c, mgr, clusterID)
+	if !scope.Any(role) {
+		return nil, errNoClusterAccess
+	}
+	client, err := mgr.GetClient(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return &scopedClient{Client: client, clusterID: clusterID, scope: scope}, nil
+}
+
+// requestedCluster returns the ?cluster= parameter, or the ID of the default
+// cluster.
+func requestedCluster(c *fiber.Ctx, mgr *cluster.Manager) (string, error) {
+	if clusterID := c.Query("cluster", ""); clusterID != "" {
+		return clusterID, nil
+	}
+	// Use default cluster for backward compatibility
+	def, err := mgr.GetStore().GetDefault(c.Context())
+	if err != nil {
+		return "", fmt.Errorf("no default cluster configured: %w", err)
+	}
+	return def.ID, nil
+}
+
+// clientError responds to a failed resolveClient.
+func clientError(c *fiber.Ctx, logger *zap.Logger, err error) error {
+	if errors.Is(err, errNoClusterAccess) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+	logger.Error("Failed to get cluster client", zap.Error(err))
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": "Cluster not found or not connected",
+	})
+}
+
+// clusterScope returns what the caller may do on a cluster.
+func clusterScope(c *fiber.Ctx, mgr *cluster.Manager, clusterID string) auth.Scope {
+	return newScopeCache(c, mgr).get(clusterID)
+}
+
+// scopeCache resolves a user's scope once per cluster, e.g. while filtering
+// lists that span clusters. A cluster's labels are only looked up, in the
+// manager's memory, when a role binding selects by label.
+type scopeCache struct {
+	authz  *auth.Authorizer
+	user   *auth.UserInfo
+	mgr    *cluster.Manager
+	scopes map[string]auth.Scope
+}
+
+func newScopeCache(c *fiber.Ctx, mgr *cluster.Manager) *scopeCache {
+	return newUserScopeCache(auth.GetAuthorizer(c), auth.GetUser(c), mgr)
+}
+
+func newUserScopeCache(authz *auth.Authorizer, user *auth.UserInfo, mgr *cluster.Manager) *scopeCache {
+	return &scopeCache{authz: authz, user: user, mgr: mgr, scopes: make(map[string]auth.Scope)}
+}
+
+func (s *scopeCache) get(clusterID string) auth.Scope {
+	scope, ok := s.scopes[clusterID]
+	if !ok {
+		scope = s.authz.Scope(s.user, clusterID, func() map[string]string {
+			return s.mgr.Labels(clusterID)
+		})
+		s.scopes[clusterID] = scope
+	}
+	return scope
+}
+
+// forbidden responds that role is required on namespaces.
+func forbidden(c *fiber.Ctx, role string, namespaces []string) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": permissionError(role, namespaces).Error()})
+}
+
+// permissionError describes a missing role on namespaces, or on the whole
+// cluster when namespaces is empty or contains "*".
+func permissionError(role string, namespaces []string) error {
+	where := "namespaces " + strings.Join(namespaces, ", ")
+	if len(namespaces) == 0 || slices.Contains(namespaces, "*") {
+		where = "the whole cluster"
+	}
+	return fmt.Errorf("%s role required on %s", role, where)
+}
+
+// errorResponse responds with a *fiber.Error from one of the validate
+// helpers.
+func errorResponse(c *fiber.Ctx, err *fiber.Error) error {
+	return c.Status(err.Code).JSON(fiber.Map{"error": err.Message})
+}
+
+// validateNamespaces checks the namespaces a backup, restore or schedule
+// selects. Callers holding role on the whole cluster may select anything.
+// Everyone else must list namespaces explicitly, since Velero reads an empty
+// list as the whole cluster, and may only list namespaces scope grants role
+// on. Malformed lists are a 400, namespaces outside the scope a 403.
+func validateNamespaces(scope auth.Scope, role string, namespaces []string) *fiber.Error {
+	if scope.Can(role, "") {
+		return nil
+	}
+	allowed := strings.Join(scope.NamespacePatterns(role), ", ")
+	if len(namespaces) == 0 || slices.Contains(namespaces, "*") {
+		return fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("includedNamespaces is required: select one or more of your namespaces (%s)", allowed))
+	}
+	for _, ns := range namespaces {
+		if !validNamespace(ns) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid namespace %q", ns))
+		}
+	}
+	if !scope.CanAll(role, namespaces) {
+		return fiber.NewError(fiber.StatusForbidden,
+			fmt.Sprintf("%s; your namespaces are %s", permissionError(role, namespaces), allowed))
+	}
+	return nil
+}
+
+// validateResources checks the cluster-scoped resources a backup, restore or
+// schedule selects. Only callers holding role on the whole cluster may select
+// cluster-scoped resources, by name or with includeClusterResources.
+// clusterScoped reports which of the resources are cluster-scoped on the
+// cluster the request acts on.
+func validateResources(scope auth.Scope, role string, resources []string, includeCluster *bool, clusterScoped func([]string) ([]string, error)) *fiber.Error {
+	if scope.Can(role, "") {
+		return nil
+	}
+	if includeCluster != nil && *includeCluster {
+		return fiber.NewError(fiber.StatusForbidden,
+			fmt.Sprintf("%s role required on the whole cluster to include cluster resources", role))
+	}
+	found, err := clusterScoped(resources)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if len(found) > 0 {
+		return fiber.NewError(fiber.StatusForbidden,
+			fmt.Sprintf("%s role required on the whole cluster to include cluster-scoped resources %s", role, strings.Join(found, ", ")))
+	}
+	return nil
+}
+
+// validNamespace reports whether ns is a namespace name, or a pattern as
+// Velero accepts in includedNamespaces.
+func validNamespace(ns string) bool {
+	if auth.IsNamespacePattern(ns) {
+		_, err := path.Match(ns, "")
+		return err == nil
+	}
+	return len(validation.IsDNS1123Label(ns)) == 0
+}
+
+// visibleTo keeps the items whose namespaces pass visible for scope.
+func visibleTo[T any](items []T, scope auth.Scope, visible func(auth.Scope, []string) bool, namespaces func(T) []string) []T {
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if visible(scope, namespaces(item)) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// canView reports whether scope lets the caller see an object touching
+// namespaces, which needs viewer on all of them.
+func canView(scope auth.Scope, namespaces []string) bool {
+	return scope.CanAll(auth.RoleViewer, namespaces)
+}
+
+// backupVisible reports whether scope lets the caller see a backup of
+// namespaces. Backups are shown as soon as they hold one of the caller's
+// namespaces, whole-cluster backups included, so app teams can restore their
+// namespaces from them. Their details, logs and comparisons take viewer on
+// all of their namespaces, see canView.
+func backupVisible(scope auth.Scope, namespaces []string) bool {
+	return scope.Intersects(auth.RoleViewer, namespaces)
+}
+
+func backupNamespaces(b k8s.BackupResponse) []string { return b.IncludedNamespaces }
+
+func scheduleNamespaces(s k8s.ScheduleResponse) []string { return s.IncludedNamespaces }
+
+func restoreNamespaces(r k8s.RestoreResponse) []string {
+	return k8s.RestoreTargets(r.IncludedNamespaces, r.NamespaceMapping)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+)
+
+// paymentsScope is a team holding operator on its namespaces only.
+var paymentsScope = auth.Scope{
+	Role:       auth.RoleNone,
+	Namespaces: map[string]string{"payments": auth.RoleOperator, "payments-*": auth.RoleOperator},
+}
+
+func TestValidateNamespaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      auth.Scope
+		namespaces []string
+		want       int // 0 for accepted
+	}{
+		{"cluster operator selects the whole cluster", auth.Scope{Role: auth.RoleOperator}, nil, 0},
+		{"whole-cluster default", paymentsScope, nil, fiber.StatusBadRequest},
+		{"star", paymentsScope, []string{"payments", "*"}, fiber.StatusBadRequest},
+		{"granted namespace", paymentsScope, []string{"payments"}, 0},
+		{"namespace matching a granted pattern", paymentsScope, []string{"payments-eu"}, 0},
+		{"identical pattern", paymentsScope, []string{"payments-*"}, 0},
+		{"narrower pattern", paymentsScope, []string{"payments-e*"}, fiber.StatusForbidden},
+		{"wider pattern", paymentsScope, []string{"pay*"}, fiber.StatusForbidden},
+		{"foreign namespace", paymentsScope, []string{"payments", "shop"}, fiber.StatusForbidden},
+		{"malformed namespace", paymentsScope, []string{"Payments_EU"}, fiber.StatusBadRequest},
+		{"malformed pattern", paymentsScope, []string{"payments-["}, fiber.StatusBadRequest},
+		{"viewer only", auth.Scope{Role: auth.RoleViewer}, []string{"payments"}, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			if err := validateNamespaces(tt.scope, auth.RoleOperator, tt.namespaces); err != nil {
+				got = err.Code
+			}
+			if got != tt.want {
+				t.Errorf("validateNamespaces(%v) = %d, want %d", tt.namespaces, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	clusterScoped := func(resources []string) ([]string, error) {
+		var found []string
+		for _, r := range resources {
+			if r == "clusterroles" || r == "crd" {
+				found = append(found, r)
+			}
+		}
+		return found, nil
+	}
+	yes, no := true, false
+
+	tests := []struct {
+		name           string
+		scope          auth.Scope
+		resources      []string
+		includeCluster *bool
+		want           int // 0 for accepted
+	}{
+		{"cluster operator selects cluster resources", auth.Scope{Role: auth.RoleOperator}, []string{"clusterroles"}, &yes, 0},
+		{"namespaced resources", paymentsScope, []string{"deployments", "configmaps"}, nil, 0},
+		{"all resources", paymentsScope, nil, nil, 0},
+		{"cluster resources excluded", paymentsScope, nil, &no, 0},
+		{"cluster-scoped resource", paymentsScope, []string{"deployments", "clusterroles"}, nil, fiber.StatusForbidden},
+		{"short name", paymentsScope, []string{"crd"}, nil, fiber.StatusForbidden},
+		{"includeClusterResources", paymentsScope, []string{"deployments"}, &yes, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			if err := validateResources(tt.scope, auth.RoleOperator, tt.resources, tt.includeCluster, clusterScoped); err != nil {
+				got = err.Code
+			}
+			if got != tt.want {
+				t.Errorf("validateResources(%v, %v) = %d, want %d", tt.resources, tt.includeCluster, got, tt.want)
+			}
+		})
+	}
+}
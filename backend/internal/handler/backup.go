@@ -2,6 +2,7 @@ package handler
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
 	"github.com/klinux/velero-dashboard/internal/k8s"
 	"go.uber.org/zap"
@@ -16,25 +17,16 @@ func NewBackupHandler(clusterMgr *cluster.Manager, logger *zap.Logger) *BackupHa
 	return &BackupHandler{clusterMgr: clusterMgr, logger: logger}
 }
 
-// getClient is a helper to get the K8s client from cluster query param or default
-func (h *BackupHandler) getClient(c *fiber.Ctx) (*k8s.Client, error) {
-	clusterID := c.Query("cluster", "")
-
-	if clusterID != "" {
-		return h.clusterMgr.GetClient(clusterID)
-	}
-
-	// Use default cluster for backward compatibility
-	return h.clusterMgr.GetDefaultClient(c.Context())
+// getClient is a helper to get the K8s client from cluster query param or
+// default, once the user holds role somewhere on that cluster
+func (h *BackupHandler) getClient(c *fiber.Ctx, role string) (*scopedClient, error) {
+	return resolveClient(c, h.clusterMgr, role)
 }
 
 func (h *BackupHandler) List(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	backups, err := client.ListBackups(c.Context())
@@ -42,16 +34,13 @@ func (h *BackupHandler) List(c *fiber.Ctx) error {
 		h.logger.Error("Failed to list backups", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(backups)
+	return c.JSON(visibleTo(backups, client.scope, backupVisible, backupNamespaces))
 }
 
 func (h *BackupHandler) Get(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
@@ -60,16 +49,16 @@ func (h *BackupHandler) Get(c *fiber.Ctx) error {
 		h.logger.Error("Failed to get backup", zap.String("name", name), zap.Error(err))
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
+	if !canView(client.scope, backup.IncludedNamespaces) {
+		return forbidden(c, auth.RoleViewer, backup.IncludedNamespaces)
+	}
 	return c.JSON(backup)
 }
 
 func (h *BackupHandler) Create(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleOperator)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	var req k8s.CreateBackupRequest
@@ -79,6 +68,12 @@ func (h *BackupHandler) Create(c *fiber.Ctx) error {
 	if req.Name == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
 	}
+	if err := validateNamespaces(client.scope, auth.RoleOperator, req.IncludedNamespaces); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := validateResources(client.scope, auth.RoleOperator, req.IncludedResources, req.IncludeClusterResources, client.ClusterScopedResources); err != nil {
+		return errorResponse(c, err)
+	}
 
 	backup, err := client.CreateBackup(c.Context(), req)
 	if err != nil {
@@ -89,15 +84,21 @@ func (h *BackupHandler) Create(c *fiber.Ctx) error {
 }
 
 func (h *BackupHandler) Delete(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleOperator)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
+	if !client.scope.Can(auth.RoleOperator, "") {
+		backup, err := client.GetBackup(c.Context(), name)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if !client.scope.CanAll(auth.RoleOperator, backup.IncludedNamespaces) {
+			return forbidden(c, auth.RoleOperator, backup.IncludedNamespaces)
+		}
+	}
 	if err := client.DeleteBackup(c.Context(), name); err != nil {
 		h.logger.Error("Failed to delete backup", zap.String("name", name), zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -106,15 +107,21 @@ func (h *BackupHandler) Delete(c *fiber.Ctx) error {
 }
 
 func (h *BackupHandler) Logs(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
+	if !client.scope.Can(auth.RoleViewer, "") {
+		backup, err := client.GetBackup(c.Context(), name)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if !client.scope.CanAll(auth.RoleViewer, backup.IncludedNamespaces) {
+			return forbidden(c, auth.RoleViewer, backup.IncludedNamespaces)
+		}
+	}
 	logs, err := client.GetBackupLogs(c.Context(), name)
 	if err != nil {
 		h.logger.Error("Failed to get backup logs", zap.String("name", name), zap.Error(err))
@@ -124,12 +131,9 @@ func (h *BackupHandler) Logs(c *fiber.Ctx) error {
 }
 
 func (h *BackupHandler) Compare(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	backup1 := c.Query("backup1")
@@ -144,6 +148,11 @@ func (h *BackupHandler) Compare(c *fiber.Ctx) error {
 		h.logger.Error("Failed to compare backups", zap.String("backup1", backup1), zap.String("backup2", backup2), zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	for _, b := range []k8s.BackupSummary{comparison.Backup1, comparison.Backup2} {
+		if !canView(client.scope, b.IncludedNamespaces) {
+			return forbidden(c, auth.RoleViewer, b.IncludedNamespaces)
+		}
+	}
 
 	return c.JSON(comparison)
 }
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/bundle"
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/notification"
+	"go.uber.org/zap"
+)
+
+// BundleHandler exports and imports the dashboard configuration.
+type BundleHandler struct {
+	manager  *cluster.Manager
+	notifMgr *notification.Manager
+	logger   *zap.Logger
+}
+
+// NewBundleHandler creates a new bundle handler.
+func NewBundleHandler(manager *cluster.Manager, notifMgr *notification.Manager, logger *zap.Logger) *BundleHandler {
+	return &BundleHandler{manager: manager, notifMgr: notifMgr, logger: logger}
+}
+
+// ExportBundleRequest is the payload for exporting a bundle.
+type ExportBundleRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+// ImportBundleRequest is the payload for importing a bundle.
+type ImportBundleRequest struct {
+	Passphrase string          `json:"passphrase"`
+	Bundle     json.RawMessage `json:"bundle"`
+	Overwrite  bool            `json:"overwrite"` // replace clusters and webhooks with the same name
+}
+
+// Export returns the clusters and webhooks as an encrypted bundle file
+// POST /api/bundle/export
+func (h *BundleHandler) Export(c *fiber.Ctx) error {
+	var req ExportBundleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if len(req.Passphrase) < bundle.MinPassphraseLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("passphrase must be at least %d characters", bundle.MinPassphraseLength),
+		})
+	}
+
+	contents, err := bundle.Export(c.Context(), h.manager.GetStore(), h.notifMgr.Store())
+	if err != nil {
+		h.logger.Error("Failed to export configuration", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export configuration"})
+	}
+	data, err := bundle.Seal(contents, req.Passphrase)
+	if err != nil {
+		h.logger.Error("Failed to seal configuration bundle", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export configuration"})
+	}
+
+	h.logger.Info("Configuration exported",
+		zap.String("user", username(c)),
+		zap.Int("clusters", len(contents.Clusters)),
+		zap.Int("webhooks", len(contents.Webhooks)))
+
+	filename := fmt.Sprintf("velero-dashboard-%s.bundle.json", contents.ExportedAt.Format("20060102-150405"))
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(data)
+}
+
+// Import restores clusters and webhooks from an encrypted bundle
+// POST /api/bundle/import
+func (h *BundleHandler) Import(c *fiber.Ctx) error {
+	var req ImportBundleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if len(req.Bundle) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "bundle is required"})
+	}
+
+	contents, err := bundle.Open(req.Bundle, req.Passphrase)
+	if errors.Is(err, bundle.ErrPassphrase) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	report, err := bundle.Import(c.Context(), contents, h.manager.GetStore(), h.notifMgr.Store(), req.Overwrite)
+	if err != nil {
+		h.logger.Error("Failed to import configuration", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to import configuration"})
+	}
+
+	h.logger.Info("Configuration imported",
+		zap.String("user", username(c)),
+		zap.Time("exportedAt", contents.ExportedAt),
+		zap.Int("clusters", len(report.Clusters)),
+		zap.Int("webhooks", len(report.Webhooks)),
+		zap.Bool("overwrite", req.Overwrite))
+
+	// (Re)connect imported clusters (async - don't block response)
+	changed := report.Changed
+	go func() {
+		ctx := context.Background()
+		for _, cl := range changed {
+			_ = h.manager.RemoveCluster(cl.ID)
+			if err := h.manager.AddCluster(ctx, cl); err != nil {
+				h.logger.Error("Failed to connect to imported cluster",
+					zap.String("id", cl.ID),
+					zap.String("name", cl.Name),
+					zap.Error(err))
+			}
+		}
+	}()
+
+	return c.JSON(report)
+}
+
+func username(c *fiber.Ctx) string {
+	if user := auth.GetUser(c); user != nil {
+		return user.Username
+	}
+	return ""
+}
@@ -2,12 +2,22 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/k8s"
 	"go.uber.org/zap"
 )
 
+// importConcurrency bounds how many contexts are probed in parallel during
+// a kubeconfig import.
+const importConcurrency = 4
+
 type ClusterHandler struct {
 	manager *cluster.Manager
 	logger  *zap.Logger
@@ -20,19 +30,107 @@ func NewClusterHandler(manager *cluster.Manager, logger *zap.Logger) *ClusterHan
 	}
 }
 
-// List returns all clusters (without kubeconfig)
-// GET /api/clusters
+// List returns the clusters the caller holds a role on (without kubeconfig)
+// GET /api/clusters?selector=env=prod&kubernetesVersion=1.27&veleroVersion=1.11&provider=aws
 func (h *ClusterHandler) List(c *fiber.Ctx) error {
-	clusters, err := h.manager.ListClusters(c.Context())
+	all, err := h.manager.ListClusters(c.Context())
 	if err != nil {
 		h.logger.Error("Failed to list clusters", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to list clusters",
 		})
 	}
+	clusters := make([]*cluster.ClusterSummary, 0, len(all))
+	for _, cl := range all {
+		labels := cl.Labels
+		if auth.GetScope(c, cl.ID, func() map[string]string { return labels }).Any(auth.RoleViewer) {
+			clusters = append(clusters, cl)
+		}
+	}
+
+	if selector := c.Query("selector"); selector != "" {
+		clusters, err = cluster.FilterBySelector(clusters, selector)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	clusters = cluster.FilterByMetadata(clusters, cluster.MetadataFilter{
+		KubernetesVersion: c.Query("kubernetesVersion"),
+		VeleroVersion:     c.Query("veleroVersion"),
+		Provider:          c.Query("provider"),
+	})
 	return c.JSON(clusters)
 }
 
+// Access returns the caller's role on a cluster as a whole and the namespace
+// patterns they hold each role on otherwise, for namespace pickers.
+// GET /api/clusters/access?cluster=<id>
+func (h *ClusterHandler) Access(c *fiber.Ctx) error {
+	clusterID, err := requestedCluster(c, h.manager)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	scope := clusterScope(c, h.manager, clusterID)
+	if !scope.Any(auth.RoleViewer) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": errNoClusterAccess.Error()})
+	}
+
+	namespaces := make(map[string][]string)
+	for _, role := range []string{auth.RoleViewer, auth.RoleOperator, auth.RoleAdmin} {
+		namespaces[role] = scope.NamespacePatterns(role)
+		if namespaces[role] == nil {
+			namespaces[role] = []string{}
+		}
+	}
+	return c.JSON(fiber.Map{
+		"clusterId":  clusterID,
+		"role":       scope.Role,
+		"namespaces": namespaces,
+	})
+}
+
+// ClusterGroup is a set of clusters sharing the same value for a label key.
+type ClusterGroup struct {
+	Value    string                    `json:"value"`
+	Clusters []*cluster.ClusterSummary `json:"clusters"`
+}
+
+// Groups returns clusters grouped by the value of a label key. Clusters
+// without the label are returned in a group with an empty value.
+// GET /api/clusters/groups?by=env
+func (h *ClusterHandler) Groups(c *fiber.Ctx) error {
+	key := c.Query("by")
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Query parameter 'by' is required",
+		})
+	}
+
+	clusters, err := h.manager.ListClusters(c.Context())
+	if err != nil {
+		h.logger.Error("Failed to list clusters", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list clusters",
+		})
+	}
+
+	byValue := make(map[string][]*cluster.ClusterSummary)
+	for _, cl := range clusters {
+		v := cl.Labels[key]
+		byValue[v] = append(byValue[v], cl)
+	}
+
+	groups := make([]ClusterGroup, 0, len(byValue))
+	for v, members := range byValue {
+		groups = append(groups, ClusterGroup{Value: v, Clusters: members})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Value < groups[j].Value })
+
+	return c.JSON(groups)
+}
+
 // Get returns a single cluster (without kubeconfig)
 // GET /api/clusters/:id
 func (h *ClusterHandler) Get(c *fiber.Ctx) error {
@@ -43,7 +141,7 @@ func (h *ClusterHandler) Get(c *fiber.Ctx) error {
 		})
 	}
 
-	clusterObj, err := h.manager.GetStore().Get(c.Context(), id)
+	summary, err := h.manager.GetCluster(c.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get cluster",
 			zap.String("id", id),
@@ -53,8 +151,8 @@ func (h *ClusterHandler) Get(c *fiber.Ctx) error {
 		})
 	}
 
-	// Convert to summary (no kubeconfig)
-	return c.JSON(clusterObj.ToSummary())
+	// Summary carries no kubeconfig
+	return c.JSON(summary)
 }
 
 // Create adds a new cluster
@@ -73,9 +171,9 @@ func (h *ClusterHandler) Create(c *fiber.Ctx) error {
 			"error": "Cluster name is required",
 		})
 	}
-	if req.Namespace == "" {
+	if err := cluster.ValidateLabels(req.Labels); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Namespace is required",
+			"error": err.Error(),
 		})
 	}
 
@@ -103,6 +201,14 @@ func (h *ClusterHandler) Create(c *fiber.Ctx) error {
 			zap.String("apiServer", req.APIServer))
 	}
 
+	namespace, err := h.preflight([]byte(req.Kubeconfig), req.Namespace, req.Name)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	req.Namespace = namespace
+
 	// Store cluster
 	newCluster, err := h.manager.GetStore().Create(c.Context(), req)
 	if err != nil {
@@ -133,6 +239,172 @@ func (h *ClusterHandler) Create(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(newCluster.ToSummary())
 }
 
+// ImportContexts lists the contexts of an uploaded kubeconfig
+// POST /api/clusters/import/contexts
+func (h *ClusterHandler) ImportContexts(c *fiber.Ctx) error {
+	var req cluster.ImportContextsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	cfg, err := cluster.LoadKubeconfig(req.Kubeconfig)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(cluster.ListKubeconfigContexts(cfg))
+}
+
+// Import registers several contexts of one kubeconfig as separate clusters.
+// Each cluster stores a minimized kubeconfig holding only its own context.
+// POST /api/clusters/import
+func (h *ClusterHandler) Import(c *fiber.Ctx) error {
+	var req cluster.ImportClustersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if len(req.Contexts) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one context is required",
+		})
+	}
+
+	cfg, err := cluster.LoadKubeconfig(req.Kubeconfig)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	results := make([]cluster.ImportResult, len(req.Contexts))
+	kubeconfigs := make([][]byte, len(req.Contexts))
+	for i, ic := range req.Contexts {
+		results[i].Context = ic.Context
+		if err := cluster.ValidateLabels(ic.Labels); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		kubeconfigs[i], err = cluster.MinimizeKubeconfig(cfg, ic.Context)
+		if err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+
+	// Verify Velero and detect its namespace for every context in parallel;
+	// a large kubeconfig would otherwise take a probe timeout per context.
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, importConcurrency)
+	for i := range req.Contexts {
+		if results[i].Error != "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ic := &req.Contexts[i]
+			ns, err := h.preflight(kubeconfigs[i], ic.Namespace, ic.Context)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			ic.Namespace = ns
+		}(i)
+	}
+	wg.Wait()
+
+	// Create sequentially so default-cluster switching stays consistent
+	for i, ic := range req.Contexts {
+		if results[i].Error != "" {
+			continue
+		}
+		name := ic.Name
+		if name == "" {
+			name = ic.Context
+		}
+
+		newCluster, err := h.manager.GetStore().Create(c.Context(), cluster.CreateClusterRequest{
+			Name:         name,
+			Namespace:    ic.Namespace,
+			SetAsDefault: ic.SetAsDefault,
+			Labels:       ic.Labels,
+			Kubeconfig:   string(kubeconfigs[i]),
+		})
+		if err != nil {
+			h.logger.Error("Failed to import cluster",
+				zap.String("context", ic.Context),
+				zap.Error(err))
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Cluster = newCluster.ToSummary()
+
+		go func() {
+			if err := h.manager.AddCluster(context.Background(), newCluster); err != nil {
+				h.logger.Error("Failed to connect to cluster",
+					zap.String("id", newCluster.ID),
+					zap.String("name", newCluster.Name),
+					zap.Error(err))
+			}
+		}()
+	}
+
+	h.logger.Info("Kubeconfig imported", zap.Int("contexts", len(req.Contexts)))
+
+	return c.JSON(results)
+}
+
+// preflight checks through the given kubeconfig that Velero is installed
+// and resolves the namespace, detecting it when empty. Only definitive
+// answers fail: an unreachable cluster is accepted so that it can be added
+// ahead of time and picked up by the reconnect loop.
+func (h *ClusterHandler) preflight(kubeconfig []byte, namespace, name string) (string, error) {
+	client, err := k8s.NewClientFromKubeconfig(kubeconfig, namespace, h.logger)
+	if err != nil {
+		return "", err
+	}
+
+	caps, err := client.DetectCapabilities()
+	if err != nil {
+		if caps != nil || errors.Is(err, k8s.ErrVeleroNotInstalled) {
+			return "", err
+		}
+		h.logger.Warn("Cluster unreachable, skipping Velero preflight",
+			zap.String("name", name),
+			zap.Error(err))
+		if namespace == "" {
+			namespace = k8s.DefaultVeleroNamespace
+		}
+		return namespace, nil
+	}
+
+	if namespace != "" {
+		return namespace, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	detected, err := client.DetectVeleroNamespace(ctx)
+	if err != nil {
+		h.logger.Warn("Velero namespace detection failed, using default",
+			zap.String("name", name),
+			zap.String("namespace", k8s.DefaultVeleroNamespace),
+			zap.Error(err))
+		return k8s.DefaultVeleroNamespace, nil
+	}
+	h.logger.Info("Detected Velero namespace",
+		zap.String("name", name),
+		zap.String("namespace", detected))
+	return detected, nil
+}
+
 // Update modifies a cluster
 // PATCH /api/clusters/:id
 func (h *ClusterHandler) Update(c *fiber.Ctx) error {
@@ -149,6 +421,11 @@ func (h *ClusterHandler) Update(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	if err := cluster.ValidateLabels(req.Labels); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	// If token-based auth provided, convert to kubeconfig
 	if req.APIServer != nil && req.Token != nil {
@@ -190,6 +467,31 @@ func (h *ClusterHandler) Update(c *fiber.Ctx) error {
 			zap.String("apiServer", *req.APIServer))
 	}
 
+	if req.Kubeconfig != nil || req.Namespace != nil {
+		existing, err := h.manager.GetStore().Get(c.Context(), id)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Cluster not found",
+			})
+		}
+		kubeconfig := existing.KubeconfigRaw
+		if req.Kubeconfig != nil {
+			kubeconfig = []byte(*req.Kubeconfig)
+		}
+		namespace := existing.Namespace
+		if req.Namespace != nil {
+			namespace = *req.Namespace
+		}
+
+		namespace, err = h.preflight(kubeconfig, namespace, existing.Name)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		req.Namespace = &namespace
+	}
+
 	// Update in store
 	if err := h.manager.GetStore().Update(c.Context(), id, req); err != nil {
 		h.logger.Error("Failed to update cluster",
@@ -201,9 +503,12 @@ func (h *ClusterHandler) Update(c *fiber.Ctx) error {
 	}
 
 	h.logger.Info("Cluster updated", zap.String("id", id))
+	if req.Labels != nil {
+		h.manager.SetLabels(id, req.Labels)
+	}
 
-	// If kubeconfig changed, reconnect
-	if req.Kubeconfig != nil {
+	// If kubeconfig or namespace changed, reconnect
+	if req.Kubeconfig != nil || req.Namespace != nil {
 		go func() {
 			// Use a new context for the async operation
 			ctx := context.Background()
@@ -253,3 +558,48 @@ func (h *ClusterHandler) Delete(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"message": "Cluster deleted successfully"})
 }
+
+// EncryptionStatus reports which keys protect the stored kubeconfigs
+// GET /api/clusters/encryption
+func (h *ClusterHandler) EncryptionStatus(c *fiber.Ctx) error {
+	rotator, ok := h.manager.GetStore().(cluster.KeyRotator)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cluster storage does not manage its own encryption keys",
+		})
+	}
+
+	usage, err := rotator.KeyUsage(c.Context())
+	if err != nil {
+		h.logger.Error("Failed to read encryption key usage", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read encryption key usage",
+		})
+	}
+	return c.JSON(usage)
+}
+
+// Reencrypt rewraps all stored kubeconfigs with the active key
+// POST /api/clusters/encryption/reencrypt
+func (h *ClusterHandler) Reencrypt(c *fiber.Ctx) error {
+	rotator, ok := h.manager.GetStore().(cluster.KeyRotator)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cluster storage does not manage its own encryption keys",
+		})
+	}
+
+	changed, err := rotator.Reencrypt(c.Context())
+	if err != nil {
+		h.logger.Error("Failed to re-encrypt kubeconfigs", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to re-encrypt kubeconfigs: " + err.Error(),
+		})
+	}
+
+	usage, err := rotator.KeyUsage(c.Context())
+	if err != nil {
+		return c.JSON(fiber.Map{"reencrypted": changed})
+	}
+	return c.JSON(fiber.Map{"reencrypted": changed, "status": usage})
+}
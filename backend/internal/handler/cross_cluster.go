@@ -4,27 +4,53 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
 	"github.com/klinux/velero-dashboard/internal/k8s"
+	"github.com/klinux/velero-dashboard/internal/migration"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+// defaultSyncTimeout bounds how long a restore job waits for a provisioned
+// storage location to sync the backup.
+const defaultSyncTimeout = 5 * time.Minute
+
 // CrossClusterHandler handles cross-cluster backup and restore operations.
 type CrossClusterHandler struct {
 	clusterMgr *cluster.Manager
+	runner     *migration.Runner
 	logger     *zap.Logger
 }
 
-// NewCrossClusterHandler creates a new cross-cluster handler.
-func NewCrossClusterHandler(clusterMgr *cluster.Manager, logger *zap.Logger) *CrossClusterHandler {
-	return &CrossClusterHandler{clusterMgr: clusterMgr, logger: logger}
+// NewCrossClusterHandler creates a new cross-cluster handler. Restores that
+// first provision a storage location run as jobs of runner.
+func NewCrossClusterHandler(clusterMgr *cluster.Manager, runner *migration.Runner, logger *zap.Logger) *CrossClusterHandler {
+	return &CrossClusterHandler{clusterMgr: clusterMgr, runner: runner, logger: logger}
 }
 
 // SharedBackups returns backups accessible across clusters via shared BSLs.
+// An optional ?cluster= label selector restricts the clusters considered,
+// while a cluster ID lists the backups of that cluster other clusters can
+// restore; clusters and backups the caller can't see are left out.
 func (h *CrossClusterHandler) SharedBackups(c *fiber.Ctx) error {
-	clients := h.clusterMgr.GetAllClients()
+	selector, source := c.Query("cluster", "all"), ""
+	if selector != "all" && !h.clusterMgr.IsSelector(selector) {
+		selector, source = "all", selector
+	}
+	clients, err := h.clusterMgr.SelectClients(c.Context(), selector)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	scopes := newScopeCache(c, h.clusterMgr)
+	for id := range clients {
+		if !scopes.get(id).Any(auth.RoleViewer) {
+			delete(clients, id)
+		}
+	}
 	if len(clients) < 2 {
 		return c.JSON([]k8s.CrossClusterBackup{})
 	}
@@ -153,9 +179,13 @@ func (h *CrossClusterHandler) SharedBackups(c *fiber.Ctx) error {
 	// Step 4: Filter backups to only those stored in shared BSLs
 	var results []k8s.CrossClusterBackup
 	for result := range backupsCh {
+		if source != "" && result.clusterID != source {
+			continue
+		}
 		for _, backup := range result.backups {
 			key := clusterBSLName{clusterID: result.clusterID, bslName: backup.StorageLocation}
-			if sharedBSLNames[key] && backup.Phase == "Completed" {
+			visible := backupVisible(scopes.get(result.clusterID), backup.IncludedNamespaces)
+			if sharedBSLNames[key] && backup.Phase == "Completed" && visible {
 				results = append(results, k8s.CrossClusterBackup{
 					BackupResponse:    backup,
 					SourceClusterID:   result.clusterID,
@@ -171,7 +201,10 @@ func (h *CrossClusterHandler) SharedBackups(c *fiber.Ctx) error {
 	return c.JSON(results)
 }
 
-// CreateCrossClusterRestore creates a restore on the target cluster from a source cluster backup.
+// CreateCrossClusterRestore creates a restore on the target cluster from a
+// source cluster backup. When the target first needs a read-only storage
+// location for the backup, syncing it can take minutes: the restore then
+// runs as a background job, returned with 202 Accepted.
 func (h *CrossClusterHandler) CreateCrossClusterRestore(c *fiber.Ctx) error {
 	var req k8s.CrossClusterRestoreRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -188,6 +221,11 @@ func (h *CrossClusterHandler) CreateCrossClusterRestore(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "source and target clusters must be different"})
 	}
 
+	source, target := clusterScope(c, h.clusterMgr, req.SourceClusterID), clusterScope(c, h.clusterMgr, req.TargetClusterID)
+	if !source.Any(auth.RoleViewer) || !target.Any(auth.RoleOperator) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": errNoClusterAccess.Error()})
+	}
+
 	// Validate clusters exist and are connected
 	sourceClient, err := h.clusterMgr.GetClient(req.SourceClusterID)
 	if err != nil {
@@ -204,6 +242,12 @@ func (h *CrossClusterHandler) CreateCrossClusterRestore(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("backup %s not found on source cluster", req.BackupName)})
 	}
+	if err := validateRestore(source, target, backup, req.CreateRestoreRequest); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := validateResources(target, auth.RoleOperator, req.IncludedResources, req.IncludeClusterResources, targetClient.ClusterScopedResources); err != nil {
+		return errorResponse(c, err)
+	}
 
 	// Verify shared BSL between source and target
 	sourceBSLs, err := sourceClient.ListBackupStorageLocations(c.Context())
@@ -231,16 +275,19 @@ func (h *CrossClusterHandler) CreateCrossClusterRestore(c *fiber.Ctx) error {
 	// Check if target has a BSL pointing to the same storage
 	hasSharedBSL := false
 	for _, bsl := range targetBSLs {
-		if bsl.Provider == sourceBSL.Provider && bsl.Bucket == sourceBSL.Bucket && bsl.Prefix == sourceBSL.Prefix {
+		if bsl.SharesStorage(*sourceBSL) {
 			hasSharedBSL = true
 			break
 		}
 	}
-	if !hasSharedBSL {
+	if !hasSharedBSL && req.ProvisionLocation == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "target cluster does not have a BSL pointing to the same storage as the source backup",
 		})
 	}
+	if !hasSharedBSL {
+		return h.provisionRestore(c, targetClient, target, req)
+	}
 
 	// Create restore on target cluster
 	restore, err := targetClient.CreateRestore(c.Context(), req.CreateRestoreRequest)
@@ -262,6 +309,96 @@ func (h *CrossClusterHandler) CreateCrossClusterRestore(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(restore)
 }
 
+// provisionRestore starts a job that mirrors the source BSL on the target as
+// a read-only one, waits for the backup to sync through it and restores it.
+// A retried request returns the job still running for the same restore
+// instead of starting another one.
+func (h *CrossClusterHandler) provisionRestore(c *fiber.Ctx, targetClient *k8s.Client, targetScope auth.Scope, req k8s.CrossClusterRestoreRequest) error {
+	// Creating storage locations is otherwise an admin-only setting
+	if !targetScope.Can(auth.RoleAdmin, "") {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "provisioning a storage location requires the admin role on the target cluster",
+		})
+	}
+
+	opts := *req.ProvisionLocation
+	if opts.Name != "" {
+		if errs := validation.IsDNS1123Subdomain(opts.Name); len(errs) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid storage location name: " + errs[0]})
+		}
+	}
+	if opts.Credential != "" {
+		if errs := validation.IsDNS1123Subdomain(opts.Credential); len(errs) > 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid credential secret name: " + errs[0]})
+		}
+	}
+	syncTimeout := defaultSyncTimeout.String()
+	if opts.SyncTimeout != "" {
+		if d, err := time.ParseDuration(opts.SyncTimeout); err != nil || d <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "syncTimeout must be a positive duration"})
+		}
+		syncTimeout = opts.SyncTimeout
+	}
+
+	if job := h.pendingRestore(c, req); job != nil {
+		return c.Status(fiber.StatusAccepted).JSON(job)
+	}
+	// The job adopts an existing restore of its name, which must be its own
+	if req.Name != "" {
+		if _, err := targetClient.GetRestore(c.Context(), req.Name); err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": fmt.Sprintf("restore %s already exists on the target cluster", req.Name),
+			})
+		}
+	}
+
+	restore := req.CreateRestoreRequest
+	job, err := h.runner.Submit(c.Context(), migration.CreateJobRequest{
+		SourceClusterID: req.SourceClusterID,
+		TargetClusterID: req.TargetClusterID,
+		// The namespaces decide who sees the job, as for migrations
+		Spec: migration.Spec{
+			IncludedNamespaces: restore.IncludedNamespaces,
+			NamespaceMapping:   restore.NamespaceMapping,
+			SyncTimeout:        syncTimeout,
+		},
+		Restore:           &restore,
+		ProvisionLocation: &opts,
+	}, username(c))
+	if err != nil {
+		h.logger.Error("Failed to start cross-cluster restore job",
+			zap.String("target", req.TargetClusterID), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start restore: " + err.Error()})
+	}
+	h.logger.Info("Cross-cluster restore job started",
+		zap.String("job", job.ID),
+		zap.String("source", req.SourceClusterID),
+		zap.String("target", req.TargetClusterID),
+		zap.String("backup", req.BackupName),
+		zap.String("user", username(c)))
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// pendingRestore returns the unfinished job restoring the same backup
+// between the same clusters, under the same restore name if one was given.
+func (h *CrossClusterHandler) pendingRestore(c *fiber.Ctx, req k8s.CrossClusterRestoreRequest) *migration.Job {
+	jobs, err := h.runner.Store().List(c.Context())
+	if err != nil {
+		h.logger.Warn("Failed to list jobs for duplicate restores", zap.Error(err))
+		return nil
+	}
+	for _, job := range jobs {
+		if job.Restore != nil && !job.Phase.Terminal() &&
+			job.SourceClusterID == req.SourceClusterID &&
+			job.TargetClusterID == req.TargetClusterID &&
+			job.Restore.BackupName == req.BackupName &&
+			job.Restore.Name == req.Name {
+			return job
+		}
+	}
+	return nil
+}
+
 func (h *CrossClusterHandler) getClusterNames(ctx context.Context) map[string]string {
 	names := make(map[string]string)
 	summaries, err := h.clusterMgr.ListClusters(ctx)
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"context"
 	"sync"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
 	"github.com/klinux/velero-dashboard/internal/k8s"
 	"go.uber.org/zap"
@@ -18,38 +20,56 @@ func NewDashboardHandler(clusterMgr *cluster.Manager, logger *zap.Logger) *Dashb
 	return &DashboardHandler{clusterMgr: clusterMgr, logger: logger}
 }
 
-func (h *DashboardHandler) getClient(c *fiber.Ctx) (*k8s.Client, error) {
-	clusterID := c.Query("cluster", "")
-	if clusterID != "" {
-		return h.clusterMgr.GetClient(clusterID)
+func (h *DashboardHandler) getClient(c *fiber.Ctx, role string) (*scopedClient, error) {
+	return resolveClient(c, h.clusterMgr, role)
+}
+
+// visibleStats counts what scope lets the caller see on a cluster.
+func visibleStats(ctx context.Context, client *k8s.Client, scope auth.Scope) (*k8s.DashboardStats, error) {
+	if scope.Can(auth.RoleViewer, "") {
+		return client.GetDashboardStats(ctx)
 	}
-	return h.clusterMgr.GetDefaultClient(c.Context())
+	in := func(keep func(auth.Scope, []string) bool) func([]string) bool {
+		return func(namespaces []string) bool { return keep(scope, namespaces) }
+	}
+	return client.GetDashboardStatsFor(ctx, k8s.StatsFilter{
+		Backups:   in(backupVisible),
+		Restores:  in(canView),
+		Schedules: in(canView),
+	})
 }
 
 func (h *DashboardHandler) Stats(c *fiber.Ctx) error {
-	// When cluster=all, aggregate stats from all connected clusters
-	if c.Query("cluster") == "all" {
-		return h.aggregatedStats(c)
+	// When cluster=all or a label selector (cluster=env=prod), aggregate
+	// stats from all matching connected clusters
+	if q := c.Query("cluster"); q == "all" || h.clusterMgr.IsSelector(q) {
+		return h.aggregatedStats(c, q)
 	}
 
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
-	stats, err := client.GetDashboardStats(c.Context())
+	result, err := visibleStats(c.Context(), client.Client, client.scope)
 	if err != nil {
 		h.logger.Error("Failed to get dashboard stats", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(stats)
+	return c.JSON(result)
 }
 
-func (h *DashboardHandler) aggregatedStats(c *fiber.Ctx) error {
-	clients := h.clusterMgr.GetAllClients()
+func (h *DashboardHandler) aggregatedStats(c *fiber.Ctx, selector string) error {
+	clients, err := h.clusterMgr.SelectClients(c.Context(), selector)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	scopes := newScopeCache(c, h.clusterMgr)
+	for id := range clients {
+		if !scopes.get(id).Any(auth.RoleViewer) {
+			delete(clients, id)
+		}
+	}
 	if len(clients) == 0 {
 		return c.JSON(&k8s.DashboardStats{})
 	}
@@ -64,9 +84,9 @@ func (h *DashboardHandler) aggregatedStats(c *fiber.Ctx) error {
 
 	for id, client := range clients {
 		wg.Add(1)
-		go func(clusterID string, cl *k8s.Client) {
+		go func(clusterID string, cl *k8s.Client, scope auth.Scope) {
 			defer wg.Done()
-			stats, err := cl.GetDashboardStats(c.Context())
+			stats, err := visibleStats(c.Context(), cl, scope)
 			if err != nil {
 				h.logger.Warn("Failed to get stats from cluster",
 					zap.String("cluster", clusterID),
@@ -75,7 +95,7 @@ func (h *DashboardHandler) aggregatedStats(c *fiber.Ctx) error {
 				return
 			}
 			results <- result{stats: stats}
-		}(id, client)
+		}(id, client, scopes.get(id))
 	}
 
 	go func() {
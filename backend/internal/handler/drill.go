@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/drill"
+	"go.uber.org/zap"
+)
+
+// DrillHandler handles scheduled restore drills.
+type DrillHandler struct {
+	clusterMgr *cluster.Manager
+	runner     *drill.Runner
+	logger     *zap.Logger
+}
+
+// NewDrillHandler creates a new drill handler.
+func NewDrillHandler(clusterMgr *cluster.Manager, runner *drill.Runner, logger *zap.Logger) *DrillHandler {
+	return &DrillHandler{clusterMgr: clusterMgr, runner: runner, logger: logger}
+}
+
+// drillResponse is a drill with its schedule state and latest run.
+type drillResponse struct {
+	*drill.Drill
+	NextRun *time.Time `json:"nextRun,omitempty"`
+	Running bool       `json:"running"`
+	LastRun *drill.Run `json:"lastRun,omitempty"`
+}
+
+func (h *DrillHandler) response(c *fiber.Ctx, d *drill.Drill) drillResponse {
+	resp := drillResponse{Drill: d, NextRun: h.runner.NextRun(d.ID), Running: h.runner.Running(d.ID)}
+	runs, err := h.runner.Store().ListRuns(c.Context(), d.ID, 1)
+	if err != nil {
+		h.logger.Warn("Failed to get last drill run", zap.String("drill", d.ID), zap.Error(err))
+	} else if len(runs) > 0 {
+		resp.LastRun = runs[0]
+	}
+	return resp
+}
+
+// List returns all drills.
+func (h *DrillHandler) List(c *fiber.Ctx) error {
+	drills, err := h.runner.Store().ListDrills(c.Context())
+	if err != nil {
+		h.logger.Error("Failed to list drills", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list drills"})
+	}
+	scopes := newScopeCache(c, h.clusterMgr)
+	resp := make([]drillResponse, 0, len(drills))
+	for _, d := range drills {
+		if authorizeDrill(scopes, d, auth.RoleViewer) == nil {
+			resp = append(resp, h.response(c, d))
+		}
+	}
+	return c.JSON(resp)
+}
+
+// Runs returns the run history of a drill, newest first.
+func (h *DrillHandler) Runs(c *fiber.Ctx) error {
+	d, err := h.runner.Store().GetDrill(c.Context(), c.Params("id"))
+	if errors.Is(err, drill.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		h.logger.Error("Failed to get drill", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get drill"})
+	}
+	if err := authorizeDrill(newScopeCache(c, h.clusterMgr), d, auth.RoleViewer); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+	return h.listRuns(c, d.ID, nil)
+}
+
+// AllRuns returns the runs of all drills, newest first.
+func (h *DrillHandler) AllRuns(c *fiber.Ctx) error {
+	drills, err := h.runner.Store().ListDrills(c.Context())
+	if err != nil {
+		h.logger.Error("Failed to list drills", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list drill runs"})
+	}
+	scopes := newScopeCache(c, h.clusterMgr)
+	visible := make(map[string]bool, len(drills))
+	for _, d := range drills {
+		visible[d.ID] = authorizeDrill(scopes, d, auth.RoleViewer) == nil
+	}
+	return h.listRuns(c, "", visible)
+}
+
+// listRuns responds with the runs of drillID, or of all drills when it is
+// empty. A non-nil visible map leaves out the runs of other drills.
+func (h *DrillHandler) listRuns(c *fiber.Ctx, drillID string, visible map[string]bool) error {
+	limit := c.QueryInt("limit", 0)
+	if limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must not be negative"})
+	}
+	runs, err := h.runner.Store().ListRuns(c.Context(), drillID, limit)
+	if err != nil {
+		h.logger.Error("Failed to list drill runs", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list drill runs"})
+	}
+	resp := make([]*drill.Run, 0, len(runs))
+	for _, run := range runs {
+		if visible == nil || visible[run.DrillID] {
+			resp = append(resp, run)
+		}
+	}
+	return c.JSON(resp)
+}
+
+// Create saves and schedules a drill.
+func (h *DrillHandler) Create(c *fiber.Ctx) error {
+	var req drill.DrillRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := authorizeDrill(newScopeCache(c, h.clusterMgr), requestedDrill(req), auth.RoleOperator); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	d, err := h.runner.Create(c.Context(), req, username(c))
+	if err != nil {
+		h.logger.Error("Failed to create drill", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create drill"})
+	}
+	h.logger.Info("Drill created", zap.String("name", d.Name), zap.String("user", username(c)))
+	return c.Status(fiber.StatusCreated).JSON(h.response(c, d))
+}
+
+// Update replaces a drill's settings.
+func (h *DrillHandler) Update(c *fiber.Ctx) error {
+	var req drill.DrillRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := h.validate(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if status, err := h.authorizeExisting(c, c.Params("id")); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := authorizeDrill(newScopeCache(c, h.clusterMgr), requestedDrill(req), auth.RoleOperator); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	d, err := h.runner.Update(c.Context(), c.Params("id"), req)
+	if errors.Is(err, drill.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		h.logger.Error("Failed to update drill", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update drill"})
+	}
+	h.logger.Info("Drill updated", zap.String("name", d.Name), zap.String("user", username(c)))
+	return c.JSON(h.response(c, d))
+}
+
+// Delete removes a drill. Its run history is kept.
+func (h *DrillHandler) Delete(c *fiber.Ctx) error {
+	if status, err := h.authorizeExisting(c, c.Params("id")); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	err := h.runner.Delete(c.Context(), c.Params("id"))
+	switch {
+	case errors.Is(err, drill.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, drill.ErrRunning):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		h.logger.Error("Failed to delete drill", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete drill"})
+	}
+
+	h.logger.Info("Drill deleted", zap.String("id", c.Params("id")), zap.String("user", username(c)))
+	return c.JSON(fiber.Map{"message": "Drill deleted"})
+}
+
+// Run starts a drill now, outside its schedule.
+func (h *DrillHandler) Run(c *fiber.Ctx) error {
+	if status, err := h.authorizeExisting(c, c.Params("id")); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	run, err := h.runner.Trigger(c.Context(), c.Params("id"), username(c))
+	switch {
+	case errors.Is(err, drill.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, drill.ErrRunning):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		h.logger.Error("Failed to start drill", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start drill"})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(run)
+}
+
+// validate checks the request and that both clusters are connected. A
+// cluster that disconnects later fails the runs that need it.
+func (h *DrillHandler) validate(req *drill.DrillRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if _, err := h.clusterMgr.GetClient(req.SourceClusterID); err != nil {
+		return errors.New("source cluster not found or not connected")
+	}
+	if _, err := h.clusterMgr.GetClient(req.TargetClusterID); err != nil {
+		return errors.New("target cluster not found or not connected")
+	}
+	return nil
+}
+
+// authorizeExisting checks that the caller may manage the drill id. On
+// failure it returns the HTTP status to respond with.
+func (h *DrillHandler) authorizeExisting(c *fiber.Ctx, id string) (int, error) {
+	d, err := h.runner.Store().GetDrill(c.Context(), id)
+	if errors.Is(err, drill.ErrNotFound) {
+		return fiber.StatusNotFound, err
+	}
+	if err != nil {
+		h.logger.Error("Failed to get drill", zap.Error(err))
+		return fiber.StatusInternalServerError, errors.New("failed to get drill")
+	}
+	if err := authorizeDrill(newScopeCache(c, h.clusterMgr), d, auth.RoleOperator); err != nil {
+		return fiber.StatusForbidden, err
+	}
+	return 0, nil
+}
+
+// requestedDrill returns the clusters and namespaces a drill request refers
+// to, for authorization.
+func requestedDrill(req drill.DrillRequest) *drill.Drill {
+	return &drill.Drill{
+		SourceClusterID:  req.SourceClusterID,
+		TargetClusterID:  req.TargetClusterID,
+		NamespaceMapping: req.NamespaceMapping,
+	}
+}
+
+// authorizeDrill checks that the caller may read the namespaces a drill
+// restores from its source cluster and holds role on the scratch namespaces
+// it restores into on the target.
+func authorizeDrill(scopes *scopeCache, d *drill.Drill, role string) error {
+	if sources := d.SourceNamespaces(); !scopes.get(d.SourceClusterID).CanAll(auth.RoleViewer, sources) {
+		return fmt.Errorf("source cluster: %w", permissionError(auth.RoleViewer, sources))
+	}
+	if scratch := d.ScratchNamespaces(); !scopes.get(d.TargetClusterID).CanAll(role, scratch) {
+		return fmt.Errorf("target cluster: %w", permissionError(role, scratch))
+	}
+	return nil
+}
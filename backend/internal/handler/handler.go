@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"github.com/klinux/velero-dashboard/internal/account"
 	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/drill"
+	"github.com/klinux/velero-dashboard/internal/migration"
 	"github.com/klinux/velero-dashboard/internal/notification"
+	"github.com/klinux/velero-dashboard/internal/session"
+	"github.com/klinux/velero-dashboard/internal/token"
 	"github.com/klinux/velero-dashboard/internal/ws"
 	"go.uber.org/zap"
 )
@@ -18,9 +23,15 @@ type Handlers struct {
 	WS           *WSHandler
 	Notification *NotificationHandler
 	CrossCluster *CrossClusterHandler
+	Bundle       *BundleHandler
+	Migration    *MigrationHandler
+	Drill        *DrillHandler
+	Token        *TokenHandler
+	Session      *SessionHandler
+	User         *UserHandler
 }
 
-func NewHandlers(clusterMgr *cluster.Manager, hub *ws.Hub, notifMgr *notification.Manager, logger *zap.Logger) *Handlers {
+func NewHandlers(clusterMgr *cluster.Manager, hub *ws.Hub, notifMgr *notification.Manager, migrations *migration.Runner, drills *drill.Runner, tokens *token.Manager, sessions *session.Manager, users *account.Manager, logger *zap.Logger) *Handlers {
 	return &Handlers{
 		Backup:       NewBackupHandler(clusterMgr, logger),
 		Restore:      NewRestoreHandler(clusterMgr, logger),
@@ -28,8 +39,14 @@ func NewHandlers(clusterMgr *cluster.Manager, hub *ws.Hub, notifMgr *notificatio
 		Settings:     NewSettingsHandler(clusterMgr, logger),
 		Dashboard:    NewDashboardHandler(clusterMgr, logger),
 		Cluster:      NewClusterHandler(clusterMgr, logger),
-		WS:           NewWSHandler(hub, logger),
+		WS:           NewWSHandler(hub, clusterMgr, logger),
 		Notification: NewNotificationHandler(notifMgr, logger),
-		CrossCluster: NewCrossClusterHandler(clusterMgr, logger),
+		CrossCluster: NewCrossClusterHandler(clusterMgr, migrations, logger),
+		Bundle:       NewBundleHandler(clusterMgr, notifMgr, logger),
+		Migration:    NewMigrationHandler(clusterMgr, migrations, logger),
+		Drill:        NewDrillHandler(clusterMgr, drills, logger),
+		Token:        NewTokenHandler(clusterMgr, tokens, logger),
+		Session:      NewSessionHandler(sessions, tokens, logger),
+		User:         NewUserHandler(users, sessions, tokens, logger),
 	}
 }
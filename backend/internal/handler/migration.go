@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"github.com/klinux/velero-dashboard/internal/migration"
+	"go.uber.org/zap"
+)
+
+// MigrationHandler handles cross-cluster migration jobs.
+type MigrationHandler struct {
+	clusterMgr *cluster.Manager
+	runner     *migration.Runner
+	logger     *zap.Logger
+}
+
+// NewMigrationHandler creates a new migration handler.
+func NewMigrationHandler(clusterMgr *cluster.Manager, runner *migration.Runner, logger *zap.Logger) *MigrationHandler {
+	return &MigrationHandler{clusterMgr: clusterMgr, runner: runner, logger: logger}
+}
+
+// List returns all migration jobs, newest first.
+func (h *MigrationHandler) List(c *fiber.Ctx) error {
+	jobs, err := h.runner.Store().List(c.Context())
+	if err != nil {
+		h.logger.Error("Failed to list migration jobs", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list migration jobs"})
+	}
+	scopes := newScopeCache(c, h.clusterMgr)
+	visible := make([]*migration.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if authorizeMigration(scopes, job.SourceClusterID, job.TargetClusterID, job.Spec, auth.RoleViewer) == nil {
+			visible = append(visible, job)
+		}
+	}
+	return c.JSON(visible)
+}
+
+// Get returns a migration job with its step history.
+func (h *MigrationHandler) Get(c *fiber.Ctx) error {
+	job, err := h.runner.Store().Get(c.Context(), c.Params("id"))
+	if errors.Is(err, migration.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		h.logger.Error("Failed to get migration job", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get migration job"})
+	}
+	if err := authorizeMigration(newScopeCache(c, h.clusterMgr), job.SourceClusterID, job.TargetClusterID, job.Spec, auth.RoleViewer); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(job)
+}
+
+// Create starts a migration job.
+func (h *MigrationHandler) Create(c *fiber.Ctx) error {
+	var req migration.CreateJobRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	scopes := newScopeCache(c, h.clusterMgr)
+	if err := authorizeMigration(scopes, req.SourceClusterID, req.TargetClusterID, req.Spec, auth.RoleOperator); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Steps wait for clusters that disconnect mid-job, but a job shouldn't
+	// start against clusters that aren't there
+	sourceClient, err := h.clusterMgr.GetClient(req.SourceClusterID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "source cluster not found or not connected"})
+	}
+	targetClient, err := h.clusterMgr.GetClient(req.TargetClusterID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "target cluster not found or not connected"})
+	}
+	if err := validateResources(scopes.get(req.SourceClusterID), auth.RoleOperator, req.IncludedResources, nil, sourceClient.ClusterScopedResources); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := validateResources(scopes.get(req.TargetClusterID), auth.RoleOperator, req.IncludedResources, nil, targetClient.ClusterScopedResources); err != nil {
+		return errorResponse(c, err)
+	}
+
+	job, err := h.runner.Submit(c.Context(), req, username(c))
+	if err != nil {
+		h.logger.Error("Failed to create migration job", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create migration job: " + err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(job)
+}
+
+// Cancel stops a running migration job.
+func (h *MigrationHandler) Cancel(c *fiber.Ctx) error {
+	job, err := h.runner.Store().Get(c.Context(), c.Params("id"))
+	if err == nil {
+		if err := authorizeMigration(newScopeCache(c, h.clusterMgr), job.SourceClusterID, job.TargetClusterID, job.Spec, auth.RoleOperator); err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		err = h.runner.Cancel(c.Context(), job.ID)
+	}
+	switch {
+	case errors.Is(err, migration.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, migration.ErrTerminal):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		h.logger.Error("Failed to cancel migration job", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to cancel migration job"})
+	}
+
+	h.logger.Info("Migration job cancelled", zap.String("id", c.Params("id")), zap.String("user", username(c)))
+	return c.JSON(fiber.Map{"message": "Migration job cancelled"})
+}
+
+// authorizeMigration checks that the caller holds role on the namespaces a
+// migration backs up on its source cluster and restores into on its target.
+func authorizeMigration(scopes *scopeCache, sourceID, targetID string, spec migration.Spec, role string) error {
+	if !scopes.get(sourceID).CanAll(role, spec.IncludedNamespaces) {
+		return fmt.Errorf("source cluster: %w", permissionError(role, spec.IncludedNamespaces))
+	}
+	targets := k8s.RestoreTargets(spec.IncludedNamespaces, spec.NamespaceMapping)
+	if !scopes.get(targetID).CanAll(role, targets) {
+		return fmt.Errorf("target cluster: %w", permissionError(role, targets))
+	}
+	return nil
+}
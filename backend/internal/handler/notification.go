@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/klinux/velero-dashboard/internal/notification"
 	"go.uber.org/zap"
@@ -73,6 +75,9 @@ func (h *NotificationHandler) UpdateWebhook(c *fiber.Ctx) error {
 	}
 
 	if err := h.notifMgr.Store().Update(c.Context(), id, req); err != nil {
+		if errors.Is(err, notification.ErrManaged) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		h.logger.Error("Failed to update webhook", zap.String("id", id), zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update webhook"})
 	}
@@ -88,6 +93,9 @@ func (h *NotificationHandler) DeleteWebhook(c *fiber.Ctx) error {
 	}
 
 	if err := h.notifMgr.Store().Delete(c.Context(), id); err != nil {
+		if errors.Is(err, notification.ErrManaged) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		h.logger.Error("Failed to delete webhook", zap.String("id", id), zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete webhook"})
 	}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
 	"github.com/klinux/velero-dashboard/internal/k8s"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 type RestoreHandler struct {
@@ -16,21 +20,14 @@ func NewRestoreHandler(clusterMgr *cluster.Manager, logger *zap.Logger) *Restore
 	return &RestoreHandler{clusterMgr: clusterMgr, logger: logger}
 }
 
-func (h *RestoreHandler) getClient(c *fiber.Ctx) (*k8s.Client, error) {
-	clusterID := c.Query("cluster", "")
-	if clusterID != "" {
-		return h.clusterMgr.GetClient(clusterID)
-	}
-	return h.clusterMgr.GetDefaultClient(c.Context())
+func (h *RestoreHandler) getClient(c *fiber.Ctx, role string) (*scopedClient, error) {
+	return resolveClient(c, h.clusterMgr, role)
 }
 
 func (h *RestoreHandler) List(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	restores, err := client.ListRestores(c.Context())
@@ -38,16 +35,13 @@ func (h *RestoreHandler) List(c *fiber.Ctx) error {
 		h.logger.Error("Failed to list restores", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(restores)
+	return c.JSON(visibleTo(restores, client.scope, canView, restoreNamespaces))
 }
 
 func (h *RestoreHandler) Get(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
@@ -56,16 +50,16 @@ func (h *RestoreHandler) Get(c *fiber.Ctx) error {
 		h.logger.Error("Failed to get restore", zap.String("name", name), zap.Error(err))
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
+	if targets := restoreNamespaces(*restore); !client.scope.CanAll(auth.RoleViewer, targets) {
+		return forbidden(c, auth.RoleViewer, targets)
+	}
 	return c.JSON(restore)
 }
 
 func (h *RestoreHandler) Create(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleOperator)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	var req k8s.CreateRestoreRequest
@@ -75,6 +69,18 @@ func (h *RestoreHandler) Create(c *fiber.Ctx) error {
 	if req.BackupName == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "backupName is required"})
 	}
+	if !client.scope.Can(auth.RoleOperator, "") {
+		backup, err := client.GetBackup(c.Context(), req.BackupName)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := validateRestore(client.scope, client.scope, backup, req); err != nil {
+			return errorResponse(c, err)
+		}
+		if err := validateResources(client.scope, auth.RoleOperator, req.IncludedResources, req.IncludeClusterResources, client.ClusterScopedResources); err != nil {
+			return errorResponse(c, err)
+		}
+	}
 
 	restore, err := client.CreateRestore(c.Context(), req)
 	if err != nil {
@@ -85,15 +91,21 @@ func (h *RestoreHandler) Create(c *fiber.Ctx) error {
 }
 
 func (h *RestoreHandler) Delete(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleOperator)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
+	if !client.scope.Can(auth.RoleOperator, "") {
+		restore, err := client.GetRestore(c.Context(), name)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if targets := restoreNamespaces(*restore); !client.scope.CanAll(auth.RoleOperator, targets) {
+			return forbidden(c, auth.RoleOperator, targets)
+		}
+	}
 	if err := client.DeleteRestore(c.Context(), name); err != nil {
 		h.logger.Error("Failed to delete restore", zap.String("name", name), zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -102,15 +114,21 @@ func (h *RestoreHandler) Delete(c *fiber.Ctx) error {
 }
 
 func (h *RestoreHandler) Logs(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
+	if !client.scope.Can(auth.RoleViewer, "") {
+		restore, err := client.GetRestore(c.Context(), name)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if targets := restoreNamespaces(*restore); !client.scope.CanAll(auth.RoleViewer, targets) {
+			return forbidden(c, auth.RoleViewer, targets)
+		}
+	}
 	logs, err := client.GetRestoreLogs(c.Context(), name)
 	if err != nil {
 		h.logger.Error("Failed to get restore logs", zap.String("name", name), zap.Error(err))
@@ -118,3 +136,24 @@ func (h *RestoreHandler) Logs(c *fiber.Ctx) error {
 	}
 	return c.SendString(logs)
 }
+
+// validateRestore checks that the caller may read the namespaces a restore
+// takes from backup on the source cluster and write the namespaces it
+// restores into on the target. Without included namespaces a restore takes
+// everything in the backup, which only callers holding viewer on all of it
+// may rely on. Namespace mappings must name plain namespaces.
+func validateRestore(source, target auth.Scope, backup *k8s.BackupResponse, req k8s.CreateRestoreRequest) *fiber.Error {
+	sources := req.IncludedNamespaces
+	if len(sources) == 0 {
+		sources = backup.IncludedNamespaces
+	}
+	if err := validateNamespaces(source, auth.RoleViewer, sources); err != nil {
+		return err
+	}
+	for from, to := range req.NamespaceMapping {
+		if len(validation.IsDNS1123Label(from)) > 0 || len(validation.IsDNS1123Label(to)) > 0 {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid namespace mapping %q: %q", from, to))
+		}
+	}
+	return validateNamespaces(target, auth.RoleOperator, k8s.RestoreTargets(sources, req.NamespaceMapping))
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/k8s"
+)
+
+func TestValidateRestore(t *testing.T) {
+	clusterAdmin := auth.Scope{Role: auth.RoleAdmin}
+	wholeCluster := &k8s.BackupResponse{Name: "nightly"}
+	payments := &k8s.BackupResponse{Name: "payments", IncludedNamespaces: []string{"payments"}}
+
+	tests := []struct {
+		name           string
+		source, target auth.Scope
+		backup         *k8s.BackupResponse
+		req            k8s.CreateRestoreRequest
+		want           int // 0 for accepted
+	}{
+		{"whole-cluster backup by default", paymentsScope, paymentsScope, wholeCluster,
+			k8s.CreateRestoreRequest{}, fiber.StatusBadRequest},
+		{"own namespace from a whole-cluster backup", paymentsScope, paymentsScope, wholeCluster,
+			k8s.CreateRestoreRequest{IncludedNamespaces: []string{"payments"}}, 0},
+		{"backup namespaces by default", paymentsScope, paymentsScope, payments,
+			k8s.CreateRestoreRequest{}, 0},
+		{"foreign namespace", paymentsScope, paymentsScope, wholeCluster,
+			k8s.CreateRestoreRequest{IncludedNamespaces: []string{"shop"}}, fiber.StatusForbidden},
+		{"mapping into an own namespace", paymentsScope, paymentsScope, payments,
+			k8s.CreateRestoreRequest{NamespaceMapping: map[string]string{"payments": "payments-restore"}}, 0},
+		{"mapping into a foreign namespace", paymentsScope, paymentsScope, payments,
+			k8s.CreateRestoreRequest{NamespaceMapping: map[string]string{"payments": "shop"}}, fiber.StatusForbidden},
+		{"mapping to a pattern", paymentsScope, paymentsScope, payments,
+			k8s.CreateRestoreRequest{NamespaceMapping: map[string]string{"payments": "payments-*"}}, fiber.StatusBadRequest},
+		{"cluster admin source, team target", clusterAdmin, paymentsScope, wholeCluster,
+			k8s.CreateRestoreRequest{}, fiber.StatusBadRequest},
+		{"cluster admin on both", clusterAdmin, clusterAdmin, wholeCluster,
+			k8s.CreateRestoreRequest{NamespaceMapping: map[string]string{"payments": "shop"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			if err := validateRestore(tt.source, tt.target, tt.backup, tt.req); err != nil {
+				got = err.Code
+			}
+			if got != tt.want {
+				t.Errorf("validateRestore = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
 	"github.com/klinux/velero-dashboard/internal/k8s"
 	"go.uber.org/zap"
@@ -16,21 +17,14 @@ func NewScheduleHandler(clusterMgr *cluster.Manager, logger *zap.Logger) *Schedu
 	return &ScheduleHandler{clusterMgr: clusterMgr, logger: logger}
 }
 
-func (h *ScheduleHandler) getClient(c *fiber.Ctx) (*k8s.Client, error) {
-	clusterID := c.Query("cluster", "")
-	if clusterID != "" {
-		return h.clusterMgr.GetClient(clusterID)
-	}
-	return h.clusterMgr.GetDefaultClient(c.Context())
+func (h *ScheduleHandler) getClient(c *fiber.Ctx, role string) (*scopedClient, error) {
+	return resolveClient(c, h.clusterMgr, role)
 }
 
 func (h *ScheduleHandler) List(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	schedules, err := client.ListSchedules(c.Context())
@@ -38,16 +32,13 @@ func (h *ScheduleHandler) List(c *fiber.Ctx) error {
 		h.logger.Error("Failed to list schedules", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(schedules)
+	return c.JSON(visibleTo(schedules, client.scope, canView, scheduleNamespaces))
 }
 
 func (h *ScheduleHandler) Get(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
@@ -56,16 +47,16 @@ func (h *ScheduleHandler) Get(c *fiber.Ctx) error {
 		h.logger.Error("Failed to get schedule", zap.String("name", name), zap.Error(err))
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
+	if !client.scope.CanAll(auth.RoleViewer, schedule.IncludedNamespaces) {
+		return forbidden(c, auth.RoleViewer, schedule.IncludedNamespaces)
+	}
 	return c.JSON(schedule)
 }
 
 func (h *ScheduleHandler) Create(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleOperator)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	var req k8s.CreateScheduleRequest
@@ -75,6 +66,12 @@ func (h *ScheduleHandler) Create(c *fiber.Ctx) error {
 	if req.Name == "" || req.Schedule == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name and schedule are required"})
 	}
+	if err := validateNamespaces(client.scope, auth.RoleOperator, req.IncludedNamespaces); err != nil {
+		return errorResponse(c, err)
+	}
+	if err := validateResources(client.scope, auth.RoleOperator, req.IncludedResources, req.IncludeClusterResources, client.ClusterScopedResources); err != nil {
+		return errorResponse(c, err)
+	}
 
 	schedule, err := client.CreateSchedule(c.Context(), req)
 	if err != nil {
@@ -85,12 +82,9 @@ func (h *ScheduleHandler) Create(c *fiber.Ctx) error {
 }
 
 func (h *ScheduleHandler) Update(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleOperator)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
@@ -98,6 +92,20 @@ func (h *ScheduleHandler) Update(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
+	if !client.scope.Can(auth.RoleOperator, "") {
+		if status, err := h.authorizeExisting(c, client, name); err != nil {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+		// Omitted namespaces leave the schedule's as they are
+		if req.IncludedNamespaces != nil {
+			if err := validateNamespaces(client.scope, auth.RoleOperator, req.IncludedNamespaces); err != nil {
+				return errorResponse(c, err)
+			}
+		}
+		if err := validateResources(client.scope, auth.RoleOperator, req.IncludedResources, req.IncludeClusterResources, client.ClusterScopedResources); err != nil {
+			return errorResponse(c, err)
+		}
+	}
 
 	schedule, err := client.UpdateSchedule(c.Context(), name, req)
 	if err != nil {
@@ -108,18 +116,33 @@ func (h *ScheduleHandler) Update(c *fiber.Ctx) error {
 }
 
 func (h *ScheduleHandler) Delete(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleOperator)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	name := c.Params("name")
+	if !client.scope.Can(auth.RoleOperator, "") {
+		if status, err := h.authorizeExisting(c, client, name); err != nil {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
 	if err := client.DeleteSchedule(c.Context(), name); err != nil {
 		h.logger.Error("Failed to delete schedule", zap.String("name", name), zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"message": "schedule deleted"})
 }
+
+// authorizeExisting checks that the caller may change the schedule name. On
+// failure it returns the HTTP status to respond with.
+func (h *ScheduleHandler) authorizeExisting(c *fiber.Ctx, client *scopedClient, name string) (int, error) {
+	schedule, err := client.GetSchedule(c.Context(), name)
+	if err != nil {
+		return fiber.StatusNotFound, err
+	}
+	if !client.scope.CanAll(auth.RoleOperator, schedule.IncludedNamespaces) {
+		return fiber.StatusForbidden, permissionError(auth.RoleOperator, schedule.IncludedNamespaces)
+	}
+	return 0, nil
+}
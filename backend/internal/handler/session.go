@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/session"
+	"github.com/klinux/velero-dashboard/internal/token"
+	"go.uber.org/zap"
+)
+
+// SessionHandler lists and revokes browser sessions.
+type SessionHandler struct {
+	sessions *session.Manager
+	tokens   *token.Manager
+	logger   *zap.Logger
+}
+
+// NewSessionHandler creates a new session handler.
+func NewSessionHandler(sessions *session.Manager, tokens *token.Manager, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{sessions: sessions, tokens: tokens, logger: logger}
+}
+
+// sessionResponse is a session as the API lists it.
+type sessionResponse struct {
+	*session.Session
+	Current bool `json:"current"`
+}
+
+// List returns the caller's sessions, or every session for global admins.
+// GET /api/sessions
+func (h *SessionHandler) List(c *fiber.Ctx) error {
+	user := auth.GetUser(c)
+	owner := user.Username
+	if isTokenAdmin(user) {
+		owner = c.Query("user", "")
+	}
+	sessions, err := h.sessions.List(c.Context(), owner)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list sessions"})
+	}
+	resp := make([]sessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, sessionResponse{Session: s.Public(), Current: s.ID == user.SessionID})
+	}
+	return c.JSON(resp)
+}
+
+// Revoke ends a session. Users revoke their own sessions, global admins any.
+// DELETE /api/sessions/:id
+func (h *SessionHandler) Revoke(c *fiber.Ctx) error {
+	user := auth.GetUser(c)
+	s, err := h.sessions.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, session.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		h.logger.Error("Failed to get session", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get session"})
+	}
+	if !isTokenAdmin(user) && s.User.Username != user.Username {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": session.ErrNotFound.Error()})
+	}
+
+	if err := h.sessions.Revoke(c.Context(), s.ID); err != nil && !errors.Is(err, session.ErrNotFound) {
+		h.logger.Error("Failed to revoke session", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke session"})
+	}
+	h.logger.Info("Session revoked", zap.String("session", s.ID),
+		zap.String("username", s.User.Username), zap.String("by", user.Username))
+	return c.JSON(fiber.Map{"message": "session revoked"})
+}
+
+// RevokeUser ends every session of a user and revokes their personal API
+// tokens, e.g. when offboarding them. Service tokens they created are kept.
+// DELETE /api/users/:username/sessions
+func (h *SessionHandler) RevokeUser(c *fiber.Ctx) error {
+	username := c.Params("username")
+	sessions, err := h.sessions.RevokeUser(c.Context(), username)
+	if err != nil {
+		h.logger.Error("Failed to revoke sessions", zap.String("username", username), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+	tokens, err := h.tokens.RevokeOwner(c.Context(), username)
+	if err != nil {
+		h.logger.Error("Failed to revoke tokens", zap.String("username", username), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke tokens"})
+	}
+	h.logger.Info("User sessions revoked", zap.String("username", username),
+		zap.Int("sessions", sessions), zap.Int("tokens", tokens), zap.String("by", auth.GetUser(c).Username))
+	return c.JSON(fiber.Map{"sessions": sessions, "tokens": tokens})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
 	"github.com/klinux/velero-dashboard/internal/cluster"
 	"github.com/klinux/velero-dashboard/internal/k8s"
 	"go.uber.org/zap"
@@ -18,21 +19,17 @@ func NewSettingsHandler(clusterMgr *cluster.Manager, logger *zap.Logger) *Settin
 	return &SettingsHandler{clusterMgr: clusterMgr, logger: logger}
 }
 
-func (h *SettingsHandler) getClient(c *fiber.Ctx) (*k8s.Client, error) {
-	clusterID := c.Query("cluster", "")
-	if clusterID != "" {
-		return h.clusterMgr.GetClient(clusterID)
-	}
-	return h.clusterMgr.GetDefaultClient(c.Context())
+// getClient resolves the request's cluster. Storage locations belong to the
+// whole cluster, so changing them needs the admin role on all of it, not
+// just on some namespaces.
+func (h *SettingsHandler) getClient(c *fiber.Ctx, role string) (*scopedClient, error) {
+	return resolveClient(c, h.clusterMgr, role)
 }
 
 func (h *SettingsHandler) BackupLocations(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 	locations, err := client.ListBackupStorageLocations(c.Context())
 	if err != nil {
@@ -43,12 +40,12 @@ func (h *SettingsHandler) BackupLocations(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) CreateBackupLocation(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleAdmin)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
+	}
+	if !client.scope.Can(auth.RoleAdmin, "") {
+		return forbidden(c, auth.RoleAdmin, nil)
 	}
 
 	var req k8s.CreateBackupStorageLocationRequest
@@ -93,12 +90,12 @@ func (h *SettingsHandler) CreateBackupLocation(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) DeleteBackupLocation(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleAdmin)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
+	}
+	if !client.scope.Can(auth.RoleAdmin, "") {
+		return forbidden(c, auth.RoleAdmin, nil)
 	}
 
 	name := c.Params("name")
@@ -116,12 +113,12 @@ func (h *SettingsHandler) DeleteBackupLocation(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) UpdateBackupLocation(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleAdmin)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
+	}
+	if !client.scope.Can(auth.RoleAdmin, "") {
+		return forbidden(c, auth.RoleAdmin, nil)
 	}
 
 	name := c.Params("name")
@@ -149,12 +146,9 @@ func (h *SettingsHandler) UpdateBackupLocation(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) SnapshotLocations(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	locations, err := client.ListVolumeSnapshotLocations(c.Context())
@@ -166,12 +160,12 @@ func (h *SettingsHandler) SnapshotLocations(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) CreateSnapshotLocation(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleAdmin)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
+	}
+	if !client.scope.Can(auth.RoleAdmin, "") {
+		return forbidden(c, auth.RoleAdmin, nil)
 	}
 
 	var req k8s.CreateVolumeSnapshotLocationRequest
@@ -211,12 +205,12 @@ func (h *SettingsHandler) CreateSnapshotLocation(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) DeleteSnapshotLocation(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleAdmin)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
+	}
+	if !client.scope.Can(auth.RoleAdmin, "") {
+		return forbidden(c, auth.RoleAdmin, nil)
 	}
 
 	name := c.Params("name")
@@ -234,12 +228,12 @@ func (h *SettingsHandler) DeleteSnapshotLocation(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) UpdateSnapshotLocation(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleAdmin)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
+	}
+	if !client.scope.Can(auth.RoleAdmin, "") {
+		return forbidden(c, auth.RoleAdmin, nil)
 	}
 
 	name := c.Params("name")
@@ -262,12 +256,9 @@ func (h *SettingsHandler) UpdateSnapshotLocation(c *fiber.Ctx) error {
 }
 
 func (h *SettingsHandler) ServerInfo(c *fiber.Ctx) error {
-	client, err := h.getClient(c)
+	client, err := h.getClient(c, auth.RoleViewer)
 	if err != nil {
-		h.logger.Error("Failed to get cluster client", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cluster not found or not connected",
-		})
+		return clientError(c, h.logger, err)
 	}
 
 	return c.JSON(fiber.Map{
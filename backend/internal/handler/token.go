@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/token"
+	"go.uber.org/zap"
+)
+
+// TokenHandler manages long-lived API tokens.
+type TokenHandler struct {
+	clusterMgr *cluster.Manager
+	tokens     *token.Manager
+	logger     *zap.Logger
+}
+
+// NewTokenHandler creates a new token handler.
+func NewTokenHandler(clusterMgr *cluster.Manager, tokens *token.Manager, logger *zap.Logger) *TokenHandler {
+	return &TokenHandler{clusterMgr: clusterMgr, tokens: tokens, logger: logger}
+}
+
+// isTokenAdmin reports whether user may see and revoke everyone's tokens.
+func isTokenAdmin(user *auth.UserInfo) bool {
+	return user.TokenID == "" && user.GlobalRole() == auth.RoleAdmin
+}
+
+// List returns the caller's tokens, or every token for global admins.
+// GET /api/tokens
+func (h *TokenHandler) List(c *fiber.Ctx) error {
+	user := auth.GetUser(c)
+	owner := user.Username
+	if isTokenAdmin(user) {
+		owner = ""
+	}
+	tokens, err := h.tokens.List(c.Context(), owner)
+	if err != nil {
+		h.logger.Error("Failed to list tokens", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list tokens"})
+	}
+	resp := make([]*token.Token, 0, len(tokens))
+	for _, t := range tokens {
+		resp = append(resp, t.Public())
+	}
+	return c.JSON(resp)
+}
+
+// Create issues a token and returns its secret, which is shown only once.
+// Personal tokens may hold up to the caller's highest role, service tokens
+// up to their global role.
+// POST /api/tokens
+func (h *TokenHandler) Create(c *fiber.Ctx) error {
+	user := auth.GetUser(c)
+	if user.TokenID != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API tokens cannot create tokens"})
+	}
+
+	var req token.CreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	grantable := auth.GetAuthorizer(c).MaxRole(user)
+	if req.Kind == token.KindService {
+		grantable = user.GlobalRole()
+	}
+	scopes := newScopeCache(c, h.clusterMgr)
+	for _, id := range req.Clusters {
+		if _, err := h.clusterMgr.GetCluster(c.Context(), id); err != nil || !scopes.get(id).Any(auth.RoleViewer) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unknown cluster: " + id})
+		}
+	}
+
+	t, secret, err := h.tokens.Create(c.Context(), user, grantable, req)
+	if errors.Is(err, token.ErrRoleTooHigh) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+	if errors.Is(err, token.ErrNameTaken) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		h.logger.Error("Failed to create token", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create token"})
+	}
+	h.logger.Info("API token created", zap.String("token", t.ID), zap.String("name", t.Name),
+		zap.String("kind", string(t.Kind)), zap.String("role", t.Role), zap.String("by", user.Username))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"token": t.Public(), "secret": secret})
+}
+
+// Revoke deletes a token. Users revoke their own tokens, global admins any.
+// DELETE /api/tokens/:id
+func (h *TokenHandler) Revoke(c *fiber.Ctx) error {
+	user := auth.GetUser(c)
+	t, err := h.tokens.Get(c.Context(), c.Params("id"))
+	if errors.Is(err, token.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		h.logger.Error("Failed to get token", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get token"})
+	}
+	if !isTokenAdmin(user) && t.CreatedBy != user.Username && t.Owner.Username != user.Username {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": token.ErrNotFound.Error()})
+	}
+
+	if err := h.tokens.Revoke(c.Context(), t.ID); err != nil && !errors.Is(err, token.ErrNotFound) {
+		h.logger.Error("Failed to revoke token", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke token"})
+	}
+	h.logger.Info("API token revoked", zap.String("token", t.ID), zap.String("by", user.Username))
+	return c.JSON(fiber.Map{"message": "token revoked"})
+}
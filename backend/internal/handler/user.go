@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klinux/velero-dashboard/internal/account"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/session"
+	"github.com/klinux/velero-dashboard/internal/token"
+	"go.uber.org/zap"
+)
+
+// UserHandler administers the local users of basic auth mode.
+type UserHandler struct {
+	users    *account.Manager
+	sessions *session.Manager
+	tokens   *token.Manager
+	logger   *zap.Logger
+}
+
+// NewUserHandler creates a new user handler.
+func NewUserHandler(users *account.Manager, sessions *session.Manager, tokens *token.Manager, logger *zap.Logger) *UserHandler {
+	return &UserHandler{users: users, sessions: sessions, tokens: tokens, logger: logger}
+}
+
+// List returns all local users.
+// GET /api/users
+func (h *UserHandler) List(c *fiber.Ctx) error {
+	users, err := h.users.List(c.Context())
+	if err != nil {
+		h.logger.Error("Failed to list users", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list users"})
+	}
+	resp := make([]*account.User, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, u.Public())
+	}
+	return c.JSON(resp)
+}
+
+// Create adds a user with a temporary password.
+// POST /api/users
+func (h *UserHandler) Create(c *fiber.Ctx) error {
+	var req account.CreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	u, err := h.users.Create(c.Context(), req)
+	if err != nil {
+		return h.fail(c, err, "create")
+	}
+	h.logger.Info("User created", zap.String("username", u.Username), zap.String("role", u.Role),
+		zap.String("by", auth.GetUser(c).Username))
+	return c.Status(fiber.StatusCreated).JSON(u.Public())
+}
+
+// Update changes a user's email, role or disabled flag. Disabling a user
+// signs them out and revokes their personal API tokens; a new role applies
+// to their tokens on the next request.
+// PATCH /api/users/:username
+func (h *UserHandler) Update(c *fiber.Ctx) error {
+	var req account.UpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	u, err := h.users.Update(c.Context(), c.Params("username"), req)
+	if err != nil {
+		return h.fail(c, err, "update")
+	}
+	h.tokens.ForgetOwner(u.Username)
+	if u.Disabled {
+		h.signOut(c, u.Username, true)
+	}
+	h.logger.Info("User updated", zap.String("username", u.Username), zap.String("role", u.Role),
+		zap.Bool("disabled", u.Disabled), zap.String("by", auth.GetUser(c).Username))
+	return c.JSON(u.Public())
+}
+
+// ResetPassword sets a temporary password the user must change on their
+// next login, and signs them out. Their personal API tokens stop working
+// until they chose a new password.
+// POST /api/users/:username/password
+func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	u, err := h.users.ResetPassword(c.Context(), c.Params("username"), req.Password)
+	if err != nil {
+		return h.fail(c, err, "reset password of")
+	}
+	h.tokens.ForgetOwner(u.Username)
+	h.signOut(c, u.Username, false)
+	h.logger.Info("User password reset", zap.String("username", u.Username), zap.String("by", auth.GetUser(c).Username))
+	return c.JSON(u.Public())
+}
+
+// Delete removes a user, signs them out and revokes their personal API
+// tokens.
+// DELETE /api/users/:username
+func (h *UserHandler) Delete(c *fiber.Ctx) error {
+	username := c.Params("username")
+	if err := h.users.Delete(c.Context(), username); err != nil {
+		return h.fail(c, err, "delete")
+	}
+	h.tokens.ForgetOwner(username)
+	h.signOut(c, username, true)
+	h.logger.Info("User deleted", zap.String("username", username), zap.String("by", auth.GetUser(c).Username))
+	return c.JSON(fiber.Map{"message": "user deleted"})
+}
+
+// signOut ends a user's sessions and, with revokeTokens, their personal API
+// tokens. Failures are only logged: the user change itself is done.
+func (h *UserHandler) signOut(c *fiber.Ctx, username string, revokeTokens bool) {
+	if _, err := h.sessions.RevokeUser(c.Context(), username); err != nil {
+		h.logger.Warn("Failed to revoke sessions", zap.String("username", username), zap.Error(err))
+	}
+	if revokeTokens {
+		if _, err := h.tokens.RevokeOwner(c.Context(), username); err != nil {
+			h.logger.Warn("Failed to revoke tokens", zap.String("username", username), zap.Error(err))
+		}
+	}
+}
+
+// fail maps user manager errors to responses.
+func (h *UserHandler) fail(c *fiber.Ctx, err error, action string) error {
+	switch {
+	case errors.Is(err, account.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, account.ErrExists), errors.Is(err, account.ErrLastAdmin),
+		errors.Is(err, account.ErrSeeded), errors.Is(err, account.ErrManaged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, auth.ErrPasswordPolicy):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.logger.Error("Failed to "+action+" user", zap.Error(err))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to " + action + " user"})
+}
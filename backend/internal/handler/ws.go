@@ -1,27 +1,68 @@
 package handler
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/gofiber/contrib/websocket"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/cluster"
+	"github.com/klinux/velero-dashboard/internal/drill"
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"github.com/klinux/velero-dashboard/internal/migration"
 	"github.com/klinux/velero-dashboard/internal/ws"
 	"go.uber.org/zap"
 )
 
 type WSHandler struct {
-	hub    *ws.Hub
-	logger *zap.Logger
+	hub        *ws.Hub
+	clusterMgr *cluster.Manager
+	logger     *zap.Logger
 }
 
-func NewWSHandler(hub *ws.Hub, logger *zap.Logger) *WSHandler {
-	return &WSHandler{hub: hub, logger: logger}
+func NewWSHandler(hub *ws.Hub, clusterMgr *cluster.Manager, logger *zap.Logger) *WSHandler {
+	return &WSHandler{hub: hub, clusterMgr: clusterMgr, logger: logger}
 }
 
+// Handle serves an authenticated WebSocket connection. The auth middleware
+// ran during the upgrade, so the user and token expiry are in the locals.
 func (h *WSHandler) Handle(conn *websocket.Conn) {
-	h.hub.Register(conn)
+	user, _ := conn.Locals(auth.UserContextKey).(*auth.UserInfo)
+	if user == nil {
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "not authenticated"))
+		_ = conn.Close()
+		return
+	}
+
+	authz, _ := conn.Locals(auth.AuthorizerContextKey).(*auth.Authorizer)
+	scopes := newConnScopes(func() *scopeCache {
+		return newUserScopeCache(authz, user, h.clusterMgr)
+	})
+	h.hub.Register(conn, scopes.visible)
 	defer func() {
 		h.hub.Unregister(conn)
 		_ = conn.Close()
 	}()
 
+	// A connection must not outlive the token it was opened with; the client
+	// reconnects with its current token
+	if expires, ok := conn.Locals(auth.ExpiresContextKey).(time.Time); ok && !expires.IsZero() {
+		timer := time.AfterFunc(time.Until(expires), func() {
+			h.logger.Debug("Closing WebSocket with expired token", zap.String("user", user.Username))
+			h.hub.Close(conn, websocket.ClosePolicyViolation, "token expired")
+		})
+		defer timer.Stop()
+	}
+
+	// Nor the session or token behind it, which may be revoked earlier
+	if recheck, ok := conn.Locals(auth.RecheckContextKey).(auth.Recheck); ok && recheck != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go h.recheck(conn, user, recheck, done)
+	}
+
 	// Keep connection alive by reading (client may send pings)
 	for {
 		_, _, err := conn.ReadMessage()
@@ -30,3 +71,81 @@ func (h *WSHandler) Handle(conn *websocket.Conn) {
 		}
 	}
 }
+
+// recheck re-validates a connection's credentials every wsScopeTTL until done
+// is closed, and closes the connection once they are rejected.
+func (h *WSHandler) recheck(conn *websocket.Conn, user *auth.UserInfo, recheck auth.Recheck, done <-chan struct{}) {
+	ticker := time.NewTicker(wsScopeTTL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err := recheck(ctx)
+			cancel()
+			if err != nil {
+				h.logger.Debug("Closing WebSocket with revoked credentials", zap.String("user", user.Username), zap.Error(err))
+				h.hub.Close(conn, websocket.ClosePolicyViolation, "credentials revoked")
+				return
+			}
+		}
+	}
+}
+
+// wsScopeTTL bounds how long a connection reuses the scopes it resolved, so
+// cluster label changes reach open connections without resolving scopes for
+// every event.
+const wsScopeTTL = 30 * time.Second
+
+// connScopes caches a connection's scopes for wsScopeTTL. The hub may filter
+// events of several broadcasts at once.
+type connScopes struct {
+	fresh func() *scopeCache
+	now   func() time.Time
+
+	mu      sync.Mutex
+	cache   *scopeCache
+	expires time.Time
+}
+
+func newConnScopes(fresh func() *scopeCache) *connScopes {
+	return &connScopes{fresh: fresh, now: time.Now}
+}
+
+func (s *connScopes) visible(event interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.cache == nil || !now.Before(s.expires) {
+		s.cache = s.fresh()
+		s.expires = now.Add(wsScopeTTL)
+	}
+	return eventVisible(s.cache, event)
+}
+
+// eventVisible reports whether the user behind scopes may receive event. It
+// mirrors the filtering of the corresponding list endpoints.
+func eventVisible(scopes *scopeCache, event interface{}) bool {
+	e, ok := event.(k8s.WSEvent)
+	if !ok {
+		return false
+	}
+	scope := scopes.get(e.ClusterID)
+
+	switch r := e.Resource.(type) {
+	case k8s.BackupResponse:
+		return backupVisible(scope, backupNamespaces(r))
+	case k8s.RestoreResponse:
+		return canView(scope, restoreNamespaces(r))
+	case k8s.ScheduleResponse:
+		return canView(scope, scheduleNamespaces(r))
+	case *migration.Job:
+		return authorizeMigration(scopes, r.SourceClusterID, r.TargetClusterID, r.Spec, auth.RoleViewer) == nil
+	case *drill.Run:
+		return scopes.get(r.SourceClusterID).Any(auth.RoleViewer) && scope.Any(auth.RoleViewer)
+	}
+	return scope.Any(auth.RoleViewer)
+}
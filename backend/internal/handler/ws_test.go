@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"github.com/klinux/velero-dashboard/internal/drill"
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"github.com/klinux/velero-dashboard/internal/migration"
+)
+
+func TestConnScopesCachedForTTL(t *testing.T) {
+	authz := auth.NewAuthorizer(nil)
+	user := &auth.UserInfo{Username: "alice", Role: auth.RoleViewer}
+	resolved := 0
+	scopes := newConnScopes(func() *scopeCache {
+		resolved++
+		return newUserScopeCache(authz, user, nil)
+	})
+	now := time.Now()
+	scopes.now = func() time.Time { return now }
+
+	event := k8s.WSEvent{ClusterID: "prod", Resource: k8s.ScheduleResponse{Name: "daily"}}
+	for i := 0; i < 3; i++ {
+		if !scopes.visible(event) {
+			t.Fatal("expected a global viewer to see the event")
+		}
+	}
+	if resolved != 1 {
+		t.Errorf("scopes resolved %d times within the TTL, want 1", resolved)
+	}
+
+	now = now.Add(wsScopeTTL)
+	scopes.visible(event)
+	if resolved != 2 {
+		t.Errorf("scopes resolved %d times after the TTL, want 2", resolved)
+	}
+}
+
+func TestEventVisible(t *testing.T) {
+	// The payments team sees its namespaces on prod and nothing on staging.
+	scopes := &scopeCache{scopes: map[string]auth.Scope{
+		"prod":    paymentsScope,
+		"staging": {Role: auth.RoleNone},
+	}}
+	payments := migration.Spec{IncludedNamespaces: []string{"payments"}}
+
+	tests := []struct {
+		name  string
+		event interface{}
+		want  bool
+	}{
+		{"backup of an own namespace", k8s.WSEvent{ClusterID: "prod",
+			Resource: k8s.BackupResponse{IncludedNamespaces: []string{"payments", "shop"}}}, true},
+		{"whole-cluster backup", k8s.WSEvent{ClusterID: "prod", Resource: k8s.BackupResponse{}}, true},
+		{"backup of a foreign namespace", k8s.WSEvent{ClusterID: "prod",
+			Resource: k8s.BackupResponse{IncludedNamespaces: []string{"shop"}}}, false},
+		{"backup on a foreign cluster", k8s.WSEvent{ClusterID: "staging",
+			Resource: k8s.BackupResponse{IncludedNamespaces: []string{"payments"}}}, false},
+		{"restore into an own namespace", k8s.WSEvent{ClusterID: "prod",
+			Resource: k8s.RestoreResponse{IncludedNamespaces: []string{"payments"}}}, true},
+		{"whole-cluster restore", k8s.WSEvent{ClusterID: "prod", Resource: k8s.RestoreResponse{}}, false},
+		{"restore mapped into a foreign namespace", k8s.WSEvent{ClusterID: "prod",
+			Resource: k8s.RestoreResponse{IncludedNamespaces: []string{"payments"},
+				NamespaceMapping: map[string]string{"payments": "shop"}}}, false},
+		{"schedule of a foreign namespace", k8s.WSEvent{ClusterID: "prod",
+			Resource: k8s.ScheduleResponse{IncludedNamespaces: []string{"shop"}}}, false},
+		{"migration within own namespaces", k8s.WSEvent{ClusterID: "prod",
+			Resource: &migration.Job{SourceClusterID: "prod", TargetClusterID: "prod", Spec: payments}}, true},
+		{"migration to a foreign cluster", k8s.WSEvent{ClusterID: "staging",
+			Resource: &migration.Job{SourceClusterID: "prod", TargetClusterID: "staging", Spec: payments}}, false},
+		{"migration mapped into a foreign namespace", k8s.WSEvent{ClusterID: "prod",
+			Resource: &migration.Job{SourceClusterID: "prod", TargetClusterID: "prod",
+				Spec: migration.Spec{IncludedNamespaces: []string{"payments"}, NamespaceMapping: map[string]string{"payments": "shop"}}}}, false},
+		{"drill run on own clusters", k8s.WSEvent{ClusterID: "prod",
+			Resource: &drill.Run{SourceClusterID: "prod", TargetClusterID: "prod"}}, true},
+		{"drill run from a foreign cluster", k8s.WSEvent{ClusterID: "prod",
+			Resource: &drill.Run{SourceClusterID: "staging", TargetClusterID: "prod"}}, false},
+		{"cluster event on a foreign cluster", k8s.WSEvent{ClusterID: "staging"}, false},
+		{"not an event", "payments", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventVisible(scopes, tt.event); got != tt.want {
+				t.Errorf("eventVisible = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package jsonstore persists records as JSON documents, either one Secret or
+// ConfigMap per record in Kubernetes or one row per record in SQLite. Stores
+// of self-contained records, such as login sessions, are built on it.
+package jsonstore
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/migrate"
+)
+
+// Config describes how records of type T are named, labelled and ordered.
+type Config[T any] struct {
+	// Kind names a record in error messages, e.g. "token".
+	Kind string
+	// Key returns the identifier a record is stored under.
+	Key func(*T) string
+	// Less orders List results.
+	Less func(a, b *T) bool
+	// ErrNotFound is returned for unknown keys. ErrExists, if set, is
+	// returned when creating a key that is already stored.
+	ErrNotFound error
+	ErrExists   error
+	// Index, if set, returns the value ListBy selects records on.
+	Index func(*T) string
+
+	// Component is the app.kubernetes.io/component label of the objects.
+	Component string
+	// Prefix starts each object name.
+	Prefix string
+	// Name, if set, maps a key to the object name suffix, for keys that
+	// aren't valid object names.
+	Name func(key string) string
+	// DataKey holds the JSON document; it defaults to "data.json".
+	DataKey string
+	// Secret stores records in Secrets rather than ConfigMaps.
+	Secret bool
+	// IndexLabel is the label holding Index on each object.
+	IndexLabel string
+
+	// Schema names the migration history of the SQLite table.
+	Schema string
+	// Migrations create Table with KeyColumn, data and TimeColumn columns,
+	// and IndexColumn if Index is set.
+	Migrations []migrate.Migration
+	Table      string
+	KeyColumn  string
+	// IndexColumn is the column holding Index.
+	IndexColumn string
+	// CreatedAt fills TimeColumn, which defaults to "created_at".
+	CreatedAt  func(*T) time.Time
+	TimeColumn string
+}
+
+func (c *Config[T]) objectName(key string) string {
+	if c.Name != nil {
+		return c.Prefix + c.Name(key)
+	}
+	return c.Prefix + key
+}
+
+func (c *Config[T]) dataKey() string {
+	if c.DataKey == "" {
+		return "data.json"
+	}
+	return c.DataKey
+}
+
+func (c *Config[T]) timeColumn() string {
+	if c.TimeColumn == "" {
+		return "created_at"
+	}
+	return c.TimeColumn
+}
+
+func (c *Config[T]) errExists(key string) error {
+	if c.ErrExists != nil {
+		return c.ErrExists
+	}
+	return fmt.Errorf("%s %q already exists", c.Kind, key)
+}
+
+func (c *Config[T]) sort(records []*T) {
+	sort.SliceStable(records, func(i, j int) bool { return c.Less(records[i], records[j]) })
+}
@@ -0,0 +1,244 @@
+package jsonstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// conflictRetry bounds retries of writes that lost a resourceVersion race.
+var conflictRetry = wait.Backoff{
+	Steps:    10,
+	Duration: 10 * time.Millisecond,
+	Factor:   1.5,
+	Jitter:   0.5,
+}
+
+// K8sStore stores each record in its own Secret or ConfigMap, so records
+// can grow without hitting the object size limit together.
+type K8sStore[T any] struct {
+	clientset kubernetes.Interface
+	namespace string
+	cfg       Config[T]
+	logger    *zap.Logger
+}
+
+// NewK8sStore creates a store in namespace using the in-cluster config.
+func NewK8sStore[T any](namespace string, cfg Config[T], logger *zap.Logger) (*K8sStore[T], error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return NewK8sStoreForClient(clientset, namespace, cfg, logger), nil
+}
+
+// NewK8sStoreForClient creates a store in namespace using clientset.
+func NewK8sStoreForClient[T any](clientset kubernetes.Interface, namespace string, cfg Config[T], logger *zap.Logger) *K8sStore[T] {
+	return &K8sStore[T]{clientset: clientset, namespace: namespace, cfg: cfg, logger: logger}
+}
+
+// Clientset returns the client the store reads and writes with.
+func (s *K8sStore[T]) Clientset() kubernetes.Interface {
+	return s.clientset
+}
+
+// Namespace returns the namespace holding the records.
+func (s *K8sStore[T]) Namespace() string {
+	return s.namespace
+}
+
+func (s *K8sStore[T]) selector() string {
+	return "app.kubernetes.io/name=velero-dashboard,app.kubernetes.io/component=" + s.cfg.Component
+}
+
+func (s *K8sStore[T]) Create(ctx context.Context, record *T) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", s.cfg.Kind, err)
+	}
+	key := s.cfg.Key(record)
+	meta := metav1.ObjectMeta{
+		Name: s.cfg.objectName(key),
+		Labels: map[string]string{
+			"app.kubernetes.io/name":      "velero-dashboard",
+			"app.kubernetes.io/component": s.cfg.Component,
+		},
+	}
+	if s.cfg.Index != nil {
+		meta.Labels[s.cfg.IndexLabel] = s.cfg.Index(record)
+	}
+	if s.cfg.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: meta,
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{s.cfg.dataKey(): data},
+		}
+		_, err = s.clientset.CoreV1().Secrets(s.namespace).Create(ctx, secret, metav1.CreateOptions{})
+	} else {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: meta,
+			Data:       map[string]string{s.cfg.dataKey(): string(data)},
+		}
+		_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Create(ctx, cm, metav1.CreateOptions{})
+	}
+	if apierrors.IsAlreadyExists(err) {
+		return s.cfg.errExists(key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.cfg.Kind, err)
+	}
+	return nil
+}
+
+func (s *K8sStore[T]) Get(ctx context.Context, key string) (*T, error) {
+	data, err := s.read(ctx, s.cfg.objectName(key))
+	if apierrors.IsNotFound(err) {
+		return nil, s.cfg.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", s.cfg.Kind, err)
+	}
+	var record T
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", s.cfg.Kind, err)
+	}
+	return &record, nil
+}
+
+func (s *K8sStore[T]) List(ctx context.Context) ([]*T, error) {
+	return s.list(ctx, s.selector())
+}
+
+// ListBy returns the records whose Index is value.
+func (s *K8sStore[T]) ListBy(ctx context.Context, value string) ([]*T, error) {
+	return s.list(ctx, s.selector()+","+s.cfg.IndexLabel+"="+value)
+}
+
+func (s *K8sStore[T]) list(ctx context.Context, selector string) ([]*T, error) {
+	type item struct {
+		name string
+		data []byte
+	}
+	var items []item
+	opts := metav1.ListOptions{LabelSelector: selector}
+	if s.cfg.Secret {
+		list, err := s.clientset.CoreV1().Secrets(s.namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %ss: %w", s.cfg.Kind, err)
+		}
+		for i := range list.Items {
+			items = append(items, item{list.Items[i].Name, list.Items[i].Data[s.cfg.dataKey()]})
+		}
+	} else {
+		list, err := s.clientset.CoreV1().ConfigMaps(s.namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %ss: %w", s.cfg.Kind, err)
+		}
+		for i := range list.Items {
+			items = append(items, item{list.Items[i].Name, []byte(list.Items[i].Data[s.cfg.dataKey()])})
+		}
+	}
+
+	records := make([]*T, 0, len(items))
+	for _, it := range items {
+		var record T
+		if err := json.Unmarshal(it.data, &record); err != nil {
+			s.logger.Error("Failed to decode "+s.cfg.Kind, zap.String("object", it.name), zap.Error(err))
+			continue
+		}
+		records = append(records, &record)
+	}
+	s.cfg.sort(records)
+	return records, nil
+}
+
+func (s *K8sStore[T]) Update(ctx context.Context, record *T) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", s.cfg.Kind, err)
+	}
+	name := s.cfg.objectName(s.cfg.Key(record))
+	err = retry.RetryOnConflict(conflictRetry, func() error {
+		if s.cfg.Secret {
+			secret, err := s.clientset.CoreV1().Secrets(s.namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[s.cfg.dataKey()] = data
+			_, err = s.clientset.CoreV1().Secrets(s.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		}
+		cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[s.cfg.dataKey()] = string(data)
+		_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return s.cfg.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", s.cfg.Kind, err)
+	}
+	return nil
+}
+
+func (s *K8sStore[T]) Delete(ctx context.Context, key string) error {
+	var err error
+	if s.cfg.Secret {
+		err = s.clientset.CoreV1().Secrets(s.namespace).Delete(ctx, s.cfg.objectName(key), metav1.DeleteOptions{})
+	} else {
+		err = s.clientset.CoreV1().ConfigMaps(s.namespace).Delete(ctx, s.cfg.objectName(key), metav1.DeleteOptions{})
+	}
+	if apierrors.IsNotFound(err) {
+		return s.cfg.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", s.cfg.Kind, err)
+	}
+	return nil
+}
+
+func (s *K8sStore[T]) Close() error {
+	return nil
+}
+
+// read returns the JSON document of the named object.
+func (s *K8sStore[T]) read(ctx context.Context, name string) ([]byte, error) {
+	if s.cfg.Secret {
+		secret, err := s.clientset.CoreV1().Secrets(s.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return secret.Data[s.cfg.dataKey()], nil
+	}
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(cm.Data[s.cfg.dataKey()]), nil
+}
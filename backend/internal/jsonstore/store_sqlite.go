@@ -0,0 +1,142 @@
+package jsonstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+// SQLiteStore stores records in a SQLite table as JSON, keyed by
+// Config.KeyColumn.
+type SQLiteStore[T any] struct {
+	db     *sql.DB
+	cfg    Config[T]
+	logger *zap.Logger
+}
+
+// NewSQLiteStore opens dbPath and applies cfg.Migrations.
+func NewSQLiteStore[T any](dbPath string, cfg Config[T], logger *zap.Logger) (*SQLiteStore[T], error) {
+	db, err := sql.Open("sqlite3", dbPath+"?_journal=WAL&_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := migrate.Apply(db, cfg.Schema, cfg.Migrations, logger); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return &SQLiteStore[T]{db: db, cfg: cfg, logger: logger}, nil
+}
+
+func (s *SQLiteStore[T]) Create(_ context.Context, record *T) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", s.cfg.Kind, err)
+	}
+	key := s.cfg.Key(record)
+	columns := []string{s.cfg.KeyColumn, "data", s.cfg.timeColumn()}
+	args := []interface{}{key, string(data), s.cfg.CreatedAt(record).Format(time.RFC3339Nano)}
+	if s.cfg.Index != nil {
+		columns = append(columns, s.cfg.IndexColumn)
+		args = append(args, s.cfg.Index(record))
+	}
+	result, err := s.db.Exec(`INSERT INTO `+s.cfg.Table+` (`+strings.Join(columns, ", ")+`) VALUES (?`+strings.Repeat(", ?", len(columns)-1)+`) ON CONFLICT DO NOTHING`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert %s: %w", s.cfg.Kind, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return s.cfg.errExists(key)
+	}
+	return nil
+}
+
+func (s *SQLiteStore[T]) Get(_ context.Context, key string) (*T, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM `+s.cfg.Table+` WHERE `+s.cfg.KeyColumn+` = ?`, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.cfg.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", s.cfg.Kind, err)
+	}
+	var record T
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", s.cfg.Kind, err)
+	}
+	return &record, nil
+}
+
+func (s *SQLiteStore[T]) List(_ context.Context) ([]*T, error) {
+	return s.list(`SELECT data FROM ` + s.cfg.Table)
+}
+
+// ListBy returns the records whose Index is value.
+func (s *SQLiteStore[T]) ListBy(_ context.Context, value string) ([]*T, error) {
+	return s.list(`SELECT data FROM `+s.cfg.Table+` WHERE `+s.cfg.IndexColumn+` = ?`, value)
+}
+
+func (s *SQLiteStore[T]) list(query string, args ...interface{}) ([]*T, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %ss: %w", s.cfg.Kind, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	records := []*T{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var record T
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			s.logger.Error("Failed to unmarshal "+s.cfg.Kind+" row", zap.Error(err))
+			continue
+		}
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	s.cfg.sort(records)
+	return records, nil
+}
+
+func (s *SQLiteStore[T]) Update(_ context.Context, record *T) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", s.cfg.Kind, err)
+	}
+	result, err := s.db.Exec(`UPDATE `+s.cfg.Table+` SET data = ? WHERE `+s.cfg.KeyColumn+` = ?`, string(data), s.cfg.Key(record))
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", s.cfg.Kind, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return s.cfg.ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore[T]) Delete(_ context.Context, key string) error {
+	result, err := s.db.Exec(`DELETE FROM `+s.cfg.Table+` WHERE `+s.cfg.KeyColumn+` = ?`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", s.cfg.Kind, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return s.cfg.ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore[T]) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,226 @@
+package jsonstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type record struct {
+	ID        string    `json:"id"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var (
+	errNotFound = errors.New("record not found")
+	errExists   = errors.New("record already exists")
+)
+
+var testConfig = Config[record]{
+	Kind:        "record",
+	Key:         func(r *record) string { return r.ID },
+	Less:        func(a, b *record) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	ErrNotFound: errNotFound,
+	ErrExists:   errExists,
+
+	Component: "test-record",
+	Prefix:    "velero-dashboard-record-",
+	Name:      strings.ToLower,
+
+	Schema: "records",
+	Migrations: []migrate.Migration{{
+		Version: 1,
+		Name:    "create records",
+		Up:      migrate.Exec(`CREATE TABLE records (id TEXT PRIMARY KEY, data TEXT NOT NULL, created_at TEXT NOT NULL)`),
+	}},
+	Table:     "records",
+	KeyColumn: "id",
+	CreatedAt: func(r *record) time.Time { return r.CreatedAt },
+}
+
+type store interface {
+	Create(ctx context.Context, r *record) error
+	Get(ctx context.Context, key string) (*record, error)
+	List(ctx context.Context) ([]*record, error)
+	Update(ctx context.Context, r *record) error
+	Delete(ctx context.Context, key string) error
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	secrets := testConfig
+	secrets.Secret = true
+
+	sqlite, err := NewSQLiteStore(filepath.Join(t.TempDir(), "records.db"), testConfig, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlite.Close() })
+
+	for name, s := range map[string]store{
+		"configmap": NewK8sStoreForClient(fake.NewSimpleClientset(), "velero", testConfig, zap.NewNop()),
+		"secret":    NewK8sStoreForClient(fake.NewSimpleClientset(), "velero", secrets, zap.NewNop()),
+		"sqlite":    sqlite,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			first := &record{ID: "One", Value: "a", CreatedAt: now}
+			second := &record{ID: "Two", Value: "b", CreatedAt: now.Add(time.Second)}
+			for _, r := range []*record{second, first} {
+				if err := s.Create(ctx, r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Create(ctx, first); !errors.Is(err, errExists) {
+				t.Errorf("expected errExists, got %v", err)
+			}
+
+			first.Value = "updated"
+			if err := s.Update(ctx, first); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Get(ctx, "One")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Value != "updated" {
+				t.Errorf("record = %+v", got)
+			}
+
+			records, err := s.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 || records[0].ID != "One" || records[1].ID != "Two" {
+				t.Errorf("records = %+v", records)
+			}
+
+			if err := s.Delete(ctx, "One"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Get(ctx, "One"); !errors.Is(err, errNotFound) {
+				t.Errorf("expected errNotFound, got %v", err)
+			}
+			if err := s.Update(ctx, first); !errors.Is(err, errNotFound) {
+				t.Errorf("expected errNotFound, got %v", err)
+			}
+			if err := s.Delete(ctx, "One"); !errors.Is(err, errNotFound) {
+				t.Errorf("expected errNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestK8sStoreObjects(t *testing.T) {
+	ctx := context.Background()
+	cs := fake.NewSimpleClientset()
+	cfg := testConfig
+	cfg.Secret = true
+	s := NewK8sStoreForClient(cs, "velero", cfg, zap.NewNop())
+	if err := s.Create(ctx, &record{ID: "Mixed", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := cs.CoreV1().Secrets("velero").Get(ctx, "velero-dashboard-record-mixed", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Labels["app.kubernetes.io/component"] != "test-record" || len(secret.Data["data.json"]) == 0 {
+		t.Errorf("secret = %+v", secret)
+	}
+}
+
+func TestK8sStoreUpdateRetriesConflicts(t *testing.T) {
+	ctx := context.Background()
+	cs := fake.NewSimpleClientset()
+	s := NewK8sStoreForClient(cs, "velero", testConfig, zap.NewNop())
+	r := &record{ID: "one", Value: "a", CreatedAt: time.Now()}
+	if err := s.Create(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lose the resourceVersion race twice, as if another replica wrote first
+	conflicts := 2
+	cs.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts == 0 {
+			return false, nil, nil
+		}
+		conflicts--
+		return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "velero-dashboard-record-one", errors.New("object was modified"))
+	})
+
+	r.Value = "b"
+	if err := s.Update(ctx, r); err != nil {
+		t.Fatalf("update should retry conflicts: %v", err)
+	}
+	if conflicts != 0 {
+		t.Errorf("conflicts left = %d", conflicts)
+	}
+	if got, _ := s.Get(ctx, "one"); got == nil || got.Value != "b" {
+		t.Errorf("record = %+v", got)
+	}
+}
+
+func TestStoreListBy(t *testing.T) {
+	cfg := testConfig
+	cfg.Index = func(r *record) string { return r.Value }
+	cfg.IndexLabel = "velero-dashboard/value"
+	cfg.Schema = "indexed-records"
+	cfg.Migrations = []migrate.Migration{{
+		Version: 1,
+		Name:    "create indexed records",
+		Up:      migrate.Exec(`CREATE TABLE records (id TEXT PRIMARY KEY, value TEXT NOT NULL, data TEXT NOT NULL, started_at TEXT NOT NULL)`),
+	}}
+	cfg.IndexColumn = "value"
+	cfg.TimeColumn = "started_at"
+
+	sqlite, err := NewSQLiteStore(filepath.Join(t.TempDir(), "records.db"), cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlite.Close() })
+
+	for name, s := range map[string]interface {
+		store
+		ListBy(ctx context.Context, value string) ([]*record, error)
+	}{
+		"configmap": NewK8sStoreForClient(fake.NewSimpleClientset(), "velero", cfg, zap.NewNop()),
+		"sqlite":    sqlite,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			for i, value := range []string{"a", "b", "a"} {
+				r := &record{ID: fmt.Sprintf("r%d", i), Value: value, CreatedAt: now.Add(time.Duration(i) * time.Second)}
+				if err := s.Create(ctx, r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, err := s.ListBy(ctx, "a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 || records[0].ID != "r0" || records[1].ID != "r2" {
+				t.Errorf("records = %+v", records)
+			}
+			if records, _ := s.ListBy(ctx, "c"); len(records) != 0 {
+				t.Errorf("records = %+v", records)
+			}
+		})
+	}
+}
@@ -3,10 +3,12 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -41,11 +43,17 @@ var (
 	ServerStatusRequestGVR = schema.GroupVersionResource{
 		Group: veleroGroup, Version: veleroVersion, Resource: "serverstatusrequests",
 	}
+	DataUploadGVR = schema.GroupVersionResource{
+		Group: veleroGroup, Version: "v2alpha1", Resource: "datauploads",
+	}
 )
 
+const discoveryTimeout = 10 * time.Second
+
 // Client wraps the Kubernetes dynamic client for Velero CRD operations.
 type Client struct {
 	dynamic   dynamic.Interface
+	discovery discovery.DiscoveryInterface
 	namespace string
 	logger    *zap.Logger
 }
@@ -75,8 +83,14 @@ func NewClient(kubeconfigPath, namespace string, logger *zap.Logger) (*Client, e
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
+	discClient, err := newDiscoveryClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
 	return &Client{
 		dynamic:   dynClient,
+		discovery: discClient,
 		namespace: namespace,
 		logger:    logger,
 	}, nil
@@ -104,10 +118,16 @@ func NewClientFromKubeconfig(kubeconfigData []byte, namespace string, logger *za
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
+	discClient, err := newDiscoveryClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
 	logger.Info("Created client from kubeconfig", zap.String("namespace", namespace))
 
 	return &Client{
 		dynamic:   dynClient,
+		discovery: discClient,
 		namespace: namespace,
 		logger:    logger,
 	}, nil
@@ -133,15 +153,29 @@ func NewClientFromToken(apiServer, token, caCert, namespace string, insecureSkip
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
+	discClient, err := newDiscoveryClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
 	logger.Info("Created client from token", zap.String("apiServer", apiServer), zap.String("namespace", namespace))
 
 	return &Client{
 		dynamic:   dynClient,
+		discovery: discClient,
 		namespace: namespace,
 		logger:    logger,
 	}, nil
 }
 
+// newDiscoveryClient creates a discovery client with a short timeout, since
+// discovery calls can't be cancelled through a context.
+func newDiscoveryClient(cfg *rest.Config) (*discovery.DiscoveryClient, error) {
+	discCfg := rest.CopyConfig(cfg)
+	discCfg.Timeout = discoveryTimeout
+	return discovery.NewDiscoveryClientForConfig(discCfg)
+}
+
 // TestConnection verifies cluster connectivity by listing backups
 func (c *Client) TestConnection(ctx context.Context) error {
 	_, err := c.dynamic.Resource(BackupGVR).Namespace(c.namespace).List(ctx, metav1.ListOptions{Limit: 1})
@@ -0,0 +1,177 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ErrVeleroNotInstalled is returned when the cluster doesn't serve the
+// velero.io/v1 API group.
+var ErrVeleroNotInstalled = errors.New("velero CRDs (velero.io/v1) are not installed")
+
+// VeleroCapabilities reports which Velero API kinds a cluster serves.
+type VeleroCapabilities struct {
+	Backups                 bool `json:"backups"`
+	Restores                bool `json:"restores"`
+	Schedules               bool `json:"schedules"`
+	BackupStorageLocations  bool `json:"backupStorageLocations"`
+	VolumeSnapshotLocations bool `json:"volumeSnapshotLocations"`
+	DeleteBackupRequests    bool `json:"deleteBackupRequests"`
+	DownloadRequests        bool `json:"downloadRequests"`
+	ServerStatusRequests    bool `json:"serverStatusRequests"`
+	PodVolumeBackups        bool `json:"podVolumeBackups"`
+	DataUploads             bool `json:"dataUploads"`   // v2alpha1, Velero 1.12+
+	DataDownloads           bool `json:"dataDownloads"` // v2alpha1, Velero 1.12+
+}
+
+// DefaultVeleroNamespace is used when the Velero deployment can't be located.
+const DefaultVeleroNamespace = "velero"
+
+// DeploymentGVR is used to locate the Velero server deployment.
+var DeploymentGVR = schema.GroupVersionResource{
+	Group: "apps", Version: "v1", Resource: "deployments",
+}
+
+// DetectVeleroNamespace returns the namespace of the Velero server
+// deployment. When Velero is installed more than once, the default
+// namespace wins, otherwise the first one alphabetically.
+func (c *Client) DetectVeleroNamespace(ctx context.Context) (string, error) {
+	list, err := c.dynamic.Resource(DeploymentGVR).List(ctx, metav1.ListOptions{
+		FieldSelector: "metadata.name=velero",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	var namespaces []string
+	for _, item := range list.Items {
+		if item.GetName() != "velero" {
+			continue
+		}
+		if item.GetNamespace() == DefaultVeleroNamespace {
+			return DefaultVeleroNamespace, nil
+		}
+		namespaces = append(namespaces, item.GetNamespace())
+	}
+	if len(namespaces) == 0 {
+		return "", fmt.Errorf("velero deployment not found")
+	}
+	sort.Strings(namespaces)
+	return namespaces[0], nil
+}
+
+// DetectCapabilities uses discovery to verify that the Velero CRDs the
+// dashboard depends on are installed and reports every kind that is served.
+func (c *Client) DetectCapabilities() (*VeleroCapabilities, error) {
+	v1, err := c.discovery.ServerResourcesForGroupVersion(veleroGroup + "/" + veleroVersion)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrVeleroNotInstalled
+		}
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+
+	served := make(map[string]bool)
+	for _, r := range v1.APIResources {
+		served[r.Name] = true
+	}
+
+	caps := &VeleroCapabilities{
+		Backups:                 served[BackupGVR.Resource],
+		Restores:                served[RestoreGVR.Resource],
+		Schedules:               served[ScheduleGVR.Resource],
+		BackupStorageLocations:  served[BackupStorageLocationGVR.Resource],
+		VolumeSnapshotLocations: served[VolumeSnapshotLocationGVR.Resource],
+		DeleteBackupRequests:    served[DeleteBackupRequestGVR.Resource],
+		DownloadRequests:        served[DownloadRequestGVR.Resource],
+		ServerStatusRequests:    served[ServerStatusRequestGVR.Resource],
+		PodVolumeBackups:        served["podvolumebackups"],
+	}
+
+	v2, err := c.discovery.ServerResourcesForGroupVersion(DataUploadGVR.GroupVersion().String())
+	switch {
+	case err == nil:
+		for _, r := range v2.APIResources {
+			switch r.Name {
+			case DataUploadGVR.Resource:
+				caps.DataUploads = true
+			case "datadownloads":
+				caps.DataDownloads = true
+			}
+		}
+	case !apierrors.IsNotFound(err):
+		c.logger.Warn("Failed to discover velero.io/v2alpha1", zap.Error(err))
+	}
+
+	var missing []string
+	for _, req := range []struct {
+		ok   bool
+		name string
+	}{
+		{caps.Backups, BackupGVR.Resource},
+		{caps.Restores, RestoreGVR.Resource},
+		{caps.Schedules, ScheduleGVR.Resource},
+		{caps.BackupStorageLocations, BackupStorageLocationGVR.Resource},
+	} {
+		if !req.ok {
+			missing = append(missing, req.name)
+		}
+	}
+	if len(missing) > 0 {
+		return caps, fmt.Errorf("required velero.io/v1 resources missing: %s", strings.Join(missing, ", "))
+	}
+	return caps, nil
+}
+
+// ClusterScopedResources returns the entries of resources that name a
+// cluster-scoped resource, in any form Velero resolves: the plural, singular
+// or short name or the kind, optionally qualified by the API group, e.g.
+// "clusterroles.rbac.authorization.k8s.io". Names the cluster doesn't serve
+// are left out, as Velero skips them too.
+func (c *Client) ClusterScopedResources(resources []string) ([]string, error) {
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	_, lists, err := c.discovery.ServerGroupsAndResources()
+	if err != nil && len(lists) == 0 {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+
+	clusterScoped := make(map[string]bool)
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if r.Namespaced || strings.Contains(r.Name, "/") {
+				continue
+			}
+			for _, name := range append([]string{r.Name, r.SingularName, strings.ToLower(r.Kind)}, r.ShortNames...) {
+				if name == "" {
+					continue
+				}
+				clusterScoped[name] = true
+				if gv.Group != "" {
+					clusterScoped[name+"."+gv.Group] = true
+				}
+			}
+		}
+	}
+
+	var found []string
+	for _, resource := range resources {
+		if clusterScoped[strings.ToLower(strings.TrimSpace(resource))] {
+			found = append(found, resource)
+		}
+	}
+	return found, nil
+}
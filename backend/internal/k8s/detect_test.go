@@ -0,0 +1,182 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func makeDeployment(name, namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+		},
+	}
+}
+
+func newDetectClient(objects ...runtime.Object) *Client {
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{DeploymentGVR: "DeploymentList"},
+		objects...,
+	)
+	logger, _ := zap.NewDevelopment()
+	return &Client{dynamic: fakeClient, logger: logger}
+}
+
+func TestDetectVeleroNamespace(t *testing.T) {
+	c := newDetectClient(
+		makeDeployment("nginx", "default"),
+		makeDeployment("velero", "backup-system"),
+	)
+
+	ns, err := c.DetectVeleroNamespace(context.Background())
+	if err != nil {
+		t.Fatalf("DetectVeleroNamespace failed: %v", err)
+	}
+	if ns != "backup-system" {
+		t.Errorf("Expected backup-system, got %q", ns)
+	}
+}
+
+func TestDetectVeleroNamespacePrefersDefault(t *testing.T) {
+	c := newDetectClient(
+		makeDeployment("velero", "a-velero"),
+		makeDeployment("velero", "velero"),
+	)
+
+	ns, err := c.DetectVeleroNamespace(context.Background())
+	if err != nil {
+		t.Fatalf("DetectVeleroNamespace failed: %v", err)
+	}
+	if ns != DefaultVeleroNamespace {
+		t.Errorf("Expected %q, got %q", DefaultVeleroNamespace, ns)
+	}
+}
+
+func TestDetectVeleroNamespaceNotFound(t *testing.T) {
+	c := newDetectClient(makeDeployment("nginx", "default"))
+
+	if _, err := c.DetectVeleroNamespace(context.Background()); err == nil {
+		t.Error("Expected error when velero is not installed")
+	}
+}
+
+func newCapabilitiesClient(resources ...*metav1.APIResourceList) *Client {
+	logger, _ := zap.NewDevelopment()
+	return &Client{
+		discovery: &discoveryfake.FakeDiscovery{Fake: &k8stesting.Fake{Resources: resources}},
+		logger:    logger,
+	}
+}
+
+func apiResources(groupVersion string, names ...string) *metav1.APIResourceList {
+	list := &metav1.APIResourceList{GroupVersion: groupVersion}
+	for _, n := range names {
+		list.APIResources = append(list.APIResources, metav1.APIResource{Name: n})
+	}
+	return list
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	c := newCapabilitiesClient(
+		apiResources("velero.io/v1", "backups", "restores", "schedules",
+			"backupstoragelocations", "volumesnapshotlocations", "deletebackuprequests"),
+		apiResources("velero.io/v2alpha1", "datauploads", "datadownloads"),
+	)
+
+	caps, err := c.DetectCapabilities()
+	if err != nil {
+		t.Fatalf("DetectCapabilities failed: %v", err)
+	}
+	if !caps.Backups || !caps.Restores || !caps.Schedules || !caps.BackupStorageLocations {
+		t.Errorf("Expected core kinds, got %+v", caps)
+	}
+	if !caps.VolumeSnapshotLocations || !caps.DeleteBackupRequests {
+		t.Errorf("Expected optional v1 kinds, got %+v", caps)
+	}
+	if caps.DownloadRequests || caps.PodVolumeBackups {
+		t.Errorf("Unexpected kinds reported: %+v", caps)
+	}
+	if !caps.DataUploads || !caps.DataDownloads {
+		t.Errorf("Expected v2alpha1 data movement kinds, got %+v", caps)
+	}
+}
+
+func TestDetectCapabilitiesWithoutV2Alpha1(t *testing.T) {
+	c := newCapabilitiesClient(
+		apiResources("velero.io/v1", "backups", "restores", "schedules", "backupstoragelocations"),
+	)
+
+	caps, err := c.DetectCapabilities()
+	if err != nil {
+		t.Fatalf("DetectCapabilities failed: %v", err)
+	}
+	if caps.DataUploads {
+		t.Error("Expected DataUploads to be unavailable")
+	}
+}
+
+func TestDetectCapabilitiesNotInstalled(t *testing.T) {
+	c := newCapabilitiesClient(apiResources("apps/v1", "deployments"))
+
+	if _, err := c.DetectCapabilities(); !errors.Is(err, ErrVeleroNotInstalled) {
+		t.Errorf("Expected ErrVeleroNotInstalled, got %v", err)
+	}
+}
+
+func TestDetectCapabilitiesMissingRequired(t *testing.T) {
+	c := newCapabilitiesClient(apiResources("velero.io/v1", "backups", "restores"))
+
+	caps, err := c.DetectCapabilities()
+	if err == nil {
+		t.Fatal("Expected error for missing schedules and backupstoragelocations")
+	}
+	if caps == nil || !caps.Backups {
+		t.Error("Expected partial capabilities alongside the error")
+	}
+}
+
+func TestClusterScopedResources(t *testing.T) {
+	c := newCapabilitiesClient(
+		&metav1.APIResourceList{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+			{Name: "persistentvolumes", SingularName: "persistentvolume", Kind: "PersistentVolume", ShortNames: []string{"pv"}},
+			{Name: "namespaces/status", Kind: "Namespace"},
+		}},
+		&metav1.APIResourceList{GroupVersion: "rbac.authorization.k8s.io/v1", APIResources: []metav1.APIResource{
+			{Name: "roles", Kind: "Role", Namespaced: true},
+			{Name: "clusterroles", Kind: "ClusterRole"},
+		}},
+		&metav1.APIResourceList{GroupVersion: "apiextensions.k8s.io/v1", APIResources: []metav1.APIResource{
+			{Name: "customresourcedefinitions", Kind: "CustomResourceDefinition", ShortNames: []string{"crd", "crds"}},
+		}},
+	)
+
+	found, err := c.ClusterScopedResources([]string{
+		"pods", "roles.rbac.authorization.k8s.io", "deployments", // namespaced or unknown
+		"clusterroles.rbac.authorization.k8s.io", "PV", "crd", "clusterrole",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"clusterroles.rbac.authorization.k8s.io", "PV", "crd", "clusterrole"}
+	if strings.Join(found, ",") != strings.Join(want, ",") {
+		t.Errorf("ClusterScopedResources = %v, want %v", found, want)
+	}
+}
@@ -0,0 +1,111 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NodeGVR is used to count nodes and infer the cloud provider.
+var NodeGVR = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+
+// ClusterInfo describes the cluster a client is connected to.
+type ClusterInfo struct {
+	KubernetesVersion string
+	VeleroVersion     string
+	Provider          string
+	NodeCount         int
+}
+
+// CollectClusterInfo gathers the server version, node count, cloud provider
+// and Velero version. Each piece is collected independently so that missing
+// permissions for one (e.g. listing nodes) don't hide the others; an error
+// is only returned when nothing could be collected.
+func (c *Client) CollectClusterInfo(ctx context.Context) (*ClusterInfo, error) {
+	info := &ClusterInfo{}
+	var errs []string
+
+	if v, err := c.discovery.ServerVersion(); err != nil {
+		errs = append(errs, fmt.Sprintf("server version: %v", err))
+	} else {
+		info.KubernetesVersion = v.GitVersion
+	}
+
+	if nodes, err := c.dynamic.Resource(NodeGVR).List(ctx, metav1.ListOptions{}); err != nil {
+		errs = append(errs, fmt.Sprintf("nodes: %v", err))
+	} else {
+		info.NodeCount = len(nodes.Items)
+		info.Provider = inferProvider(nodes.Items)
+	}
+
+	if v, err := c.veleroVersion(ctx); err != nil {
+		errs = append(errs, fmt.Sprintf("velero version: %v", err))
+	} else {
+		info.VeleroVersion = v
+	}
+
+	if len(errs) == 3 {
+		return nil, fmt.Errorf("failed to collect cluster info: %s", strings.Join(errs, "; "))
+	}
+	if len(errs) > 0 {
+		c.logger.Debug("Partial cluster info", zap.Strings("errors", errs))
+	}
+	return info, nil
+}
+
+// veleroVersion reads the image tag of the Velero server deployment.
+func (c *Client) veleroVersion(ctx context.Context) (string, error) {
+	deploy, err := c.dynamic.Resource(DeploymentGVR).Namespace(c.namespace).Get(ctx, "velero", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	containers, _, _ := unstructured.NestedSlice(deploy.Object, "spec", "template", "spec", "containers")
+	for _, item := range containers {
+		container, ok := item.(map[string]interface{})
+		if !ok || container["name"] != "velero" {
+			continue
+		}
+		image, _ := container["image"].(string)
+		if tag := imageTag(image); tag != "" {
+			return tag, nil
+		}
+	}
+	return "", fmt.Errorf("velero container image has no version tag")
+}
+
+// imageTag returns the tag of an image reference, ignoring any digest.
+func imageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	slash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > slash {
+		return image[colon+1:]
+	}
+	return ""
+}
+
+// inferProvider returns the most common providerID scheme among nodes,
+// e.g. "aws", "gce", "azure". It is empty when no node has a providerID.
+func inferProvider(nodes []unstructured.Unstructured) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, node := range nodes {
+		providerID, _, _ := unstructured.NestedString(node.Object, "spec", "providerID")
+		scheme, _, found := strings.Cut(providerID, "://")
+		if !found || scheme == "" {
+			continue
+		}
+		counts[scheme]++
+		if counts[scheme] > counts[best] || (counts[scheme] == counts[best] && scheme < best) {
+			best = scheme
+		}
+	}
+	return best
+}
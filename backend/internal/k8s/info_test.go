@@ -0,0 +1,124 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func makeNode(name, providerID string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Node",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       map[string]interface{}{"providerID": providerID},
+		},
+	}
+}
+
+func makeVeleroDeployment(namespace, image string) *unstructured.Unstructured {
+	d := makeDeployment("velero", namespace)
+	_ = unstructured.SetNestedSlice(d.Object, []interface{}{
+		map[string]interface{}{"name": "velero", "image": image},
+	}, "spec", "template", "spec", "containers")
+	return d
+}
+
+func newInfoClient(objects ...runtime.Object) *Client {
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			NodeGVR:       "NodeList",
+			DeploymentGVR: "DeploymentList",
+		},
+		objects...,
+	)
+	logger, _ := zap.NewDevelopment()
+	return &Client{
+		dynamic: fakeClient,
+		discovery: &discoveryfake.FakeDiscovery{
+			Fake:               &k8stesting.Fake{},
+			FakedServerVersion: &version.Info{GitVersion: "v1.27.3"},
+		},
+		namespace: "velero",
+		logger:    logger,
+	}
+}
+
+func TestCollectClusterInfo(t *testing.T) {
+	c := newInfoClient(
+		makeNode("n1", "aws:///us-east-1a/i-1"),
+		makeNode("n2", "aws:///us-east-1b/i-2"),
+		makeNode("n3", ""),
+		makeVeleroDeployment("velero", "velero/velero:v1.11.1"),
+	)
+
+	info, err := c.CollectClusterInfo(context.Background())
+	if err != nil {
+		t.Fatalf("CollectClusterInfo failed: %v", err)
+	}
+	if info.KubernetesVersion != "v1.27.3" {
+		t.Errorf("Expected v1.27.3, got %q", info.KubernetesVersion)
+	}
+	if info.NodeCount != 3 {
+		t.Errorf("Expected 3 nodes, got %d", info.NodeCount)
+	}
+	if info.Provider != "aws" {
+		t.Errorf("Expected provider aws, got %q", info.Provider)
+	}
+	if info.VeleroVersion != "v1.11.1" {
+		t.Errorf("Expected velero v1.11.1, got %q", info.VeleroVersion)
+	}
+}
+
+func TestCollectClusterInfoPartial(t *testing.T) {
+	// No velero deployment: the rest is still collected
+	c := newInfoClient(makeNode("n1", "gce://project/zone/n1"))
+
+	info, err := c.CollectClusterInfo(context.Background())
+	if err != nil {
+		t.Fatalf("CollectClusterInfo failed: %v", err)
+	}
+	if info.VeleroVersion != "" || info.Provider != "gce" || info.NodeCount != 1 {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+}
+
+func TestImageTag(t *testing.T) {
+	cases := map[string]string{
+		"velero/velero:v1.13.0":               "v1.13.0",
+		"registry:5000/velero/velero:v1.12.2": "v1.12.2",
+		"velero/velero:v1.14.0@sha256:abcdef": "v1.14.0",
+		"registry:5000/velero/velero":         "",
+		"velero/velero@sha256:abcdef":         "",
+		"ghcr.io/org/velero:1.15.0-custom":    "1.15.0-custom",
+	}
+	for image, want := range cases {
+		if got := imageTag(image); got != want {
+			t.Errorf("imageTag(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestInferProvider(t *testing.T) {
+	nodes := []unstructured.Unstructured{
+		*makeNode("a", "azure:///subscriptions/x/vm-a"),
+		*makeNode("b", "kind://docker/kind/b"),
+		*makeNode("c", "azure:///subscriptions/x/vm-c"),
+	}
+	if got := inferProvider(nodes); got != "azure" {
+		t.Errorf("Expected azure, got %q", got)
+	}
+	if got := inferProvider([]unstructured.Unstructured{*makeNode("a", "")}); got != "" {
+		t.Errorf("Expected no provider for bare-metal nodes, got %q", got)
+	}
+}
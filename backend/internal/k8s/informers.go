@@ -24,7 +24,7 @@ type EventNotifier interface {
 
 // NotificationPayload carries the data needed for a notification dispatch.
 type NotificationPayload struct {
-	EventType   string // e.g. "backup_failed", "restore_failed", "bsl_unavailable"
+	EventType   string // e.g. "backup_failed", "restore_failed", "bsl_unavailable", "cluster_disconnected"
 	Title       string
 	Message     string
 	ClusterID   string
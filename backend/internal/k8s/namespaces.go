@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// NamespaceGVR is used to manage the scratch namespaces of restore drills.
+var NamespaceGVR = schema.GroupVersionResource{
+	Group: "", Version: "v1", Resource: "namespaces",
+}
+
+// Labels marking scratch namespaces owned by a restore drill. Only namespaces
+// carrying the drill's label are ever deleted.
+const (
+	ScratchDrillLabel = "velero-dashboard/drill"
+	ScratchRunLabel   = "velero-dashboard/drill-run"
+)
+
+var (
+	// ErrNamespaceInUse is returned for a namespace not owned by the drill.
+	ErrNamespaceInUse = errors.New("namespace exists and is not a scratch namespace of this drill")
+	// ErrNamespaceTerminating is returned while a previous run's scratch
+	// namespace is still being deleted; callers should retry.
+	ErrNamespaceTerminating = errors.New("scratch namespace from a previous run is being deleted")
+)
+
+// PrepareScratchNamespace creates a labelled namespace for a drill run to
+// restore into. A namespace left over from an earlier run of the same drill
+// is deleted first, reporting ErrNamespaceTerminating until it is gone.
+func (c *Client) PrepareScratchNamespace(ctx context.Context, name, drillID, runID string) error {
+	resource := c.dynamic.Resource(NamespaceGVR)
+	existing, err := resource.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		ns := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Namespace",
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"app.kubernetes.io/managed-by": "velero-dashboard",
+						ScratchDrillLabel:              drillID,
+						ScratchRunLabel:                runID,
+					},
+				},
+			},
+		}
+		if _, err := resource.Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return ErrNamespaceTerminating
+			}
+			return fmt.Errorf("failed to create namespace %s: %w", name, err)
+		}
+		c.logger.Info("Scratch namespace created", zap.String("namespace", name), zap.String("drill", drillID))
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+
+	labels := existing.GetLabels()
+	if labels[ScratchDrillLabel] != drillID {
+		return fmt.Errorf("%w: %s", ErrNamespaceInUse, name)
+	}
+	if labels[ScratchRunLabel] == runID {
+		return nil
+	}
+	if existing.GetDeletionTimestamp() == nil {
+		if err := resource.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete leftover namespace %s: %w", name, err)
+		}
+	}
+	return ErrNamespaceTerminating
+}
+
+// DeleteScratchNamespace deletes a namespace created by PrepareScratchNamespace.
+// Namespaces that do not belong to the drill are left alone.
+func (c *Client) DeleteScratchNamespace(ctx context.Context, name, drillID string) error {
+	resource := c.dynamic.Resource(NamespaceGVR)
+	existing, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+	if existing.GetLabels()[ScratchDrillLabel] != drillID {
+		return fmt.Errorf("%w: %s", ErrNamespaceInUse, name)
+	}
+	if err := resource.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete namespace %s: %w", name, err)
+	}
+	c.logger.Info("Scratch namespace deleted", zap.String("namespace", name), zap.String("drill", drillID))
+	return nil
+}
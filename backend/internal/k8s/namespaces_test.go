@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func makeNamespace(name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+		},
+	}
+}
+
+func TestPrepareScratchNamespace(t *testing.T) {
+	client := newWorkloadClient(makeNamespace("prod", map[string]interface{}{}))
+	ctx := context.Background()
+
+	if err := client.PrepareScratchNamespace(ctx, "drill-prod", "d1", "r1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ns, err := client.dynamic.Resource(NamespaceGVR).Get(ctx, "drill-prod", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.GetLabels()[ScratchDrillLabel] != "d1" || ns.GetLabels()[ScratchRunLabel] != "r1" {
+		t.Errorf("unexpected labels: %v", ns.GetLabels())
+	}
+
+	// Preparing again in the same run is a no-op
+	if err := client.PrepareScratchNamespace(ctx, "drill-prod", "d1", "r1"); err != nil {
+		t.Errorf("expected idempotent prepare, got %v", err)
+	}
+
+	// A namespace the drill does not own is never touched
+	err = client.PrepareScratchNamespace(ctx, "prod", "d1", "r1")
+	if !errors.Is(err, ErrNamespaceInUse) {
+		t.Errorf("expected ErrNamespaceInUse, got %v", err)
+	}
+	if _, err := client.dynamic.Resource(NamespaceGVR).Get(ctx, "prod", metav1.GetOptions{}); err != nil {
+		t.Errorf("prod namespace should still exist: %v", err)
+	}
+}
+
+func TestPrepareScratchNamespaceReplacesLeftover(t *testing.T) {
+	client := newWorkloadClient(makeNamespace("drill-prod", map[string]interface{}{
+		ScratchDrillLabel: "d1",
+		ScratchRunLabel:   "old-run",
+	}))
+	ctx := context.Background()
+
+	err := client.PrepareScratchNamespace(ctx, "drill-prod", "d1", "r2")
+	if !errors.Is(err, ErrNamespaceTerminating) {
+		t.Fatalf("expected ErrNamespaceTerminating, got %v", err)
+	}
+
+	// The fake deletes immediately, so the retry creates a fresh namespace
+	if err := client.PrepareScratchNamespace(ctx, "drill-prod", "d1", "r2"); err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	ns, _ := client.dynamic.Resource(NamespaceGVR).Get(ctx, "drill-prod", metav1.GetOptions{})
+	if ns.GetLabels()[ScratchRunLabel] != "r2" {
+		t.Errorf("expected namespace of run r2, got %v", ns.GetLabels())
+	}
+}
+
+func TestDeleteScratchNamespace(t *testing.T) {
+	client := newWorkloadClient(
+		makeNamespace("drill-prod", map[string]interface{}{ScratchDrillLabel: "d1"}),
+		makeNamespace("prod", map[string]interface{}{}),
+	)
+	ctx := context.Background()
+
+	if err := client.DeleteScratchNamespace(ctx, "drill-prod", "d1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.dynamic.Resource(NamespaceGVR).Get(ctx, "drill-prod", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected drill-prod to be deleted, got %v", err)
+	}
+
+	if err := client.DeleteScratchNamespace(ctx, "prod", "d1"); !errors.Is(err, ErrNamespaceInUse) {
+		t.Errorf("expected ErrNamespaceInUse, got %v", err)
+	}
+	if err := client.DeleteScratchNamespace(ctx, "missing", "d1"); err != nil {
+		t.Errorf("deleting a missing namespace should succeed, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SelfSubjectAccessReviewGVR is used to ask the API server what the
+// client's own identity may do.
+var SelfSubjectAccessReviewGVR = schema.GroupVersionResource{
+	Group: "authorization.k8s.io", Version: "v1", Resource: "selfsubjectaccessreviews",
+}
+
+// requiredPermission is a set of verbs the Client uses on one resource.
+// Core resources are checked cluster-wide; Velero resources in the Velero
+// namespace. A non-empty features list marks permissions only those
+// features need: denying them does not degrade the cluster.
+type requiredPermission struct {
+	group    string
+	resource string
+	verbs    []string
+	features []string
+}
+
+// requiredPermissions lists every verb the Client uses per resource.
+var requiredPermissions = []requiredPermission{
+	{veleroGroup, BackupGVR.Resource, []string{"get", "list", "watch", "create"}, nil},
+	{veleroGroup, RestoreGVR.Resource, []string{"get", "list", "watch", "create", "delete"}, nil},
+	{veleroGroup, ScheduleGVR.Resource, []string{"get", "list", "watch", "create", "update", "delete"}, nil},
+	{veleroGroup, BackupStorageLocationGVR.Resource, []string{"get", "list", "watch", "create", "update", "delete"}, nil},
+	{veleroGroup, VolumeSnapshotLocationGVR.Resource, []string{"get", "list", "create", "update", "delete"}, nil},
+	{veleroGroup, DeleteBackupRequestGVR.Resource, []string{"create"}, nil},
+	{veleroGroup, DownloadRequestGVR.Resource, []string{"get", "create", "delete"}, nil},
+	{"", NamespaceGVR.Resource, []string{"get", "create", "delete"}, []string{"drills"}},
+	{"", PodGVR.Resource, []string{"list"}, []string{"drills", "migrations"}},
+}
+
+// permissionFeatures returns the features that alone need verb on
+// resource, or nil for permissions the whole dashboard depends on.
+func permissionFeatures(resource, verb string) []string {
+	for _, rp := range requiredPermissions {
+		if rp.resource != resource {
+			continue
+		}
+		for _, v := range rp.verbs {
+			if v == verb {
+				return rp.features
+			}
+		}
+	}
+	return nil
+}
+
+// PermissionMatrix maps resource -> verb -> allowed for the Velero namespace.
+type PermissionMatrix map[string]map[string]bool
+
+// Allowed reports whether verb is permitted on resource.
+func (p PermissionMatrix) Allowed(resource, verb string) bool {
+	return p[resource][verb]
+}
+
+// Missing returns the denied permissions the whole dashboard depends on as
+// sorted "verb resource" strings.
+func (p PermissionMatrix) Missing() []string {
+	var missing []string
+	for resource, verbs := range p {
+		for verb, allowed := range verbs {
+			if !allowed && permissionFeatures(resource, verb) == nil {
+				missing = append(missing, verb+" "+resource)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// MissingOptional returns the denied permissions only some features need,
+// as sorted "verb resource (feature, ...)" strings.
+func (p PermissionMatrix) MissingOptional() []string {
+	var missing []string
+	for resource, verbs := range p {
+		for verb, allowed := range verbs {
+			if features := permissionFeatures(resource, verb); !allowed && features != nil {
+				missing = append(missing, fmt.Sprintf("%s %s (%s)", verb, resource, strings.Join(features, ", ")))
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// CheckPermissions runs a SelfSubjectAccessReview for every verb and
+// resource the Client uses.
+func (c *Client) CheckPermissions(ctx context.Context) (PermissionMatrix, error) {
+	matrix := make(PermissionMatrix, len(requiredPermissions))
+	for _, rp := range requiredPermissions {
+		matrix[rp.resource] = make(map[string]bool, len(rp.verbs))
+		for _, verb := range rp.verbs {
+			allowed, err := c.canI(ctx, rp, verb)
+			if err != nil {
+				return nil, fmt.Errorf("access review for %s %s failed: %w", verb, rp.resource, err)
+			}
+			matrix[rp.resource][verb] = allowed
+		}
+	}
+	return matrix, nil
+}
+
+func (c *Client) canI(ctx context.Context, rp requiredPermission, verb string) (bool, error) {
+	namespace := c.namespace
+	if rp.group == "" {
+		// Drills and migrations touch namespaces and pods outside Velero's.
+		namespace = ""
+	}
+	review := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "authorization.k8s.io/v1",
+			"kind":       "SelfSubjectAccessReview",
+			"spec": map[string]interface{}{
+				"resourceAttributes": map[string]interface{}{
+					"namespace": namespace,
+					"group":     rp.group,
+					"resource":  rp.resource,
+					"verb":      verb,
+				},
+			},
+		},
+	}
+
+	result, err := c.dynamic.Resource(SelfSubjectAccessReviewGVR).Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	allowed, _, _ := unstructured.NestedBool(result.Object, "status", "allowed")
+	return allowed, nil
+}
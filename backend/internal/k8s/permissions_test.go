@@ -0,0 +1,112 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newReviewClient answers access reviews with allowed unless the
+// "verb resource" pair is in denied.
+func newReviewClient(denied map[string]bool, reviewErr error) *Client {
+	fakeClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	fakeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if reviewErr != nil {
+			return true, nil, reviewErr
+		}
+		review := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		attrs, _, _ := unstructured.NestedStringMap(review.Object, "spec", "resourceAttributes")
+		switch attrs["group"] {
+		case "velero.io":
+			if attrs["namespace"] != "velero" {
+				return true, nil, fmt.Errorf("unexpected attributes %v", attrs)
+			}
+		case "":
+			if attrs["namespace"] != "" {
+				return true, nil, fmt.Errorf("unexpected attributes %v", attrs)
+			}
+		default:
+			return true, nil, fmt.Errorf("unexpected attributes %v", attrs)
+		}
+		allowed := !denied[attrs["verb"]+" "+attrs["resource"]]
+		_ = unstructured.SetNestedField(review.Object, allowed, "status", "allowed")
+		return true, review, nil
+	})
+
+	logger, _ := zap.NewDevelopment()
+	return &Client{dynamic: fakeClient, namespace: "velero", logger: logger}
+}
+
+func TestCheckPermissionsAllAllowed(t *testing.T) {
+	c := newReviewClient(nil, nil)
+
+	perms, err := c.CheckPermissions(context.Background())
+	if err != nil {
+		t.Fatalf("CheckPermissions failed: %v", err)
+	}
+	if missing := perms.Missing(); len(missing) != 0 {
+		t.Errorf("Expected no missing permissions, got %v", missing)
+	}
+	if !perms.Allowed("backups", "create") || !perms.Allowed("downloadrequests", "get") {
+		t.Error("Expected checked permissions to be allowed")
+	}
+	if perms.Allowed("backups", "delete") {
+		t.Error("Unchecked permissions must not be reported as allowed")
+	}
+	if !perms.Allowed("namespaces", "create") || !perms.Allowed("pods", "list") {
+		t.Error("Expected drill and migration permissions to be checked")
+	}
+}
+
+func TestCheckPermissionsMissing(t *testing.T) {
+	c := newReviewClient(map[string]bool{
+		"create deletebackuprequests": true,
+		"create downloadrequests":     true,
+	}, nil)
+
+	perms, err := c.CheckPermissions(context.Background())
+	if err != nil {
+		t.Fatalf("CheckPermissions failed: %v", err)
+	}
+	want := []string{"create deletebackuprequests", "create downloadrequests"}
+	if got := perms.Missing(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected missing %v, got %v", want, got)
+	}
+	if perms.Allowed("deletebackuprequests", "create") {
+		t.Error("Expected create deletebackuprequests to be denied")
+	}
+}
+
+func TestCheckPermissionsMissingOptional(t *testing.T) {
+	c := newReviewClient(map[string]bool{
+		"create namespaces": true,
+		"list pods":         true,
+	}, nil)
+
+	perms, err := c.CheckPermissions(context.Background())
+	if err != nil {
+		t.Fatalf("CheckPermissions failed: %v", err)
+	}
+	if missing := perms.Missing(); len(missing) != 0 {
+		t.Errorf("Feature permissions must not count as missing, got %v", missing)
+	}
+	want := []string{"create namespaces (drills)", "list pods (drills, migrations)"}
+	if got := perms.MissingOptional(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected optional missing %v, got %v", want, got)
+	}
+}
+
+func TestCheckPermissionsReviewError(t *testing.T) {
+	c := newReviewClient(nil, fmt.Errorf("forbidden"))
+
+	if _, err := c.CheckPermissions(context.Background()); err == nil {
+		t.Error("Expected error when access reviews fail")
+	}
+}
@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrLocationConflict is returned when a BSL to be provisioned already exists
+// and points at different storage.
+var ErrLocationConflict = errors.New("a storage location with that name already exists and points to different storage")
+
+// ReadOnlyLocationName is the default name of a BSL mirrored from source.
+func ReadOnlyLocationName(source BackupStorageLocationResponse) string {
+	return source.Name + "-readonly"
+}
+
+func (c *Client) GetBackupStorageLocation(ctx context.Context, name string) (*BackupStorageLocationResponse, error) {
+	obj, err := c.dynamic.Resource(BackupStorageLocationGVR).Namespace(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup storage location %s: %w", name, err)
+	}
+	bsl := parseBSL(*obj)
+	return &bsl, nil
+}
+
+// ProvisionReadOnlyLocation creates a ReadOnly BSL that mirrors the provider,
+// bucket, prefix and config of source, so backups written by another cluster
+// become visible here without this cluster ever writing to the bucket. An
+// existing BSL with the same name is reused if it already shares the storage.
+func (c *Client) ProvisionReadOnlyLocation(ctx context.Context, source BackupStorageLocationResponse, req ProvisionLocationRequest) (*BackupStorageLocationResponse, error) {
+	name := req.Name
+	if name == "" {
+		name = ReadOnlyLocationName(source)
+	}
+
+	created, err := c.CreateBackupStorageLocation(ctx, CreateBackupStorageLocationRequest{
+		Name:          name,
+		Provider:      source.Provider,
+		Bucket:        source.Bucket,
+		Prefix:        source.Prefix,
+		Config:        source.Config,
+		Credential:    req.Credential,
+		CredentialKey: req.CredentialKey,
+		AccessMode:    "ReadOnly",
+		Labels: map[string]string{
+			"app.kubernetes.io/managed-by": "velero-dashboard",
+		},
+	})
+	if err == nil {
+		return created, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	existing, getErr := c.GetBackupStorageLocation(ctx, name)
+	if getErr != nil {
+		return nil, getErr
+	}
+	if !existing.SharesStorage(source) {
+		return nil, fmt.Errorf("%w: %s", ErrLocationConflict, name)
+	}
+	c.logger.Info("Reusing existing storage location", zap.String("name", name))
+	return existing, nil
+}
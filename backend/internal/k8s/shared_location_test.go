@@ -0,0 +1,71 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func sourceLocation() BackupStorageLocationResponse {
+	return BackupStorageLocationResponse{
+		Name:     "primary",
+		Provider: "aws",
+		Bucket:   "dr-bucket",
+		Prefix:   "prod",
+		Config:   map[string]string{"region": "eu-west-1", "s3ForcePathStyle": "true"},
+	}
+}
+
+func TestProvisionReadOnlyLocation(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	bsl, err := client.ProvisionReadOnlyLocation(ctx, sourceLocation(), ProvisionLocationRequest{Credential: "dr-creds"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bsl.Name != "primary-readonly" || bsl.AccessMode != "ReadOnly" {
+		t.Errorf("got name=%s accessMode=%s", bsl.Name, bsl.AccessMode)
+	}
+	if !bsl.SharesStorage(sourceLocation()) {
+		t.Errorf("provisioned location does not share storage: %+v", bsl)
+	}
+	if bsl.Config["region"] != "eu-west-1" || bsl.Config["s3ForcePathStyle"] != "true" {
+		t.Errorf("config not mirrored: %v", bsl.Config)
+	}
+	if bsl.Labels["app.kubernetes.io/managed-by"] != "velero-dashboard" {
+		t.Errorf("missing managed-by label: %v", bsl.Labels)
+	}
+
+	obj, err := client.dynamic.Resource(BackupStorageLocationGVR).Namespace("velero").Get(ctx, "primary-readonly", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "credential")
+	if credential["name"] != "dr-creds" || credential["key"] != "cloud" {
+		t.Errorf("unexpected credential: %v", credential)
+	}
+}
+
+func TestProvisionReadOnlyLocationReusesExisting(t *testing.T) {
+	existing := makeBSL("shared", "aws", "dr-bucket", "Available")
+	_ = unstructured.SetNestedField(existing.Object, "prod", "spec", "objectStorage", "prefix")
+	client := newTestClient(t, existing, makeBSL("other", "aws", "another-bucket", "Available"))
+	ctx := context.Background()
+
+	bsl, err := client.ProvisionReadOnlyLocation(ctx, sourceLocation(), ProvisionLocationRequest{Name: "shared"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bsl.Phase != "Available" {
+		t.Errorf("expected the existing location to be returned, got %+v", bsl)
+	}
+
+	_, err = client.ProvisionReadOnlyLocation(ctx, sourceLocation(), ProvisionLocationRequest{Name: "other"})
+	if !errors.Is(err, ErrLocationConflict) {
+		t.Errorf("expected ErrLocationConflict, got %v", err)
+	}
+}
@@ -81,6 +81,12 @@ type BackupStorageLocationResponse struct {
 	Labels             map[string]string `json:"labels,omitempty"`
 }
 
+// SharesStorage reports whether both locations point at the same object
+// storage, so backups written through one are visible through the other.
+func (b BackupStorageLocationResponse) SharesStorage(other BackupStorageLocationResponse) bool {
+	return b.Provider == other.Provider && b.Bucket == other.Bucket && b.Prefix == other.Prefix
+}
+
 // VolumeSnapshotLocationResponse is the DTO for a VSL.
 type VolumeSnapshotLocationResponse struct {
 	Name      string            `json:"name"`
@@ -114,20 +120,23 @@ type CreateBackupRequest struct {
 	TTL                     string   `json:"ttl,omitempty"`
 	SnapshotVolumes         *bool    `json:"snapshotVolumes,omitempty"`
 	DefaultVolumesToFS      *bool    `json:"defaultVolumesToFsBackup,omitempty"`
+	IncludeClusterResources *bool    `json:"includeClusterResources,omitempty"`
 	LabelSelector           string   `json:"labelSelector,omitempty"`
 }
 
 // CreateRestoreRequest is the payload for creating a restore.
 type CreateRestoreRequest struct {
-	Name                   string            `json:"name"`
-	BackupName             string            `json:"backupName"`
-	IncludedNamespaces     []string          `json:"includedNamespaces,omitempty"`
-	ExcludedNamespaces     []string          `json:"excludedNamespaces,omitempty"`
-	IncludedResources      []string          `json:"includedResources,omitempty"`
-	ExcludedResources      []string          `json:"excludedResources,omitempty"`
-	RestorePVs             *bool             `json:"restorePVs,omitempty"`
-	NamespaceMapping       map[string]string `json:"namespaceMapping,omitempty"`
-	ExistingResourcePolicy string            `json:"existingResourcePolicy,omitempty"` // "none" or "update"
+	Name                    string            `json:"name"`
+	BackupName              string            `json:"backupName"`
+	IncludedNamespaces      []string          `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces      []string          `json:"excludedNamespaces,omitempty"`
+	IncludedResources       []string          `json:"includedResources,omitempty"`
+	ExcludedResources       []string          `json:"excludedResources,omitempty"`
+	RestorePVs              *bool             `json:"restorePVs,omitempty"`
+	IncludeClusterResources *bool             `json:"includeClusterResources,omitempty"`
+	NamespaceMapping        map[string]string `json:"namespaceMapping,omitempty"`
+	ExistingResourcePolicy  string            `json:"existingResourcePolicy,omitempty"` // "none" or "update"
+	ResourceModifier        string            `json:"resourceModifier,omitempty"`       // ConfigMap with resource modifier rules
 }
 
 // CreateScheduleRequest is the payload for creating a schedule.
@@ -143,22 +152,24 @@ type CreateScheduleRequest struct {
 	TTL                     string   `json:"ttl,omitempty"`
 	SnapshotVolumes         *bool    `json:"snapshotVolumes,omitempty"`
 	DefaultVolumesToFS      *bool    `json:"defaultVolumesToFsBackup,omitempty"`
+	IncludeClusterResources *bool    `json:"includeClusterResources,omitempty"`
 	LabelSelector           string   `json:"labelSelector,omitempty"`
 	Paused                  bool     `json:"paused,omitempty"`
 }
 
 // UpdateScheduleRequest is the payload for updating a schedule.
 type UpdateScheduleRequest struct {
-	Schedule           *string  `json:"schedule,omitempty"`
-	Paused             *bool    `json:"paused,omitempty"`
-	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
-	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
-	IncludedResources  []string `json:"includedResources,omitempty"`
-	ExcludedResources  []string `json:"excludedResources,omitempty"`
-	StorageLocation    *string  `json:"storageLocation,omitempty"`
-	TTL                *string  `json:"ttl,omitempty"`
-	SnapshotVolumes    *bool    `json:"snapshotVolumes,omitempty"`
-	DefaultVolumesToFS *bool    `json:"defaultVolumesToFsBackup,omitempty"`
+	Schedule                *string  `json:"schedule,omitempty"`
+	Paused                  *bool    `json:"paused,omitempty"`
+	IncludedNamespaces      []string `json:"includedNamespaces,omitempty"`
+	ExcludedNamespaces      []string `json:"excludedNamespaces,omitempty"`
+	IncludedResources       []string `json:"includedResources,omitempty"`
+	ExcludedResources       []string `json:"excludedResources,omitempty"`
+	StorageLocation         *string  `json:"storageLocation,omitempty"`
+	TTL                     *string  `json:"ttl,omitempty"`
+	SnapshotVolumes         *bool    `json:"snapshotVolumes,omitempty"`
+	DefaultVolumesToFS      *bool    `json:"defaultVolumesToFsBackup,omitempty"`
+	IncludeClusterResources *bool    `json:"includeClusterResources,omitempty"`
 }
 
 // CreateBackupStorageLocationRequest is the payload for creating a BSL.
@@ -174,9 +185,11 @@ type CreateBackupStorageLocationRequest struct {
 	ResourceGroup    string            `json:"resourceGroup,omitempty"`  // Azure
 	SubscriptionId   string            `json:"subscriptionId,omitempty"` // Azure
 	Credential       string            `json:"credential,omitempty"`     // Name of existing K8s secret
+	CredentialKey    string            `json:"credentialKey,omitempty"`  // Key in the secret, defaults to "cloud"
 	Config           map[string]string `json:"config,omitempty"`         // Additional provider-specific config
 	Default          bool              `json:"default,omitempty"`
 	AccessMode       string            `json:"accessMode,omitempty"`     // ReadWrite (default) or ReadOnly
+	Labels           map[string]string `json:"-"`
 }
 
 // CreateVolumeSnapshotLocationRequest is the payload for creating a VSL.
@@ -215,9 +228,21 @@ type CrossClusterBackup struct {
 type CrossClusterRestoreRequest struct {
 	SourceClusterID string `json:"sourceClusterId"`
 	TargetClusterID string `json:"targetClusterId"`
+	// ProvisionLocation creates a read-only BSL on the target when it has no
+	// BSL pointing to the backup's storage.
+	ProvisionLocation *ProvisionLocationRequest `json:"provisionLocation,omitempty"`
 	CreateRestoreRequest
 }
 
+// ProvisionLocationRequest describes the read-only BSL created on a restore
+// target. Provider, bucket, prefix and config are copied from the source BSL.
+type ProvisionLocationRequest struct {
+	Name          string `json:"name,omitempty"`          // Defaults to "<source BSL>-readonly"
+	Credential    string `json:"credential,omitempty"`    // Name of existing K8s secret on the target
+	CredentialKey string `json:"credentialKey,omitempty"` // Key in the secret, defaults to "cloud"
+	SyncTimeout   string `json:"syncTimeout,omitempty"`   // How long to wait for the backup to sync, defaults to 5m
+}
+
 // WSEvent is a WebSocket message sent to clients on resource changes.
 type WSEvent struct {
 	Type      string      `json:"type"`      // "backup", "restore", "schedule", "bsl"
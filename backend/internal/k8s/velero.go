@@ -67,6 +67,9 @@ func (c *Client) CreateBackup(ctx context.Context, req CreateBackupRequest) (*Ba
 	if req.DefaultVolumesToFS != nil {
 		spec["defaultVolumesToFsBackup"] = *req.DefaultVolumesToFS
 	}
+	if req.IncludeClusterResources != nil {
+		spec["includeClusterResources"] = *req.IncludeClusterResources
+	}
 	if req.LabelSelector != "" {
 		spec["labelSelector"] = map[string]interface{}{
 			"matchLabels": map[string]interface{}{},
@@ -163,6 +166,9 @@ func (c *Client) CreateRestore(ctx context.Context, req CreateRestoreRequest) (*
 	if req.RestorePVs != nil {
 		spec["restorePVs"] = *req.RestorePVs
 	}
+	if req.IncludeClusterResources != nil {
+		spec["includeClusterResources"] = *req.IncludeClusterResources
+	}
 	if len(req.NamespaceMapping) > 0 {
 		mapping := map[string]interface{}{}
 		for k, v := range req.NamespaceMapping {
@@ -173,6 +179,12 @@ func (c *Client) CreateRestore(ctx context.Context, req CreateRestoreRequest) (*
 	if req.ExistingResourcePolicy != "" {
 		spec["existingResourcePolicy"] = req.ExistingResourcePolicy
 	}
+	if req.ResourceModifier != "" {
+		spec["resourceModifier"] = map[string]interface{}{
+			"kind": "ConfigMap",
+			"name": req.ResourceModifier,
+		}
+	}
 
 	name := req.Name
 	if name == "" {
@@ -210,6 +222,23 @@ func (c *Client) DeleteRestore(ctx context.Context, name string) error {
 	return nil
 }
 
+// RestoreTargets returns the namespaces a restore writes to: the included
+// namespaces after applying the namespace mapping. It is empty when the
+// restore includes every namespace of its backup.
+func RestoreTargets(included []string, mapping map[string]string) []string {
+	if len(included) == 0 {
+		return nil
+	}
+	targets := make([]string, 0, len(included))
+	for _, ns := range included {
+		if target, ok := mapping[ns]; ok && target != "" {
+			ns = target
+		}
+		targets = append(targets, ns)
+	}
+	return targets
+}
+
 func (c *Client) GetRestoreLogs(ctx context.Context, restoreName string) (string, error) {
 	requestName := fmt.Sprintf("%s-restore-logs-%d", restoreName, time.Now().Unix())
 
@@ -354,6 +383,9 @@ func (c *Client) CreateSchedule(ctx context.Context, req CreateScheduleRequest)
 	if req.DefaultVolumesToFS != nil {
 		template["defaultVolumesToFsBackup"] = *req.DefaultVolumesToFS
 	}
+	if req.IncludeClusterResources != nil {
+		template["includeClusterResources"] = *req.IncludeClusterResources
+	}
 
 	spec := map[string]interface{}{
 		"schedule": req.Schedule,
@@ -421,6 +453,9 @@ func (c *Client) UpdateSchedule(ctx context.Context, name string, req UpdateSche
 	if req.DefaultVolumesToFS != nil {
 		_ = unstructured.SetNestedField(obj.Object, *req.DefaultVolumesToFS, "spec", "template", "defaultVolumesToFsBackup")
 	}
+	if req.IncludeClusterResources != nil {
+		_ = unstructured.SetNestedField(obj.Object, *req.IncludeClusterResources, "spec", "template", "includeClusterResources")
+	}
 
 	updated, err := c.dynamic.Resource(ScheduleGVR).Namespace(c.namespace).Update(ctx, obj, metav1.UpdateOptions{})
 	if err != nil {
@@ -510,9 +545,13 @@ func (c *Client) CreateBackupStorageLocation(ctx context.Context, req CreateBack
 	}
 
 	if req.Credential != "" {
+		key := req.CredentialKey
+		if key == "" {
+			key = "cloud"
+		}
 		spec["credential"] = map[string]interface{}{
 			"name": req.Credential,
-			"key":  "cloud",
+			"key":  key,
 		}
 	}
 
@@ -537,6 +576,9 @@ func (c *Client) CreateBackupStorageLocation(ctx context.Context, req CreateBack
 			"spec": spec,
 		},
 	}
+	if len(req.Labels) > 0 {
+		obj.SetLabels(req.Labels)
+	}
 
 	created, err := c.dynamic.Resource(BackupStorageLocationGVR).Namespace(c.namespace).Create(ctx, obj, metav1.CreateOptions{})
 	if err != nil {
@@ -757,6 +799,21 @@ func (c *Client) UpdateVolumeSnapshotLocation(ctx context.Context, name string,
 // --- Dashboard Stats ---
 
 func (c *Client) GetDashboardStats(ctx context.Context) (*DashboardStats, error) {
+	return c.GetDashboardStatsFor(ctx, StatsFilter{})
+}
+
+// StatsFilter selects what GetDashboardStatsFor counts by the namespaces of
+// each object; restores are judged by RestoreTargets. A nil func counts
+// everything of its kind.
+type StatsFilter struct {
+	Backups   func(namespaces []string) bool
+	Restores  func(namespaces []string) bool
+	Schedules func(namespaces []string) bool
+}
+
+// GetDashboardStatsFor counts only the backups, restores and schedules that
+// pass filter.
+func (c *Client) GetDashboardStatsFor(ctx context.Context, filter StatsFilter) (*DashboardStats, error) {
 	backups, err := c.ListBackups(ctx)
 	if err != nil {
 		return nil, err
@@ -777,6 +834,18 @@ func (c *Client) GetDashboardStats(ctx context.Context) (*DashboardStats, error)
 		return nil, err
 	}
 
+	if filter.Backups != nil {
+		backups = filterSlice(backups, func(b BackupResponse) bool { return filter.Backups(b.IncludedNamespaces) })
+	}
+	if filter.Restores != nil {
+		restores = filterSlice(restores, func(r RestoreResponse) bool {
+			return filter.Restores(RestoreTargets(r.IncludedNamespaces, r.NamespaceMapping))
+		})
+	}
+	if filter.Schedules != nil {
+		schedules = filterSlice(schedules, func(s ScheduleResponse) bool { return filter.Schedules(s.IncludedNamespaces) })
+	}
+
 	stats := &DashboardStats{
 		TotalBackups:     int64(len(backups)),
 		TotalRestores:    int64(len(restores)),
@@ -897,6 +966,16 @@ func diffSlices(slice1, slice2 []string) []string {
 }
 
 // formatTimePtr formats a time pointer to string, returns empty string if nil.
+func filterSlice[T any](items []T, keep func(T) bool) []T {
+	kept := items[:0:0]
+	for _, item := range items {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
 func formatTimePtr(t *time.Time) string {
 	if t == nil {
 		return ""
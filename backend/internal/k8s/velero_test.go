@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -21,12 +22,12 @@ func newTestClient(t *testing.T, objects ...runtime.Object) *Client {
 	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
 		scheme,
 		map[schema.GroupVersionResource]string{
-			BackupGVR:                "BackupList",
-			RestoreGVR:               "RestoreList",
-			ScheduleGVR:              "ScheduleList",
-			BackupStorageLocationGVR: "BackupStorageLocationList",
+			BackupGVR:                 "BackupList",
+			RestoreGVR:                "RestoreList",
+			ScheduleGVR:               "ScheduleList",
+			BackupStorageLocationGVR:  "BackupStorageLocationList",
 			VolumeSnapshotLocationGVR: "VolumeSnapshotLocationList",
-			DeleteBackupRequestGVR:   "DeleteBackupRequestList",
+			DeleteBackupRequestGVR:    "DeleteBackupRequestList",
 		},
 		objects...,
 	)
@@ -441,6 +442,54 @@ func TestGetDashboardStats(t *testing.T) {
 	}
 }
 
+func TestGetDashboardStatsFor(t *testing.T) {
+	withNamespaces := func(obj *unstructured.Unstructured, namespaces ...interface{}) *unstructured.Unstructured {
+		obj.Object["spec"].(map[string]interface{})["includedNamespaces"] = namespaces
+		return obj
+	}
+	client := newTestClient(t,
+		withNamespaces(makeBackup("b1", "Completed", 0, 0), "payments"),
+		withNamespaces(makeBackup("b2", "Failed", 1, 0), "billing"),
+		makeBackup("b3", "Completed", 0, 0),
+		makeSchedule("s1", "0 2 * * *", "Enabled", false),
+	)
+
+	payments := func(namespaces []string) bool {
+		return len(namespaces) == 1 && namespaces[0] == "payments"
+	}
+	stats, err := client.GetDashboardStatsFor(context.Background(), StatsFilter{Backups: payments, Schedules: payments})
+	if err != nil {
+		t.Fatalf("GetDashboardStatsFor failed: %v", err)
+	}
+	if stats.TotalBackups != 1 || stats.CompletedBackups != 1 || stats.FailedBackups != 0 {
+		t.Errorf("expected only the payments backup, got %+v", stats)
+	}
+	if stats.TotalSchedules != 0 {
+		t.Errorf("expected cluster-wide schedule to be hidden, got %d", stats.TotalSchedules)
+	}
+}
+
+func TestRestoreTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		included []string
+		mapping  map[string]string
+		want     []string
+	}{
+		{"whole cluster", nil, map[string]string{"a": "b"}, nil},
+		{"no mapping", []string{"payments"}, nil, []string{"payments"}},
+		{"mapped", []string{"payments", "billing"}, map[string]string{"payments": "payments-restore"}, []string{"payments-restore", "billing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RestoreTargets(tt.included, tt.mapping)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RestoreTargets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // --- Parser Tests ---
 
 func TestParseBackup(t *testing.T) {
@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Core resources used to verify restored workloads
+var (
+	PodGVR = schema.GroupVersionResource{
+		Group: "", Version: "v1", Resource: "pods",
+	}
+	ConfigMapGVR = schema.GroupVersionResource{
+		Group: "", Version: "v1", Resource: "configmaps",
+	}
+)
+
+// resourceModifierKey is the ConfigMap key holding resource modifier rules.
+// Velero reads the single entry of the ConfigMap regardless of its key.
+const resourceModifierKey = "resource-modifiers.yaml"
+
+// PodReadiness summarizes the readiness of pods in a set of namespaces.
+type PodReadiness struct {
+	Total    int      `json:"total"`
+	Ready    int      `json:"ready"`
+	NotReady []string `json:"notReady,omitempty"` // namespace/name
+}
+
+// AllReady reports whether every counted pod is ready.
+func (p *PodReadiness) AllReady() bool {
+	return p.Ready == p.Total
+}
+
+// GetPodReadiness counts ready pods in the given namespaces. Pods that ran
+// to completion are ignored, since jobs are expected to finish.
+func (c *Client) GetPodReadiness(ctx context.Context, namespaces []string) (*PodReadiness, error) {
+	result := &PodReadiness{}
+	for _, ns := range namespaces {
+		list, err := c.dynamic.Resource(PodGVR).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods in %s: %w", ns, err)
+		}
+		for _, pod := range list.Items {
+			if nestedString(pod.Object, "status", "phase") == "Succeeded" {
+				continue
+			}
+			result.Total++
+			if podReady(pod) {
+				result.Ready++
+			} else {
+				result.NotReady = append(result.NotReady, ns+"/"+pod.GetName())
+			}
+		}
+	}
+	sort.Strings(result.NotReady)
+	return result, nil
+}
+
+func podReady(pod unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(pod.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if cond["type"] == "Ready" {
+			return cond["status"] == "True"
+		}
+	}
+	return false
+}
+
+// ApplyResourceModifiers creates or replaces a ConfigMap in the Velero
+// namespace holding resource modifier rules, for use by a Restore.
+func (c *Client) ApplyResourceModifiers(ctx context.Context, name, rules string) error {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": c.namespace,
+				"labels": map[string]interface{}{
+					"app.kubernetes.io/managed-by": "velero-dashboard",
+				},
+			},
+			"data": map[string]interface{}{
+				resourceModifierKey: rules,
+			},
+		},
+	}
+
+	resource := c.dynamic.Resource(ConfigMapGVR).Namespace(c.namespace)
+	_, err := resource.Create(ctx, obj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		existing, getErr := resource.Get(ctx, name, metav1.GetOptions{})
+		if getErr != nil {
+			return fmt.Errorf("failed to get resource modifiers %s: %w", name, getErr)
+		}
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		_, err = resource.Update(ctx, obj, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply resource modifiers %s: %w", name, err)
+	}
+
+	c.logger.Info("Resource modifiers applied", zap.String("name", name))
+	return nil
+}
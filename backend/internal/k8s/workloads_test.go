@@ -0,0 +1,119 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newWorkloadClient(objects ...runtime.Object) *Client {
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			PodGVR:       "PodList",
+			ConfigMapGVR: "ConfigMapList",
+			NamespaceGVR: "NamespaceList",
+		},
+		objects...,
+	)
+	return &Client{dynamic: fakeClient, namespace: "velero", logger: zap.NewNop()}
+}
+
+func makePod(namespace, name, phase, ready string) *unstructured.Unstructured {
+	status := map[string]interface{}{"phase": phase}
+	if ready != "" {
+		status["conditions"] = []interface{}{
+			map[string]interface{}{"type": "PodScheduled", "status": "True"},
+			map[string]interface{}{"type": "Ready", "status": ready},
+		}
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+			"status":     status,
+		},
+	}
+}
+
+func TestGetPodReadiness(t *testing.T) {
+	client := newWorkloadClient(
+		makePod("app", "web-1", "Running", "True"),
+		makePod("app", "web-2", "Running", "False"),
+		makePod("app", "migrate-job", "Succeeded", "False"),
+		makePod("db", "pg-0", "Pending", ""),
+		makePod("other", "ignored", "Running", "False"),
+	)
+
+	got, err := client.GetPodReadiness(context.Background(), []string{"app", "db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 3 || got.Ready != 1 || got.AllReady() {
+		t.Errorf("readiness = %+v", got)
+	}
+	want := []string{"app/web-2", "db/pg-0"}
+	if len(got.NotReady) != 2 || got.NotReady[0] != want[0] || got.NotReady[1] != want[1] {
+		t.Errorf("notReady = %v, want %v", got.NotReady, want)
+	}
+
+	empty, err := client.GetPodReadiness(context.Background(), []string{"missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !empty.AllReady() {
+		t.Errorf("no pods should count as ready: %+v", empty)
+	}
+}
+
+func TestApplyResourceModifiersCreatesAndReplaces(t *testing.T) {
+	client := newWorkloadClient()
+	ctx := context.Background()
+
+	if err := client.ApplyResourceModifiers(ctx, "mods", "version: v1\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ApplyResourceModifiers(ctx, "mods", "version: v1\nresourceModifierRules: []\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	cm, err := client.dynamic.Resource(ConfigMapGVR).Namespace("velero").Get(ctx, "mods", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nestedString(cm.Object, "data", resourceModifierKey); got != "version: v1\nresourceModifierRules: []\n" {
+		t.Errorf("rules = %q", got)
+	}
+}
+
+func TestCreateRestoreWithResourceModifier(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	restore, err := client.CreateRestore(ctx, CreateRestoreRequest{
+		Name:             "r1",
+		BackupName:       "b1",
+		ResourceModifier: "mods",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := client.dynamic.Resource(RestoreGVR).Namespace("velero").Get(ctx, restore.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind := nestedString(obj.Object, "spec", "resourceModifier", "kind"); kind != "ConfigMap" {
+		t.Errorf("resourceModifier.kind = %q", kind)
+	}
+	if name := nestedString(obj.Object, "spec", "resourceModifier", "name"); name != "mods" {
+		t.Errorf("resourceModifier.name = %q", name)
+	}
+}
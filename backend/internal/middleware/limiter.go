@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// NewFailureLimiter returns a Fiber middleware that rejects a client after
+// max failed requests within window. Successful requests aren't counted, so
+// it slows down password guessing without locking out users who sign in.
+func NewFailureLimiter(max int, window time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many failed attempts. Try again later.",
+			})
+		},
+		SkipSuccessfulRequests: true,
+	})
+}
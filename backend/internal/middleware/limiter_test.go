@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFailureLimiter(t *testing.T) {
+	app := fiber.New()
+	app.Post("/login", NewFailureLimiter(2, time.Minute), func(c *fiber.Ctx) error {
+		if c.Query("password") != "secret" {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	login := func(password string) int {
+		resp, err := app.Test(httptest.NewRequest("POST", "/login?password="+password, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	// Successful attempts don't count
+	for i := 0; i < 3; i++ {
+		if got := login("secret"); got != fiber.StatusOK {
+			t.Fatalf("login %d = %d", i, got)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if got := login("guess"); got != fiber.StatusUnauthorized {
+			t.Fatalf("failed login %d = %d", i, got)
+		}
+	}
+	if got := login("secret"); got != fiber.StatusTooManyRequests {
+		t.Errorf("login after failures = %d, want %d", got, fiber.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,161 @@
+// Package migrate applies versioned schema migrations to SQLite databases.
+//
+// Several stores share one database file, so applied versions are recorded
+// per component in the schema_migrations table.
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Migration is a single schema change. Versions start at 1 and must be
+// strictly increasing within a component.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+// Exec returns an Up function running the given statements in order.
+func Exec(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	component TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	applied_at DATETIME NOT NULL,
+	PRIMARY KEY (component, version)
+)`
+
+// Apply runs every migration of component that hasn't been applied yet,
+// each in its own transaction together with its schema_migrations row.
+// It refuses to run when the database was migrated by a newer version of
+// the application, since the current code can't know what changed.
+func Apply(db *sql.DB, component string, migrations []Migration, logger *zap.Logger) error {
+	if err := validate(migrations); err != nil {
+		return fmt.Errorf("%s migrations: %w", component, err)
+	}
+	if _, err := db.Exec(createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := Version(db, component)
+	if err != nil {
+		return err
+	}
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if current > latest {
+		return fmt.Errorf("%s schema is at version %d but this build only knows up to %d; refusing to start an older build against a newer database", component, current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := apply(db, component, m); err != nil {
+			return fmt.Errorf("%s migration %d (%s): %w", component, m.Version, m.Name, err)
+		}
+		logger.Info("Applied schema migration",
+			zap.String("component", component),
+			zap.Int("version", m.Version),
+			zap.String("name", m.Name))
+	}
+	return nil
+}
+
+func apply(db *sql.DB, component string, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := m.Up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO schema_migrations (component, version, name, applied_at) VALUES (?, ?, ?, ?)",
+		component, m.Version, m.Name, time.Now(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Version returns the highest applied version of component, 0 when none.
+func Version(db *sql.DB, component string) (int, error) {
+	var version sql.NullInt64
+	err := db.QueryRow("SELECT MAX(version) FROM schema_migrations WHERE component = ?", component).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+func validate(migrations []Migration) error {
+	prev := 0
+	for _, m := range migrations {
+		if m.Version <= prev {
+			return fmt.Errorf("version %d (%s) is not greater than %d", m.Version, m.Name, prev)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("version %d (%s) has no Up function", m.Version, m.Name)
+		}
+		prev = m.Version
+	}
+	return nil
+}
+
+// AddColumn adds a column unless it already exists. Databases created
+// before versioned migrations may already carry columns that later
+// migrations add, so such migrations must tolerate them.
+func AddColumn(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name, typ  string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultVal, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
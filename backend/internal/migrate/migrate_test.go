@@ -0,0 +1,126 @@
+package migrate
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create items", Up: Exec(`CREATE TABLE items (id TEXT PRIMARY KEY)`)},
+	{Version: 2, Name: "add note", Up: func(tx *sql.Tx) error {
+		return AddColumn(tx, "items", "note", "TEXT")
+	}},
+}
+
+func TestApplyInOrderAndIdempotent(t *testing.T) {
+	db := openTestDB(t)
+
+	for i := 0; i < 2; i++ {
+		if err := Apply(db, "items", testMigrations, zap.NewNop()); err != nil {
+			t.Fatalf("Apply #%d failed: %v", i+1, err)
+		}
+	}
+
+	version, err := Version(db, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 2 {
+		t.Errorf("expected version 2, got %d", version)
+	}
+	if _, err := db.Exec(`INSERT INTO items (id, note) VALUES ('a', 'b')`); err != nil {
+		t.Errorf("schema not migrated: %v", err)
+	}
+
+	var count int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE component = 'items'`).Scan(&count)
+	if count != 2 {
+		t.Errorf("expected 2 recorded migrations, got %d", count)
+	}
+}
+
+func TestApplyComponentsAreIndependent(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Apply(db, "items", testMigrations, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	other := []Migration{{Version: 1, Name: "create others", Up: Exec(`CREATE TABLE others (id TEXT)`)}}
+	if err := Apply(db, "others", other, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := Version(db, "others"); v != 1 {
+		t.Errorf("expected others at version 1, got %d", v)
+	}
+}
+
+func TestApplyRollsBackFailedMigration(t *testing.T) {
+	db := openTestDB(t)
+
+	failing := append([]Migration{}, testMigrations[0], Migration{
+		Version: 2,
+		Name:    "half done",
+		Up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`ALTER TABLE items ADD COLUMN note TEXT`); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	})
+
+	err := Apply(db, "items", failing, zap.NewNop())
+	if err == nil || !strings.Contains(err.Error(), "half done") {
+		t.Fatalf("expected migration error, got %v", err)
+	}
+	if v, _ := Version(db, "items"); v != 1 {
+		t.Errorf("expected version 1 after failure, got %d", v)
+	}
+
+	// The partial ALTER must have been rolled back, so the fixed migration applies
+	if err := Apply(db, "items", []Migration{testMigrations[0], {
+		Version: 2, Name: "add note", Up: Exec(`ALTER TABLE items ADD COLUMN note TEXT`),
+	}}, zap.NewNop()); err != nil {
+		t.Fatalf("re-apply after rollback failed: %v", err)
+	}
+}
+
+func TestApplyRefusesNewerDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Apply(db, "items", testMigrations, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	err := Apply(db, "items", testMigrations[:1], zap.NewNop())
+	if err == nil || !strings.Contains(err.Error(), "version 2") {
+		t.Errorf("expected refusal for newer schema, got %v", err)
+	}
+}
+
+func TestApplyRejectsUnorderedMigrations(t *testing.T) {
+	db := openTestDB(t)
+
+	unordered := []Migration{testMigrations[1], testMigrations[0]}
+	if err := Apply(db, "items", unordered, zap.NewNop()); err == nil {
+		t.Error("expected error for unordered migrations")
+	}
+	duplicate := []Migration{testMigrations[0], testMigrations[0]}
+	if err := Apply(db, "items", duplicate, zap.NewNop()); err == nil {
+		t.Error("expected error for duplicate versions")
+	}
+}
@@ -0,0 +1,643 @@
+// Package migration moves namespaces between clusters as persistent jobs:
+// a fresh backup on the source, a restore on the target once its storage
+// location has synced the backup, and a check that the restored pods come
+// up. Cross-cluster restores of existing backups run as jobs too, when the
+// target first needs a storage location for them. Every step is
+// idempotent, so jobs interrupted by a restart resume at the step they were
+// in.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Client is the part of the Velero client used by migration jobs.
+type Client interface {
+	CreateBackup(ctx context.Context, req k8s.CreateBackupRequest) (*k8s.BackupResponse, error)
+	GetBackup(ctx context.Context, name string) (*k8s.BackupResponse, error)
+	ListBackupStorageLocations(ctx context.Context) ([]k8s.BackupStorageLocationResponse, error)
+	CreateRestore(ctx context.Context, req k8s.CreateRestoreRequest) (*k8s.RestoreResponse, error)
+	GetRestore(ctx context.Context, name string) (*k8s.RestoreResponse, error)
+	ApplyResourceModifiers(ctx context.Context, name, rules string) error
+	GetPodReadiness(ctx context.Context, namespaces []string) (*k8s.PodReadiness, error)
+	ProvisionReadOnlyLocation(ctx context.Context, source k8s.BackupStorageLocationResponse, req k8s.ProvisionLocationRequest) (*k8s.BackupStorageLocationResponse, error)
+}
+
+// ClientFunc returns the client of a connected cluster.
+type ClientFunc func(clusterID string) (Client, error)
+
+// Broadcaster streams job progress to WebSocket clients.
+type Broadcaster interface {
+	Broadcast(event interface{})
+}
+
+const (
+	defaultPollInterval = 5 * time.Second
+	saveTimeout         = 10 * time.Second
+)
+
+// Runner executes migration jobs and persists their progress.
+type Runner struct {
+	store        Store
+	clients      ClientFunc
+	hub          Broadcaster
+	logger       *zap.Logger
+	pollInterval time.Duration
+
+	mu      sync.Mutex
+	ctx     context.Context
+	running map[string]*execution
+	wg      sync.WaitGroup
+}
+
+type execution struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// NewRunner creates a job runner. Call Start before submitting jobs.
+func NewRunner(store Store, clients ClientFunc, hub Broadcaster, logger *zap.Logger) *Runner {
+	return &Runner{
+		store:        store,
+		clients:      clients,
+		hub:          hub,
+		logger:       logger,
+		pollInterval: defaultPollInterval,
+		running:      make(map[string]*execution),
+	}
+}
+
+// Store returns the job store.
+func (r *Runner) Store() Store {
+	return r.store
+}
+
+// Start resumes the jobs that were in flight when the dashboard stopped.
+// Jobs run until ctx is cancelled; unfinished ones resume on the next start.
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+
+	jobs, err := r.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list migration jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Phase.Terminal() {
+			continue
+		}
+		r.logger.Info("Resuming migration job",
+			zap.String("id", job.ID),
+			zap.String("name", job.Name),
+			zap.String("step", string(currentStep(job))))
+		r.launch(job)
+	}
+	return nil
+}
+
+// Wait blocks until all running jobs have returned.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Submit creates a job and starts running it.
+func (r *Runner) Submit(ctx context.Context, req CreateJobRequest, createdBy string) (*Job, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	now := time.Now().UTC()
+	job := &Job{
+		ID:                id,
+		Name:              req.Name,
+		SourceClusterID:   req.SourceClusterID,
+		TargetClusterID:   req.TargetClusterID,
+		Spec:              req.Spec,
+		Phase:             PhasePending,
+		CreatedBy:         createdBy,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Restore:           req.Restore,
+		ProvisionLocation: req.ProvisionLocation,
+	}
+	if job.Restore != nil {
+		job.BackupName = job.Restore.BackupName
+		job.RestoreName = job.Restore.Name
+	}
+	if job.Name == "" {
+		job.Name = "migration-" + shortID(id)
+		if job.Restore != nil {
+			job.Name = "restore-" + shortID(id)
+		}
+	}
+	for _, name := range req.steps() {
+		job.Steps = append(job.Steps, Step{Name: name, Phase: PhasePending})
+	}
+
+	r.mu.Lock()
+	started := r.ctx != nil
+	r.mu.Unlock()
+	if !started {
+		return nil, fmt.Errorf("migration runner is not started")
+	}
+
+	if err := r.store.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	r.logger.Info("Migration job created",
+		zap.String("id", job.ID),
+		zap.String("name", job.Name),
+		zap.String("source", job.SourceClusterID),
+		zap.String("target", job.TargetClusterID),
+		zap.Strings("namespaces", job.Spec.IncludedNamespaces))
+
+	r.broadcast(job)
+	r.launch(job.clone())
+	return job, nil
+}
+
+// Cancel stops a job. Backups and restores already created in the clusters
+// are left in place.
+func (r *Runner) Cancel(ctx context.Context, id string) error {
+	r.mu.Lock()
+	if exec, ok := r.running[id]; ok {
+		exec.cancelled = true
+		exec.cancel()
+		r.mu.Unlock()
+		return nil
+	}
+	r.mu.Unlock()
+
+	job, err := r.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.Phase.Terminal() {
+		return ErrTerminal
+	}
+	r.finish(job, nil, PhaseCancelled, "cancelled by user")
+	return nil
+}
+
+func (r *Runner) launch(job *Job) {
+	r.mu.Lock()
+	if _, ok := r.running[job.ID]; ok {
+		r.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	exec := &execution{cancel: cancel}
+	r.running[job.ID] = exec
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() {
+			r.mu.Lock()
+			delete(r.running, job.ID)
+			r.mu.Unlock()
+			cancel()
+		}()
+		r.run(ctx, job, exec)
+	}()
+}
+
+func (r *Runner) run(ctx context.Context, job *Job, exec *execution) {
+	job.Phase = PhaseRunning
+	job.Message = ""
+	r.save(job)
+
+	for i := range job.Steps {
+		step := &job.Steps[i]
+		if step.Phase == PhaseSucceeded {
+			continue
+		}
+		now := time.Now().UTC()
+		step.Phase = PhaseRunning
+		if step.StartedAt == nil {
+			step.StartedAt = &now
+		}
+		r.save(job)
+
+		err := r.execute(ctx, job, step)
+		if ctx.Err() != nil {
+			r.mu.Lock()
+			cancelled := exec.cancelled
+			r.mu.Unlock()
+			if cancelled {
+				r.finish(job, step, PhaseCancelled, "cancelled by user")
+			}
+			// Otherwise the dashboard is shutting down; the job resumes on restart
+			return
+		}
+		if err != nil {
+			r.logger.Warn("Migration job failed",
+				zap.String("id", job.ID),
+				zap.String("step", string(step.Name)),
+				zap.Error(err))
+			r.finish(job, step, PhaseFailed, err.Error())
+			return
+		}
+
+		done := time.Now().UTC()
+		step.Phase = PhaseSucceeded
+		step.CompletedAt = &done
+		r.save(job)
+	}
+
+	job.Phase = PhaseSucceeded
+	job.Message = fmt.Sprintf("migrated %s to the target cluster", strings.Join(job.Spec.IncludedNamespaces, ", "))
+	if job.Restore != nil {
+		job.Message = fmt.Sprintf("restored backup %s on the target cluster", job.BackupName)
+	}
+	r.save(job)
+	r.logger.Info("Migration job succeeded", zap.String("id", job.ID), zap.String("name", job.Name))
+}
+
+// finish moves the job, and the step it stopped in, to a final phase.
+func (r *Runner) finish(job *Job, step *Step, phase Phase, message string) {
+	now := time.Now().UTC()
+	if step == nil {
+		for i := range job.Steps {
+			if !job.Steps[i].Phase.Terminal() {
+				step = &job.Steps[i]
+				break
+			}
+		}
+	}
+	if step != nil {
+		step.Phase = phase
+		step.Message = message
+		step.CompletedAt = &now
+	}
+	job.Phase = phase
+	job.Message = message
+	r.save(job)
+}
+
+func (r *Runner) execute(ctx context.Context, job *Job, step *Step) error {
+	switch step.Name {
+	case StepBackup:
+		return r.createBackup(ctx, job, step)
+	case StepWaitBackup:
+		return r.waitBackup(ctx, job, step)
+	case StepProvision:
+		return r.provision(ctx, job, step)
+	case StepWaitSync:
+		return r.waitSync(ctx, job, step)
+	case StepRestore:
+		return r.restore(ctx, job, step)
+	case StepVerify:
+		return r.verify(ctx, job, step)
+	default:
+		return fmt.Errorf("unknown step %q", step.Name)
+	}
+}
+
+func (r *Runner) createBackup(ctx context.Context, job *Job, step *Step) error {
+	// The name is recorded first, so a resumed job finds its own backup
+	if job.BackupName == "" {
+		job.BackupName = "migrate-" + shortID(job.ID)
+		r.save(job)
+	}
+
+	src, err := r.clients(job.SourceClusterID)
+	if err != nil {
+		return fmt.Errorf("source cluster: %w", err)
+	}
+	_, err = src.CreateBackup(ctx, k8s.CreateBackupRequest{
+		Name:               job.BackupName,
+		IncludedNamespaces: job.Spec.IncludedNamespaces,
+		IncludedResources:  job.Spec.IncludedResources,
+		ExcludedResources:  job.Spec.ExcludedResources,
+		StorageLocation:    job.Spec.StorageLocation,
+		SnapshotVolumes:    job.Spec.SnapshotVolumes,
+		DefaultVolumesToFS: job.Spec.DefaultVolumesToFS,
+	})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	r.progress(job, step, fmt.Sprintf("backup %s created on the source cluster", job.BackupName))
+	return nil
+}
+
+func (r *Runner) waitBackup(ctx context.Context, job *Job, step *Step) error {
+	d := timeout(job.Spec.BackupTimeout, DefaultBackupTimeout)
+	return r.poll(ctx, d, "the backup to complete", func(ctx context.Context) (bool, error) {
+		src, err := r.clients(job.SourceClusterID)
+		if err != nil {
+			r.progress(job, step, "waiting for the source cluster: "+err.Error())
+			return false, nil
+		}
+		backup, err := src.GetBackup(ctx, job.BackupName)
+		if apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("backup %s no longer exists on the source cluster", job.BackupName)
+		}
+		if err != nil {
+			r.progress(job, step, "failed to read backup: "+err.Error())
+			return false, nil
+		}
+
+		switch backup.Phase {
+		case "Completed":
+			r.progress(job, step, fmt.Sprintf("backup completed with %d items", backup.ItemsBackedUp))
+			return true, nil
+		case "PartiallyFailed", "Failed", "FailedValidation":
+			return false, fmt.Errorf("backup %s %s with %d errors", job.BackupName, backup.Phase, backup.Errors)
+		}
+		r.progress(job, step, fmt.Sprintf("backup %s: %d/%d items", phaseOr(backup.Phase, "New"), backup.ItemsBackedUp, backup.TotalItems))
+		return false, nil
+	})
+}
+
+// provision mirrors the storage location of the backup on the target as a
+// read-only one. An existing location sharing the storage is reused.
+func (r *Runner) provision(ctx context.Context, job *Job, step *Step) error {
+	src, err := r.clients(job.SourceClusterID)
+	if err != nil {
+		return fmt.Errorf("source cluster: %w", err)
+	}
+	tgt, err := r.clients(job.TargetClusterID)
+	if err != nil {
+		return fmt.Errorf("target cluster: %w", err)
+	}
+
+	source, err := sourceLocation(ctx, src, job.BackupName)
+	if err != nil {
+		return err
+	}
+	var opts k8s.ProvisionLocationRequest
+	if job.ProvisionLocation != nil {
+		opts = *job.ProvisionLocation
+	}
+	bsl, err := tgt.ProvisionReadOnlyLocation(ctx, *source, opts)
+	if err != nil {
+		return err
+	}
+	r.progress(job, step, fmt.Sprintf("read-only storage location %s provisioned", bsl.Name))
+	return nil
+}
+
+func (r *Runner) waitSync(ctx context.Context, job *Job, step *Step) error {
+	src, err := r.clients(job.SourceClusterID)
+	if err != nil {
+		return fmt.Errorf("source cluster: %w", err)
+	}
+	tgt, err := r.clients(job.TargetClusterID)
+	if err != nil {
+		return fmt.Errorf("target cluster: %w", err)
+	}
+
+	// Fail fast when the target can't see the source's storage at all
+	location, err := sharedLocation(ctx, src, tgt, job.BackupName)
+	if err != nil {
+		return err
+	}
+
+	d := timeout(job.Spec.SyncTimeout, DefaultSyncTimeout)
+	return r.poll(ctx, d, "the target cluster to sync the backup", func(ctx context.Context) (bool, error) {
+		backup, err := tgt.GetBackup(ctx, job.BackupName)
+		if err == nil && backup.Phase == "Completed" {
+			r.progress(job, step, fmt.Sprintf("backup synced through storage location %s", location.Name))
+			return true, nil
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			r.progress(job, step, "failed to read backup on the target: "+err.Error())
+			return false, nil
+		}
+		r.progress(job, step, fmt.Sprintf("waiting for storage location %s (%s) to sync the backup",
+			location.Name, phaseOr(location.Phase, "Unknown")))
+		return false, nil
+	})
+}
+
+// sharedLocation returns the target's storage location that points at the
+// storage the backup was written to.
+func sharedLocation(ctx context.Context, src, tgt Client, backupName string) (*k8s.BackupStorageLocationResponse, error) {
+	s, err := sourceLocation(ctx, src, backupName)
+	if err != nil {
+		return nil, err
+	}
+	targetBSLs, err := tgt.ListBackupStorageLocations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list target storage locations: %w", err)
+	}
+	for i := range targetBSLs {
+		if targetBSLs[i].SharesStorage(*s) {
+			return &targetBSLs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("target cluster has no storage location pointing to %s bucket %s", s.Provider, s.Bucket)
+}
+
+// sourceLocation returns the source's storage location the backup was
+// written to.
+func sourceLocation(ctx context.Context, src Client, backupName string) (*k8s.BackupStorageLocationResponse, error) {
+	backup, err := src.GetBackup(ctx, backupName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup on the source cluster: %w", err)
+	}
+	sourceBSLs, err := src.ListBackupStorageLocations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source storage locations: %w", err)
+	}
+	for i := range sourceBSLs {
+		if sourceBSLs[i].Name == backup.StorageLocation {
+			return &sourceBSLs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("storage location %s not found on the source cluster", backup.StorageLocation)
+}
+
+func (r *Runner) restore(ctx context.Context, job *Job, step *Step) error {
+	if job.RestoreName == "" {
+		job.RestoreName = job.BackupName + "-restore"
+		if job.Restore != nil {
+			// Existing backups may have been restored before
+			job.RestoreName += "-" + shortID(job.ID)
+		}
+		r.save(job)
+	}
+
+	tgt, err := r.clients(job.TargetClusterID)
+	if err != nil {
+		return fmt.Errorf("target cluster: %w", err)
+	}
+
+	var modifier string
+	if job.Spec.ResourceModifiers != "" {
+		modifier = job.RestoreName + "-modifiers"
+		if err := tgt.ApplyResourceModifiers(ctx, modifier, job.Spec.ResourceModifiers); err != nil {
+			return err
+		}
+	}
+
+	req := k8s.CreateRestoreRequest{
+		IncludedNamespaces:     job.Spec.IncludedNamespaces,
+		IncludedResources:      job.Spec.IncludedResources,
+		ExcludedResources:      job.Spec.ExcludedResources,
+		RestorePVs:             job.Spec.RestorePVs,
+		NamespaceMapping:       job.Spec.NamespaceMapping,
+		ExistingResourcePolicy: job.Spec.ExistingResourcePolicy,
+		ResourceModifier:       modifier,
+	}
+	if job.Restore != nil {
+		req = *job.Restore
+	}
+	req.Name = job.RestoreName
+	req.BackupName = job.BackupName
+	_, err = tgt.CreateRestore(ctx, req)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	d := timeout(job.Spec.RestoreTimeout, DefaultRestoreTimeout)
+	return r.poll(ctx, d, "the restore to complete", func(ctx context.Context) (bool, error) {
+		tgt, err := r.clients(job.TargetClusterID)
+		if err != nil {
+			r.progress(job, step, "waiting for the target cluster: "+err.Error())
+			return false, nil
+		}
+		restore, err := tgt.GetRestore(ctx, job.RestoreName)
+		if apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("restore %s no longer exists on the target cluster", job.RestoreName)
+		}
+		if err != nil {
+			r.progress(job, step, "failed to read restore: "+err.Error())
+			return false, nil
+		}
+
+		switch restore.Phase {
+		case "Completed":
+			r.progress(job, step, fmt.Sprintf("restore completed with %d items and %d warnings", restore.ItemsRestored, restore.Warnings))
+			return true, nil
+		case "PartiallyFailed", "Failed", "FailedValidation":
+			return false, fmt.Errorf("restore %s %s with %d errors", job.RestoreName, restore.Phase, restore.Errors)
+		}
+		r.progress(job, step, fmt.Sprintf("restore %s: %d/%d items", phaseOr(restore.Phase, "New"), restore.ItemsRestored, restore.TotalItems))
+		return false, nil
+	})
+}
+
+func (r *Runner) verify(ctx context.Context, job *Job, step *Step) error {
+	namespaces := job.TargetNamespaces()
+	var last *k8s.PodReadiness
+
+	d := timeout(job.Spec.ReadyTimeout, DefaultReadyTimeout)
+	err := r.poll(ctx, d, "pods to become ready", func(ctx context.Context) (bool, error) {
+		tgt, err := r.clients(job.TargetClusterID)
+		if err != nil {
+			r.progress(job, step, "waiting for the target cluster: "+err.Error())
+			return false, nil
+		}
+		readiness, err := tgt.GetPodReadiness(ctx, namespaces)
+		if err != nil {
+			r.progress(job, step, "failed to read pods: "+err.Error())
+			return false, nil
+		}
+		last = readiness
+		if readiness.AllReady() {
+			r.progress(job, step, fmt.Sprintf("%d/%d pods ready", readiness.Ready, readiness.Total))
+			return true, nil
+		}
+		r.progress(job, step, fmt.Sprintf("%d/%d pods ready, waiting for %s", readiness.Ready, readiness.Total, summarize(readiness.NotReady)))
+		return false, nil
+	})
+	if err != nil && last != nil && ctx.Err() == nil {
+		return fmt.Errorf("%w; not ready: %s", err, summarize(last.NotReady))
+	}
+	return err
+}
+
+// poll runs condition until it reports done, returns an error, or d elapses.
+func (r *Runner) poll(ctx context.Context, d time.Duration, what string, condition wait.ConditionWithContextFunc) error {
+	err := wait.PollUntilContextTimeout(ctx, r.pollInterval, d, true, condition)
+	if err != nil && wait.Interrupted(err) && ctx.Err() == nil {
+		return fmt.Errorf("timed out after %s waiting for %s", d, what)
+	}
+	return err
+}
+
+// progress records a step message, saving only when it changed.
+func (r *Runner) progress(job *Job, step *Step, message string) {
+	if step.Message == message {
+		return
+	}
+	step.Message = message
+	r.save(job)
+}
+
+// save persists the job and streams it to WebSocket clients. Saving uses its
+// own context, so progress is recorded even while the job is being stopped.
+func (r *Runner) save(job *Job) {
+	job.UpdatedAt = time.Now().UTC()
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+	if err := r.store.Update(ctx, job); err != nil {
+		r.logger.Error("Failed to save migration job", zap.String("id", job.ID), zap.Error(err))
+	}
+	r.broadcast(job)
+}
+
+func (r *Runner) broadcast(job *Job) {
+	if r.hub == nil {
+		return
+	}
+	r.hub.Broadcast(k8s.WSEvent{
+		Type:      "migration",
+		Action:    "modified",
+		Resource:  job.clone(),
+		ClusterID: job.TargetClusterID,
+	})
+}
+
+func (j *Job) clone() *Job {
+	c := *j
+	c.Steps = append([]Step(nil), j.Steps...)
+	return &c
+}
+
+func currentStep(job *Job) StepName {
+	for _, s := range job.Steps {
+		if s.Phase != PhaseSucceeded {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func phaseOr(phase, def string) string {
+	if phase == "" {
+		return def
+	}
+	return phase
+}
+
+// summarize lists the first few names, so messages stay readable.
+func summarize(names []string) string {
+	const max = 3
+	if len(names) <= max {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:max], ", "), len(names)-max)
+}
@@ -0,0 +1,483 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeCluster simulates Velero on one cluster. Backups and restores
+// complete on the first read after creation.
+type fakeCluster struct {
+	mu           sync.Mutex
+	backups      map[string]*k8s.BackupResponse
+	restores     map[string]*k8s.RestoreResponse
+	bsls         []k8s.BackupStorageLocationResponse
+	modifiers    map[string]string
+	restoreReqs  []k8s.CreateRestoreRequest
+	backupCalls  int
+	restorePhase string // final restore phase, "Completed" by default
+	podsReady    bool
+	syncFrom     *fakeCluster // backups of this cluster become visible here
+}
+
+func newFakeCluster(bucket string) *fakeCluster {
+	return &fakeCluster{
+		backups:   make(map[string]*k8s.BackupResponse),
+		restores:  make(map[string]*k8s.RestoreResponse),
+		modifiers: make(map[string]string),
+		bsls: []k8s.BackupStorageLocationResponse{
+			{Name: "default", Provider: "aws", Bucket: bucket, Phase: "Available"},
+		},
+		podsReady: true,
+	}
+}
+
+func (f *fakeCluster) CreateBackup(_ context.Context, req k8s.CreateBackupRequest) (*k8s.BackupResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backupCalls++
+	if _, ok := f.backups[req.Name]; ok {
+		return nil, apierrors.NewAlreadyExists(schema.GroupResource{Group: "velero.io", Resource: "backups"}, req.Name)
+	}
+	b := &k8s.BackupResponse{Name: req.Name, Phase: "InProgress", StorageLocation: "default", IncludedNamespaces: req.IncludedNamespaces}
+	f.backups[req.Name] = b
+	return b, nil
+}
+
+func (f *fakeCluster) GetBackup(_ context.Context, name string) (*k8s.BackupResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.backups[name]
+	if !ok && f.syncFrom != nil {
+		if synced, err := f.syncFrom.completedBackup(name); err == nil {
+			f.backups[name] = synced
+			// Visible from the next read, like a BSL sync interval
+			return nil, apierrors.NewNotFound(schema.GroupResource{Group: "velero.io", Resource: "backups"}, name)
+		}
+	}
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: "velero.io", Resource: "backups"}, name)
+	}
+	out := *b
+	if b.Phase == "InProgress" {
+		b.Phase = "Completed"
+		b.ItemsBackedUp = 42
+	}
+	return &out, nil
+}
+
+func (f *fakeCluster) completedBackup(name string) (*k8s.BackupResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.backups[name]
+	if !ok || b.Phase != "Completed" {
+		return nil, fmt.Errorf("not completed")
+	}
+	out := *b
+	return &out, nil
+}
+
+func (f *fakeCluster) ListBackupStorageLocations(_ context.Context) ([]k8s.BackupStorageLocationResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]k8s.BackupStorageLocationResponse(nil), f.bsls...), nil
+}
+
+func (f *fakeCluster) CreateRestore(_ context.Context, req k8s.CreateRestoreRequest) (*k8s.RestoreResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.restores[req.Name]; ok {
+		return nil, apierrors.NewAlreadyExists(schema.GroupResource{Group: "velero.io", Resource: "restores"}, req.Name)
+	}
+	f.restoreReqs = append(f.restoreReqs, req)
+	r := &k8s.RestoreResponse{Name: req.Name, BackupName: req.BackupName, Phase: "InProgress"}
+	f.restores[req.Name] = r
+	return r, nil
+}
+
+func (f *fakeCluster) GetRestore(_ context.Context, name string) (*k8s.RestoreResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.restores[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: "velero.io", Resource: "restores"}, name)
+	}
+	out := *r
+	if r.Phase == "InProgress" {
+		r.Phase = "Completed"
+		if f.restorePhase != "" {
+			r.Phase = f.restorePhase
+			r.Errors = 3
+		}
+		r.ItemsRestored = 42
+	}
+	return &out, nil
+}
+
+func (f *fakeCluster) ApplyResourceModifiers(_ context.Context, name, rules string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modifiers[name] = rules
+	return nil
+}
+
+func (f *fakeCluster) GetPodReadiness(_ context.Context, namespaces []string) (*k8s.PodReadiness, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.podsReady {
+		return &k8s.PodReadiness{Total: 2, Ready: 2}, nil
+	}
+	return &k8s.PodReadiness{Total: 2, Ready: 1, NotReady: []string{namespaces[0] + "/web-1"}}, nil
+}
+
+func (f *fakeCluster) ProvisionReadOnlyLocation(_ context.Context, source k8s.BackupStorageLocationResponse, req k8s.ProvisionLocationRequest) (*k8s.BackupStorageLocationResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bsl := source
+	bsl.Name = req.Name
+	bsl.AccessMode = "ReadOnly"
+	f.bsls = append(f.bsls, bsl)
+	return &bsl, nil
+}
+
+func (f *fakeCluster) setPodsReady(ready bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.podsReady = ready
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []k8s.WSEvent
+}
+
+func (r *recorder) Broadcast(event interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event.(k8s.WSEvent))
+}
+
+func (r *recorder) last() *Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1].Resource.(*Job)
+}
+
+type testEnv struct {
+	source, target *fakeCluster
+	store          *SQLiteStore
+	hub            *recorder
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "jobs.db"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	source := newFakeCluster("shared")
+	target := newFakeCluster("shared")
+	target.syncFrom = source
+	return &testEnv{source: source, target: target, store: store, hub: &recorder{}}
+}
+
+func (e *testEnv) runner(t *testing.T, ctx context.Context) *Runner {
+	t.Helper()
+	clients := func(id string) (Client, error) {
+		switch id {
+		case "src":
+			return e.source, nil
+		case "tgt":
+			return e.target, nil
+		}
+		return nil, fmt.Errorf("cluster not found or not connected")
+	}
+	r := NewRunner(e.store, clients, e.hub, zap.NewNop())
+	r.pollInterval = time.Millisecond
+	if err := r.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func testRequest() CreateJobRequest {
+	return CreateJobRequest{
+		SourceClusterID: "src",
+		TargetClusterID: "tgt",
+		Spec: Spec{
+			IncludedNamespaces: []string{"shop"},
+			NamespaceMapping:   map[string]string{"shop": "shop-dr"},
+			ResourceModifiers:  "version: v1\nresourceModifierRules: []\n",
+		},
+	}
+}
+
+// waitFor polls the store until the job satisfies cond.
+func waitFor(t *testing.T, store Store, id string, cond func(*Job) bool) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := store.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cond(job) {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach the expected state", id)
+	return nil
+}
+
+func TestRunnerCompletesMigration(t *testing.T) {
+	env := newTestEnv(t)
+	r := env.runner(t, context.Background())
+
+	job, err := r.Submit(context.Background(), testRequest(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	got, err := env.store.Get(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Phase != PhaseSucceeded {
+		t.Fatalf("phase = %s (%s)", got.Phase, got.Message)
+	}
+	for _, s := range got.Steps {
+		if s.Phase != PhaseSucceeded || s.StartedAt == nil || s.CompletedAt == nil {
+			t.Errorf("step %s = %+v", s.Name, s)
+		}
+	}
+	if got.CreatedBy != "alice" || got.BackupName == "" || got.RestoreName == "" {
+		t.Errorf("job = %+v", got)
+	}
+
+	if len(env.target.restoreReqs) != 1 {
+		t.Fatalf("restores = %d, want 1", len(env.target.restoreReqs))
+	}
+	req := env.target.restoreReqs[0]
+	if req.BackupName != got.BackupName || req.NamespaceMapping["shop"] != "shop-dr" {
+		t.Errorf("restore request = %+v", req)
+	}
+	if req.ResourceModifier == "" || env.target.modifiers[req.ResourceModifier] != testRequest().ResourceModifiers {
+		t.Errorf("resource modifiers not applied: %q %v", req.ResourceModifier, env.target.modifiers)
+	}
+
+	if last := env.hub.last(); last.Phase != PhaseSucceeded || last.ID != job.ID {
+		t.Errorf("last broadcast = %s %s", last.ID, last.Phase)
+	}
+}
+
+func TestRunnerFailsOnPartiallyFailedRestore(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.restorePhase = "PartiallyFailed"
+	r := env.runner(t, context.Background())
+
+	job, err := r.Submit(context.Background(), testRequest(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	got, _ := env.store.Get(context.Background(), job.ID)
+	if got.Phase != PhaseFailed || !strings.Contains(got.Message, "PartiallyFailed") {
+		t.Fatalf("job = %s %q", got.Phase, got.Message)
+	}
+	if step := got.Steps[3]; step.Name != StepRestore || step.Phase != PhaseFailed {
+		t.Errorf("restore step = %+v", step)
+	}
+	if got.Steps[4].Phase != PhasePending {
+		t.Errorf("verify step ran after failure: %+v", got.Steps[4])
+	}
+}
+
+func TestRunnerFailsWithoutSharedLocation(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.bsls[0].Bucket = "elsewhere"
+	r := env.runner(t, context.Background())
+
+	job, err := r.Submit(context.Background(), testRequest(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	got, _ := env.store.Get(context.Background(), job.ID)
+	if got.Phase != PhaseFailed || got.Steps[2].Phase != PhaseFailed || !strings.Contains(got.Message, "no storage location") {
+		t.Fatalf("job = %s %q steps=%+v", got.Phase, got.Message, got.Steps)
+	}
+}
+
+func TestRunnerTimesOutWaitingForPods(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.setPodsReady(false)
+	r := env.runner(t, context.Background())
+
+	req := testRequest()
+	req.ReadyTimeout = "20ms"
+	job, err := r.Submit(context.Background(), req, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	got, _ := env.store.Get(context.Background(), job.ID)
+	if got.Phase != PhaseFailed || !strings.Contains(got.Message, "timed out") || !strings.Contains(got.Message, "shop-dr/web-1") {
+		t.Fatalf("job = %s %q", got.Phase, got.Message)
+	}
+}
+
+func TestRunnerResumesAfterRestart(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.setPodsReady(false)
+
+	ctx, stop := context.WithCancel(context.Background())
+	r := env.runner(t, ctx)
+	job, err := r.Submit(context.Background(), testRequest(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, env.store, job.ID, func(j *Job) bool { return j.Steps[4].Phase == PhaseRunning })
+
+	// Simulate the dashboard stopping mid-job
+	stop()
+	r.Wait()
+	stopped, _ := env.store.Get(context.Background(), job.ID)
+	if stopped.Phase != PhaseRunning {
+		t.Fatalf("phase after shutdown = %s, want Running", stopped.Phase)
+	}
+
+	env.target.setPodsReady(true)
+	r2 := env.runner(t, context.Background())
+	r2.Wait()
+
+	got, _ := env.store.Get(context.Background(), job.ID)
+	if got.Phase != PhaseSucceeded {
+		t.Fatalf("phase after resume = %s (%s)", got.Phase, got.Message)
+	}
+	if env.source.backupCalls != 1 || len(env.target.restoreReqs) != 1 {
+		t.Errorf("completed steps re-ran: backups=%d restores=%d", env.source.backupCalls, len(env.target.restoreReqs))
+	}
+}
+
+func TestRunnerResumeIsIdempotent(t *testing.T) {
+	env := newTestEnv(t)
+	r := env.runner(t, context.Background())
+	job, err := r.Submit(context.Background(), testRequest(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	// Pretend the restore step was interrupted after creating the restore
+	got, _ := env.store.Get(context.Background(), job.ID)
+	got.Phase = PhaseRunning
+	got.Steps[3].Phase = PhaseRunning
+	got.Steps[4].Phase = PhasePending
+	if err := env.store.Update(context.Background(), got); err != nil {
+		t.Fatal(err)
+	}
+
+	r2 := env.runner(t, context.Background())
+	r2.Wait()
+
+	final, _ := env.store.Get(context.Background(), job.ID)
+	if final.Phase != PhaseSucceeded {
+		t.Fatalf("phase = %s (%s)", final.Phase, final.Message)
+	}
+}
+
+func TestRunnerCancel(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.setPodsReady(false)
+	r := env.runner(t, context.Background())
+
+	job, err := r.Submit(context.Background(), testRequest(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, env.store, job.ID, func(j *Job) bool { return j.Steps[4].Phase == PhaseRunning })
+
+	if err := r.Cancel(context.Background(), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	got, _ := env.store.Get(context.Background(), job.ID)
+	if got.Phase != PhaseCancelled || got.Steps[4].Phase != PhaseCancelled {
+		t.Fatalf("job = %s steps=%+v", got.Phase, got.Steps)
+	}
+	if err := r.Cancel(context.Background(), job.ID); err != ErrTerminal {
+		t.Errorf("cancel finished job: err = %v, want ErrTerminal", err)
+	}
+}
+
+func TestRunnerRestoresExistingBackup(t *testing.T) {
+	env := newTestEnv(t)
+	env.target.bsls[0].Bucket = "elsewhere"
+	env.source.backups["nightly"] = &k8s.BackupResponse{Name: "nightly", Phase: "Completed", StorageLocation: "default"}
+	r := env.runner(t, context.Background())
+
+	job, err := r.Submit(context.Background(), CreateJobRequest{
+		SourceClusterID:   "src",
+		TargetClusterID:   "tgt",
+		Restore:           &k8s.CreateRestoreRequest{BackupName: "nightly", ExcludedNamespaces: []string{"kube-system"}},
+		ProvisionLocation: &k8s.ProvisionLocationRequest{Name: "default-readonly"},
+	}, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Wait()
+
+	got, _ := env.store.Get(context.Background(), job.ID)
+	if got.Phase != PhaseSucceeded || !strings.Contains(got.Message, "restored backup nightly") {
+		t.Fatalf("job = %s %q steps=%+v", got.Phase, got.Message, got.Steps)
+	}
+	var steps []StepName
+	for _, s := range got.Steps {
+		steps = append(steps, s.Name)
+	}
+	if fmt.Sprint(steps) != fmt.Sprint([]StepName{StepProvision, StepWaitSync, StepRestore}) {
+		t.Errorf("steps = %v", steps)
+	}
+	if env.source.backupCalls != 0 || len(env.target.bsls) != 2 {
+		t.Errorf("backups = %d, target locations = %d", env.source.backupCalls, len(env.target.bsls))
+	}
+	if len(env.target.restoreReqs) != 1 {
+		t.Fatalf("restores = %d, want 1", len(env.target.restoreReqs))
+	}
+	req := env.target.restoreReqs[0]
+	if req.BackupName != "nightly" || req.Name != got.RestoreName || req.ExcludedNamespaces[0] != "kube-system" {
+		t.Errorf("restore request = %+v", req)
+	}
+}
+
+func TestRunnerSubmitValidates(t *testing.T) {
+	env := newTestEnv(t)
+	r := env.runner(t, context.Background())
+
+	req := testRequest()
+	req.TargetClusterID = "src"
+	if _, err := r.Submit(context.Background(), req, ""); err == nil {
+		t.Fatal("expected error for identical clusters")
+	}
+	if jobs, _ := env.store.List(context.Background()); len(jobs) != 0 {
+		t.Errorf("jobs = %d, want 0", len(jobs))
+	}
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+)
+
+// Store persists migration jobs so they survive restarts.
+type Store interface {
+	Create(ctx context.Context, job *Job) error
+	Get(ctx context.Context, id string) (*Job, error)
+	List(ctx context.Context) ([]*Job, error)
+	Update(ctx context.Context, job *Job) error
+	Close() error
+}
+
+// StoreConfig holds configuration for creating a job store.
+type StoreConfig struct {
+	StorageType string // "auto", "kubernetes", "sqlite"
+	DBPath      string // For SQLite
+	Namespace   string // For Kubernetes
+}
+
+// NewStore creates a job store based on the storage type.
+func NewStore(cfg StoreConfig, logger *zap.Logger) (Store, error) {
+	storageType := cfg.StorageType
+	if storageType == "" || storageType == "auto" {
+		if isInCluster() {
+			storageType = "kubernetes"
+		} else {
+			storageType = "sqlite"
+		}
+	}
+
+	switch storageType {
+	case "kubernetes":
+		return NewK8sStore(cfg.Namespace, logger)
+	case "sqlite":
+		dbPath := cfg.DBPath
+		if dbPath == "" {
+			dbPath = "./migrations.db"
+		}
+		return NewSQLiteStore(dbPath, logger)
+	default:
+		return nil, fmt.Errorf("unknown migration storage type: %s", storageType)
+	}
+}
+
+func isInCluster() bool {
+	_, err := rest.InClusterConfig()
+	return err == nil
+}
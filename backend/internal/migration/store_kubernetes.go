@@ -0,0 +1,33 @@
+package migration
+
+import (
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+)
+
+// k8sRecords stores each migration job in its own ConfigMap, so that step
+// history can grow without hitting the ConfigMap size limit.
+var k8sRecords = jsonstore.Config[Job]{
+	Kind:        "migration job",
+	Key:         func(job *Job) string { return job.ID },
+	Less:        func(a, b *Job) bool { return a.CreatedAt.After(b.CreatedAt) },
+	ErrNotFound: ErrNotFound,
+
+	Component: "migration-job",
+	Prefix:    "velero-dashboard-migration-",
+	DataKey:   "job.json",
+}
+
+// NewK8sStore creates a new Kubernetes job store.
+func NewK8sStore(namespace string, logger *zap.Logger) (Store, error) {
+	store, err := jsonstore.NewK8sStore(namespace, k8sRecords, logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func newK8sStore(clientset kubernetes.Interface, namespace string, logger *zap.Logger) *jsonstore.K8sStore[Job] {
+	return jsonstore.NewK8sStoreForClient(clientset, namespace, k8sRecords, logger)
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newK8sStore(fake.NewSimpleClientset(), "velero", zap.NewNop())
+
+	older := &Job{ID: "one", Name: "first", Phase: PhaseSucceeded, CreatedAt: time.Now().Add(-time.Hour),
+		Steps: []Step{{Name: StepBackup, Phase: PhaseSucceeded}}}
+	newer := &Job{ID: "two", Name: "second", Phase: PhasePending, CreatedAt: time.Now(),
+		Spec: Spec{IncludedNamespaces: []string{"shop"}}}
+	for _, job := range []*Job{older, newer} {
+		if err := store.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newer.Phase = PhaseRunning
+	newer.BackupName = "migrate-two"
+	if err := store.Update(ctx, newer); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Get(ctx, "two")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Phase != PhaseRunning || got.BackupName != "migrate-two" || got.Spec.IncludedNamespaces[0] != "shop" {
+		t.Errorf("job = %+v", got)
+	}
+
+	jobs, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != "two" || jobs[1].ID != "one" {
+		t.Errorf("list order = %v", jobs)
+	}
+
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get missing: err = %v", err)
+	}
+	if err := store.Update(ctx, &Job{ID: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update missing: err = %v", err)
+	}
+}
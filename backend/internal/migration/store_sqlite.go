@@ -0,0 +1,171 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+// SQLiteStore stores migration jobs in SQLite.
+type SQLiteStore struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// NewSQLiteStore creates a new SQLite job store.
+func NewSQLiteStore(dbPath string, logger *zap.Logger) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", dbPath+"?_journal=WAL&_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := migrate.Apply(db, "migration-jobs", sqliteMigrations, logger); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return &SQLiteStore{db: db, logger: logger}, nil
+}
+
+// sqliteMigrations is the job store schema history. Never edit an applied
+// migration; append a new one instead.
+var sqliteMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create migration jobs",
+		Up: migrate.Exec(`
+		CREATE TABLE IF NOT EXISTS migration_jobs (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			source_cluster_id TEXT NOT NULL,
+			target_cluster_id TEXT NOT NULL,
+			spec TEXT NOT NULL,
+			phase TEXT NOT NULL,
+			message TEXT,
+			steps TEXT NOT NULL,
+			backup_name TEXT,
+			restore_name TEXT,
+			created_by TEXT,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		)`),
+	},
+}
+
+const jobColumns = `id, name, source_cluster_id, target_cluster_id, spec, phase, message, steps, backup_name, restore_name, created_by, created_at, updated_at`
+
+func (s *SQLiteStore) Create(_ context.Context, job *Job) error {
+	spec, steps, err := marshalJob(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO migration_jobs (`+jobColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID, job.Name, job.SourceClusterID, job.TargetClusterID, spec, string(job.Phase), job.Message, steps,
+		job.BackupName, job.RestoreName, job.CreatedBy, job.CreatedAt.Format(time.RFC3339Nano), job.UpdatedAt.Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert migration job: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Get(_ context.Context, id string) (*Job, error) {
+	row := s.db.QueryRow(`SELECT `+jobColumns+` FROM migration_jobs WHERE id = ?`, id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+func (s *SQLiteStore) List(_ context.Context) ([]*Job, error) {
+	rows, err := s.db.Query(`SELECT ` + jobColumns + ` FROM migration_jobs ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration jobs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			s.logger.Error("Failed to scan migration job row", zap.Error(err))
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *SQLiteStore) Update(_ context.Context, job *Job) error {
+	spec, steps, err := marshalJob(job)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(
+		`UPDATE migration_jobs SET spec = ?, phase = ?, message = ?, steps = ?, backup_name = ?, restore_name = ?, updated_at = ? WHERE id = ?`,
+		spec, string(job.Phase), job.Message, steps, job.BackupName, job.RestoreName, job.UpdatedAt.Format(time.RFC3339Nano), job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update migration job: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func marshalJob(job *Job) (string, string, error) {
+	spec, err := json.Marshal(job.Spec)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal spec: %w", err)
+	}
+	steps, err := json.Marshal(job.Steps)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal steps: %w", err)
+	}
+	return string(spec), string(steps), nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row scanner) (*Job, error) {
+	var (
+		job                                         Job
+		spec, steps, phase, createdAt, updatedAt    string
+		message, backupName, restoreName, createdBy sql.NullString
+	)
+	err := row.Scan(&job.ID, &job.Name, &job.SourceClusterID, &job.TargetClusterID, &spec, &phase, &message, &steps,
+		&backupName, &restoreName, &createdBy, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(spec), &job.Spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %w", err)
+	}
+	if err := json.Unmarshal([]byte(steps), &job.Steps); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal steps: %w", err)
+	}
+	job.Phase = Phase(phase)
+	job.Message = message.String
+	job.BackupName = backupName.String
+	job.RestoreName = restoreName.String
+	job.CreatedBy = createdBy.String
+	job.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	job.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	return &job, nil
+}
@@ -0,0 +1,215 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/k8s"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Phase is the state of a job or one of its steps.
+type Phase string
+
+const (
+	PhasePending   Phase = "Pending"
+	PhaseRunning   Phase = "Running"
+	PhaseSucceeded Phase = "Succeeded"
+	PhaseFailed    Phase = "Failed"
+	PhaseCancelled Phase = "Cancelled"
+)
+
+// Terminal reports whether p is a final phase.
+func (p Phase) Terminal() bool {
+	return p == PhaseSucceeded || p == PhaseFailed || p == PhaseCancelled
+}
+
+// StepName identifies a step of a migration job.
+type StepName string
+
+const (
+	StepBackup     StepName = "backup"             // create a backup on the source
+	StepWaitBackup StepName = "wait-backup"        // wait for it to complete
+	StepProvision  StepName = "provision-location" // create a read-only BSL on the target
+	StepWaitSync   StepName = "wait-sync"          // wait for the target BSL to sync it
+	StepRestore    StepName = "restore"            // restore on the target and wait for it
+	StepVerify     StepName = "verify"             // wait for restored pods to become ready
+)
+
+// stepOrder is the order in which the steps of a migration run.
+var stepOrder = []StepName{StepBackup, StepWaitBackup, StepWaitSync, StepRestore, StepVerify}
+
+// Default step timeouts, used when a job doesn't set its own.
+const (
+	DefaultBackupTimeout  = 4 * time.Hour
+	DefaultSyncTimeout    = 15 * time.Minute
+	DefaultRestoreTimeout = 4 * time.Hour
+	DefaultReadyTimeout   = 10 * time.Minute
+)
+
+// ErrNotFound is returned when a job doesn't exist.
+var ErrNotFound = errors.New("migration job not found")
+
+// ErrTerminal is returned when cancelling a job that already finished.
+var ErrTerminal = errors.New("migration job already finished")
+
+// Step records the progress of one step.
+type Step struct {
+	Name        StepName   `json:"name"`
+	Phase       Phase      `json:"phase"`
+	Message     string     `json:"message,omitempty"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// Spec describes what to migrate and how to restore it.
+type Spec struct {
+	IncludedNamespaces []string `json:"includedNamespaces"`
+	IncludedResources  []string `json:"includedResources,omitempty"`
+	ExcludedResources  []string `json:"excludedResources,omitempty"`
+	StorageLocation    string   `json:"storageLocation,omitempty"`
+	SnapshotVolumes    *bool    `json:"snapshotVolumes,omitempty"`
+	DefaultVolumesToFS *bool    `json:"defaultVolumesToFsBackup,omitempty"`
+
+	NamespaceMapping       map[string]string `json:"namespaceMapping,omitempty"`
+	RestorePVs             *bool             `json:"restorePVs,omitempty"`
+	ExistingResourcePolicy string            `json:"existingResourcePolicy,omitempty"` // "none" or "update"
+	ResourceModifiers      string            `json:"resourceModifiers,omitempty"`      // Velero resource modifier rules (YAML)
+
+	// Step timeouts as Go durations, e.g. "30m"
+	BackupTimeout  string `json:"backupTimeout,omitempty"`
+	SyncTimeout    string `json:"syncTimeout,omitempty"`
+	RestoreTimeout string `json:"restoreTimeout,omitempty"`
+	ReadyTimeout   string `json:"readyTimeout,omitempty"`
+}
+
+// Job is a migration of namespaces from a source to a target cluster.
+type Job struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	SourceClusterID string    `json:"sourceClusterId"`
+	TargetClusterID string    `json:"targetClusterId"`
+	Spec            Spec      `json:"spec"`
+	Phase           Phase     `json:"phase"`
+	Message         string    `json:"message,omitempty"`
+	Steps           []Step    `json:"steps"`
+	BackupName      string    `json:"backupName,omitempty"`
+	RestoreName     string    `json:"restoreName,omitempty"`
+	CreatedBy       string    `json:"createdBy,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+
+	// Restore, when set, makes the job restore BackupName, an existing
+	// backup of the source, exactly as requested instead of migrating
+	// Spec's namespaces: it skips the backup and verify steps.
+	Restore *k8s.CreateRestoreRequest `json:"restore,omitempty"`
+	// ProvisionLocation, for restores, first mirrors the backup's storage
+	// location on the target as a read-only one.
+	ProvisionLocation *k8s.ProvisionLocationRequest `json:"provisionLocation,omitempty"`
+}
+
+// TargetNamespaces returns the namespaces the restore writes to.
+func (j *Job) TargetNamespaces() []string {
+	namespaces := make([]string, len(j.Spec.IncludedNamespaces))
+	for i, ns := range j.Spec.IncludedNamespaces {
+		namespaces[i] = ns
+		if mapped := j.Spec.NamespaceMapping[ns]; mapped != "" {
+			namespaces[i] = mapped
+		}
+	}
+	return namespaces
+}
+
+// CreateJobRequest is the payload for starting a migration.
+type CreateJobRequest struct {
+	Name            string `json:"name"`
+	SourceClusterID string `json:"sourceClusterId"`
+	TargetClusterID string `json:"targetClusterId"`
+	Spec
+
+	// Restore and ProvisionLocation are only set by cross-cluster restores;
+	// see Job.
+	Restore           *k8s.CreateRestoreRequest     `json:"-"`
+	ProvisionLocation *k8s.ProvisionLocationRequest `json:"-"`
+}
+
+// Validate checks the request before a job is created.
+func (r *CreateJobRequest) Validate() error {
+	if r.SourceClusterID == "" || r.TargetClusterID == "" {
+		return fmt.Errorf("sourceClusterId and targetClusterId are required")
+	}
+	if r.SourceClusterID == r.TargetClusterID {
+		return fmt.Errorf("source and target clusters must be different")
+	}
+	if r.Restore != nil {
+		// The restore handler checks namespaces against the caller's scope
+		if r.Restore.BackupName == "" {
+			return fmt.Errorf("backupName is required")
+		}
+	} else if err := validateNamespaces(r.IncludedNamespaces); err != nil {
+		return err
+	}
+	for from, to := range r.NamespaceMapping {
+		if errs := validation.IsDNS1123Label(to); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace mapping %s=%s: %s", from, to, errs[0])
+		}
+	}
+	switch r.ExistingResourcePolicy {
+	case "", "none", "update":
+	default:
+		return fmt.Errorf("existingResourcePolicy must be none or update")
+	}
+	for _, d := range []struct{ name, value string }{
+		{"backupTimeout", r.BackupTimeout},
+		{"syncTimeout", r.SyncTimeout},
+		{"restoreTimeout", r.RestoreTimeout},
+		{"readyTimeout", r.ReadyTimeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v <= 0 {
+			return fmt.Errorf("%s must be a positive duration such as 30m", d.name)
+		}
+	}
+	return nil
+}
+
+// validateNamespaces checks the namespaces of a migration. Readiness is
+// verified per namespace, so the whole cluster can't be migrated.
+func validateNamespaces(namespaces []string) error {
+	if len(namespaces) == 0 {
+		return fmt.Errorf("at least one namespace is required")
+	}
+	for _, ns := range namespaces {
+		if ns == "*" {
+			return fmt.Errorf("namespaces must be listed explicitly")
+		}
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q: %s", ns, errs[0])
+		}
+	}
+	return nil
+}
+
+// steps returns the steps of the job r creates, in order.
+func (r *CreateJobRequest) steps() []StepName {
+	if r.Restore == nil {
+		return stepOrder
+	}
+	var steps []StepName
+	if r.ProvisionLocation != nil {
+		steps = append(steps, StepProvision)
+	}
+	return append(steps, StepWaitSync, StepRestore)
+}
+
+// timeout returns the parsed duration or def when unset. Values are
+// validated on creation.
+func timeout(value string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,54 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCreateJobRequestValidate(t *testing.T) {
+	valid := func() CreateJobRequest {
+		return CreateJobRequest{
+			SourceClusterID: "a",
+			TargetClusterID: "b",
+			Spec:            Spec{IncludedNamespaces: []string{"shop"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *CreateJobRequest)
+		wantErr bool
+	}{
+		{"valid", func(r *CreateJobRequest) {}, false},
+		{"missing target", func(r *CreateJobRequest) { r.TargetClusterID = "" }, true},
+		{"same cluster", func(r *CreateJobRequest) { r.TargetClusterID = "a" }, true},
+		{"no namespaces", func(r *CreateJobRequest) { r.IncludedNamespaces = nil }, true},
+		{"wildcard", func(r *CreateJobRequest) { r.IncludedNamespaces = []string{"*"} }, true},
+		{"invalid namespace", func(r *CreateJobRequest) { r.IncludedNamespaces = []string{"Shop_1"} }, true},
+		{"invalid mapping", func(r *CreateJobRequest) { r.NamespaceMapping = map[string]string{"shop": "Bad"} }, true},
+		{"invalid policy", func(r *CreateJobRequest) { r.ExistingResourcePolicy = "replace" }, true},
+		{"update policy", func(r *CreateJobRequest) { r.ExistingResourcePolicy = "update" }, false},
+		{"timeout", func(r *CreateJobRequest) { r.ReadyTimeout = "15m" }, false},
+		{"invalid timeout", func(r *CreateJobRequest) { r.BackupTimeout = "soon" }, true},
+		{"negative timeout", func(r *CreateJobRequest) { r.SyncTimeout = "-1m" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(&r)
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTargetNamespaces(t *testing.T) {
+	job := &Job{Spec: Spec{
+		IncludedNamespaces: []string{"shop", "db"},
+		NamespaceMapping:   map[string]string{"shop": "shop-dr"},
+	}}
+	if got := job.TargetNamespaces(); !reflect.DeepEqual(got, []string{"shop-dr", "db"}) {
+		t.Errorf("TargetNamespaces() = %v", got)
+	}
+}
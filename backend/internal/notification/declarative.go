@@ -0,0 +1,309 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// definitionSelector selects Secrets and ConfigMaps holding webhook
+// definitions. Each object defines one webhook with the keys:
+//
+//	name          display name (defaults to the object name)
+//	type          slack, teams, discord or webhook
+//	events        comma-separated event types
+//	enabled       "false" to disable (defaults to true)
+//	url           webhook URL, or
+//	urlSecret     name of a Secret in the same namespace holding the URL
+//	urlSecretKey  key in urlSecret (defaults to "url")
+const definitionSelector = "app.kubernetes.io/name=velero-dashboard,app.kubernetes.io/component=webhook-definition"
+
+// definitionResyncInterval re-reads definitions periodically, since Secrets
+// referenced by urlSecret aren't watched.
+const definitionResyncInterval = 5 * time.Minute
+
+// webhookDefinition is a webhook declared by a labelled Secret or ConfigMap.
+type webhookDefinition struct {
+	ID      string
+	Source  string
+	Name    string
+	Type    WebhookType
+	URL     string
+	Events  []EventType
+	Enabled bool
+}
+
+// definitionID derives a stable webhook ID from the defining object.
+func definitionID(kind, name string) string {
+	return strings.ToLower(kind) + "-" + name
+}
+
+// parseDefinition reads a webhook definition from an object's data.
+// lookupURL resolves urlSecret references.
+func parseDefinition(kind, name string, data map[string]string, lookupURL func(secret, key string) (string, error)) (*webhookDefinition, error) {
+	def := &webhookDefinition{
+		ID:      definitionID(kind, name),
+		Source:  kind + "/" + name,
+		Name:    strings.TrimSpace(data["name"]),
+		Type:    WebhookType(strings.TrimSpace(data["type"])),
+		Enabled: strings.TrimSpace(data["enabled"]) != "false",
+	}
+	if def.Name == "" {
+		def.Name = name
+	}
+	if !def.Type.Valid() {
+		return nil, fmt.Errorf("invalid type %q", def.Type)
+	}
+
+	for _, raw := range strings.Split(data["events"], ",") {
+		event := EventType(strings.TrimSpace(raw))
+		if event == "" {
+			continue
+		}
+		if !event.Valid() {
+			return nil, fmt.Errorf("invalid event %q", event)
+		}
+		def.Events = append(def.Events, event)
+	}
+	if len(def.Events) == 0 {
+		return nil, fmt.Errorf("at least one event is required")
+	}
+
+	def.URL = strings.TrimSpace(data["url"])
+	if ref := strings.TrimSpace(data["urlSecret"]); ref != "" {
+		key := strings.TrimSpace(data["urlSecretKey"])
+		if key == "" {
+			key = "url"
+		}
+		url, err := lookupURL(ref, key)
+		if err != nil {
+			return nil, fmt.Errorf("urlSecret %s: %w", ref, err)
+		}
+		def.URL = strings.TrimSpace(url)
+	}
+	if def.URL == "" {
+		return nil, fmt.Errorf("url or urlSecret is required")
+	}
+
+	return def, nil
+}
+
+// WatchDefinitions watches labelled Secrets and ConfigMaps and reconciles
+// the webhooks they define into the store. Declared webhooks are read-only
+// through the API; delivery status is still tracked.
+func (s *K8sStore) WatchDefinitions(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		opts := metav1.ListOptions{LabelSelector: definitionSelector}
+		secretWatcher, err := s.clientset.CoreV1().Secrets(s.namespace).Watch(ctx, opts)
+		if err != nil {
+			s.logger.Error("Failed to watch webhook definition Secrets", zap.Error(err))
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		cmWatcher, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Watch(ctx, opts)
+		if err != nil {
+			secretWatcher.Stop()
+			s.logger.Error("Failed to watch webhook definition ConfigMaps", zap.Error(err))
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		// Initial reconciliation on watch start
+		s.reconcileDefinitions(ctx)
+
+		s.logger.Info("Started watching webhook definitions",
+			zap.String("namespace", s.namespace),
+			zap.String("labelSelector", definitionSelector))
+
+		func() {
+			defer secretWatcher.Stop()
+			defer cmWatcher.Stop()
+			ticker := time.NewTicker(definitionResyncInterval)
+			defer ticker.Stop()
+
+			for {
+				var (
+					event watch.Event
+					ok    bool
+				)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.reconcileDefinitions(ctx)
+					continue
+				case event, ok = <-secretWatcher.ResultChan():
+				case event, ok = <-cmWatcher.ResultChan():
+				}
+				if !ok {
+					s.logger.Warn("Webhook definition watch channel closed, restarting")
+					return
+				}
+
+				switch event.Type {
+				case watch.Added, watch.Deleted, watch.Modified:
+					s.reconcileDefinitions(ctx)
+				}
+			}
+		}()
+
+		// Brief pause before reconnecting
+		time.Sleep(time.Second)
+	}
+}
+
+// reconcileDefinitions makes the managed webhooks in the store match the
+// definitions. Returns true if changes were made.
+func (s *K8sStore) reconcileDefinitions(ctx context.Context) bool {
+	defs, invalid, err := s.loadDefinitions(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load webhook definitions", zap.Error(err))
+		return false
+	}
+
+	var removed []string
+	changed := false
+	now := time.Now()
+	err = s.mutateMetadata(ctx, func(metadata map[string]*webhookMetadata) error {
+		// Re-evaluated from scratch when a conflict forces a retry
+		removed = nil
+		changed = false
+
+		for id, def := range defs {
+			meta, exists := metadata[id]
+			if !exists {
+				meta = &webhookMetadata{ID: id, CreatedAt: now}
+				metadata[id] = meta
+			} else if meta.Managed && meta.Source == def.Source && meta.Name == def.Name &&
+				meta.Type == def.Type && meta.Enabled == def.Enabled && reflect.DeepEqual(meta.Events, def.Events) {
+				continue
+			}
+			meta.Name = def.Name
+			meta.Type = def.Type
+			meta.Events = def.Events
+			meta.Enabled = def.Enabled
+			meta.Managed = true
+			meta.Source = def.Source
+			meta.UpdatedAt = now
+			changed = true
+		}
+
+		for id, meta := range metadata {
+			if meta.Managed && defs[id] == nil && !invalid[id] {
+				delete(metadata, id)
+				removed = append(removed, id)
+				changed = true
+			}
+		}
+
+		if !changed {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to save reconciled webhooks", zap.Error(err))
+		return false
+	}
+
+	urls, err := s.loadURLs(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load webhook URLs for reconciliation", zap.Error(err))
+		return changed
+	}
+	urlsChanged := len(removed) > 0
+	for id, def := range defs {
+		if urls[id] != def.URL {
+			urlsChanged = true
+		}
+	}
+	if urlsChanged {
+		err := s.mutateURLs(ctx, func(data map[string][]byte) {
+			for id, def := range defs {
+				data[id] = []byte(def.URL)
+			}
+			for _, id := range removed {
+				delete(data, id)
+			}
+		})
+		if err != nil {
+			s.logger.Error("Failed to save reconciled webhook URLs", zap.Error(err))
+			return changed
+		}
+		changed = true
+	}
+
+	if changed {
+		s.logger.Info("Webhook definitions reconciled", zap.Int("managed", len(defs)))
+	}
+	return changed
+}
+
+// loadDefinitions reads all valid webhook definitions, keyed by ID. Invalid
+// definitions are logged and reported separately, so that a typo leaves the
+// previously reconciled webhook in place instead of removing it.
+func (s *K8sStore) loadDefinitions(ctx context.Context) (map[string]*webhookDefinition, map[string]bool, error) {
+	opts := metav1.ListOptions{LabelSelector: definitionSelector}
+	secrets, err := s.clientset.CoreV1().Secrets(s.namespace).List(ctx, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list Secrets: %w", err)
+	}
+	configMaps, err := s.clientset.CoreV1().ConfigMaps(s.namespace).List(ctx, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list ConfigMaps: %w", err)
+	}
+
+	lookupURL := func(name, key string) (string, error) {
+		secret, err := s.clientset.CoreV1().Secrets(s.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		value, ok := secret.Data[key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found", key)
+		}
+		return string(value), nil
+	}
+
+	defs := make(map[string]*webhookDefinition)
+	invalid := make(map[string]bool)
+	add := func(kind, name string, data map[string]string) {
+		def, err := parseDefinition(kind, name, data, lookupURL)
+		if err != nil {
+			s.logger.Warn("Invalid webhook definition, skipping",
+				zap.String("source", kind+"/"+name), zap.Error(err))
+			invalid[definitionID(kind, name)] = true
+			return
+		}
+		defs[def.ID] = def
+	}
+
+	for _, secret := range secrets.Items {
+		add("Secret", secret.Name, secretStringData(&secret))
+	}
+	for _, cm := range configMaps.Items {
+		add("ConfigMap", cm.Name, cm.Data)
+	}
+	return defs, invalid, nil
+}
+
+func secretStringData(secret *corev1.Secret) map[string]string {
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	return data
+}
@@ -0,0 +1,179 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var definitionLabels = map[string]string{
+	"app.kubernetes.io/name":      "velero-dashboard",
+	"app.kubernetes.io/component": "webhook-definition",
+}
+
+func TestParseDefinition(t *testing.T) {
+	lookup := func(secret, key string) (string, error) {
+		if secret == "slack-url" && key == "webhook" {
+			return "https://hooks.slack.com/from-secret\n", nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+
+	tests := []struct {
+		name    string
+		data    map[string]string
+		wantErr bool
+		check   func(t *testing.T, def *webhookDefinition)
+	}{
+		{
+			name: "defaults",
+			data: map[string]string{"type": "slack", "url": "https://x", "events": "backup_failed, restore_failed"},
+			check: func(t *testing.T, def *webhookDefinition) {
+				if def.ID != "secret-ops" || def.Name != "ops" || def.Source != "Secret/ops" || !def.Enabled {
+					t.Errorf("unexpected defaults: %+v", def)
+				}
+				if len(def.Events) != 2 || def.Events[1] != EventRestoreFailed {
+					t.Errorf("unexpected events: %v", def.Events)
+				}
+			},
+		},
+		{
+			name: "url from secret",
+			data: map[string]string{"type": "teams", "urlSecret": "slack-url", "urlSecretKey": "webhook", "events": "bsl_unavailable", "enabled": "false", "name": "Ops"},
+			check: func(t *testing.T, def *webhookDefinition) {
+				if def.URL != "https://hooks.slack.com/from-secret" || def.Enabled || def.Name != "Ops" {
+					t.Errorf("unexpected definition: %+v", def)
+				}
+			},
+		},
+		{name: "invalid type", data: map[string]string{"type": "pager", "url": "https://x", "events": "backup_failed"}, wantErr: true},
+		{name: "invalid event", data: map[string]string{"type": "slack", "url": "https://x", "events": "backup_done"}, wantErr: true},
+		{name: "no events", data: map[string]string{"type": "slack", "url": "https://x"}, wantErr: true},
+		{name: "no url", data: map[string]string{"type": "slack", "events": "backup_failed"}, wantErr: true},
+		{name: "missing url secret", data: map[string]string{"type": "slack", "urlSecret": "nope", "events": "backup_failed"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := parseDefinition("Secret", "ops", tt.data, lookup)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", def)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, def)
+		})
+	}
+}
+
+func TestReconcileDefinitions(t *testing.T) {
+	ctx := context.Background()
+	cs := newVersionedClientset()
+	store, err := newK8sStore(ctx, cs, "velero", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manual, err := store.Create(ctx, CreateWebhookRequest{Name: "manual", Type: WebhookGeneric, URL: "https://manual", Events: []EventType{EventBackupFailed}, Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secrets := cs.CoreV1().Secrets("velero")
+	configMaps := cs.CoreV1().ConfigMaps("velero")
+	_, _ = secrets.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ops-slack", Labels: definitionLabels},
+		Data: map[string][]byte{
+			"type":   []byte("slack"),
+			"url":    []byte("https://hooks.slack.com/ops"),
+			"events": []byte("backup_failed,cluster_disconnected"),
+		},
+	}, metav1.CreateOptions{})
+	_, _ = secrets.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "teams-url"},
+		Data:       map[string][]byte{"url": []byte("https://teams/hook")},
+	}, metav1.CreateOptions{})
+	_, _ = configMaps.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "platform-teams", Labels: definitionLabels},
+		Data:       map[string]string{"type": "teams", "urlSecret": "teams-url", "events": "restore_failed"},
+	}, metav1.CreateOptions{})
+
+	if !store.reconcileDefinitions(ctx) {
+		t.Fatal("expected reconciliation to change the store")
+	}
+	if store.reconcileDefinitions(ctx) {
+		t.Error("second reconciliation should be a no-op")
+	}
+
+	slack, err := store.Get(ctx, "secret-ops-slack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slack.Managed || slack.Source != "Secret/ops-slack" || slack.URL != "https://hooks.slack.com/ops" || len(slack.Events) != 2 {
+		t.Errorf("unexpected managed webhook: %+v", slack)
+	}
+	teams, err := store.Get(ctx, "configmap-platform-teams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teams.URL != "https://teams/hook" || teams.Type != WebhookTeams {
+		t.Errorf("unexpected managed webhook: %+v", teams)
+	}
+
+	// Managed webhooks are read-only but still record deliveries
+	name := "renamed"
+	if err := store.Update(ctx, slack.ID, UpdateWebhookRequest{Name: &name}); !errors.Is(err, ErrManaged) {
+		t.Errorf("expected ErrManaged on update, got %v", err)
+	}
+	if err := store.Delete(ctx, slack.ID); !errors.Is(err, ErrManaged) {
+		t.Errorf("expected ErrManaged on delete, got %v", err)
+	}
+	if err := store.UpdateDeliveryStatus(ctx, slack.ID, "success", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Editing the definition updates the webhook and keeps its delivery status
+	cm, _ := configMaps.Get(ctx, "platform-teams", metav1.GetOptions{})
+	cm.Data["enabled"] = "false"
+	_, _ = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	// A broken definition keeps the previously reconciled webhook
+	secret, _ := secrets.Get(ctx, "ops-slack", metav1.GetOptions{})
+	secret.Data["type"] = []byte("pagerduty")
+	_, _ = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+
+	store.reconcileDefinitions(ctx)
+	teams, _ = store.Get(ctx, "configmap-platform-teams")
+	if teams.Enabled {
+		t.Error("definition change not applied")
+	}
+	slack, err = store.Get(ctx, "secret-ops-slack")
+	if err != nil || slack.Type != WebhookSlack || slack.LastStatus != "success" {
+		t.Errorf("invalid definition should leave webhook untouched: %+v %v", slack, err)
+	}
+
+	// Removing the definitions removes the managed webhooks and their URLs
+	_ = secrets.Delete(ctx, "ops-slack", metav1.DeleteOptions{})
+	_ = configMaps.Delete(ctx, "platform-teams", metav1.DeleteOptions{})
+	store.reconcileDefinitions(ctx)
+
+	webhooks, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(webhooks) != 1 || webhooks[0].ID != manual.ID {
+		t.Errorf("expected only the manual webhook to remain, got %+v", webhooks)
+	}
+	urls, _ := store.loadURLs(ctx)
+	if _, ok := urls["secret-ops-slack"]; ok {
+		t.Error("managed webhook URL not removed")
+	}
+}
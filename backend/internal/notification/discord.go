@@ -48,7 +48,7 @@ func (s *DiscordSender) Send(ctx context.Context, url string, event Notification
 // Discord uses decimal colors
 func discordColor(t EventType) int {
 	switch t {
-	case EventBackupFailed, EventRestoreFailed:
+	case EventBackupFailed, EventRestoreFailed, EventClusterDisconnected, EventDrillFailed:
 		return 0xED4245 // Red
 	case EventBackupPartiallyFailed:
 		return 0xFEE75C // Yellow
@@ -41,6 +41,19 @@ func (m *Manager) Store() Store {
 	return m.store
 }
 
+// StartReconciliation keeps declaratively defined webhooks in sync. It is
+// only available with Kubernetes storage and blocks until ctx is done.
+func (m *Manager) StartReconciliation(ctx context.Context) error {
+	k8sStore, ok := m.store.(*K8sStore)
+	if !ok {
+		m.logger.Info("Webhook definitions not available (not using Kubernetes storage)")
+		return nil
+	}
+
+	m.logger.Info("Starting webhook reconciliation (watching for webhook definitions)")
+	return k8sStore.WatchDefinitions(ctx)
+}
+
 // Dispatch sends an event to all matching enabled webhooks.
 func (m *Manager) Dispatch(ctx context.Context, event NotificationEvent) {
 	webhooks, err := m.store.List(ctx)
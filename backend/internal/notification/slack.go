@@ -47,7 +47,7 @@ func (s *SlackSender) Send(ctx context.Context, url string, event NotificationEv
 
 func eventColor(t EventType) string {
 	switch t {
-	case EventBackupFailed, EventRestoreFailed:
+	case EventBackupFailed, EventRestoreFailed, EventClusterDisconnected, EventDrillFailed:
 		return "danger"
 	case EventBackupPartiallyFailed:
 		return "warning"
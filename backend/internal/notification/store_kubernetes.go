@@ -11,8 +11,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 )
 
 const (
@@ -21,6 +23,21 @@ const (
 	webhookDataKey       = "webhooks.json"
 )
 
+// conflictRetry bounds retries of writes that lost a resourceVersion race,
+// e.g. delivery status updates of webhooks firing for the same event.
+var conflictRetry = wait.Backoff{
+	Steps:    10,
+	Duration: 10 * time.Millisecond,
+	Factor:   1.5,
+	Jitter:   0.5,
+}
+
+// errNotFound aborts a mutation whose webhook has disappeared.
+var errNotFound = fmt.Errorf("webhook not found")
+
+// errUnchanged lets a mutation skip the write when it changed nothing.
+var errUnchanged = fmt.Errorf("unchanged")
+
 // K8sStore stores webhook configurations in Kubernetes ConfigMap + Secret.
 type K8sStore struct {
 	clientset kubernetes.Interface
@@ -40,6 +57,8 @@ type webhookMetadata struct {
 	LastSentAt *time.Time  `json:"lastSentAt,omitempty"`
 	LastStatus string      `json:"lastStatus,omitempty"`
 	LastError  string      `json:"lastError,omitempty"`
+	Managed    bool        `json:"managed,omitempty"`
+	Source     string      `json:"source,omitempty"`
 }
 
 // NewK8sStore creates a new Kubernetes notification store.
@@ -54,13 +73,17 @@ func NewK8sStore(namespace string, logger *zap.Logger) (*K8sStore, error) {
 		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
+	return newK8sStore(context.Background(), clientset, namespace, logger)
+}
+
+func newK8sStore(ctx context.Context, clientset kubernetes.Interface, namespace string, logger *zap.Logger) (*K8sStore, error) {
 	s := &K8sStore{
 		clientset: clientset,
 		namespace: namespace,
 		logger:    logger,
 	}
 
-	if err := s.ensureResources(context.Background()); err != nil {
+	if err := s.ensureResources(ctx); err != nil {
 		return nil, fmt.Errorf("failed to ensure resources: %w", err)
 	}
 
@@ -118,7 +141,10 @@ func (s *K8sStore) loadMetadata(ctx context.Context) (map[string]*webhookMetadat
 	if err != nil {
 		return nil, fmt.Errorf("failed to get configmap: %w", err)
 	}
+	return decodeMetadata(cm)
+}
 
+func decodeMetadata(cm *corev1.ConfigMap) (map[string]*webhookMetadata, error) {
 	data := cm.Data[webhookDataKey]
 	if data == "" {
 		return make(map[string]*webhookMetadata), nil
@@ -128,26 +154,45 @@ func (s *K8sStore) loadMetadata(ctx context.Context) (map[string]*webhookMetadat
 	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
 	}
+	if metadata == nil {
+		metadata = make(map[string]*webhookMetadata)
+	}
 	return metadata, nil
 }
 
-func (s *K8sStore) saveMetadata(ctx context.Context, metadata map[string]*webhookMetadata) error {
-	data, err := json.Marshal(metadata)
-	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
-	}
+// mutateMetadata applies fn to the webhook metadata and writes it back.
+// The update carries the resourceVersion of the read, so a concurrent write
+// makes it fail with a conflict and fn is re-applied to a fresh copy.
+func (s *K8sStore) mutateMetadata(ctx context.Context, fn func(metadata map[string]*webhookMetadata) error) error {
+	configMaps := s.clientset.CoreV1().ConfigMaps(s.namespace)
+	err := retry.RetryOnConflict(conflictRetry, func() error {
+		cm, err := configMaps.Get(ctx, webhookConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get configmap: %w", err)
+		}
+		metadata, err := decodeMetadata(cm)
+		if err != nil {
+			return err
+		}
+		if err := fn(metadata); err != nil {
+			return err
+		}
 
-	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, webhookConfigMapName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get configmap: %w", err)
-	}
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[webhookDataKey] = string(data)
 
-	if cm.Data == nil {
-		cm.Data = make(map[string]string)
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err == errUnchanged {
+		return nil
 	}
-	cm.Data[webhookDataKey] = string(data)
-
-	_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, metav1.UpdateOptions{})
 	return err
 }
 
@@ -164,55 +209,55 @@ func (s *K8sStore) loadURLs(ctx context.Context) (map[string]string, error) {
 	return urls, nil
 }
 
-func (s *K8sStore) saveURL(ctx context.Context, id string, url string) error {
-	secret, err := s.clientset.CoreV1().Secrets(s.namespace).Get(ctx, webhookSecretName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get secret: %w", err)
-	}
-
-	if secret.Data == nil {
-		secret.Data = make(map[string][]byte)
-	}
-	secret.Data[id] = []byte(url)
+// mutateURLs applies fn to the webhook URLs Secret, retrying on conflicts.
+func (s *K8sStore) mutateURLs(ctx context.Context, fn func(data map[string][]byte)) error {
+	secrets := s.clientset.CoreV1().Secrets(s.namespace)
+	return retry.RetryOnConflict(conflictRetry, func() error {
+		secret, err := secrets.Get(ctx, webhookSecretName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		fn(secret.Data)
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
 
-	_, err = s.clientset.CoreV1().Secrets(s.namespace).Update(ctx, secret, metav1.UpdateOptions{})
-	return err
+func (s *K8sStore) saveURL(ctx context.Context, id string, url string) error {
+	return s.mutateURLs(ctx, func(data map[string][]byte) {
+		data[id] = []byte(url)
+	})
 }
 
 func (s *K8sStore) deleteURL(ctx context.Context, id string) error {
-	secret, err := s.clientset.CoreV1().Secrets(s.namespace).Get(ctx, webhookSecretName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get secret: %w", err)
-	}
-
-	delete(secret.Data, id)
-	_, err = s.clientset.CoreV1().Secrets(s.namespace).Update(ctx, secret, metav1.UpdateOptions{})
-	return err
+	return s.mutateURLs(ctx, func(data map[string][]byte) {
+		delete(data, id)
+	})
 }
 
 func (s *K8sStore) Create(ctx context.Context, req CreateWebhookRequest) (*WebhookConfig, error) {
 	id := uuid.New().String()
 	now := time.Now()
 
-	metadata, err := s.loadMetadata(ctx)
+	err := s.mutateMetadata(ctx, func(metadata map[string]*webhookMetadata) error {
+		metadata[id] = &webhookMetadata{
+			ID:        id,
+			Name:      req.Name,
+			Type:      req.Type,
+			Events:    req.Events,
+			Enabled:   req.Enabled,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	metadata[id] = &webhookMetadata{
-		ID:        id,
-		Name:      req.Name,
-		Type:      req.Type,
-		Events:    req.Events,
-		Enabled:   req.Enabled,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-
-	if err := s.saveMetadata(ctx, metadata); err != nil {
-		return nil, err
-	}
-
 	if err := s.saveURL(ctx, id, req.URL); err != nil {
 		return nil, err
 	}
@@ -257,6 +302,8 @@ func (s *K8sStore) Get(ctx context.Context, id string) (*WebhookConfig, error) {
 		LastSentAt: meta.LastSentAt,
 		LastStatus: meta.LastStatus,
 		LastError:  meta.LastError,
+		Managed:    meta.Managed,
+		Source:     meta.Source,
 	}, nil
 }
 
@@ -285,37 +332,39 @@ func (s *K8sStore) List(ctx context.Context) ([]*WebhookConfig, error) {
 			LastSentAt: meta.LastSentAt,
 			LastStatus: meta.LastStatus,
 			LastError:  meta.LastError,
+			Managed:    meta.Managed,
+			Source:     meta.Source,
 		})
 	}
 	return results, nil
 }
 
 func (s *K8sStore) Update(ctx context.Context, id string, req UpdateWebhookRequest) error {
-	metadata, err := s.loadMetadata(ctx)
-	if err != nil {
-		return err
-	}
-
-	meta, ok := metadata[id]
-	if !ok {
-		return fmt.Errorf("webhook not found: %s", id)
-	}
-
-	if req.Name != nil {
-		meta.Name = *req.Name
-	}
-	if req.Type != nil {
-		meta.Type = *req.Type
-	}
-	if req.Events != nil {
-		meta.Events = req.Events
-	}
-	if req.Enabled != nil {
-		meta.Enabled = *req.Enabled
-	}
-	meta.UpdatedAt = time.Now()
+	err := s.mutateMetadata(ctx, func(metadata map[string]*webhookMetadata) error {
+		meta, ok := metadata[id]
+		if !ok {
+			return fmt.Errorf("webhook not found: %s", id)
+		}
+		if meta.Managed {
+			return ErrManaged
+		}
 
-	if err := s.saveMetadata(ctx, metadata); err != nil {
+		if req.Name != nil {
+			meta.Name = *req.Name
+		}
+		if req.Type != nil {
+			meta.Type = *req.Type
+		}
+		if req.Events != nil {
+			meta.Events = req.Events
+		}
+		if req.Enabled != nil {
+			meta.Enabled = *req.Enabled
+		}
+		meta.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
@@ -329,41 +378,41 @@ func (s *K8sStore) Update(ctx context.Context, id string, req UpdateWebhookReque
 }
 
 func (s *K8sStore) Delete(ctx context.Context, id string) error {
-	metadata, err := s.loadMetadata(ctx)
+	err := s.mutateMetadata(ctx, func(metadata map[string]*webhookMetadata) error {
+		meta, ok := metadata[id]
+		if !ok {
+			return fmt.Errorf("webhook not found: %s", id)
+		}
+		if meta.Managed {
+			return ErrManaged
+		}
+		delete(metadata, id)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	if _, ok := metadata[id]; !ok {
-		return fmt.Errorf("webhook not found: %s", id)
-	}
-
-	delete(metadata, id)
-	if err := s.saveMetadata(ctx, metadata); err != nil {
-		return err
-	}
-
 	return s.deleteURL(ctx, id)
 }
 
 func (s *K8sStore) UpdateDeliveryStatus(ctx context.Context, id string, status string, errMsg string) error {
-	metadata, err := s.loadMetadata(ctx)
-	if err != nil {
-		return err
-	}
-
-	meta, ok := metadata[id]
-	if !ok {
+	now := time.Now()
+	err := s.mutateMetadata(ctx, func(metadata map[string]*webhookMetadata) error {
+		meta, ok := metadata[id]
+		if !ok {
+			return errNotFound
+		}
+		meta.LastSentAt = &now
+		meta.LastStatus = status
+		meta.LastError = errMsg
+		meta.UpdatedAt = now
+		return nil
+	})
+	if err == errNotFound {
 		return nil // Silently ignore missing webhooks during delivery
 	}
-
-	now := time.Now()
-	meta.LastSentAt = &now
-	meta.LastStatus = status
-	meta.LastError = errMsg
-	meta.UpdatedAt = now
-
-	return s.saveMetadata(ctx, metadata)
+	return err
 }
 
 func (s *K8sStore) Close() error {
@@ -0,0 +1,181 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newVersionedClientset returns a fake clientset whose updates enforce
+// resourceVersion preconditions like the API server does.
+func newVersionedClientset() *fake.Clientset {
+	cs := fake.NewSimpleClientset()
+	for _, resource := range []string{"configmaps", "secrets"} {
+		cs.PrependReactor("update", resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+			update := action.(k8stesting.UpdateAction)
+			obj := update.GetObject().(metav1.Object)
+			gvr := update.GetResource()
+
+			current, err := cs.Tracker().Get(gvr, update.GetNamespace(), obj.GetName())
+			if err != nil {
+				return true, nil, err
+			}
+			currentVersion := current.(metav1.Object).GetResourceVersion()
+			if obj.GetResourceVersion() != currentVersion {
+				return true, nil, apierrors.NewConflict(gvr.GroupResource(), obj.GetName(),
+					fmt.Errorf("resourceVersion %q is stale, current is %q", obj.GetResourceVersion(), currentVersion))
+			}
+
+			n, _ := strconv.Atoi(currentVersion)
+			next := update.GetObject().DeepCopyObject()
+			next.(metav1.Object).SetResourceVersion(strconv.Itoa(n + 1))
+			if err := cs.Tracker().Update(gvr, next, update.GetNamespace()); err != nil {
+				return true, nil, err
+			}
+			return true, next, nil
+		})
+	}
+	return cs
+}
+
+func TestK8sStoreConcurrentWrites(t *testing.T) {
+	store, err := newK8sStore(context.Background(), newVersionedClientset(), "velero", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	const n = 6
+	ids := make([]string, n)
+	for i := range ids {
+		wh, err := store.Create(ctx, CreateWebhookRequest{
+			Name:    fmt.Sprintf("hook-%d", i),
+			Type:    WebhookSlack,
+			URL:     fmt.Sprintf("https://hooks.example.com/%d", i),
+			Events:  []EventType{EventBackupFailed},
+			Enabled: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = wh.ID
+	}
+
+	// Deliveries for one event fan out to every webhook while an admin edits them
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i, id := range ids {
+		wg.Add(2)
+		go func(id string) {
+			defer wg.Done()
+			errs <- store.UpdateDeliveryStatus(ctx, id, "success", "")
+		}(id)
+		go func(i int, id string) {
+			defer wg.Done()
+			name := fmt.Sprintf("renamed-%d", i)
+			url := fmt.Sprintf("https://hooks.example.com/new/%d", i)
+			errs <- store.Update(ctx, id, UpdateWebhookRequest{Name: &name, URL: &url})
+		}(i, id)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent write failed: %v", err)
+		}
+	}
+
+	for i, id := range ids {
+		wh, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wh.LastStatus != "success" || wh.LastSentAt == nil {
+			t.Errorf("delivery status lost for %s", wh.Name)
+		}
+		if wh.Name != fmt.Sprintf("renamed-%d", i) {
+			t.Errorf("rename lost: %s", wh.Name)
+		}
+		if wh.URL != fmt.Sprintf("https://hooks.example.com/new/%d", i) {
+			t.Errorf("URL update lost for %s: %s", wh.Name, wh.URL)
+		}
+	}
+}
+
+func TestK8sStoreRetriesOnConflict(t *testing.T) {
+	cs := newVersionedClientset()
+	store, err := newK8sStore(context.Background(), cs, "velero", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	wh, err := store.Create(ctx, CreateWebhookRequest{Name: "a", Type: WebhookSlack, URL: "https://a", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Just before the delivery status update lands, another replica records
+	// a new webhook. The fake clientset holds its lock while reactors run,
+	// so the competing write goes through the tracker.
+	interleaved := false
+	cs.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if interleaved {
+			return false, nil, nil
+		}
+		interleaved = true
+
+		update := action.(k8stesting.UpdateAction)
+		obj, err := cs.Tracker().Get(update.GetResource(), "velero", webhookConfigMapName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cm := obj.(*corev1.ConfigMap).DeepCopy()
+		metadata, _ := decodeMetadata(cm)
+		metadata["b"] = &webhookMetadata{ID: "b", Name: "b", Type: WebhookTeams}
+		data, _ := json.Marshal(metadata)
+		cm.Data[webhookDataKey] = string(data)
+		n, _ := strconv.Atoi(cm.ResourceVersion)
+		cm.ResourceVersion = strconv.Itoa(n + 1)
+		if err := cs.Tracker().Update(update.GetResource(), cm, "velero"); err != nil {
+			t.Fatal(err)
+		}
+		return false, nil, nil
+	})
+
+	if err := store.UpdateDeliveryStatus(ctx, wh.ID, "failed", "timeout"); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := store.loadMetadata(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := metadata["b"]; !ok {
+		t.Error("competing write lost")
+	}
+	if metadata[wh.ID].LastStatus != "failed" {
+		t.Errorf("delivery status lost: %+v", metadata[wh.ID])
+	}
+}
+
+func TestK8sStoreDeliveryStatusIgnoresMissingWebhook(t *testing.T) {
+	store, err := newK8sStore(context.Background(), newVersionedClientset(), "velero", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateDeliveryStatus(context.Background(), "missing", "success", ""); err != nil {
+		t.Errorf("expected missing webhook to be ignored, got %v", err)
+	}
+}
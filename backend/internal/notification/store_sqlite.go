@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/klinux/velero-dashboard/internal/migrate"
 	_ "github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
 )
@@ -25,16 +26,21 @@ func NewSQLiteStore(dbPath string, logger *zap.Logger) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	s := &SQLiteStore{db: db, logger: logger}
-	if err := s.migrate(); err != nil {
+	if err := migrate.Apply(db, "notifications", sqliteMigrations, logger); err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return s, nil
+	return &SQLiteStore{db: db, logger: logger}, nil
 }
 
-func (s *SQLiteStore) migrate() error {
-	_, err := s.db.Exec(`
+// sqliteMigrations is the notification store schema history. Never edit an
+// applied migration; append a new one instead.
+var sqliteMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create webhooks",
+		Up: migrate.Exec(`
 		CREATE TABLE IF NOT EXISTS webhooks (
 			id TEXT PRIMARY KEY,
 			name TEXT NOT NULL,
@@ -47,9 +53,8 @@ func (s *SQLiteStore) migrate() error {
 			last_sent_at TEXT,
 			last_status TEXT,
 			last_error TEXT
-		)
-	`)
-	return err
+		)`),
+	},
 }
 
 func (s *SQLiteStore) Create(_ context.Context, req CreateWebhookRequest) (*WebhookConfig, error) {
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteStoreUpgradeFromUnversionedSchema(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join("testdata", "schema_unversioned.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "clusters.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	store, err := NewSQLiteStore(path, zap.NewNop())
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	if v, err := migrate.Version(store.db, "notifications"); err != nil || v != len(sqliteMigrations) {
+		t.Errorf("expected version %d, got %d (%v)", len(sqliteMigrations), v, err)
+	}
+
+	webhooks, err := store.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(webhooks) != 1 || webhooks[0].Name != "ops" || webhooks[0].Events[0] != EventBackupFailed {
+		t.Errorf("webhook not preserved: %+v", webhooks)
+	}
+}
@@ -63,7 +63,7 @@ func (s *TeamsSender) Send(ctx context.Context, url string, event NotificationEv
 
 func teamsColor(t EventType) string {
 	switch t {
-	case EventBackupFailed, EventRestoreFailed:
+	case EventBackupFailed, EventRestoreFailed, EventClusterDisconnected, EventDrillFailed:
 		return "Attention"
 	case EventBackupPartiallyFailed, EventBSLUnavailable:
 		return "Warning"
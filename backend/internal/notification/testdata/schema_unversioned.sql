@@ -0,0 +1,17 @@
+-- Database as written before versioned migrations.
+CREATE TABLE webhooks (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	url TEXT NOT NULL,
+	events TEXT NOT NULL,
+	enabled INTEGER NOT NULL DEFAULT 1,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	last_sent_at TEXT,
+	last_status TEXT,
+	last_error TEXT
+);
+
+INSERT INTO webhooks (id, name, type, url, events, enabled, created_at, updated_at)
+VALUES ('w1', 'ops', 'slack', 'https://hooks.example.com/x', '["backup_failed"]', 1, '2024-05-01T10:00:00Z', '2024-05-01T10:00:00Z');
@@ -1,6 +1,9 @@
 package notification
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // WebhookType identifies the notification service type.
 type WebhookType string
@@ -12,6 +15,15 @@ const (
 	WebhookGeneric WebhookType = "webhook"
 )
 
+// Valid reports whether t is a supported webhook type.
+func (t WebhookType) Valid() bool {
+	switch t {
+	case WebhookSlack, WebhookTeams, WebhookDiscord, WebhookGeneric:
+		return true
+	}
+	return false
+}
+
 // EventType identifies the type of event that triggers a notification.
 type EventType string
 
@@ -20,8 +32,25 @@ const (
 	EventBackupPartiallyFailed EventType = "backup_partially_failed"
 	EventRestoreFailed         EventType = "restore_failed"
 	EventBSLUnavailable        EventType = "bsl_unavailable"
+	EventClusterDisconnected   EventType = "cluster_disconnected"
+	EventClusterRecovered      EventType = "cluster_recovered"
+	EventDrillFailed           EventType = "drill_failed"
 )
 
+// Valid reports whether e is a known event type.
+func (e EventType) Valid() bool {
+	switch e {
+	case EventBackupFailed, EventBackupPartiallyFailed, EventRestoreFailed,
+		EventBSLUnavailable, EventClusterDisconnected, EventClusterRecovered,
+		EventDrillFailed:
+		return true
+	}
+	return false
+}
+
+// ErrManaged is returned when changing a webhook defined declaratively.
+var ErrManaged = errors.New("webhook is managed declaratively and read-only")
+
 // WebhookConfig stores the configuration for a webhook endpoint.
 type WebhookConfig struct {
 	ID        string      `json:"id"`
@@ -37,6 +66,10 @@ type WebhookConfig struct {
 	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
 	LastStatus string     `json:"lastStatus,omitempty"` // "success" or "error"
 	LastError  string     `json:"lastError,omitempty"`
+
+	// Declarative webhooks, reconciled from labelled Secrets or ConfigMaps
+	Managed bool   `json:"managed,omitempty"`
+	Source  string `json:"source,omitempty"` // e.g. "Secret/ops-slack"
 }
 
 // CreateWebhookRequest is the payload for creating a webhook.
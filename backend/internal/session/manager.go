@@ -0,0 +1,315 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"go.uber.org/zap"
+)
+
+const (
+	// reuseGrace is how long the refresh token replaced by a rotation is
+	// still accepted, so tabs refreshing at the same time don't end the
+	// session.
+	reuseGrace = 30 * time.Second
+	// activeCacheTTL bounds how long Active trusts an earlier lookup.
+	// Revocations through this process take effect at once, those through
+	// another replica within this delay.
+	activeCacheTTL = 10 * time.Second
+	// pruneInterval is how often expired sessions are deleted.
+	pruneInterval = time.Hour
+)
+
+// Manager starts, refreshes and revokes browser sessions. Refresh tokens
+// look like "<id>.<secret>"; the ID finds the session, the secret is checked
+// against its SHA-256 hash. It implements auth.SessionStore.
+type Manager struct {
+	store  Store
+	ttl    time.Duration
+	logger *zap.Logger
+	now    func() time.Time
+
+	refreshMu sync.Mutex // serializes refreshes
+
+	mu     sync.Mutex
+	active map[string]time.Time // session ID -> when it was last seen in the store
+}
+
+// NewManager creates a session manager. Sessions end ttl after login,
+// however often they are refreshed.
+func NewManager(store Store, ttl time.Duration, logger *zap.Logger) *Manager {
+	return &Manager{
+		store:  store,
+		ttl:    ttl,
+		logger: logger,
+		now:    time.Now,
+		active: make(map[string]time.Time),
+	}
+}
+
+// Start deletes expired sessions periodically until ctx is done.
+func (m *Manager) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		for {
+			m.prune(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (m *Manager) prune(ctx context.Context) {
+	sessions, err := m.store.List(ctx)
+	if err != nil {
+		m.logger.Warn("Failed to list sessions for pruning", zap.Error(err))
+		return
+	}
+	now := m.now()
+	for _, s := range sessions {
+		if s.Expired(now) {
+			if err := m.delete(ctx, s.ID); err != nil && !errors.Is(err, ErrNotFound) {
+				m.logger.Warn("Failed to delete expired session", zap.String("session", s.ID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Create starts a session for user.
+func (m *Manager) Create(ctx context.Context, user auth.UserInfo, client auth.SessionClient) (*auth.IssuedSession, error) {
+	now := m.now()
+	s := &Session{
+		ID:          uuid.New().String(),
+		User:        user,
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+		CreatedAt:   now,
+		RefreshedAt: now,
+		ExpiresAt:   now.Add(m.ttl),
+	}
+	raw, err := s.rotate()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Create(ctx, s); err != nil {
+		return nil, err
+	}
+	m.markActive(s.ID, now)
+	return s.issued(raw), nil
+}
+
+// Refresh rotates a refresh token and returns the session's new one. A
+// token replaced more than reuseGrace ago has leaked, so presenting it ends
+// the session.
+func (m *Manager) Refresh(ctx context.Context, raw string, reload auth.UserReloader) (*auth.IssuedSession, error) {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	s, err := m.lookup(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	h := hash(raw)
+	switch {
+	case s.Expired(now):
+		return nil, ErrInvalid
+	case subtle.ConstantTimeCompare([]byte(s.RefreshHash), []byte(h)) == 1:
+	case subtle.ConstantTimeCompare([]byte(s.PreviousHash), []byte(h)) == 1 && now.Sub(s.RefreshedAt) < reuseGrace:
+	case subtle.ConstantTimeCompare([]byte(s.PreviousHash), []byte(h)) == 1:
+		m.logger.Warn("Refresh token reused, revoking session",
+			zap.String("session", s.ID), zap.String("username", s.User.Username))
+		if err := m.delete(ctx, s.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		return nil, ErrInvalid
+	default:
+		return nil, ErrInvalid
+	}
+
+	if reload != nil {
+		user, err := reload(s.User)
+		if err != nil {
+			m.logger.Info("Ending session of rejected user",
+				zap.String("session", s.ID), zap.String("username", s.User.Username), zap.Error(err))
+			if err := m.delete(ctx, s.ID); err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+			return nil, ErrInvalid
+		}
+		s.User = user
+	}
+
+	next, err := s.rotate()
+	if err != nil {
+		return nil, err
+	}
+	s.RefreshedAt = now
+	if err := m.store.Update(ctx, s); err != nil {
+		return nil, err
+	}
+	m.markActive(s.ID, now)
+	return s.issued(next), nil
+}
+
+// Active reports whether a session exists and hasn't expired. Lookups are
+// cached for activeCacheTTL; store errors count as inactive.
+func (m *Manager) Active(ctx context.Context, id string) bool {
+	now := m.now()
+	m.mu.Lock()
+	seen, ok := m.active[id]
+	m.mu.Unlock()
+	if ok && now.Sub(seen) < activeCacheTTL {
+		return true
+	}
+
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			m.logger.Warn("Failed to look up session", zap.String("session", id), zap.Error(err))
+		}
+		m.forget(id)
+		return false
+	}
+	if s.Expired(now) {
+		m.forget(id)
+		return false
+	}
+	m.markActive(id, now)
+	return true
+}
+
+// Logout ends the session a refresh token belongs to.
+func (m *Manager) Logout(ctx context.Context, raw string) error {
+	s, err := m.lookup(ctx, raw)
+	if err != nil {
+		return err
+	}
+	h := hash(raw)
+	if subtle.ConstantTimeCompare([]byte(s.RefreshHash), []byte(h)) != 1 &&
+		subtle.ConstantTimeCompare([]byte(s.PreviousHash), []byte(h)) != 1 {
+		return ErrInvalid
+	}
+	return m.delete(ctx, s.ID)
+}
+
+// Get returns a session.
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	return m.store.Get(ctx, id)
+}
+
+// List returns the unexpired sessions of username, or of everyone when
+// username is empty.
+func (m *Manager) List(ctx context.Context, username string) ([]*Session, error) {
+	all, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	sessions := make([]*Session, 0, len(all))
+	for _, s := range all {
+		if !s.Expired(now) && (username == "" || s.User.Username == username) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+// Revoke ends a session; requests with its access tokens fail from then on.
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	return m.delete(ctx, id)
+}
+
+// RevokeUser ends every session of username and returns how many there were.
+func (m *Manager) RevokeUser(ctx context.Context, username string) (int, error) {
+	all, err := m.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, s := range all {
+		if s.User.Username != username {
+			continue
+		}
+		if err := m.delete(ctx, s.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// Close closes the underlying store.
+func (m *Manager) Close() error {
+	return m.store.Close()
+}
+
+func (m *Manager) lookup(ctx context.Context, raw string) (*Session, error) {
+	id, _, ok := strings.Cut(raw, ".")
+	if !ok || id == "" {
+		return nil, ErrInvalid
+	}
+	s, err := m.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalid
+	}
+	return s, err
+}
+
+func (m *Manager) delete(ctx context.Context, id string) error {
+	m.forget(id)
+	return m.store.Delete(ctx, id)
+}
+
+func (m *Manager) markActive(id string, at time.Time) {
+	m.mu.Lock()
+	m.active[id] = at
+	m.mu.Unlock()
+}
+
+func (m *Manager) forget(id string) {
+	m.mu.Lock()
+	delete(m.active, id)
+	m.mu.Unlock()
+}
+
+// rotate replaces the session's refresh token and returns the new one.
+func (s *Session) rotate() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	raw := s.ID + "." + base64.RawURLEncoding.EncodeToString(secret)
+	s.PreviousHash = s.RefreshHash
+	s.RefreshHash = hash(raw)
+	return raw, nil
+}
+
+func (s *Session) issued(refreshToken string) *auth.IssuedSession {
+	return &auth.IssuedSession{
+		ID:           s.ID,
+		User:         s.User,
+		RefreshToken: refreshToken,
+		ExpiresAt:    s.ExpiresAt,
+	}
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
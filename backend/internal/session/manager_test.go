@@ -0,0 +1,188 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"go.uber.org/zap"
+)
+
+func newTestManager(t *testing.T) (*Manager, *time.Time) {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "sessions.db"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	m := NewManager(store, 12*time.Hour, zap.NewNop())
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestManagerRefreshRotates(t *testing.T) {
+	ctx := context.Background()
+	m, now := newTestManager(t)
+	alice := auth.UserInfo{Username: "alice", Role: auth.RoleAdmin}
+
+	issued, err := m.Create(ctx, alice, auth.SessionClient{UserAgent: "test", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Active(ctx, issued.ID) {
+		t.Fatal("new session must be active")
+	}
+	stored, err := m.Get(ctx, issued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.RefreshHash == "" || stored.RefreshHash == issued.RefreshToken {
+		t.Fatalf("refresh token must be stored hashed, got %q", stored.RefreshHash)
+	}
+
+	*now = now.Add(15 * time.Minute)
+	next, err := m.Refresh(ctx, issued.RefreshToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ID != issued.ID || next.RefreshToken == issued.RefreshToken || next.User.Username != "alice" {
+		t.Errorf("refreshed = %+v", next)
+	}
+	if !next.ExpiresAt.Equal(issued.ExpiresAt) {
+		t.Errorf("refreshing must not extend the session: %v != %v", next.ExpiresAt, issued.ExpiresAt)
+	}
+
+	// A second tab refreshing with the old token right away is tolerated.
+	*now = now.Add(5 * time.Second)
+	if _, err := m.Refresh(ctx, issued.RefreshToken, nil); err != nil {
+		t.Fatalf("refresh within the grace period: %v", err)
+	}
+
+	if _, err := m.Refresh(ctx, issued.ID+".forged", nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a forged token, got %v", err)
+	}
+
+	*now = now.Add(12 * time.Hour)
+	latest, err := m.List(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 0 {
+		t.Errorf("expired sessions must not be listed, got %d", len(latest))
+	}
+	if m.Active(ctx, issued.ID) {
+		t.Error("expired session must not be active")
+	}
+}
+
+func TestManagerRefreshReuseRevokes(t *testing.T) {
+	ctx := context.Background()
+	m, now := newTestManager(t)
+
+	issued, err := m.Create(ctx, auth.UserInfo{Username: "alice", Role: auth.RoleViewer}, auth.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := m.Refresh(ctx, issued.RefreshToken, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, err := m.Refresh(ctx, issued.RefreshToken, nil); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for a reused token, got %v", err)
+	}
+	if m.Active(ctx, issued.ID) {
+		t.Error("reusing a rotated refresh token must end the session")
+	}
+	if _, err := m.Refresh(ctx, next.RefreshToken, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("the latest token must die with the session, got %v", err)
+	}
+}
+
+func TestManagerRefreshReload(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	issued, err := m.Create(ctx, auth.UserInfo{Username: "alice", Role: auth.RoleAdmin}, auth.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	demote := func(u auth.UserInfo) (auth.UserInfo, error) {
+		u.Role = auth.RoleViewer
+		return u, nil
+	}
+	next, err := m.Refresh(ctx, issued.RefreshToken, demote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.User.Role != auth.RoleViewer {
+		t.Errorf("role = %q, want viewer", next.User.Role)
+	}
+
+	reject := func(auth.UserInfo) (auth.UserInfo, error) { return auth.UserInfo{}, errors.New("gone") }
+	if _, err := m.Refresh(ctx, next.RefreshToken, reject); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid for a rejected user, got %v", err)
+	}
+	if m.Active(ctx, issued.ID) {
+		t.Error("rejected user's session must end")
+	}
+}
+
+func TestManagerRevoke(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	var ids []string
+	for _, name := range []string{"alice", "alice", "bob"} {
+		issued, err := m.Create(ctx, auth.UserInfo{Username: name, Role: auth.RoleViewer}, auth.SessionClient{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, issued.ID)
+	}
+
+	n, err := m.RevokeUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("revoked %d sessions, want 2", n)
+	}
+	if m.Active(ctx, ids[0]) || m.Active(ctx, ids[1]) || !m.Active(ctx, ids[2]) {
+		t.Error("only alice's sessions must be revoked")
+	}
+
+	if err := m.Revoke(ctx, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if m.Active(ctx, ids[2]) {
+		t.Error("revoked session must not be active")
+	}
+	if err := m.Revoke(ctx, ids[2]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestManagerLogout(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t)
+
+	issued, err := m.Create(ctx, auth.UserInfo{Username: "alice", Role: auth.RoleViewer}, auth.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Logout(ctx, issued.ID+".forged"); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	if err := m.Logout(ctx, issued.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if m.Active(ctx, issued.ID) {
+		t.Error("session must end on logout")
+	}
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Store persists sessions.
+type Store interface {
+	Create(ctx context.Context, s *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	List(ctx context.Context) ([]*Session, error)
+	Update(ctx context.Context, s *Session) error
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
+
+// StoreConfig holds configuration for creating a session store.
+type StoreConfig struct {
+	StorageType string // "auto", "kubernetes", "sqlite"
+	DBPath      string // For SQLite
+	Namespace   string // For Kubernetes
+}
+
+// NewStore creates a session store based on the storage type.
+func NewStore(cfg StoreConfig, logger *zap.Logger) (Store, error) {
+	storageType := cfg.StorageType
+	if storageType == "" || storageType == "auto" {
+		if isInCluster() {
+			storageType = "kubernetes"
+		} else {
+			storageType = "sqlite"
+		}
+	}
+
+	switch storageType {
+	case "kubernetes":
+		return NewK8sStore(cfg.Namespace, logger)
+	case "sqlite":
+		dbPath := cfg.DBPath
+		if dbPath == "" {
+			dbPath = "./sessions.db"
+		}
+		return NewSQLiteStore(dbPath, logger)
+	default:
+		return nil, fmt.Errorf("unknown session storage type: %s", storageType)
+	}
+}
+
+// records stores each session in its own Secret, or as a row of the
+// sessions table.
+var records = jsonstore.Config[Session]{
+	Kind:        "session",
+	Key:         func(s *Session) string { return s.ID },
+	Less:        func(a, b *Session) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	ErrNotFound: ErrNotFound,
+
+	Component: "session",
+	Prefix:    "velero-dashboard-session-",
+	Secret:    true,
+
+	Schema:     "sessions",
+	Migrations: sqliteMigrations,
+	Table:      "sessions",
+	KeyColumn:  "id",
+	CreatedAt:  func(s *Session) time.Time { return s.CreatedAt },
+}
+
+// sqliteMigrations is the session store schema history. Never edit an
+// applied migration; append a new one instead.
+var sqliteMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create sessions",
+		Up: migrate.Exec(
+			`CREATE TABLE IF NOT EXISTS sessions (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`,
+		),
+	},
+}
+
+// NewK8sStore creates a store keeping sessions in Secrets.
+func NewK8sStore(namespace string, logger *zap.Logger) (Store, error) {
+	store, err := jsonstore.NewK8sStore(namespace, records, logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func newK8sStore(clientset kubernetes.Interface, namespace string, logger *zap.Logger) *jsonstore.K8sStore[Session] {
+	return jsonstore.NewK8sStoreForClient(clientset, namespace, records, logger)
+}
+
+// NewSQLiteStore creates a store keeping sessions in SQLite.
+func NewSQLiteStore(dbPath string, logger *zap.Logger) (Store, error) {
+	store, err := jsonstore.NewSQLiteStore(dbPath, records, logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func isInCluster() bool {
+	_, err := rest.InClusterConfig()
+	return err == nil
+}
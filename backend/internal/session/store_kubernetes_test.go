@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newK8sStore(fake.NewSimpleClientset(), "velero", zap.NewNop())
+
+	now := time.Now()
+	first := &Session{ID: "one", User: auth.UserInfo{Username: "alice"}, RefreshHash: "abc", CreatedAt: now}
+	second := &Session{ID: "two", User: auth.UserInfo{Username: "bob"}, RefreshHash: "def", CreatedAt: now.Add(time.Second)}
+	for _, s := range []*Session{second, first} {
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first.RefreshedAt = now
+	if err := store.Update(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RefreshHash != "abc" || !got.RefreshedAt.Equal(now) {
+		t.Errorf("session = %+v", got)
+	}
+
+	sessions, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "one" {
+		t.Errorf("sessions = %+v", sessions)
+	}
+
+	if err := store.Delete(ctx, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "one"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Update(ctx, first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
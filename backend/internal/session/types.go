@@ -0,0 +1,48 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+)
+
+var (
+	// ErrNotFound is returned when a session doesn't exist.
+	ErrNotFound = errors.New("session not found")
+	// ErrInvalid is returned for malformed, unknown, revoked, reused and
+	// expired refresh tokens alike.
+	ErrInvalid = errors.New("invalid or expired session")
+)
+
+// Session is the server-side half of a browser login. Access tokens carry
+// its ID and are only accepted while it exists; the refresh token, of which
+// only a hash is stored, rotates on every use.
+type Session struct {
+	ID          string        `json:"id"`
+	User        auth.UserInfo `json:"user"`
+	UserAgent   string        `json:"userAgent,omitempty"`
+	IP          string        `json:"ip,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	RefreshedAt time.Time     `json:"refreshedAt"`
+	ExpiresAt   time.Time     `json:"expiresAt"`
+
+	// RefreshHash is the hash of the current refresh token, PreviousHash
+	// that of the token it replaced. Presenting the previous token again
+	// after a short grace period means it was stolen, and ends the session.
+	RefreshHash  string `json:"refreshHash,omitempty"`
+	PreviousHash string `json:"previousHash,omitempty"`
+}
+
+// Expired reports whether the session has expired at now.
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// Public returns the session without its hashes, as the API lists it.
+func (s *Session) Public() *Session {
+	cp := *s
+	cp.RefreshHash = ""
+	cp.PreviousHash = ""
+	return &cp
+}
@@ -0,0 +1,240 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"go.uber.org/zap"
+)
+
+// lastUsedInterval bounds how often a token's last use is written back.
+const lastUsedInterval = time.Minute
+
+// ownerCacheTTL bounds how long a personal token owner's resolved role and
+// groups are reused.
+const ownerCacheTTL = 30 * time.Second
+
+// Manager issues, verifies and revokes API tokens. Tokens look like
+// "vdt_<id>.<secret>"; the ID finds the record, the secret is checked
+// against its SHA-256 hash. Secrets are random, so a plain hash suffices.
+type Manager struct {
+	store  Store
+	logger *zap.Logger
+	now    func() time.Time
+
+	users  auth.UserResolver
+	mu     sync.Mutex
+	owners map[string]cachedOwner
+
+	// createMu serializes creating service tokens, whose names are unique.
+	createMu sync.Mutex
+}
+
+type cachedOwner struct {
+	user    auth.UserInfo
+	expires time.Time
+}
+
+// NewManager creates a token manager.
+func NewManager(store Store, logger *zap.Logger) *Manager {
+	return &Manager{store: store, logger: logger, now: time.Now, owners: map[string]cachedOwner{}}
+}
+
+// SetUsers makes personal tokens act with their owner's current role and
+// groups, as users resolves them, instead of those stored on creation.
+// Tokens of owners it rejects stop working.
+func (m *Manager) SetUsers(users auth.UserResolver) {
+	m.users = users
+}
+
+// ForgetOwner drops the cached role and groups of username, so a change to
+// the user applies to their tokens on the next request.
+func (m *Manager) ForgetOwner(username string) {
+	m.mu.Lock()
+	delete(m.owners, username)
+	m.mu.Unlock()
+}
+
+// Create issues a token for creator. grantable is the highest role the
+// token may hold. It returns the stored token and the secret token string,
+// which can't be recovered later.
+func (m *Manager) Create(ctx context.Context, creator *auth.UserInfo, grantable string, req CreateRequest) (*Token, string, error) {
+	now := m.now()
+	if err := req.Validate(now); err != nil {
+		return nil, "", err
+	}
+	if !auth.RoleHierarchy(grantable, req.Role) {
+		return nil, "", ErrRoleTooHigh
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	t := &Token{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Role:      req.Role,
+		Clusters:  req.Clusters,
+		CreatedBy: creator.Username,
+		CreatedAt: now,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if t.Kind == KindPersonal {
+		t.Owner = auth.UserInfo{
+			Username: creator.Username,
+			Email:    creator.Email,
+			Role:     creator.Role,
+			Groups:   creator.Groups,
+		}
+	}
+	raw := auth.APITokenPrefix + t.ID + "." + base64.RawURLEncoding.EncodeToString(secret)
+	t.Hash = hash(raw)
+
+	// Service tokens act as "token:<name>", which role bindings and audit
+	// logs refer to, so two live ones can't share a name
+	if t.Kind == KindService {
+		m.createMu.Lock()
+		defer m.createMu.Unlock()
+		all, err := m.store.List(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, other := range all {
+			if other.Kind == KindService && other.Name == t.Name && !other.Expired(now) {
+				return nil, "", ErrNameTaken
+			}
+		}
+	}
+	if err := m.store.Create(ctx, t); err != nil {
+		return nil, "", err
+	}
+	return t, raw, nil
+}
+
+// Verify returns who raw acts as. It implements auth.APITokenVerifier.
+func (m *Manager) Verify(ctx context.Context, raw string) (*auth.UserInfo, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(raw, auth.APITokenPrefix), ".")
+	if !ok || id == "" {
+		return nil, ErrInvalid
+	}
+	t, err := m.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hash(raw))) != 1 {
+		return nil, ErrInvalid
+	}
+	now := m.now()
+	if t.Expired(now) {
+		return nil, ErrInvalid
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= lastUsedInterval {
+		t.LastUsedAt = &now
+		if err := m.store.Update(ctx, t); err != nil {
+			m.logger.Warn("Failed to record token use", zap.String("token", t.ID), zap.Error(err))
+		}
+	}
+
+	if t.Kind != KindPersonal || m.users == nil {
+		return t.User(), nil
+	}
+	owner, err := m.owner(ctx, t.Owner)
+	if err != nil {
+		m.logger.Debug("Token owner rejected", zap.String("token", t.ID), zap.String("owner", t.Owner.Username), zap.Error(err))
+		return nil, ErrInvalid
+	}
+	return t.userAs(owner), nil
+}
+
+// owner resolves the current role and groups of a personal token's owner.
+func (m *Manager) owner(ctx context.Context, stored auth.UserInfo) (auth.UserInfo, error) {
+	now := m.now()
+	m.mu.Lock()
+	cached, ok := m.owners[stored.Username]
+	m.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.user, nil
+	}
+
+	user, err := m.users.ResolveUser(ctx, stored)
+	if err != nil {
+		return auth.UserInfo{}, err
+	}
+	m.mu.Lock()
+	m.owners[stored.Username] = cachedOwner{user: user, expires: now.Add(ownerCacheTTL)}
+	m.mu.Unlock()
+	return user, nil
+}
+
+// Get returns a token.
+func (m *Manager) Get(ctx context.Context, id string) (*Token, error) {
+	return m.store.Get(ctx, id)
+}
+
+// List returns the tokens created by or acting as username, or all tokens
+// when username is empty.
+func (m *Manager) List(ctx context.Context, username string) ([]*Token, error) {
+	all, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]*Token, 0, len(all))
+	for _, t := range all {
+		if username == "" || t.CreatedBy == username || t.Owner.Username == username {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+// Revoke deletes a token; requests using it fail from then on.
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	return m.store.Delete(ctx, id)
+}
+
+// RevokeOwner deletes every personal token acting as username and returns
+// how many there were. Service tokens are kept: they don't act as a person.
+func (m *Manager) RevokeOwner(ctx context.Context, username string) (int, error) {
+	all, err := m.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, t := range all {
+		if t.Kind != KindPersonal || t.Owner.Username != username {
+			continue
+		}
+		if err := m.store.Delete(ctx, t.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// Close closes the underlying store.
+func (m *Manager) Close() error {
+	return m.store.Close()
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,240 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+	"go.uber.org/zap"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "tokens.db"), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return NewManager(store, zap.NewNop())
+}
+
+func TestManagerCreateAndVerify(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	creator := &auth.UserInfo{Username: "alice", Email: "alice@example.com", Role: auth.RoleAdmin, Groups: []string{"platform"}}
+
+	tok, raw, err := m.Create(ctx, creator, auth.RoleAdmin, CreateRequest{Name: "ci", Role: auth.RoleOperator, Clusters: []string{"prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth.IsAPIToken(raw) || strings.Contains(tok.Hash, raw) || tok.Hash == "" {
+		t.Fatalf("unexpected token %q with hash %q", raw, tok.Hash)
+	}
+	if tok.Kind != KindPersonal {
+		t.Errorf("kind = %q, want personal", tok.Kind)
+	}
+
+	user, err := m.Verify(ctx, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice" || user.Role != auth.RoleOperator || user.RoleLimit != auth.RoleOperator ||
+		user.TokenID != tok.ID || len(user.Clusters) != 1 || user.Groups[0] != "platform" {
+		t.Errorf("user = %+v", user)
+	}
+	if user.GlobalRole() != auth.RoleNone {
+		t.Errorf("cluster-scoped token must hold no global role, got %q", user.GlobalRole())
+	}
+
+	stored, err := m.Get(ctx, tok.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.LastUsedAt == nil {
+		t.Error("expected last use to be recorded")
+	}
+
+	for _, bad := range []string{raw + "x", auth.APITokenPrefix + tok.ID, auth.APITokenPrefix + "missing.secret"} {
+		if _, err := m.Verify(ctx, bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Verify(%q) = %v, want ErrInvalid", bad, err)
+		}
+	}
+
+	if err := m.Revoke(ctx, tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, raw); !errors.Is(err, ErrInvalid) {
+		t.Errorf("revoked token verified: %v", err)
+	}
+}
+
+func TestManagerExpiry(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	expires := now.Add(time.Hour)
+	_, raw, err := m.Create(ctx, &auth.UserInfo{Username: "bob", Role: auth.RoleViewer}, auth.RoleViewer,
+		CreateRequest{Name: "short", Role: auth.RoleViewer, ExpiresAt: &expires})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, raw); err != nil {
+		t.Fatal(err)
+	}
+	now = expires
+	if _, err := m.Verify(ctx, raw); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expired token verified: %v", err)
+	}
+
+	past := now.Add(-time.Minute)
+	if _, _, err := m.Create(ctx, &auth.UserInfo{Username: "bob", Role: auth.RoleViewer}, auth.RoleViewer,
+		CreateRequest{Name: "past", Role: auth.RoleViewer, ExpiresAt: &past}); err == nil {
+		t.Error("expected error for an expiry in the past")
+	}
+}
+
+func TestManagerServiceNamesUnique(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	admin := &auth.UserInfo{Username: "alice", Role: auth.RoleAdmin}
+	service := func(name string, expires *time.Time) error {
+		_, _, err := m.Create(ctx, admin, auth.RoleAdmin, CreateRequest{Name: name, Kind: KindService, Role: auth.RoleViewer, ExpiresAt: expires})
+		return err
+	}
+
+	expires := now.Add(time.Hour)
+	if err := service("ci", &expires); err != nil {
+		t.Fatal(err)
+	}
+	if err := service("ci", nil); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("expected ErrNameTaken, got %v", err)
+	}
+	// Personal tokens act as their owner and may share names
+	if _, _, err := m.Create(ctx, admin, auth.RoleAdmin, CreateRequest{Name: "ci", Role: auth.RoleViewer}); err != nil {
+		t.Errorf("personal token named like a service token: %v", err)
+	}
+	// The name is free again once the token expired
+	now = expires
+	if err := service("ci", nil); err != nil {
+		t.Errorf("name of an expired token: %v", err)
+	}
+}
+
+func TestManagerRoleLimits(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	operator := &auth.UserInfo{Username: "olga", Role: auth.RoleOperator}
+
+	if _, _, err := m.Create(ctx, operator, auth.RoleOperator, CreateRequest{Name: "admin", Role: auth.RoleAdmin}); !errors.Is(err, ErrRoleTooHigh) {
+		t.Errorf("expected ErrRoleTooHigh, got %v", err)
+	}
+
+	_, raw, err := m.Create(ctx, operator, auth.RoleOperator, CreateRequest{Name: "deploys", Kind: KindService, Role: auth.RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := m.Verify(ctx, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "token:deploys" || user.Role != auth.RoleViewer || len(user.Groups) != 0 {
+		t.Errorf("service token user = %+v", user)
+	}
+
+	// A personal token never exceeds its owner's global role, even when
+	// role bindings let the owner create it with a higher one.
+	scoped := &auth.UserInfo{Username: "pat", Role: auth.RoleNone, Groups: []string{"payments"}}
+	_, raw, err = m.Create(ctx, scoped, auth.RoleOperator, CreateRequest{Name: "pat-ci", Role: auth.RoleOperator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := m.Verify(ctx, raw); user.Role != auth.RoleNone || user.RoleLimit != auth.RoleOperator {
+		t.Errorf("personal token user = %+v", user)
+	}
+
+	if mine, _ := m.List(ctx, "olga"); len(mine) != 1 {
+		t.Errorf("olga has %d tokens, want 1", len(mine))
+	}
+	if all, _ := m.List(ctx, ""); len(all) != 2 {
+		t.Errorf("%d tokens, want 2", len(all))
+	}
+}
+
+func TestManagerRevokeOwner(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	admin := &auth.UserInfo{Username: "alice", Role: auth.RoleAdmin}
+
+	for _, req := range []CreateRequest{
+		{Name: "laptop", Role: auth.RoleAdmin},
+		{Name: "ci", Role: auth.RoleViewer},
+		{Name: "backups", Kind: KindService, Role: auth.RoleOperator},
+	} {
+		if _, _, err := m.Create(ctx, admin, auth.RoleAdmin, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := m.RevokeOwner(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("revoked %d tokens, want 2", n)
+	}
+	left, _ := m.List(ctx, "")
+	if len(left) != 1 || left[0].Kind != KindService {
+		t.Errorf("service tokens must survive their creator's offboarding, left %+v", left)
+	}
+}
+
+// fakeUsers resolves owners from a map; missing users are rejected.
+type fakeUsers map[string]auth.UserInfo
+
+func (f fakeUsers) ResolveUser(_ context.Context, info auth.UserInfo) (auth.UserInfo, error) {
+	user, ok := f[info.Username]
+	if !ok {
+		return auth.UserInfo{}, auth.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func TestManagerResolvesOwner(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	users := fakeUsers{"alice": {Username: "alice", Role: auth.RoleAdmin, Groups: []string{"platform"}}}
+	m.SetUsers(users)
+
+	_, raw, err := m.Create(ctx, &auth.UserInfo{Username: "alice", Role: auth.RoleAdmin}, auth.RoleAdmin, CreateRequest{Name: "laptop", Role: auth.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, err := m.Verify(ctx, raw); err != nil || user.Role != auth.RoleAdmin || user.Groups[0] != "platform" {
+		t.Fatalf("user = %+v, %v", user, err)
+	}
+
+	// A demoted owner's token loses the role it was created with.
+	users["alice"] = auth.UserInfo{Username: "alice", Role: auth.RoleViewer}
+	if user, _ := m.Verify(ctx, raw); user.Role != auth.RoleAdmin {
+		t.Errorf("expected the cached owner before ForgetOwner, got %+v", user)
+	}
+	m.ForgetOwner("alice")
+	if user, err := m.Verify(ctx, raw); err != nil || user.Role != auth.RoleViewer || len(user.Groups) != 0 {
+		t.Errorf("demoted owner's token user = %+v, %v", user, err)
+	}
+
+	// Cached owners expire on their own too.
+	delete(users, "alice")
+	m.now = func() time.Time { return time.Now().Add(ownerCacheTTL) }
+	if _, err := m.Verify(ctx, raw); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a removed owner, got %v", err)
+	}
+}
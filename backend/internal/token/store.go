@@ -0,0 +1,117 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/jsonstore"
+	"github.com/klinux/velero-dashboard/internal/migrate"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Store persists API tokens.
+type Store interface {
+	Create(ctx context.Context, t *Token) error
+	Get(ctx context.Context, id string) (*Token, error)
+	List(ctx context.Context) ([]*Token, error)
+	Update(ctx context.Context, t *Token) error
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
+
+// StoreConfig holds configuration for creating a token store.
+type StoreConfig struct {
+	StorageType string // "auto", "kubernetes", "sqlite"
+	DBPath      string // For SQLite
+	Namespace   string // For Kubernetes
+}
+
+// NewStore creates a token store based on the storage type.
+func NewStore(cfg StoreConfig, logger *zap.Logger) (Store, error) {
+	storageType := cfg.StorageType
+	if storageType == "" || storageType == "auto" {
+		if isInCluster() {
+			storageType = "kubernetes"
+		} else {
+			storageType = "sqlite"
+		}
+	}
+
+	switch storageType {
+	case "kubernetes":
+		return NewK8sStore(cfg.Namespace, logger)
+	case "sqlite":
+		dbPath := cfg.DBPath
+		if dbPath == "" {
+			dbPath = "./tokens.db"
+		}
+		return NewSQLiteStore(dbPath, logger)
+	default:
+		return nil, fmt.Errorf("unknown token storage type: %s", storageType)
+	}
+}
+
+// records stores each token in its own Secret, or as a row of the
+// api_tokens table.
+var records = jsonstore.Config[Token]{
+	Kind:        "token",
+	Key:         func(t *Token) string { return t.ID },
+	Less:        func(a, b *Token) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	ErrNotFound: ErrNotFound,
+
+	Component: "api-token",
+	Prefix:    "velero-dashboard-token-",
+	Secret:    true,
+
+	Schema:     "api-tokens",
+	Migrations: sqliteMigrations,
+	Table:      "api_tokens",
+	KeyColumn:  "id",
+	CreatedAt:  func(t *Token) time.Time { return t.CreatedAt },
+}
+
+// sqliteMigrations is the token store schema history. Never edit an
+// applied migration; append a new one instead.
+var sqliteMigrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create api tokens",
+		Up: migrate.Exec(
+			`CREATE TABLE IF NOT EXISTS api_tokens (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			)`,
+		),
+	},
+}
+
+// NewK8sStore creates a store keeping tokens in Secrets.
+func NewK8sStore(namespace string, logger *zap.Logger) (Store, error) {
+	store, err := jsonstore.NewK8sStore(namespace, records, logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func newK8sStore(clientset kubernetes.Interface, namespace string, logger *zap.Logger) *jsonstore.K8sStore[Token] {
+	return jsonstore.NewK8sStoreForClient(clientset, namespace, records, logger)
+}
+
+// NewSQLiteStore creates a store keeping tokens in SQLite.
+func NewSQLiteStore(dbPath string, logger *zap.Logger) (Store, error) {
+	store, err := jsonstore.NewSQLiteStore(dbPath, records, logger)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func isInCluster() bool {
+	_, err := rest.InClusterConfig()
+	return err == nil
+}
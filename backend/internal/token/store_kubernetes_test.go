@@ -0,0 +1,55 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newK8sStore(fake.NewSimpleClientset(), "velero", zap.NewNop())
+
+	now := time.Now()
+	first := &Token{ID: "one", Name: "ci", Kind: KindService, Role: "operator", Hash: "abc", CreatedAt: now}
+	second := &Token{ID: "two", Name: "cli", Kind: KindPersonal, Role: "viewer", Hash: "def", CreatedAt: now.Add(time.Second)}
+	for _, tok := range []*Token{second, first} {
+		if err := store.Create(ctx, tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first.LastUsedAt = &now
+	if err := store.Update(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Hash != "abc" || got.LastUsedAt == nil {
+		t.Errorf("token = %+v", got)
+	}
+
+	tokens, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].ID != "one" {
+		t.Errorf("tokens = %+v", tokens)
+	}
+
+	if err := store.Delete(ctx, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "one"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Update(ctx, first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/klinux/velero-dashboard/internal/auth"
+)
+
+// Kind tells whose permissions a token carries.
+type Kind string
+
+const (
+	// KindPersonal tokens act as the user who created them, within the
+	// token's role and clusters.
+	KindPersonal Kind = "personal"
+	// KindService tokens act as "token:<name>", holding the token's role.
+	// Role bindings can name that user like any other.
+	KindService Kind = "service"
+)
+
+// ServiceUserPrefix starts the username of service tokens.
+const ServiceUserPrefix = "token:"
+
+var (
+	// ErrNotFound is returned when a token doesn't exist.
+	ErrNotFound = errors.New("token not found")
+	// ErrInvalid is returned for malformed, unknown, revoked and expired
+	// tokens alike.
+	ErrInvalid = errors.New("invalid or expired token")
+	// ErrRoleTooHigh is returned when a token would exceed its creator's role.
+	ErrRoleTooHigh = errors.New("token role exceeds your own")
+	// ErrNameTaken is returned when an unexpired service token already has
+	// the name, which service tokens act under.
+	ErrNameTaken = errors.New("a service token with this name already exists")
+)
+
+// Token is a long-lived API token. Only a hash of its secret is stored; the
+// secret is returned once, on creation.
+type Token struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Kind       Kind          `json:"kind"`
+	Role       string        `json:"role"`
+	Clusters   []string      `json:"clusters,omitempty"` // empty allows every cluster
+	Owner      auth.UserInfo `json:"owner"`              // who a personal token acts as
+	CreatedBy  string        `json:"createdBy"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ExpiresAt  *time.Time    `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time    `json:"lastUsedAt,omitempty"`
+	Hash       string        `json:"hash,omitempty"`
+}
+
+// Expired reports whether the token has expired at now.
+func (t *Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Public returns the token without its hash, as the API lists it.
+func (t *Token) Public() *Token {
+	cp := *t
+	cp.Hash = ""
+	return &cp
+}
+
+// User returns who the token acts as, with the role and groups its owner
+// had when creating it.
+func (t *Token) User() *auth.UserInfo {
+	return t.userAs(t.Owner)
+}
+
+// userAs returns who the token acts as when its owner currently is owner.
+// Personal tokens never exceed the token's role.
+func (t *Token) userAs(owner auth.UserInfo) *auth.UserInfo {
+	user := &auth.UserInfo{
+		Username: ServiceUserPrefix + t.Name,
+		Role:     t.Role,
+	}
+	if t.Kind == KindPersonal {
+		user.Username = owner.Username
+		user.Email = owner.Email
+		user.Groups = owner.Groups
+		user.Role = owner.Role
+		if !auth.RoleHierarchy(t.Role, user.Role) {
+			user.Role = t.Role
+		}
+	}
+	user.TokenID = t.ID
+	user.Clusters = t.Clusters
+	user.RoleLimit = t.Role
+	if t.ExpiresAt != nil {
+		user.TokenExpiresAt = *t.ExpiresAt
+	}
+	return user
+}
+
+// CreateRequest is the payload for creating a token.
+type CreateRequest struct {
+	Name      string     `json:"name"`
+	Kind      Kind       `json:"kind,omitempty"` // defaults to personal
+	Role      string     `json:"role"`
+	Clusters  []string   `json:"clusters,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Validate checks the request and fills in defaults.
+func (r *CreateRequest) Validate(now time.Time) error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > 63 {
+		return errors.New("name must be at most 63 characters")
+	}
+	if r.Kind == "" {
+		r.Kind = KindPersonal
+	}
+	if r.Kind != KindPersonal && r.Kind != KindService {
+		return fmt.Errorf("kind must be %s or %s", KindPersonal, KindService)
+	}
+	if r.Role != auth.RoleViewer && r.Role != auth.RoleOperator && r.Role != auth.RoleAdmin {
+		return errors.New("role must be viewer, operator or admin")
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		return errors.New("expiresAt must be in the future")
+	}
+	return nil
+}
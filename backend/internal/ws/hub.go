@@ -3,30 +3,50 @@ package ws
 import (
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/gofiber/contrib/websocket"
 	"go.uber.org/zap"
 )
 
+const writeTimeout = 10 * time.Second
+
+// Filter reports whether an event may be sent to a client.
+type Filter func(event interface{}) bool
+
+type client struct {
+	conn   *websocket.Conn
+	filter Filter
+	mu     sync.Mutex // a conn supports only one concurrent writer
+}
+
+func (c *client) write(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteMessage(messageType, data)
+}
+
 // Hub manages WebSocket connections and broadcasts events.
 type Hub struct {
 	mu      sync.RWMutex
-	clients map[*websocket.Conn]bool
+	clients map[*websocket.Conn]*client
 	logger  *zap.Logger
 }
 
 func NewHub(logger *zap.Logger) *Hub {
 	return &Hub{
-		clients: make(map[*websocket.Conn]bool),
+		clients: make(map[*websocket.Conn]*client),
 		logger:  logger,
 	}
 }
 
-// Register adds a new WebSocket connection.
-func (h *Hub) Register(conn *websocket.Conn) {
+// Register adds a new WebSocket connection. Only events accepted by filter
+// are sent to it.
+func (h *Hub) Register(conn *websocket.Conn, filter Filter) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.clients[conn] = true
+	h.clients[conn] = &client{conn: conn, filter: filter}
 	h.logger.Debug("WebSocket client connected", zap.Int("total", len(h.clients)))
 }
 
@@ -38,7 +58,19 @@ func (h *Hub) Unregister(conn *websocket.Conn) {
 	h.logger.Debug("WebSocket client disconnected", zap.Int("total", len(h.clients)))
 }
 
-// Broadcast sends a message to all connected clients.
+// Close sends a close frame to a connection and closes it.
+func (h *Hub) Close(conn *websocket.Conn, code int, reason string) {
+	h.mu.RLock()
+	c, ok := h.clients[conn]
+	h.mu.RUnlock()
+	if ok {
+		_ = c.write(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+	}
+	h.Unregister(conn)
+	_ = conn.Close()
+}
+
+// Broadcast sends a message to all connected clients whose filter accepts it.
 func (h *Hub) Broadcast(event interface{}) {
 	data, err := json.Marshal(event)
 	if err != nil {
@@ -49,8 +81,11 @@ func (h *Hub) Broadcast(event interface{}) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for conn := range h.clients {
-		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	for conn, c := range h.clients {
+		if c.filter != nil && !c.filter(event) {
+			continue
+		}
+		if err := c.write(websocket.TextMessage, data); err != nil {
 			h.logger.Debug("Failed to write to WebSocket client", zap.Error(err))
 			go func(c *websocket.Conn) {
 				h.Unregister(c)
@@ -111,6 +111,12 @@ rules:
 - apiGroups: [""]
   resources: ["pods", "pods/log", "namespaces", "persistentvolumes", "persistentvolumeclaims"]
   verbs: ["get", "list", "watch"]
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["get", "create", "update"]
+- apiGroups: [""]
+  resources: ["namespaces"]
+  verbs: ["create", "delete"]
 EOF
 echo -e "${GREEN}✓${NC} ClusterRole created"
 echo ""
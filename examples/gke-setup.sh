@@ -127,6 +127,14 @@ rules:
 - apiGroups: [""]
   resources: ["namespaces", "persistentvolumes", "persistentvolumeclaims"]
   verbs: ["get", "list", "watch"]
+# Restore resource modifiers (migration jobs)
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["get", "create", "update"]
+# Scratch namespaces (restore drills)
+- apiGroups: [""]
+  resources: ["namespaces"]
+  verbs: ["create", "delete"]
 EOF
 echo -e "${GREEN}✓${NC} Created ClusterRole 'velero-dashboard'"
 echo ""
@@ -16,7 +16,7 @@ const localStorageMock = {
 Object.defineProperty(globalThis, "localStorage", { value: localStorageMock });
 
 // Import after mock
-import { listBackups, createBackup, deleteBackup, getDashboardStats } from "@/lib/api";
+import { listBackups, createBackup, deleteBackup, getDashboardStats, changePassword } from "@/lib/api";
 
 beforeEach(() => {
   mockFetch.mockReset();
@@ -96,4 +96,50 @@ describe("API client", () => {
       expect.any(Object)
     );
   });
+
+  it("refreshes an expired access token once and retries", async () => {
+    localStorageMock.getItem.mockReturnValue("expired-token");
+    const backups = [{ name: "test", phase: "Completed" }];
+    mockFetch
+      .mockResolvedValueOnce({ ok: false, status: 401, json: async () => ({}) })
+      .mockResolvedValueOnce({
+        ok: true,
+        status: 200,
+        json: async () => ({ token: "fresh-token", username: "alice", role: "admin" }),
+      })
+      .mockResolvedValueOnce({ ok: true, status: 200, json: async () => backups });
+
+    const result = await listBackups();
+    expect(result).toEqual(backups);
+    expect(mockFetch).toHaveBeenNthCalledWith(
+      2,
+      "/api/auth/refresh",
+      expect.objectContaining({ method: "POST", credentials: "include" })
+    );
+    expect(localStorageMock.setItem).toHaveBeenCalledWith("velero_token", "fresh-token");
+  });
+
+  it("reports a rejected password change without refreshing", async () => {
+    mockFetch.mockResolvedValueOnce({
+      ok: false,
+      status: 401,
+      json: async () => ({ error: "invalid credentials" }),
+    });
+
+    await expect(changePassword("bob", "wrong", "brand-new-secret")).rejects.toThrow(
+      "invalid credentials"
+    );
+    expect(mockFetch).toHaveBeenCalledTimes(1);
+    expect(mockFetch).toHaveBeenCalledWith(
+      "/api/auth/password",
+      expect.objectContaining({
+        method: "POST",
+        body: JSON.stringify({
+          username: "bob",
+          currentPassword: "wrong",
+          newPassword: "brand-new-secret",
+        }),
+      })
+    );
+  });
 });
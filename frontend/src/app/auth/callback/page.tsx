@@ -1,36 +1,34 @@
 "use client";
 
 import { useEffect } from "react";
-import { useRouter, useSearchParams } from "next/navigation";
+import { useRouter } from "next/navigation";
 import { Center, Loader, Text, Stack } from "@mantine/core";
 import { useAuthStore, type Role } from "@/lib/auth";
-import { getMe } from "@/lib/api";
+import { getMe, refreshSession } from "@/lib/api";
 
 export default function AuthCallbackPage() {
   const router = useRouter();
-  const searchParams = useSearchParams();
   const { setAuth } = useAuthStore();
 
   useEffect(() => {
-    const token = searchParams.get("token");
-    if (!token) {
-      router.push("/login");
-      return;
-    }
-
-    // Temporarily store token so getMe() can use it
-    localStorage.setItem("velero_token", token);
-
-    getMe()
+    // The backend started the session in the HttpOnly refresh cookie; trade
+    // it for an access token, then ask who we are.
+    refreshSession()
+      .then((ok) => {
+        if (!ok) throw new Error("no session");
+        return getMe();
+      })
       .then((user) => {
+        const token = useAuthStore.getState().token;
+        if (!token) throw new Error("no session");
         setAuth(token, user.username, user.role as Role);
         router.push("/");
       })
       .catch(() => {
-        localStorage.removeItem("velero_token");
+        useAuthStore.getState().logout();
         router.push("/login");
       });
-  }, [searchParams, setAuth, router]);
+  }, [setAuth, router]);
 
   return (
     <Center h="100vh">
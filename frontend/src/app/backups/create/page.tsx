@@ -19,11 +19,13 @@ import Link from "next/link";
 import { useRouter } from "next/navigation";
 import { useCreateBackup } from "@/hooks/use-backups";
 import { useBackupLocations } from "@/hooks/use-settings";
+import { useNamespaceAccess } from "@/hooks/use-clusters";
 
 export default function CreateBackupPage() {
   const router = useRouter();
   const createMutation = useCreateBackup();
   const { data: locations } = useBackupLocations();
+  const nsAccess = useNamespaceAccess("operator");
 
   const form = useForm({
     initialValues: {
@@ -41,6 +43,14 @@ export default function CreateBackupPage() {
       notifications.show({ title: "Error", message: "Backup name is required", color: "red" });
       return;
     }
+    if (nsAccess.restricted && values.includedNamespaces.length === 0) {
+      notifications.show({
+        title: "Error",
+        message: "Select at least one of your namespaces",
+        color: "red",
+      });
+      return;
+    }
 
     createMutation.mutate(
       {
@@ -102,7 +112,13 @@ export default function CreateBackupPage() {
             <TagsInput
               label="Included Namespaces"
               placeholder="Press Enter to add"
-              description="Leave empty for all namespaces"
+              description={
+                nsAccess.restricted
+                  ? `Your namespaces: ${nsAccess.namespaces.join(", ")}`
+                  : "Leave empty for all namespaces"
+              }
+              data={nsAccess.namespaces}
+              withAsterisk={nsAccess.restricted}
               {...form.getInputProps("includedNamespaces")}
             />
 
@@ -125,8 +125,11 @@ export default function ClustersPage() {
     switch (status) {
       case "connected":
         return "green";
-      case "disconnected":
+      case "connecting":
+        return "blue";
+      case "degraded":
         return "yellow";
+      case "disconnected":
       case "error":
         return "red";
       default:
@@ -138,6 +141,7 @@ export default function ClustersPage() {
     switch (status) {
       case "connected":
         return <IconPlugConnected size={14} />;
+      case "degraded":
       case "error":
         return <IconAlertTriangle size={14} />;
       default:
@@ -198,6 +202,7 @@ export default function ClustersPage() {
                   <Table.Th>Cluster</Table.Th>
                   <Table.Th>Status</Table.Th>
                   <Table.Th>Namespace</Table.Th>
+                  <Table.Th>Versions</Table.Th>
                   <Table.Th>Created</Table.Th>
                   <Table.Th>Last Check</Table.Th>
                   <Table.Th ta="right">Actions</Table.Th>
@@ -261,6 +266,28 @@ export default function ClustersPage() {
                         {cluster.namespace}
                       </Text>
                     </Table.Td>
+                    <Table.Td>
+                      {cluster.metadata ? (
+                        <div>
+                          <Text size="sm">
+                            K8s {cluster.metadata.kubernetesVersion || "?"} · Velero{" "}
+                            {cluster.metadata.veleroVersion || "?"}
+                          </Text>
+                          <Text size="xs" c="dimmed">
+                            {[
+                              cluster.metadata.provider,
+                              `${cluster.metadata.nodeCount} nodes`,
+                            ]
+                              .filter(Boolean)
+                              .join(" · ")}
+                          </Text>
+                        </div>
+                      ) : (
+                        <Text size="sm" c="dimmed">
+                          —
+                        </Text>
+                      )}
+                    </Table.Td>
                     <Table.Td>
                       <Group gap={4}>
                         <IconClock
@@ -0,0 +1,353 @@
+"use client";
+
+import {
+  Title,
+  Stack,
+  Group,
+  Button,
+  Text,
+  Badge,
+  ActionIcon,
+  Tooltip,
+  Table,
+  List,
+  ThemeIcon,
+  Loader,
+} from "@mantine/core";
+import { useDisclosure } from "@mantine/hooks";
+import { notifications } from "@mantine/notifications";
+import { DataTable } from "mantine-datatable";
+import {
+  IconPlus,
+  IconPlayerPlay,
+  IconEdit,
+  IconTrash,
+  IconCheck,
+  IconX,
+} from "@tabler/icons-react";
+import { useState } from "react";
+import { StatusBadge } from "@/components/status-badge";
+import { ConfirmDelete } from "@/components/confirm-delete";
+import { DrillModal } from "@/components/drill-modal";
+import { useDrills, useDrillRuns, useDeleteDrill, useRunDrill } from "@/hooks/use-drills";
+import { useClusters } from "@/hooks/use-clusters";
+import { useAuthStore, hasRole } from "@/lib/auth";
+import { formatDate, formatDuration } from "@/lib/utils";
+import type { Drill, DrillRun } from "@/lib/types";
+
+function RunChecks({ run }: { run: DrillRun }) {
+  if (run.checks.length === 0) {
+    return (
+      <Text size="xs" c="dimmed">
+        {run.message || "No checks recorded"}
+      </Text>
+    );
+  }
+
+  return (
+    <List spacing={2} size="xs">
+      {run.checks.map((check) => (
+        <List.Item
+          key={check.name}
+          icon={
+            <ThemeIcon color={check.passed ? "green" : "red"} size={16} radius="xl">
+              {check.passed ? <IconCheck size={10} /> : <IconX size={10} />}
+            </ThemeIcon>
+          }
+        >
+          <Text span size="xs" fw={500}>
+            {check.name}
+          </Text>{" "}
+          <Text span size="xs" c="dimmed">
+            {check.message}
+          </Text>
+        </List.Item>
+      ))}
+    </List>
+  );
+}
+
+function RunHistory({ drill }: { drill: Drill }) {
+  const { data: runs, isLoading } = useDrillRuns(drill.id);
+
+  if (isLoading) {
+    return (
+      <Group p="md">
+        <Loader size="sm" />
+      </Group>
+    );
+  }
+  if (!runs || runs.length === 0) {
+    return (
+      <Text size="sm" c="dimmed" p="md">
+        This drill has not run yet
+      </Text>
+    );
+  }
+
+  return (
+    <Table p="md" verticalSpacing="xs">
+      <Table.Thead>
+        <Table.Tr>
+          <Table.Th>Started</Table.Th>
+          <Table.Th>Result</Table.Th>
+          <Table.Th>Backup</Table.Th>
+          <Table.Th>Items</Table.Th>
+          <Table.Th>Checks</Table.Th>
+          <Table.Th>Cleanup</Table.Th>
+        </Table.Tr>
+      </Table.Thead>
+      <Table.Tbody>
+        {runs.map((run) => (
+          <Table.Tr key={run.id}>
+            <Table.Td>
+              <Text size="xs">{formatDate(run.startedAt)}</Text>
+              <Text size="xs" c="dimmed">
+                {run.trigger === "manual" ? `manual${run.triggeredBy ? ` by ${run.triggeredBy}` : ""}` : "scheduled"}
+                {" · "}
+                {formatDuration(run.startedAt, run.completedAt)}
+              </Text>
+            </Table.Td>
+            <Table.Td>
+              <StatusBadge phase={run.phase} />
+            </Table.Td>
+            <Table.Td>
+              <Text size="xs">{run.backupName || "-"}</Text>
+            </Table.Td>
+            <Table.Td>
+              <Text size="xs">
+                {run.itemsRestored}
+                {run.errors > 0 && ` (${run.errors} errors)`}
+              </Text>
+            </Table.Td>
+            <Table.Td>
+              <RunChecks run={run} />
+              {run.phase === "Failed" && run.checks.length > 0 && run.message && (
+                <Text size="xs" c="red" mt={4} lineClamp={2}>
+                  {run.message}
+                </Text>
+              )}
+            </Table.Td>
+            <Table.Td>
+              <Text size="xs" c="dimmed">
+                {run.cleanup || "-"}
+              </Text>
+            </Table.Td>
+          </Table.Tr>
+        ))}
+      </Table.Tbody>
+    </Table>
+  );
+}
+
+export default function DrillsPage() {
+  const { role } = useAuthStore();
+  const canManage = hasRole(role, "operator");
+  const { data: drills, isLoading } = useDrills();
+  const { data: clusters } = useClusters();
+  const deleteMutation = useDeleteDrill();
+  const runMutation = useRunDrill();
+
+  const [modalOpened, { open: openModal, close: closeModal }] = useDisclosure(false);
+  const [editTarget, setEditTarget] = useState<Drill | null>(null);
+  const [deleteTarget, setDeleteTarget] = useState<Drill | null>(null);
+  const [deleteOpened, { open: openDelete, close: closeDelete }] = useDisclosure(false);
+
+  const clusterName = (id: string) => clusters?.find((c) => c.id === id)?.name || id;
+
+  const handleRun = (drill: Drill) => {
+    runMutation.mutate(drill.id, {
+      onSuccess: () => {
+        notifications.show({
+          title: "Drill started",
+          message: `Restore drill "${drill.name}" is running`,
+          color: "blue",
+        });
+      },
+      onError: (err) => {
+        notifications.show({ title: "Failed to start drill", message: err.message, color: "red" });
+      },
+    });
+  };
+
+  const confirmDelete = () => {
+    if (!deleteTarget) return;
+    deleteMutation.mutate(deleteTarget.id, {
+      onSuccess: () => {
+        notifications.show({
+          title: "Drill deleted",
+          message: `Restore drill "${deleteTarget.name}" deleted`,
+          color: "green",
+        });
+        closeDelete();
+        setDeleteTarget(null);
+      },
+      onError: (err) => {
+        notifications.show({ title: "Delete failed", message: err.message, color: "red" });
+      },
+    });
+  };
+
+  return (
+    <Stack gap="lg">
+      <Group justify="space-between">
+        <Title order={2}>Restore Drills</Title>
+        {canManage && (
+          <Button
+            leftSection={<IconPlus size={16} />}
+            onClick={() => {
+              setEditTarget(null);
+              openModal();
+            }}
+          >
+            New Drill
+          </Button>
+        )}
+      </Group>
+
+      <Text size="sm" c="dimmed">
+        A restore drill periodically restores the latest backup of a schedule into scratch
+        namespaces, checks the result against its success criteria, and deletes the namespaces
+        again. Expand a drill to see its run history.
+      </Text>
+
+      <DataTable
+        withTableBorder={false}
+        borderRadius="md"
+        striped
+        highlightOnHover
+        fetching={isLoading}
+        records={drills || []}
+        idAccessor="id"
+        minHeight={150}
+        noRecordsText="No restore drills yet"
+        rowExpansion={{ content: ({ record }) => <RunHistory drill={record} /> }}
+        columns={[
+          {
+            accessor: "name",
+            title: "Name",
+            render: (drill) => (
+              <Group gap="xs">
+                <Text size="sm">{drill.name}</Text>
+                {!drill.enabled && (
+                  <Badge color="gray" variant="light" size="sm">
+                    Disabled
+                  </Badge>
+                )}
+              </Group>
+            ),
+          },
+          {
+            accessor: "lastRun",
+            title: "Last Result",
+            render: (drill) =>
+              drill.running ? (
+                <StatusBadge phase="Running" />
+              ) : drill.lastRun ? (
+                <Tooltip label={drill.lastRun.message || ""} disabled={!drill.lastRun.message} multiline w={320}>
+                  <span>
+                    <StatusBadge phase={drill.lastRun.phase} />
+                  </span>
+                </Tooltip>
+              ) : (
+                "-"
+              ),
+          },
+          {
+            accessor: "schedule",
+            title: "Schedule",
+            render: (drill) => `${clusterName(drill.sourceClusterId)} / ${drill.schedule}`,
+          },
+          {
+            accessor: "targetClusterId",
+            title: "Restores Into",
+            render: (drill) => (
+              <Text size="sm">
+                {clusterName(drill.targetClusterId)}: {Object.values(drill.namespaceMapping).join(", ")}
+              </Text>
+            ),
+          },
+          {
+            accessor: "cron",
+            title: "Cron",
+            render: (drill) => (
+              <Text size="sm" ff="monospace">
+                {drill.cron}
+              </Text>
+            ),
+          },
+          {
+            accessor: "lastRunAt",
+            title: "Last Run",
+            render: (drill) => (drill.lastRun ? formatDate(drill.lastRun.startedAt) : "-"),
+          },
+          {
+            accessor: "nextRun",
+            title: "Next Run",
+            render: (drill) => (drill.nextRun ? formatDate(drill.nextRun) : "-"),
+          },
+          {
+            accessor: "actions",
+            title: "",
+            textAlign: "right",
+            render: (drill) =>
+              canManage ? (
+                <Group gap={4} justify="flex-end" wrap="nowrap">
+                  <Tooltip label="Run now">
+                    <ActionIcon
+                      variant="subtle"
+                      disabled={drill.running}
+                      loading={runMutation.isPending && runMutation.variables === drill.id}
+                      onClick={(e) => {
+                        e.stopPropagation();
+                        handleRun(drill);
+                      }}
+                    >
+                      <IconPlayerPlay size={16} />
+                    </ActionIcon>
+                  </Tooltip>
+                  <Tooltip label="Edit">
+                    <ActionIcon
+                      variant="subtle"
+                      onClick={(e) => {
+                        e.stopPropagation();
+                        setEditTarget(drill);
+                        openModal();
+                      }}
+                    >
+                      <IconEdit size={16} />
+                    </ActionIcon>
+                  </Tooltip>
+                  <Tooltip label="Delete">
+                    <ActionIcon
+                      variant="subtle"
+                      color="red"
+                      disabled={drill.running}
+                      onClick={(e) => {
+                        e.stopPropagation();
+                        setDeleteTarget(drill);
+                        openDelete();
+                      }}
+                    >
+                      <IconTrash size={16} />
+                    </ActionIcon>
+                  </Tooltip>
+                </Group>
+              ) : null,
+          },
+        ]}
+      />
+
+      <DrillModal opened={modalOpened} onClose={closeModal} drill={editTarget} />
+
+      <ConfirmDelete
+        opened={deleteOpened}
+        onClose={closeDelete}
+        onConfirm={confirmDelete}
+        title="Delete Drill"
+        message={`Delete restore drill "${deleteTarget?.name}"? Its run history is kept.`}
+        loading={deleteMutation.isPending}
+      />
+    </Stack>
+  );
+}
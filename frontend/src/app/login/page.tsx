@@ -6,6 +6,7 @@ import {
   Paper,
   TextInput,
   PasswordInput,
+  Textarea,
   Button,
   Title,
   Stack,
@@ -14,9 +15,10 @@ import {
   Text,
 } from "@mantine/core";
 import { IconAlertCircle } from "@tabler/icons-react";
-import { useAuthStore, type Role } from "@/lib/auth";
-import { loginBasic, getAuthConfig } from "@/lib/api";
+import { useAuthStore, type Role, type AuthMode } from "@/lib/auth";
+import { loginBasic, loginKubernetes, getAuthConfig, PASSWORD_CHANGE_REQUIRED } from "@/lib/api";
 import { VeleroLogo } from "@/components/velero-logo";
+import { ChangePasswordForm } from "@/components/change-password-form";
 
 export default function LoginPage() {
   const router = useRouter();
@@ -25,6 +27,8 @@ export default function LoginPage() {
   const [password, setPassword] = useState("");
   const [error, setError] = useState("");
   const [loading, setLoading] = useState(false);
+  const [mustChange, setMustChange] = useState(false);
+  const [k8sToken, setK8sToken] = useState("");
 
   useEffect(() => {
     if (isAuthenticated && authMode !== "none") {
@@ -35,7 +39,7 @@ export default function LoginPage() {
   useEffect(() => {
     if (!authMode) {
       getAuthConfig()
-        .then((c) => setAuthMode(c.mode as "none" | "basic" | "oidc"))
+        .then((c) => setAuthMode(c.mode as AuthMode))
         .catch(() => setAuthMode("none"));
     }
   }, [authMode, setAuthMode]);
@@ -51,12 +55,33 @@ export default function LoginPage() {
     } catch (err: unknown) {
       const message =
         err instanceof Error ? err.message : "Login failed";
+      if (message === PASSWORD_CHANGE_REQUIRED) {
+        // An admin chose this password; the user must pick their own.
+        setMustChange(true);
+        setPassword("");
+        return;
+      }
       setError(message);
     } finally {
       setLoading(false);
     }
   };
 
+  const handleKubernetesLogin = async (e?: React.FormEvent) => {
+    e?.preventDefault();
+    setLoading(true);
+    setError("");
+    try {
+      const res = await loginKubernetes(k8sToken.trim());
+      setAuth(res.token, res.username, res.role as Role);
+      router.push("/");
+    } catch (err: unknown) {
+      setError(err instanceof Error ? err.message : "Login failed");
+    } finally {
+      setLoading(false);
+    }
+  };
+
   const handleOIDCLogin = () => {
     const apiBase = process.env.NEXT_PUBLIC_API_URL || "";
     window.location.href = `${apiBase}/api/auth/oidc/login`;
@@ -86,7 +111,25 @@ export default function LoginPage() {
           </Alert>
         )}
 
-        {authMode === "basic" && (
+        {authMode === "basic" && mustChange && (
+          <Stack>
+            <Alert color="yellow" variant="light">
+              Choose a new password for {username} to finish signing in.
+            </Alert>
+            <ChangePasswordForm
+              username={username}
+              currentLabel="Temporary password"
+              submitLabel="Set password and sign in"
+              onChanged={(res) => {
+                setAuth(res.token, res.username, res.role as Role);
+                router.push("/");
+              }}
+              onError={setError}
+            />
+          </Stack>
+        )}
+
+        {(authMode === "basic" || authMode === "ldap") && !mustChange && (
           <form onSubmit={handleBasicLogin}>
             <Stack>
               <TextInput
@@ -118,6 +161,34 @@ export default function LoginPage() {
           </Stack>
         )}
 
+        {authMode === "kubernetes" && (
+          <form onSubmit={handleKubernetesLogin}>
+            <Stack>
+              <Textarea
+                label="Kubernetes token"
+                description="For example the output of kubectl create token <service-account>"
+                placeholder="eyJhbGciOi..."
+                value={k8sToken}
+                onChange={(e) => setK8sToken(e.currentTarget.value)}
+                autosize
+                minRows={3}
+                maxRows={6}
+                required
+              />
+              <Button type="submit" fullWidth loading={loading}>
+                Sign in
+              </Button>
+            </Stack>
+          </form>
+        )}
+
+        {authMode === "proxy" && (
+          <Text c="dimmed" size="sm" ta="center">
+            Sign-in is handled by the reverse proxy in front of the dashboard.
+            Reload the page to sign in again.
+          </Text>
+        )}
+
         {authMode === "none" && (
           <Text c="dimmed" size="sm" ta="center">
             Authentication is disabled. Redirecting...
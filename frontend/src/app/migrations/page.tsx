@@ -0,0 +1,177 @@
+"use client";
+
+import { Title, Stack, Group, Button, Text, Timeline, ActionIcon, Tooltip } from "@mantine/core";
+import { useDisclosure } from "@mantine/hooks";
+import { notifications } from "@mantine/notifications";
+import { DataTable } from "mantine-datatable";
+import { IconPlus, IconPlayerStop } from "@tabler/icons-react";
+import { useState } from "react";
+import { StatusBadge } from "@/components/status-badge";
+import { ConfirmDelete } from "@/components/confirm-delete";
+import { CreateMigrationModal } from "@/components/create-migration-modal";
+import { useMigrations, useCancelMigration } from "@/hooks/use-migrations";
+import { useClusters } from "@/hooks/use-clusters";
+import { useAuthStore, hasRole } from "@/lib/auth";
+import { formatDate, formatDuration, phaseColor } from "@/lib/utils";
+import type { MigrationJob } from "@/lib/types";
+
+const TERMINAL_PHASES = ["Succeeded", "Failed", "Cancelled"];
+
+function StepTimeline({ job }: { job: MigrationJob }) {
+  const active = job.steps.filter((s) => s.phase !== "Pending").length - 1;
+
+  return (
+    <Timeline active={active} bulletSize={16} lineWidth={2} p="md">
+      {job.steps.map((step) => (
+        <Timeline.Item
+          key={step.name}
+          color={phaseColor(step.phase)}
+          title={
+            <Group gap="xs">
+              <Text size="sm" fw={500}>
+                {step.name}
+              </Text>
+              <StatusBadge phase={step.phase} />
+            </Group>
+          }
+        >
+          {step.message && (
+            <Text size="xs" c="dimmed">
+              {step.message}
+            </Text>
+          )}
+          {step.startedAt && (
+            <Text size="xs" c="dimmed">
+              {formatDate(step.startedAt)} &middot; {formatDuration(step.startedAt, step.completedAt)}
+            </Text>
+          )}
+        </Timeline.Item>
+      ))}
+    </Timeline>
+  );
+}
+
+export default function MigrationsPage() {
+  const { role } = useAuthStore();
+  const canManage = hasRole(role, "operator");
+  const { data: migrations, isLoading } = useMigrations();
+  const { data: clusters } = useClusters();
+  const cancelMutation = useCancelMigration();
+
+  const [createOpened, { open: openCreate, close: closeCreate }] = useDisclosure(false);
+  const [cancelTarget, setCancelTarget] = useState<MigrationJob | null>(null);
+  const [cancelOpened, { open: openCancel, close: closeCancel }] = useDisclosure(false);
+
+  const clusterName = (id: string) => clusters?.find((c) => c.id === id)?.name || id;
+
+  const confirmCancel = () => {
+    if (!cancelTarget) return;
+    cancelMutation.mutate(cancelTarget.id, {
+      onSuccess: () => {
+        notifications.show({
+          title: "Migration cancelled",
+          message: `Migration "${cancelTarget.name}" cancelled`,
+          color: "green",
+        });
+        closeCancel();
+        setCancelTarget(null);
+      },
+      onError: (err) => {
+        notifications.show({ title: "Cancel failed", message: err.message, color: "red" });
+      },
+    });
+  };
+
+  return (
+    <Stack gap="lg">
+      <Group justify="space-between">
+        <Title order={2}>Migrations</Title>
+        {canManage && (
+          <Button leftSection={<IconPlus size={16} />} onClick={openCreate}>
+            New Migration
+          </Button>
+        )}
+      </Group>
+
+      <Text size="sm" c="dimmed">
+        A migration backs up namespaces on the source cluster, waits for the backup to sync to the
+        target through a shared storage location, restores it, and checks that the restored pods
+        become ready. Jobs resume automatically if the dashboard restarts.
+      </Text>
+
+      <DataTable
+        withTableBorder={false}
+        borderRadius="md"
+        striped
+        highlightOnHover
+        fetching={isLoading}
+        records={migrations || []}
+        idAccessor="id"
+        minHeight={150}
+        noRecordsText="No migrations yet"
+        rowExpansion={{ content: ({ record }) => <StepTimeline job={record} /> }}
+        columns={[
+          { accessor: "name", title: "Name" },
+          {
+            accessor: "phase",
+            title: "Status",
+            render: (job) => <StatusBadge phase={job.phase} />,
+          },
+          {
+            accessor: "clusters",
+            title: "Source → Target",
+            render: (job) => `${clusterName(job.sourceClusterId)} → ${clusterName(job.targetClusterId)}`,
+          },
+          {
+            accessor: "namespaces",
+            title: "Namespaces",
+            render: (job) => job.spec.includedNamespaces.join(", "),
+          },
+          {
+            accessor: "message",
+            title: "Message",
+            render: (job) => (
+              <Text size="sm" lineClamp={1}>
+                {job.message || "-"}
+              </Text>
+            ),
+          },
+          { accessor: "createdBy", title: "Created By", render: (job) => job.createdBy || "-" },
+          { accessor: "createdAt", title: "Created", render: (job) => formatDate(job.createdAt) },
+          {
+            accessor: "actions",
+            title: "",
+            textAlign: "right",
+            render: (job) =>
+              canManage && !TERMINAL_PHASES.includes(job.phase) ? (
+                <Tooltip label="Cancel migration">
+                  <ActionIcon
+                    variant="subtle"
+                    color="red"
+                    onClick={(e) => {
+                      e.stopPropagation();
+                      setCancelTarget(job);
+                      openCancel();
+                    }}
+                  >
+                    <IconPlayerStop size={16} />
+                  </ActionIcon>
+                </Tooltip>
+              ) : null,
+          },
+        ]}
+      />
+
+      <CreateMigrationModal opened={createOpened} onClose={closeCreate} />
+
+      <ConfirmDelete
+        opened={cancelOpened}
+        onClose={closeCancel}
+        onConfirm={confirmCancel}
+        title="Cancel Migration"
+        message={`Cancel migration "${cancelTarget?.name}"? Velero objects already created are left in place.`}
+        loading={cancelMutation.isPending}
+      />
+    </Stack>
+  );
+}
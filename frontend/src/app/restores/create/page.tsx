@@ -38,10 +38,17 @@ import {
 } from "@tabler/icons-react";
 import Link from "next/link";
 import { useRouter } from "next/navigation";
-import { useCreateRestore, useSharedBackups, useCreateCrossClusterRestore } from "@/hooks/use-restores";
+import {
+  useCreateRestore,
+  useSharedBackups,
+  useCreateCrossClusterRestore,
+  useClusterBackups,
+  useClusterBackupLocations,
+} from "@/hooks/use-restores";
 import { useBackups } from "@/hooks/use-backups";
-import { useClusters } from "@/hooks/use-clusters";
-import type { CrossClusterBackup } from "@/lib/types";
+import { useClusters, useNamespaceAccess } from "@/hooks/use-clusters";
+import type { BackupStorageLocation, CrossClusterBackup } from "@/lib/types";
+import { useAuthStore, hasRole } from "@/lib/auth";
 import { formatDate } from "@/lib/utils";
 
 interface NamespaceMapping {
@@ -49,12 +56,17 @@ interface NamespaceMapping {
   target: string;
 }
 
+function sameStorage(a: BackupStorageLocation, b: BackupStorageLocation) {
+  return a.provider === b.provider && a.bucket === b.bucket && (a.prefix || "") === (b.prefix || "");
+}
+
 export default function CreateRestorePage() {
   const router = useRouter();
   const createMutation = useCreateRestore();
   const crossClusterMutation = useCreateCrossClusterRestore();
   const { data: backups } = useBackups();
   const { data: clusters } = useClusters();
+  const nsAccess = useNamespaceAccess("operator");
   const { data: sharedBackups, isLoading: sharedLoading } = useSharedBackups();
   const [namespaceMappings, setNamespaceMappings] = useState<NamespaceMapping[]>([]);
   const [mode, setMode] = useState<string>("standard");
@@ -64,6 +76,14 @@ export default function CreateRestorePage() {
   const [ccSourceCluster, setCcSourceCluster] = useState<string>("");
   const [ccSelectedBackup, setCcSelectedBackup] = useState<CrossClusterBackup | null>(null);
   const [ccTargetCluster, setCcTargetCluster] = useState<string>("");
+  const [ccProvision, setCcProvision] = useState(false);
+  const [ccLocationName, setCcLocationName] = useState("");
+  const [ccCredential, setCcCredential] = useState("");
+  const { role } = useAuthStore();
+  const isAdmin = hasRole(role, "admin");
+  const { data: sourceBackups } = useClusterBackups(isAdmin ? ccSourceCluster : "");
+  const { data: sourceLocations } = useClusterBackupLocations(ccSourceCluster);
+  const { data: targetLocations } = useClusterBackupLocations(ccTargetCluster);
 
   const hasMultipleClusters = (clusters || []).length >= 2;
   const completedBackups = (backups || []).filter((b) => b.phase === "Completed");
@@ -177,9 +197,24 @@ export default function CreateRestorePage() {
             ? (values.existingResourcePolicy as "update")
             : undefined,
         namespaceMapping: Object.keys(nsMapping).length > 0 ? nsMapping : undefined,
+        provisionLocation: needsProvision
+          ? {
+              name: ccLocationName || undefined,
+              credential: ccCredential || undefined,
+            }
+          : undefined,
       },
       {
-        onSuccess: () => {
+        onSuccess: (result) => {
+          if ("steps" in result) {
+            notifications.show({
+              title: "Cross-cluster restore started",
+              message: `Provisioning a storage location, then restoring backup "${ccSelectedBackup.name}" as job ${result.name}`,
+              color: "green",
+            });
+            router.push("/migrations");
+            return;
+          }
           notifications.show({
             title: "Cross-cluster restore created",
             message: `Restoring backup "${ccSelectedBackup.name}" to target cluster`,
@@ -198,10 +233,25 @@ export default function CreateRestorePage() {
     );
   };
 
-  // Filter shared backups by source cluster
-  const filteredSharedBackups = (sharedBackups || []).filter(
+  // Filter shared backups by source cluster. Admins also see backups that no
+  // other cluster can read yet, since they can provision a location for them.
+  const sharedForSource = (sharedBackups || []).filter(
     (b) => b.sourceClusterId === ccSourceCluster
   );
+  const sharedNames = new Set(sharedForSource.map((b) => b.name));
+  const sourceClusterName = (clusters || []).find((c) => c.id === ccSourceCluster)?.name || "";
+  const unsharedBackups: CrossClusterBackup[] = (sourceBackups || [])
+    .filter((b) => b.phase === "Completed" && !sharedNames.has(b.name))
+    .map((b) => ({ ...b, sourceClusterId: ccSourceCluster, sourceClusterName }));
+  const filteredSharedBackups = [...sharedForSource, ...unsharedBackups];
+
+  // Whether the target can already read the selected backup's storage
+  const sourceLocation = (sourceLocations || []).find(
+    (l) => l.name === ccSelectedBackup?.storageLocation
+  );
+  const targetShares =
+    !sourceLocation || !targetLocations || targetLocations.some((l) => sameStorage(l, sourceLocation));
+  const needsProvision = !targetShares && ccProvision;
 
   // Target clusters exclude the source
   const targetClusterOptions = (clusters || [])
@@ -399,7 +449,12 @@ export default function CreateRestorePage() {
               <TagsInput
                 label="Included Namespaces"
                 placeholder="Press Enter to add"
-                description="Leave empty for all namespaces from backup"
+                description={
+                  nsAccess.restricted
+                    ? `Required unless the backup only holds your namespaces: ${nsAccess.namespaces.join(", ")}`
+                    : "Leave empty for all namespaces from backup"
+                }
+                data={nsAccess.namespaces}
                 {...form.getInputProps("includedNamespaces")}
               />
 
@@ -599,11 +654,18 @@ export default function CreateRestorePage() {
                             </Table.Td>
                             <Table.Td>{formatDate(b.created)}</Table.Td>
                             <Table.Td>
-                              {ccSelectedBackup?.name === b.name && (
-                                <Badge color="indigo" variant="light" size="sm">
-                                  Selected
-                                </Badge>
-                              )}
+                              <Group gap={4} wrap="nowrap">
+                                {!sharedNames.has(b.name) && (
+                                  <Badge color="gray" variant="light" size="sm">
+                                    Not shared
+                                  </Badge>
+                                )}
+                                {ccSelectedBackup?.name === b.name && (
+                                  <Badge color="indigo" variant="light" size="sm">
+                                    Selected
+                                  </Badge>
+                                )}
+                              </Group>
                             </Table.Td>
                           </Table.Tr>
                         ))}
@@ -632,10 +694,51 @@ export default function CreateRestorePage() {
                     placeholder="Select the cluster to restore into"
                     data={targetClusterOptions}
                     value={ccTargetCluster}
-                    onChange={(v) => setCcTargetCluster(v || "")}
+                    onChange={(v) => {
+                      setCcTargetCluster(v || "");
+                      setCcProvision(false);
+                    }}
                     searchable
                   />
 
+                  {ccSelectedBackup && ccTargetCluster && !targetShares && (
+                    <Stack gap="xs">
+                      <Alert icon={<IconAlertTriangle size={16} />} color="yellow" variant="light">
+                        The target cluster has no Backup Storage Location pointing to{" "}
+                        {sourceLocation?.provider} bucket &quot;{sourceLocation?.bucket}&quot;
+                        {sourceLocation?.prefix ? ` (prefix "${sourceLocation.prefix}")` : ""}.
+                        {isAdmin
+                          ? " A read-only location mirroring the source can be created on the target."
+                          : " Ask an admin to add one before restoring."}
+                      </Alert>
+                      {isAdmin && (
+                        <>
+                          <Switch
+                            label="Provision a read-only storage location on the target"
+                            checked={ccProvision}
+                            onChange={(e) => setCcProvision(e.currentTarget.checked)}
+                          />
+                          {ccProvision && (
+                            <>
+                              <TextInput
+                                label="Location Name"
+                                placeholder={`${ccSelectedBackup.storageLocation}-readonly`}
+                                value={ccLocationName}
+                                onChange={(e) => setCcLocationName(e.currentTarget.value)}
+                              />
+                              <TextInput
+                                label="Credential Secret"
+                                description="Existing secret on the target cluster (key: cloud). Leave empty to use Velero's default credentials."
+                                value={ccCredential}
+                                onChange={(e) => setCcCredential(e.currentTarget.value)}
+                              />
+                            </>
+                          )}
+                        </>
+                      )}
+                    </Stack>
+                  )}
+
                   {ccSelectedBackup && ccTargetCluster && (
                     <Alert icon={<IconInfoCircle size={16} />} color="blue" variant="light">
                       Backup &quot;{ccSelectedBackup.name}&quot; from cluster &quot;
@@ -649,7 +752,7 @@ export default function CreateRestorePage() {
                       Back
                     </Button>
                     <Button
-                      disabled={!ccTargetCluster}
+                      disabled={!ccTargetCluster || (!targetShares && !ccProvision)}
                       onClick={() => setCcStep(3)}
                     >
                       Next
@@ -778,6 +881,13 @@ export default function CreateRestorePage() {
                       ))}
                     </Stack>
 
+                    {needsProvision && (
+                      <Alert icon={<IconInfoCircle size={16} />} color="blue" variant="light">
+                        The storage location is created first, then the restore waits for it to
+                        become Available and for Velero to sync the backup (up to 5 minutes).
+                      </Alert>
+                    )}
+
                     <Group justify="space-between">
                       <Button variant="default" onClick={() => setCcStep(2)}>
                         Back
@@ -19,12 +19,14 @@ import Link from "next/link";
 import { useRouter } from "next/navigation";
 import { useCreateSchedule } from "@/hooks/use-schedules";
 import { useBackupLocations } from "@/hooks/use-settings";
+import { useNamespaceAccess } from "@/hooks/use-clusters";
 import { CronBuilder } from "@/components/cron-builder";
 
 export default function CreateSchedulePage() {
   const router = useRouter();
   const createMutation = useCreateSchedule();
   const { data: locations } = useBackupLocations();
+  const nsAccess = useNamespaceAccess("operator");
 
   const form = useForm({
     initialValues: {
@@ -48,6 +50,14 @@ export default function CreateSchedulePage() {
       });
       return;
     }
+    if (nsAccess.restricted && values.includedNamespaces.length === 0) {
+      notifications.show({
+        title: "Error",
+        message: "Select at least one of your namespaces",
+        color: "red",
+      });
+      return;
+    }
 
     createMutation.mutate(
       {
@@ -121,7 +131,13 @@ export default function CreateSchedulePage() {
             <TagsInput
               label="Included Namespaces"
               placeholder="Press Enter to add"
-              description="Leave empty for all namespaces"
+              description={
+                nsAccess.restricted
+                  ? `Your namespaces: ${nsAccess.namespaces.join(", ")}`
+                  : "Leave empty for all namespaces"
+              }
+              data={nsAccess.namespaces}
+              withAsterisk={nsAccess.restricted}
               {...form.getInputProps("includedNamespaces")}
             />
 
@@ -23,6 +23,11 @@ import { EditBSLModal } from "@/components/edit-bsl-modal";
 import { EditVSLModal } from "@/components/edit-vsl-modal";
 import { ConfirmDelete } from "@/components/confirm-delete";
 import { WebhookConfigModal } from "@/components/webhook-config-modal";
+import { ConfigBundleCard } from "@/components/config-bundle-card";
+import { ApiTokensCard } from "@/components/api-tokens-card";
+import { SessionsCard } from "@/components/sessions-card";
+import { PasswordCard } from "@/components/password-card";
+import { UsersCard } from "@/components/users-card";
 import {
   useBackupLocations,
   useSnapshotLocations,
@@ -58,7 +63,7 @@ export default function SettingsPage() {
   const { data: bsls, isLoading: bslLoading } = useBackupLocations();
   const { data: vsls, isLoading: vslLoading } = useSnapshotLocations();
   const { data: serverInfo } = useServerInfo();
-  const { role } = useAuthStore();
+  const { role, authMode } = useAuthStore();
 
   const createMutation = useCreateBackupLocation();
   const deleteMutation = useDeleteBackupLocation();
@@ -332,7 +337,18 @@ export default function SettingsPage() {
           <Table.Tbody>
             {(webhooks || []).map((wh) => (
               <Table.Tr key={wh.id}>
-                <Table.Td fw={500}>{wh.name}</Table.Td>
+                <Table.Td fw={500}>
+                  <Group gap={6} wrap="nowrap">
+                    {wh.name}
+                    {wh.managed && (
+                      <Tooltip label={`Managed by ${wh.source}; edit it there`}>
+                        <Badge variant="light" color="gray" size="xs">
+                          managed
+                        </Badge>
+                      </Tooltip>
+                    )}
+                  </Group>
+                </Table.Td>
                 <Table.Td>
                   <Badge variant="light" size="sm">
                     {wh.type}
@@ -399,10 +415,11 @@ export default function SettingsPage() {
                           <IconSend size={16} />
                         </ActionIcon>
                       </Tooltip>
-                      <Tooltip label="Edit">
+                      <Tooltip label={wh.managed ? "Managed declaratively" : "Edit"}>
                         <ActionIcon
                           variant="subtle"
                           color="blue"
+                          disabled={wh.managed}
                           onClick={() => {
                             setEditingWebhook(wh);
                             setWebhookModalOpened(true);
@@ -411,10 +428,11 @@ export default function SettingsPage() {
                           <IconEdit size={16} />
                         </ActionIcon>
                       </Tooltip>
-                      <Tooltip label="Delete">
+                      <Tooltip label={wh.managed ? "Managed declaratively" : "Delete"}>
                         <ActionIcon
                           variant="subtle"
                           color="red"
+                          disabled={wh.managed}
                           onClick={() => {
                             setSelectedWebhookId(wh.id);
                             setDeleteWebhookModalOpened(true);
@@ -442,6 +460,21 @@ export default function SettingsPage() {
         </Table>
       </Paper>
 
+      {/* API tokens (any signed-in user) */}
+      {authMode !== "none" && <ApiTokensCard />}
+
+      {/* Login sessions (any signed-in user) */}
+      {authMode !== "none" && <SessionsCard />}
+
+      {/* Own password (basic auth) */}
+      {authMode === "basic" && <PasswordCard />}
+
+      {/* Local users (basic auth, admin only) */}
+      {authMode === "basic" && isAdmin && <UsersCard />}
+
+      {/* Configuration backup (admin only) */}
+      {isAdmin && <ConfigBundleCard />}
+
       {/* Modals */}
       <CreateBSLModal
         opened={createModalOpened}
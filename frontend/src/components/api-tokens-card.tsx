@@ -0,0 +1,256 @@
+"use client";
+
+import { useState } from "react";
+import {
+  Paper,
+  Group,
+  Stack,
+  Text,
+  Button,
+  TextInput,
+  Select,
+  MultiSelect,
+  SegmentedControl,
+  Table,
+  Badge,
+  ActionIcon,
+  Alert,
+  Code,
+  CopyButton,
+  Tooltip,
+} from "@mantine/core";
+import { notifications } from "@mantine/notifications";
+import { IconKey, IconTrash, IconCopy, IconCheck } from "@tabler/icons-react";
+import dayjs from "dayjs";
+import { useApiTokens, useCreateApiToken, useRevokeApiToken } from "@/hooks/use-tokens";
+import { useClusters } from "@/hooks/use-clusters";
+import { useAuthStore, hasRole } from "@/lib/auth";
+import { formatDate, timeAgo } from "@/lib/utils";
+import type { ApiToken, ApiTokenKind } from "@/lib/types";
+
+const EXPIRY_OPTIONS = [
+  { value: "7", label: "7 days" },
+  { value: "30", label: "30 days" },
+  { value: "90", label: "90 days" },
+  { value: "365", label: "1 year" },
+  { value: "never", label: "Never" },
+];
+
+const roleColors: Record<ApiToken["role"], string> = {
+  viewer: "gray",
+  operator: "blue",
+  admin: "red",
+};
+
+export function ApiTokensCard() {
+  const { role, username } = useAuthStore();
+  const { data: tokens } = useApiTokens();
+  const { data: clusters } = useClusters();
+  const createMutation = useCreateApiToken();
+  const revokeMutation = useRevokeApiToken();
+
+  const [name, setName] = useState("");
+  const [kind, setKind] = useState<ApiTokenKind>("personal");
+  const [tokenRole, setTokenRole] = useState<ApiToken["role"]>("viewer");
+  const [tokenClusters, setTokenClusters] = useState<string[]>([]);
+  const [expiry, setExpiry] = useState("90");
+  const [secret, setSecret] = useState<string | null>(null);
+
+  const roleOptions = (["viewer", "operator", "admin"] as const)
+    .filter((r) => hasRole(role, r))
+    .map((r) => ({ value: r, label: r }));
+  const clusterNames = Object.fromEntries((clusters || []).map((c) => [c.id, c.name]));
+
+  const handleCreate = async () => {
+    try {
+      const res = await createMutation.mutateAsync({
+        name,
+        kind,
+        role: tokenRole,
+        clusters: tokenClusters.length > 0 ? tokenClusters : undefined,
+        expiresAt:
+          expiry === "never" ? undefined : dayjs().add(Number(expiry), "day").toISOString(),
+      });
+      setSecret(res.secret);
+      setName("");
+      setTokenClusters([]);
+    } catch (err) {
+      notifications.show({
+        title: "Failed to create token",
+        message: (err as Error).message,
+        color: "red",
+      });
+    }
+  };
+
+  const handleRevoke = async (token: ApiToken) => {
+    try {
+      await revokeMutation.mutateAsync(token.id);
+      notifications.show({ title: "Token revoked", message: token.name, color: "green" });
+    } catch (err) {
+      notifications.show({
+        title: "Failed to revoke token",
+        message: (err as Error).message,
+        color: "red",
+      });
+    }
+  };
+
+  return (
+    <Paper p="md" radius="md" withBorder>
+      <Stack gap="md">
+        <Group gap="xs">
+          <IconKey size={20} />
+          <Text fw={600}>API Tokens</Text>
+        </Group>
+        <Text size="sm" c="dimmed">
+          Long-lived tokens for scripts and CI pipelines. Send them as{" "}
+          <Code>Authorization: Bearer &lt;token&gt;</Code>. Personal tokens act as you, service
+          tokens as <Code>token:&lt;name&gt;</Code>; both are limited to the role and clusters
+          chosen here.
+        </Text>
+
+        {secret && (
+          <Alert color="green" variant="light" title="Copy your token now" withCloseButton onClose={() => setSecret(null)}>
+            <Stack gap="xs">
+              <Text size="sm">It won&apos;t be shown again.</Text>
+              <Group gap="xs" wrap="nowrap">
+                <Code style={{ wordBreak: "break-all" }}>{secret}</Code>
+                <CopyButton value={secret}>
+                  {({ copied, copy }) => (
+                    <Tooltip label={copied ? "Copied" : "Copy"}>
+                      <ActionIcon variant="subtle" color={copied ? "green" : "gray"} onClick={copy}>
+                        {copied ? <IconCheck size={16} /> : <IconCopy size={16} />}
+                      </ActionIcon>
+                    </Tooltip>
+                  )}
+                </CopyButton>
+              </Group>
+            </Stack>
+          </Alert>
+        )}
+
+        <Group align="flex-end" wrap="wrap">
+          <TextInput
+            label="Name"
+            placeholder="ci-pre-deploy"
+            value={name}
+            onChange={(e) => setName(e.currentTarget.value)}
+          />
+          {hasRole(role, "admin") && (
+            <SegmentedControl
+              value={kind}
+              onChange={(v) => setKind(v as ApiTokenKind)}
+              data={[
+                { value: "personal", label: "Personal" },
+                { value: "service", label: "Service" },
+              ]}
+            />
+          )}
+          <Select
+            label="Role"
+            data={roleOptions}
+            value={tokenRole}
+            onChange={(v) => v && setTokenRole(v as ApiToken["role"])}
+            w={130}
+          />
+          <MultiSelect
+            label="Clusters"
+            placeholder="All clusters"
+            data={(clusters || []).map((c) => ({ value: c.id, label: c.name }))}
+            value={tokenClusters}
+            onChange={setTokenClusters}
+            clearable
+            miw={200}
+          />
+          <Select
+            label="Expires"
+            data={EXPIRY_OPTIONS}
+            value={expiry}
+            onChange={(v) => v && setExpiry(v)}
+            w={120}
+          />
+          <Button onClick={handleCreate} loading={createMutation.isPending} disabled={!name}>
+            Create Token
+          </Button>
+        </Group>
+
+        <Table>
+          <Table.Thead>
+            <Table.Tr>
+              <Table.Th>Name</Table.Th>
+              <Table.Th>Kind</Table.Th>
+              <Table.Th>Role</Table.Th>
+              <Table.Th>Clusters</Table.Th>
+              <Table.Th>Created</Table.Th>
+              <Table.Th>Expires</Table.Th>
+              <Table.Th>Last Used</Table.Th>
+              <Table.Th />
+            </Table.Tr>
+          </Table.Thead>
+          <Table.Tbody>
+            {(tokens || []).map((t) => (
+              <Table.Tr key={t.id}>
+                <Table.Td>
+                  <Text size="sm" fw={500}>
+                    {t.name}
+                  </Text>
+                  {t.createdBy !== username && (
+                    <Text size="xs" c="dimmed">
+                      by {t.createdBy}
+                    </Text>
+                  )}
+                </Table.Td>
+                <Table.Td>
+                  <Badge variant="light" color={t.kind === "service" ? "violet" : "gray"}>
+                    {t.kind}
+                  </Badge>
+                </Table.Td>
+                <Table.Td>
+                  <Badge variant="light" color={roleColors[t.role]}>
+                    {t.role}
+                  </Badge>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">
+                    {t.clusters?.length ? t.clusters.map((id) => clusterNames[id] || id).join(", ") : "All"}
+                  </Text>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">{formatDate(t.createdAt)}</Text>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">{t.expiresAt ? formatDate(t.expiresAt) : "Never"}</Text>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">{t.lastUsedAt ? timeAgo(t.lastUsedAt) : "Never"}</Text>
+                </Table.Td>
+                <Table.Td>
+                  <Tooltip label="Revoke">
+                    <ActionIcon
+                      variant="subtle"
+                      color="red"
+                      onClick={() => handleRevoke(t)}
+                      loading={revokeMutation.isPending && revokeMutation.variables === t.id}
+                    >
+                      <IconTrash size={16} />
+                    </ActionIcon>
+                  </Tooltip>
+                </Table.Td>
+              </Table.Tr>
+            ))}
+            {tokens && tokens.length === 0 && (
+              <Table.Tr>
+                <Table.Td colSpan={8}>
+                  <Text size="sm" c="dimmed" ta="center">
+                    No API tokens yet
+                  </Text>
+                </Table.Td>
+              </Table.Tr>
+            )}
+          </Table.Tbody>
+        </Table>
+      </Stack>
+    </Paper>
+  );
+}
@@ -22,6 +22,7 @@ import { ClusterSelector } from "./cluster-selector";
 import { useWebSocket } from "@/hooks/use-ws";
 import { useClusterAutoSelect } from "@/hooks/use-cluster-auto-select";
 import { useAuthStore } from "@/lib/auth";
+import { logoutSession } from "@/lib/api";
 import { useRouter } from "next/navigation";
 import { useClusterStore } from "@/lib/cluster";
 import { useEffect } from "react";
@@ -55,7 +56,8 @@ export function DashboardShell({ children }: { children: React.ReactNode }) {
   // Auto-select a cluster if none is selected or if selected was deleted
   useClusterAutoSelect();
 
-  const handleLogout = () => {
+  const handleLogout = async () => {
+    await logoutSession();
     logout();
     router.push("/login");
   };
@@ -100,13 +102,15 @@ export function DashboardShell({ children }: { children: React.ReactNode }) {
                     {role}
                   </Badge>
                 </div>
-                <ActionIcon
-                  onClick={handleLogout}
-                  title="Logout"
-                  color="gray"
-                >
-                  <IconLogout size={18} />
-                </ActionIcon>
+                {authMode !== "proxy" && (
+                  <ActionIcon
+                    onClick={handleLogout}
+                    title="Logout"
+                    color="gray"
+                  >
+                    <IconLogout size={18} />
+                  </ActionIcon>
+                )}
               </>
             )}
             <ActionIcon
@@ -3,15 +3,19 @@
 import { useEffect, useState } from "react";
 import { useRouter, usePathname } from "next/navigation";
 import { Center, Loader } from "@mantine/core";
-import { useAuthStore, type AuthMode } from "@/lib/auth";
-import { getAuthConfig } from "@/lib/api";
+import { useAuthStore, tokenExpiry, type AuthMode, type Role } from "@/lib/auth";
+import { getAccess, getAuthConfig, getMe, refreshSession } from "@/lib/api";
+
+// Access tokens are short-lived; refresh them this long before they expire
+// so the WebSocket reconnects with a valid one.
+const REFRESH_AHEAD_MS = 60_000;
 
 const PUBLIC_PATHS = ["/login", "/auth/callback"];
 
 export function AuthGuard({ children }: { children: React.ReactNode }) {
   const router = useRouter();
   const pathname = usePathname();
-  const { isAuthenticated, authMode, setAuthMode, setAuth, initialize } =
+  const { token, isAuthenticated, authMode, setAuthMode, setAuth, setRole, initialize } =
     useAuthStore();
   const [loading, setLoading] = useState(true);
 
@@ -25,6 +29,14 @@ export function AuthGuard({ children }: { children: React.ReactNode }) {
         if (mode === "none") {
           setAuth("none", "anonymous", "admin");
         }
+        // The reverse proxy authenticates every request, so there is no
+        // token to keep; just ask who the proxy says we are. The login page
+        // is where a failed lookup lands, so don't retry it there.
+        if (mode === "proxy" && !PUBLIC_PATHS.includes(pathname)) {
+          return getMe()
+            .then((me) => setAuth("none", me.username, me.role as Role))
+            .catch(() => {});
+        }
       })
       .catch(() => {
         setAuthMode("none");
@@ -34,6 +46,25 @@ export function AuthGuard({ children }: { children: React.ReactNode }) {
     // eslint-disable-next-line react-hooks/exhaustive-deps
   }, []);
 
+  // Role bindings can grant more than the global role in the token, so ask
+  // the server for the effective role once signed in.
+  useEffect(() => {
+    if (loading || authMode === "none" || !isAuthenticated) return;
+    getAccess()
+      .then((access) => setRole(access.role as Role))
+      .catch(() => {});
+  }, [loading, authMode, isAuthenticated, setRole]);
+
+  useEffect(() => {
+    if (loading || authMode === "none" || !isAuthenticated) return;
+    const expires = tokenExpiry(token);
+    if (!expires) return;
+    const timer = setTimeout(() => {
+      refreshSession();
+    }, Math.max(expires - Date.now() - REFRESH_AHEAD_MS, 0));
+    return () => clearTimeout(timer);
+  }, [loading, authMode, isAuthenticated, token]);
+
   useEffect(() => {
     if (loading) return;
     if (authMode === "none") return;
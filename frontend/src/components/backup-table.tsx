@@ -8,6 +8,7 @@ import { BackupProgress } from "./backup-progress";
 import { formatDate, formatDuration, formatBytes } from "@/lib/utils";
 import type { Backup } from "@/lib/types";
 import { useAuthStore, hasRole } from "@/lib/auth";
+import { useClusterPermission } from "@/hooks/use-clusters";
 import Link from "next/link";
 
 interface BackupTableProps {
@@ -38,6 +39,10 @@ export function BackupTable({
   const { role } = useAuthStore();
   const canDelete = hasRole(role, "operator");
   const canRestore = hasRole(role, "operator");
+  const allowedLogs = useClusterPermission("downloadrequests", "create");
+  const allowedRestore = useClusterPermission("restores", "create");
+  const allowedDelete = useClusterPermission("deletebackuprequests", "create");
+  const notPermitted = "Not permitted by this cluster's RBAC";
 
   return (
     <DataTable
@@ -123,10 +128,11 @@ export function BackupTable({
                 </ActionIcon>
               </Tooltip>
               {(backup.phase === "Completed" || backup.phase === "Failed" || backup.phase === "PartiallyFailed") && (
-                <Tooltip label="View logs">
+                <Tooltip label={allowedLogs ? "View logs" : notPermitted}>
                   <ActionIcon
                     variant="subtle"
                     color="gray"
+                    disabled={!allowedLogs}
                     onClick={() => onViewLogs(backup.name)}
                   >
                     <IconFileText size={16} />
@@ -134,10 +140,11 @@ export function BackupTable({
                 </Tooltip>
               )}
               {canRestore && backup.phase === "Completed" && (
-                <Tooltip label="Restore">
+                <Tooltip label={allowedRestore ? "Restore" : notPermitted}>
                   <ActionIcon
                     variant="subtle"
                     color="green"
+                    disabled={!allowedRestore}
                     onClick={() => onRestore(backup.name)}
                   >
                     <IconRestore size={16} />
@@ -145,10 +152,11 @@ export function BackupTable({
                 </Tooltip>
               )}
               {canDelete && (
-                <Tooltip label="Delete">
+                <Tooltip label={allowedDelete ? "Delete" : notPermitted}>
                   <ActionIcon
                     variant="subtle"
                     color="red"
+                    disabled={!allowedDelete}
                     onClick={() => onDelete(backup.name)}
                   >
                     <IconTrash size={16} />
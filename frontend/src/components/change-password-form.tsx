@@ -0,0 +1,79 @@
+"use client";
+
+import { useState } from "react";
+import { PasswordInput, Button, Stack } from "@mantine/core";
+import { changePassword } from "@/lib/api";
+
+interface ChangePasswordFormProps {
+  username: string;
+  currentLabel?: string;
+  submitLabel?: string;
+  onChanged: (session: { token: string; username: string; role: string }) => void;
+  onError: (message: string) => void;
+}
+
+// ChangePasswordForm replaces a basic-mode user's password. The server ends
+// the user's other sessions and answers with a new one.
+export function ChangePasswordForm({
+  username,
+  currentLabel = "Current password",
+  submitLabel = "Change password",
+  onChanged,
+  onError,
+}: ChangePasswordFormProps) {
+  const [current, setCurrent] = useState("");
+  const [next, setNext] = useState("");
+  const [confirm, setConfirm] = useState("");
+  const [loading, setLoading] = useState(false);
+
+  const mismatch = confirm !== "" && next !== confirm;
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (mismatch) return;
+    setLoading(true);
+    try {
+      const session = await changePassword(username, current, next);
+      setCurrent("");
+      setNext("");
+      setConfirm("");
+      onChanged(session);
+    } catch (err) {
+      onError(err instanceof Error ? err.message : "Failed to change password");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <form onSubmit={handleSubmit}>
+      <Stack>
+        <PasswordInput
+          label={currentLabel}
+          value={current}
+          onChange={(e) => setCurrent(e.currentTarget.value)}
+          autoComplete="current-password"
+          required
+        />
+        <PasswordInput
+          label="New password"
+          value={next}
+          onChange={(e) => setNext(e.currentTarget.value)}
+          autoComplete="new-password"
+          required
+        />
+        <PasswordInput
+          label="Confirm new password"
+          value={confirm}
+          onChange={(e) => setConfirm(e.currentTarget.value)}
+          error={mismatch ? "Passwords do not match" : undefined}
+          autoComplete="new-password"
+          required
+        />
+        <Button type="submit" loading={loading} disabled={mismatch || !current || !next}>
+          {submitLabel}
+        </Button>
+      </Stack>
+    </form>
+  );
+}
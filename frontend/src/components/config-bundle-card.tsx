@@ -0,0 +1,188 @@
+"use client";
+
+import { useState } from "react";
+import {
+  Paper,
+  Group,
+  Stack,
+  Text,
+  Button,
+  PasswordInput,
+  FileInput,
+  Checkbox,
+  Table,
+  Badge,
+} from "@mantine/core";
+import { notifications } from "@mantine/notifications";
+import { useQueryClient } from "@tanstack/react-query";
+import { IconDownload, IconUpload, IconArchive } from "@tabler/icons-react";
+import { exportBundle, importBundle } from "@/lib/api";
+import type { BundleImportReport, BundleItemResult } from "@/lib/types";
+
+const MIN_PASSPHRASE = 12;
+
+const actionColors: Record<BundleItemResult["action"], string> = {
+  created: "green",
+  updated: "blue",
+  skipped: "gray",
+  failed: "red",
+};
+
+export function ConfigBundleCard() {
+  const queryClient = useQueryClient();
+  const [exportPassphrase, setExportPassphrase] = useState("");
+  const [exporting, setExporting] = useState(false);
+  const [file, setFile] = useState<File | null>(null);
+  const [importPassphrase, setImportPassphrase] = useState("");
+  const [overwrite, setOverwrite] = useState(false);
+  const [importing, setImporting] = useState(false);
+  const [report, setReport] = useState<BundleImportReport | null>(null);
+
+  const handleExport = async () => {
+    setExporting(true);
+    try {
+      const bundle = await exportBundle(exportPassphrase);
+      const blob = new Blob([JSON.stringify(bundle, null, 2)], { type: "application/json" });
+      const url = URL.createObjectURL(blob);
+      const a = document.createElement("a");
+      a.href = url;
+      a.download = `velero-dashboard-${new Date().toISOString().slice(0, 10)}.bundle.json`;
+      a.click();
+      URL.revokeObjectURL(url);
+      setExportPassphrase("");
+    } catch (err) {
+      notifications.show({ title: "Export failed", message: (err as Error).message, color: "red" });
+    } finally {
+      setExporting(false);
+    }
+  };
+
+  const handleImport = async () => {
+    if (!file) return;
+    setImporting(true);
+    try {
+      const bundle = JSON.parse(await file.text());
+      const result = await importBundle(bundle, importPassphrase, overwrite);
+      setReport(result);
+      setImportPassphrase("");
+      queryClient.invalidateQueries({ queryKey: ["clusters"] });
+      queryClient.invalidateQueries({ queryKey: ["webhooks"] });
+    } catch (err) {
+      notifications.show({ title: "Import failed", message: (err as Error).message, color: "red" });
+    } finally {
+      setImporting(false);
+    }
+  };
+
+  const rows = report
+    ? [
+        ...report.clusters.map((r) => ({ ...r, kind: "Cluster" })),
+        ...report.webhooks.map((r) => ({ ...r, kind: "Webhook" })),
+      ]
+    : [];
+
+  return (
+    <Paper p="md">
+      <Group gap="xs" mb="xs">
+        <IconArchive size={18} />
+        <Text fw={600}>Configuration Backup</Text>
+      </Group>
+      <Text size="sm" c="dimmed" mb="md">
+        Export clusters (including kubeconfigs) and webhooks as an encrypted file, or import one to restore them
+        into this dashboard, whichever storage backend it uses. Keep the passphrase safe: the file cannot be
+        decrypted without it.
+      </Text>
+
+      <Group align="flex-end" mb="lg">
+        <PasswordInput
+          label="Passphrase"
+          description={`At least ${MIN_PASSPHRASE} characters`}
+          value={exportPassphrase}
+          onChange={(e) => setExportPassphrase(e.currentTarget.value)}
+          style={{ flex: 1 }}
+        />
+        <Button
+          leftSection={<IconDownload size={16} />}
+          onClick={handleExport}
+          loading={exporting}
+          disabled={exportPassphrase.length < MIN_PASSPHRASE}
+        >
+          Export
+        </Button>
+      </Group>
+
+      <Stack gap="sm">
+        <Group align="flex-end">
+          <FileInput
+            label="Bundle file"
+            placeholder="velero-dashboard-….bundle.json"
+            accept="application/json,.json"
+            value={file}
+            onChange={setFile}
+            clearable
+            style={{ flex: 1 }}
+          />
+          <PasswordInput
+            label="Passphrase"
+            value={importPassphrase}
+            onChange={(e) => setImportPassphrase(e.currentTarget.value)}
+            style={{ flex: 1 }}
+          />
+          <Button
+            leftSection={<IconUpload size={16} />}
+            onClick={handleImport}
+            loading={importing}
+            disabled={!file || !importPassphrase}
+          >
+            Import
+          </Button>
+        </Group>
+        <Checkbox
+          label="Overwrite clusters and webhooks with the same name"
+          checked={overwrite}
+          onChange={(e) => setOverwrite(e.currentTarget.checked)}
+        />
+      </Stack>
+
+      {report && (
+        <Table mt="md" striped>
+          <Table.Thead>
+            <Table.Tr>
+              <Table.Th>Type</Table.Th>
+              <Table.Th>Name</Table.Th>
+              <Table.Th>Result</Table.Th>
+              <Table.Th>Details</Table.Th>
+            </Table.Tr>
+          </Table.Thead>
+          <Table.Tbody>
+            {rows.map((r, i) => (
+              <Table.Tr key={`${r.kind}-${r.name}-${i}`}>
+                <Table.Td>{r.kind}</Table.Td>
+                <Table.Td>{r.name}</Table.Td>
+                <Table.Td>
+                  <Badge color={actionColors[r.action]} variant="light" size="sm">
+                    {r.action}
+                  </Badge>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="xs" c="dimmed">
+                    {r.reason}
+                  </Text>
+                </Table.Td>
+              </Table.Tr>
+            ))}
+            {rows.length === 0 && (
+              <Table.Tr>
+                <Table.Td colSpan={4}>
+                  <Text size="sm" c="dimmed" ta="center">
+                    The bundle was empty.
+                  </Text>
+                </Table.Td>
+              </Table.Tr>
+            )}
+          </Table.Tbody>
+        </Table>
+      )}
+    </Paper>
+  );
+}
@@ -35,7 +35,7 @@ export function CreateClusterModal({ opened, onClose }: CreateClusterModalProps)
     initialValues: {
       name: "",
       kubeconfig: "",
-      namespace: "velero",
+      namespace: "",
       setAsDefault: false,
       // Token auth fields
       apiServer: "",
@@ -69,7 +69,6 @@ export function CreateClusterModal({ opened, onClose }: CreateClusterModalProps)
         }
         return null;
       },
-      namespace: (value) => (!value ? "Namespace is required" : null),
     },
   });
 
@@ -94,7 +93,7 @@ export function CreateClusterModal({ opened, onClose }: CreateClusterModalProps)
       // Clean up the request based on auth mode
       const requestData: CreateClusterRequest = {
         name: values.name,
-        namespace: values.namespace,
+        namespace: values.namespace || undefined,
         setAsDefault: values.setAsDefault,
       };
 
@@ -148,9 +147,8 @@ export function CreateClusterModal({ opened, onClose }: CreateClusterModalProps)
 
           <TextInput
             label="Velero Namespace"
-            description="Kubernetes namespace where Velero is installed"
-            placeholder="velero"
-            required
+            description="Leave empty to detect the namespace of the Velero deployment"
+            placeholder="auto-detect"
             {...form.getInputProps("namespace")}
           />
 
@@ -0,0 +1,263 @@
+"use client";
+
+import { useState } from "react";
+import {
+  Modal,
+  TextInput,
+  Textarea,
+  TagsInput,
+  Select,
+  Switch,
+  Button,
+  Stack,
+  Group,
+  Text,
+  ActionIcon,
+  SimpleGrid,
+} from "@mantine/core";
+import { useForm } from "@mantine/form";
+import { notifications } from "@mantine/notifications";
+import { IconPlus, IconTrash } from "@tabler/icons-react";
+import { useClusters } from "@/hooks/use-clusters";
+import { useCreateMigration } from "@/hooks/use-migrations";
+import type { CreateMigrationRequest } from "@/lib/types";
+
+interface CreateMigrationModalProps {
+  opened: boolean;
+  onClose: () => void;
+}
+
+interface NamespaceMapping {
+  source: string;
+  target: string;
+}
+
+interface FormValues {
+  name: string;
+  sourceClusterId: string;
+  targetClusterId: string;
+  includedNamespaces: string[];
+  excludedResources: string[];
+  storageLocation: string;
+  defaultVolumesToFsBackup: boolean;
+  restorePVs: boolean;
+  existingResourcePolicy: string;
+  resourceModifiers: string;
+  readyTimeout: string;
+}
+
+export function CreateMigrationModal({ opened, onClose }: CreateMigrationModalProps) {
+  const { data: clusters } = useClusters();
+  const createMigration = useCreateMigration();
+  const [mappings, setMappings] = useState<NamespaceMapping[]>([]);
+
+  const form = useForm<FormValues>({
+    initialValues: {
+      name: "",
+      sourceClusterId: "",
+      targetClusterId: "",
+      includedNamespaces: [],
+      excludedResources: [],
+      storageLocation: "",
+      defaultVolumesToFsBackup: false,
+      restorePVs: true,
+      existingResourcePolicy: "none",
+      resourceModifiers: "",
+      readyTimeout: "",
+    },
+    validate: {
+      sourceClusterId: (value) => (value ? null : "Source cluster is required"),
+      targetClusterId: (value, values) => {
+        if (!value) return "Target cluster is required";
+        if (value === values.sourceClusterId) return "Target must differ from source";
+        return null;
+      },
+      includedNamespaces: (value) =>
+        value.length > 0 ? null : "Select at least one namespace to migrate",
+    },
+  });
+
+  const clusterOptions = (clusters || []).map((c) => ({
+    value: c.id,
+    label: `${c.name}${c.status !== "connected" ? ` (${c.status})` : ""}`,
+    disabled: c.status !== "connected",
+  }));
+
+  const updateMapping = (index: number, field: keyof NamespaceMapping, value: string) => {
+    const updated = [...mappings];
+    updated[index] = { ...updated[index], [field]: value };
+    setMappings(updated);
+  };
+
+  const handleClose = () => {
+    form.reset();
+    setMappings([]);
+    onClose();
+  };
+
+  const handleSubmit = (values: FormValues) => {
+    const nsMapping: Record<string, string> = {};
+    mappings.forEach((m) => {
+      if (m.source && m.target) nsMapping[m.source] = m.target;
+    });
+
+    const data: CreateMigrationRequest = {
+      name: values.name || undefined,
+      sourceClusterId: values.sourceClusterId,
+      targetClusterId: values.targetClusterId,
+      includedNamespaces: values.includedNamespaces,
+      excludedResources: values.excludedResources.length > 0 ? values.excludedResources : undefined,
+      storageLocation: values.storageLocation || undefined,
+      defaultVolumesToFsBackup: values.defaultVolumesToFsBackup,
+      restorePVs: values.restorePVs,
+      existingResourcePolicy: values.existingResourcePolicy as "none" | "update",
+      namespaceMapping: Object.keys(nsMapping).length > 0 ? nsMapping : undefined,
+      resourceModifiers: values.resourceModifiers || undefined,
+      readyTimeout: values.readyTimeout || undefined,
+    };
+
+    createMigration.mutate(data, {
+      onSuccess: (job) => {
+        notifications.show({
+          title: "Migration started",
+          message: `Migration "${job.name}" is running`,
+          color: "green",
+        });
+        handleClose();
+      },
+      onError: (err) => {
+        notifications.show({ title: "Migration failed to start", message: err.message, color: "red" });
+      },
+    });
+  };
+
+  return (
+    <Modal opened={opened} onClose={handleClose} title="New Migration" size="lg">
+      <form onSubmit={form.onSubmit(handleSubmit)}>
+        <Stack gap="md">
+          <TextInput
+            label="Name"
+            placeholder="Generated when empty"
+            {...form.getInputProps("name")}
+          />
+          <SimpleGrid cols={2}>
+            <Select
+              label="Source cluster"
+              placeholder="Cluster to back up"
+              data={clusterOptions}
+              required
+              {...form.getInputProps("sourceClusterId")}
+            />
+            <Select
+              label="Target cluster"
+              placeholder="Cluster to restore into"
+              data={clusterOptions.filter((c) => c.value !== form.values.sourceClusterId)}
+              required
+              {...form.getInputProps("targetClusterId")}
+            />
+          </SimpleGrid>
+          <TagsInput
+            label="Namespaces"
+            description="Namespaces to migrate. Readiness is checked in their target namespaces."
+            placeholder="Type and press Enter"
+            required
+            {...form.getInputProps("includedNamespaces")}
+          />
+          <TagsInput
+            label="Excluded resources"
+            placeholder="e.g. events, events.events.k8s.io"
+            {...form.getInputProps("excludedResources")}
+          />
+          <TextInput
+            label="Storage location"
+            description="Source BSL; the target must have one pointing to the same bucket and prefix"
+            placeholder="default"
+            {...form.getInputProps("storageLocation")}
+          />
+
+          <Stack gap="xs">
+            <Group justify="space-between">
+              <Text size="sm" fw={500}>
+                Namespace mapping
+              </Text>
+              <Button
+                size="xs"
+                variant="subtle"
+                leftSection={<IconPlus size={14} />}
+                onClick={() => setMappings([...mappings, { source: "", target: "" }])}
+              >
+                Add mapping
+              </Button>
+            </Group>
+            {mappings.map((mapping, index) => (
+              <Group key={index} gap="xs" wrap="nowrap">
+                <TextInput
+                  placeholder="source"
+                  value={mapping.source}
+                  onChange={(e) => updateMapping(index, "source", e.currentTarget.value)}
+                  style={{ flex: 1 }}
+                />
+                <TextInput
+                  placeholder="target"
+                  value={mapping.target}
+                  onChange={(e) => updateMapping(index, "target", e.currentTarget.value)}
+                  style={{ flex: 1 }}
+                />
+                <ActionIcon
+                  color="red"
+                  variant="subtle"
+                  onClick={() => setMappings(mappings.filter((_, i) => i !== index))}
+                >
+                  <IconTrash size={16} />
+                </ActionIcon>
+              </Group>
+            ))}
+          </Stack>
+
+          <SimpleGrid cols={2}>
+            <Select
+              label="Existing resources"
+              data={[
+                { value: "none", label: "Skip existing" },
+                { value: "update", label: "Update existing" },
+              ]}
+              {...form.getInputProps("existingResourcePolicy")}
+            />
+            <TextInput
+              label="Ready timeout"
+              placeholder="10m"
+              {...form.getInputProps("readyTimeout")}
+            />
+          </SimpleGrid>
+          <Group>
+            <Switch
+              label="File system backup for volumes"
+              {...form.getInputProps("defaultVolumesToFsBackup", { type: "checkbox" })}
+            />
+            <Switch
+              label="Restore persistent volumes"
+              {...form.getInputProps("restorePVs", { type: "checkbox" })}
+            />
+          </Group>
+          <Textarea
+            label="Resource modifiers"
+            description="Velero resource modifier rules (YAML) applied on the target, e.g. to rewrite storage classes or image registries"
+            autosize
+            minRows={3}
+            styles={{ input: { fontFamily: "monospace" } }}
+            {...form.getInputProps("resourceModifiers")}
+          />
+
+          <Group justify="flex-end">
+            <Button variant="default" onClick={handleClose}>
+              Cancel
+            </Button>
+            <Button type="submit" loading={createMigration.isPending}>
+              Start Migration
+            </Button>
+          </Group>
+        </Stack>
+      </form>
+    </Modal>
+  );
+}
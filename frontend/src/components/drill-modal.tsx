@@ -0,0 +1,320 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import {
+  Modal,
+  TextInput,
+  NumberInput,
+  Select,
+  Switch,
+  Button,
+  Stack,
+  Group,
+  Text,
+  ActionIcon,
+  SimpleGrid,
+} from "@mantine/core";
+import { useForm } from "@mantine/form";
+import { notifications } from "@mantine/notifications";
+import { IconPlus, IconTrash } from "@tabler/icons-react";
+import { CronBuilder } from "@/components/cron-builder";
+import { useClusters } from "@/hooks/use-clusters";
+import { useClusterSchedules, useCreateDrill, useUpdateDrill } from "@/hooks/use-drills";
+import type { Drill, DrillRequest } from "@/lib/types";
+
+interface DrillModalProps {
+  opened: boolean;
+  onClose: () => void;
+  drill?: Drill | null;
+}
+
+interface NamespaceMapping {
+  source: string;
+  target: string;
+}
+
+interface FormValues {
+  name: string;
+  sourceClusterId: string;
+  schedule: string;
+  targetClusterId: string;
+  cron: string;
+  enabled: boolean;
+  minItems: number | string;
+  allowErrors: boolean;
+  requirePodsReady: boolean;
+  readyTimeout: string;
+  syncTimeout: string;
+  restoreTimeout: string;
+}
+
+const emptyValues: FormValues = {
+  name: "",
+  sourceClusterId: "",
+  schedule: "",
+  targetClusterId: "",
+  cron: "0 3 * * 0",
+  enabled: true,
+  minItems: 0,
+  allowErrors: false,
+  requirePodsReady: true,
+  readyTimeout: "",
+  syncTimeout: "",
+  restoreTimeout: "",
+};
+
+export function DrillModal({ opened, onClose, drill }: DrillModalProps) {
+  const { data: clusters } = useClusters();
+  const createDrill = useCreateDrill();
+  const updateDrill = useUpdateDrill();
+  const [mappings, setMappings] = useState<NamespaceMapping[]>([{ source: "", target: "" }]);
+
+  const form = useForm<FormValues>({
+    initialValues: emptyValues,
+    validate: {
+      name: (value) => (value ? null : "Name is required"),
+      sourceClusterId: (value) => (value ? null : "Source cluster is required"),
+      schedule: (value) => (value ? null : "Schedule is required"),
+      targetClusterId: (value) => (value ? null : "Target cluster is required"),
+      cron: (value) => (value ? null : "Cron expression is required"),
+    },
+  });
+
+  const { data: schedules } = useClusterSchedules(form.values.sourceClusterId);
+
+  useEffect(() => {
+    if (!opened) return;
+    if (drill) {
+      form.setValues({
+        name: drill.name,
+        sourceClusterId: drill.sourceClusterId,
+        schedule: drill.schedule,
+        targetClusterId: drill.targetClusterId,
+        cron: drill.cron,
+        enabled: drill.enabled,
+        minItems: drill.criteria.minItems || 0,
+        allowErrors: !!drill.criteria.allowErrors,
+        requirePodsReady: drill.criteria.requirePodsReady,
+        readyTimeout: drill.criteria.readyTimeout || "",
+        syncTimeout: drill.syncTimeout || "",
+        restoreTimeout: drill.restoreTimeout || "",
+      });
+      setMappings(
+        Object.entries(drill.namespaceMapping).map(([source, target]) => ({ source, target }))
+      );
+    } else {
+      form.setValues(emptyValues);
+      setMappings([{ source: "", target: "" }]);
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [opened, drill]);
+
+  const clusterOptions = (clusters || []).map((c) => ({
+    value: c.id,
+    label: `${c.name}${c.status !== "connected" ? ` (${c.status})` : ""}`,
+    disabled: c.status !== "connected",
+  }));
+
+  const scheduleOptions = (schedules || []).map((s) => s.name);
+  if (form.values.schedule && !scheduleOptions.includes(form.values.schedule)) {
+    scheduleOptions.push(form.values.schedule);
+  }
+
+  const updateMapping = (index: number, field: keyof NamespaceMapping, value: string) => {
+    const updated = [...mappings];
+    updated[index] = { ...updated[index], [field]: value };
+    setMappings(updated);
+  };
+
+  const handleClose = () => {
+    form.reset();
+    setMappings([{ source: "", target: "" }]);
+    onClose();
+  };
+
+  const handleSubmit = (values: FormValues) => {
+    const nsMapping: Record<string, string> = {};
+    mappings.forEach((m) => {
+      if (m.source && m.target) nsMapping[m.source] = m.target;
+    });
+    if (Object.keys(nsMapping).length === 0) {
+      notifications.show({
+        title: "Namespace mapping required",
+        message: "Map at least one namespace to a scratch namespace",
+        color: "red",
+      });
+      return;
+    }
+
+    const data: DrillRequest = {
+      name: values.name,
+      sourceClusterId: values.sourceClusterId,
+      schedule: values.schedule,
+      targetClusterId: values.targetClusterId,
+      namespaceMapping: nsMapping,
+      cron: values.cron,
+      enabled: values.enabled,
+      criteria: {
+        minItems: Number(values.minItems) || undefined,
+        allowErrors: values.allowErrors,
+        requirePodsReady: values.requirePodsReady,
+        readyTimeout: values.readyTimeout || undefined,
+      },
+      syncTimeout: values.syncTimeout || undefined,
+      restoreTimeout: values.restoreTimeout || undefined,
+    };
+
+    const callbacks = {
+      onSuccess: (saved: Drill) => {
+        notifications.show({
+          title: drill ? "Drill updated" : "Drill created",
+          message: `Restore drill "${saved.name}" saved`,
+          color: "green",
+        });
+        handleClose();
+      },
+      onError: (err: Error) => {
+        notifications.show({ title: "Failed to save drill", message: err.message, color: "red" });
+      },
+    };
+
+    if (drill) {
+      updateDrill.mutate({ id: drill.id, data }, callbacks);
+    } else {
+      createDrill.mutate(data, callbacks);
+    }
+  };
+
+  return (
+    <Modal
+      opened={opened}
+      onClose={handleClose}
+      title={drill ? `Edit Drill: ${drill.name}` : "New Restore Drill"}
+      size="lg"
+    >
+      <form onSubmit={form.onSubmit(handleSubmit)}>
+        <Stack gap="md">
+          <TextInput label="Name" required {...form.getInputProps("name")} />
+          <SimpleGrid cols={2}>
+            <Select
+              label="Source cluster"
+              placeholder="Cluster with the schedule"
+              data={clusterOptions}
+              required
+              {...form.getInputProps("sourceClusterId")}
+            />
+            <Select
+              label="Schedule"
+              placeholder="Velero schedule"
+              description="The latest completed backup is restored"
+              data={scheduleOptions}
+              searchable
+              required
+              disabled={!form.values.sourceClusterId}
+              {...form.getInputProps("schedule")}
+            />
+          </SimpleGrid>
+          <Select
+            label="Target cluster"
+            description="Other clusters need a storage location pointing to the same bucket and prefix"
+            data={clusterOptions}
+            required
+            {...form.getInputProps("targetClusterId")}
+          />
+
+          <Stack gap="xs">
+            <Group justify="space-between">
+              <div>
+                <Text size="sm" fw={500}>
+                  Namespaces
+                </Text>
+                <Text size="xs" c="dimmed">
+                  Each backed-up namespace is restored into a scratch namespace that is deleted afterwards
+                </Text>
+              </div>
+              <Button
+                size="xs"
+                variant="subtle"
+                leftSection={<IconPlus size={14} />}
+                onClick={() => setMappings([...mappings, { source: "", target: "" }])}
+              >
+                Add namespace
+              </Button>
+            </Group>
+            {mappings.map((mapping, index) => (
+              <Group key={index} gap="xs" wrap="nowrap">
+                <TextInput
+                  placeholder="namespace"
+                  value={mapping.source}
+                  onChange={(e) => updateMapping(index, "source", e.currentTarget.value)}
+                  style={{ flex: 1 }}
+                />
+                <TextInput
+                  placeholder="scratch namespace"
+                  value={mapping.target}
+                  onChange={(e) => updateMapping(index, "target", e.currentTarget.value)}
+                  style={{ flex: 1 }}
+                />
+                <ActionIcon
+                  color="red"
+                  variant="subtle"
+                  disabled={mappings.length === 1}
+                  onClick={() => setMappings(mappings.filter((_, i) => i !== index))}
+                >
+                  <IconTrash size={16} />
+                </ActionIcon>
+              </Group>
+            ))}
+          </Stack>
+
+          <CronBuilder
+            value={form.values.cron}
+            onChange={(value) => form.setFieldValue("cron", value)}
+            error={form.errors.cron as string}
+          />
+
+          <Text size="sm" fw={500}>
+            Success criteria
+          </Text>
+          <SimpleGrid cols={2}>
+            <NumberInput
+              label="Minimum items restored"
+              min={0}
+              {...form.getInputProps("minItems")}
+            />
+            <TextInput
+              label="Pod ready timeout"
+              placeholder="10m"
+              disabled={!form.values.requirePodsReady}
+              {...form.getInputProps("readyTimeout")}
+            />
+          </SimpleGrid>
+          <Group>
+            <Switch
+              label="Require restored pods to become ready"
+              {...form.getInputProps("requirePodsReady", { type: "checkbox" })}
+            />
+            <Switch
+              label="Allow restore errors"
+              {...form.getInputProps("allowErrors", { type: "checkbox" })}
+            />
+          </Group>
+          <SimpleGrid cols={2}>
+            <TextInput label="Sync timeout" placeholder="15m" {...form.getInputProps("syncTimeout")} />
+            <TextInput label="Restore timeout" placeholder="1h" {...form.getInputProps("restoreTimeout")} />
+          </SimpleGrid>
+          <Switch label="Enabled" {...form.getInputProps("enabled", { type: "checkbox" })} />
+
+          <Group justify="flex-end">
+            <Button variant="default" onClick={handleClose}>
+              Cancel
+            </Button>
+            <Button type="submit" loading={createDrill.isPending || updateDrill.isPending}>
+              {drill ? "Save" : "Create Drill"}
+            </Button>
+          </Group>
+        </Stack>
+      </form>
+    </Modal>
+  );
+}
@@ -8,6 +8,8 @@ import {
   IconCalendarEvent,
   IconSettings,
   IconServer,
+  IconTransfer,
+  IconShieldCheck,
 } from "@tabler/icons-react";
 import { usePathname } from "next/navigation";
 import Link from "next/link";
@@ -18,6 +20,8 @@ const links = [
   { href: "/backups", label: "Backups", icon: IconDatabaseExport, minRole: null },
   { href: "/restores", label: "Restores", icon: IconDatabaseImport, minRole: null },
   { href: "/schedules", label: "Schedules", icon: IconCalendarEvent, minRole: null },
+  { href: "/migrations", label: "Migrations", icon: IconTransfer, minRole: null },
+  { href: "/drills", label: "Restore Drills", icon: IconShieldCheck, minRole: null },
   { href: "/settings", label: "Settings", icon: IconSettings, minRole: "admin" as const },
   { href: "/clusters", label: "Clusters", icon: IconServer, minRole: "admin" as const },
 ];
@@ -0,0 +1,42 @@
+"use client";
+
+import { Paper, Group, Stack, Text, Box } from "@mantine/core";
+import { notifications } from "@mantine/notifications";
+import { IconLock } from "@tabler/icons-react";
+import { useQueryClient } from "@tanstack/react-query";
+import { ChangePasswordForm } from "@/components/change-password-form";
+import { useAuthStore } from "@/lib/auth";
+
+export function PasswordCard() {
+  const { username, setToken } = useAuthStore();
+  const queryClient = useQueryClient();
+
+  if (!username) return null;
+
+  return (
+    <Paper p="md" radius="md" withBorder>
+      <Stack gap="md">
+        <Group gap="xs">
+          <IconLock size={20} />
+          <Text fw={600}>Password</Text>
+        </Group>
+        <Text size="sm" c="dimmed">
+          Changing your password signs you out everywhere else.
+        </Text>
+        <Box maw={400}>
+          <ChangePasswordForm
+            username={username}
+            onChanged={(res) => {
+              setToken(res.token);
+              queryClient.invalidateQueries({ queryKey: ["sessions"] });
+              notifications.show({ title: "Password changed", message: username, color: "green" });
+            }}
+            onError={(message) =>
+              notifications.show({ title: "Failed to change password", message, color: "red" })
+            }
+          />
+        </Box>
+      </Stack>
+    </Paper>
+  );
+}
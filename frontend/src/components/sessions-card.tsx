@@ -0,0 +1,180 @@
+"use client";
+
+import { useState } from "react";
+import {
+  Paper,
+  Group,
+  Stack,
+  Text,
+  Button,
+  TextInput,
+  Table,
+  Badge,
+  ActionIcon,
+  Tooltip,
+} from "@mantine/core";
+import { notifications } from "@mantine/notifications";
+import { IconDevices, IconLogout, IconUserOff } from "@tabler/icons-react";
+import { useSessions, useRevokeSession, useRevokeUserSessions } from "@/hooks/use-sessions";
+import { useAuthStore, hasRole } from "@/lib/auth";
+import { formatDate, timeAgo } from "@/lib/utils";
+import type { LoginSession } from "@/lib/types";
+
+export function SessionsCard() {
+  const { role } = useAuthStore();
+  const isAdmin = hasRole(role, "admin");
+  const { data: sessions } = useSessions();
+  const revokeMutation = useRevokeSession();
+  const revokeUserMutation = useRevokeUserSessions();
+  const [offboard, setOffboard] = useState("");
+
+  const handleRevoke = async (s: LoginSession) => {
+    try {
+      await revokeMutation.mutateAsync(s.id);
+      notifications.show({ title: "Session revoked", message: s.user.username, color: "green" });
+    } catch (err) {
+      notifications.show({
+        title: "Failed to revoke session",
+        message: (err as Error).message,
+        color: "red",
+      });
+    }
+  };
+
+  const handleRevokeUser = async (username: string) => {
+    try {
+      const res = await revokeUserMutation.mutateAsync(username);
+      notifications.show({
+        title: `Signed out ${username}`,
+        message: `${res.sessions} session(s) ended, ${res.tokens} personal token(s) revoked`,
+        color: "green",
+      });
+      setOffboard("");
+    } catch (err) {
+      notifications.show({
+        title: "Failed to revoke sessions",
+        message: (err as Error).message,
+        color: "red",
+      });
+    }
+  };
+
+  return (
+    <Paper p="md" radius="md" withBorder>
+      <Stack gap="md">
+        <Group gap="xs">
+          <IconDevices size={20} />
+          <Text fw={600}>Sessions</Text>
+        </Group>
+        <Text size="sm" c="dimmed">
+          {isAdmin
+            ? "Everyone signed in to the dashboard. Revoking a session signs it out within seconds."
+            : "Browsers you are signed in on. Revoking a session signs it out within seconds."}
+        </Text>
+
+        {isAdmin && (
+          <Group align="flex-end">
+            <TextInput
+              label="Sign out a user everywhere"
+              description="Ends all their sessions and revokes their personal API tokens"
+              placeholder="username"
+              value={offboard}
+              onChange={(e) => setOffboard(e.currentTarget.value)}
+              miw={280}
+            />
+            <Button
+              color="red"
+              variant="light"
+              leftSection={<IconUserOff size={16} />}
+              onClick={() => handleRevokeUser(offboard.trim())}
+              loading={revokeUserMutation.isPending}
+              disabled={!offboard.trim()}
+            >
+              Revoke All
+            </Button>
+          </Group>
+        )}
+
+        <Table>
+          <Table.Thead>
+            <Table.Tr>
+              {isAdmin && <Table.Th>User</Table.Th>}
+              <Table.Th>Client</Table.Th>
+              <Table.Th>Signed In</Table.Th>
+              <Table.Th>Last Active</Table.Th>
+              <Table.Th>Expires</Table.Th>
+              <Table.Th />
+            </Table.Tr>
+          </Table.Thead>
+          <Table.Tbody>
+            {(sessions || []).map((s) => (
+              <Table.Tr key={s.id}>
+                {isAdmin && (
+                  <Table.Td>
+                    <Group gap={4}>
+                      <Text size="sm" fw={500}>
+                        {s.user.username}
+                      </Text>
+                      {!s.current && (
+                        <Tooltip label="Sign out everywhere">
+                          <ActionIcon
+                            variant="subtle"
+                            color="red"
+                            size="sm"
+                            onClick={() => handleRevokeUser(s.user.username)}
+                          >
+                            <IconUserOff size={14} />
+                          </ActionIcon>
+                        </Tooltip>
+                      )}
+                    </Group>
+                  </Table.Td>
+                )}
+                <Table.Td>
+                  <Group gap="xs" wrap="nowrap">
+                    <Text size="sm" lineClamp={1} maw={280} title={s.userAgent}>
+                      {s.userAgent || "Unknown"}
+                    </Text>
+                    {s.current && (
+                      <Badge variant="light" color="green" size="sm">
+                        this browser
+                      </Badge>
+                    )}
+                  </Group>
+                  {s.ip && (
+                    <Text size="xs" c="dimmed">
+                      {s.ip}
+                    </Text>
+                  )}
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">{formatDate(s.createdAt)}</Text>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">{timeAgo(s.refreshedAt)}</Text>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">{formatDate(s.expiresAt)}</Text>
+                </Table.Td>
+                <Table.Td>
+                  {!s.current && (
+                    <Tooltip label="Revoke">
+                      <ActionIcon
+                        variant="subtle"
+                        color="red"
+                        onClick={() => handleRevoke(s)}
+                        loading={revokeMutation.isPending && revokeMutation.variables === s.id}
+                      >
+                        <IconLogout size={16} />
+                      </ActionIcon>
+                    </Tooltip>
+                  )}
+                </Table.Td>
+              </Table.Tr>
+            ))}
+          </Table.Tbody>
+        </Table>
+      </Stack>
+    </Paper>
+  );
+}
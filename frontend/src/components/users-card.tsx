@@ -0,0 +1,301 @@
+"use client";
+
+import { useState } from "react";
+import {
+  Paper,
+  Group,
+  Stack,
+  Text,
+  Button,
+  TextInput,
+  PasswordInput,
+  Select,
+  Table,
+  Badge,
+  ActionIcon,
+  Tooltip,
+  Modal,
+} from "@mantine/core";
+import { notifications } from "@mantine/notifications";
+import { IconUsers, IconUserPlus, IconKey, IconTrash, IconLock, IconLockOpen } from "@tabler/icons-react";
+import {
+  useUsers,
+  useCreateUser,
+  useUpdateUser,
+  useResetUserPassword,
+  useDeleteUser,
+} from "@/hooks/use-users";
+import { ConfirmDelete } from "@/components/confirm-delete";
+import { useAuthStore } from "@/lib/auth";
+import { formatDate } from "@/lib/utils";
+import type { LocalUser } from "@/lib/types";
+
+const ROLE_OPTIONS = ["none", "viewer", "operator", "admin"].map((r) => ({ value: r, label: r }));
+
+export function UsersCard() {
+  const { username: me } = useAuthStore();
+  const { data: users } = useUsers();
+  const createMutation = useCreateUser();
+  const updateMutation = useUpdateUser();
+  const resetMutation = useResetUserPassword();
+  const deleteMutation = useDeleteUser();
+
+  const [newUsername, setNewUsername] = useState("");
+  const [newEmail, setNewEmail] = useState("");
+  const [newRole, setNewRole] = useState("viewer");
+  const [newPassword, setNewPassword] = useState("");
+  const [resetting, setResetting] = useState<LocalUser | null>(null);
+  const [resetPassword, setResetPassword] = useState("");
+  const [deleting, setDeleting] = useState<LocalUser | null>(null);
+
+  const fail = (title: string, err: unknown) =>
+    notifications.show({ title, message: (err as Error).message, color: "red" });
+
+  const handleCreate = async () => {
+    try {
+      const u = await createMutation.mutateAsync({
+        username: newUsername.trim(),
+        email: newEmail.trim() || undefined,
+        role: newRole,
+        password: newPassword,
+      });
+      notifications.show({
+        title: "User created",
+        message: `${u.username} must change the temporary password on first login`,
+        color: "green",
+      });
+      setNewUsername("");
+      setNewEmail("");
+      setNewPassword("");
+    } catch (err) {
+      fail("Failed to create user", err);
+    }
+  };
+
+  const handleUpdate = async (u: LocalUser, data: { role?: string; disabled?: boolean }) => {
+    try {
+      await updateMutation.mutateAsync({ username: u.username, data });
+      notifications.show({ title: "User updated", message: u.username, color: "green" });
+    } catch (err) {
+      fail("Failed to update user", err);
+    }
+  };
+
+  const handleReset = async () => {
+    if (!resetting) return;
+    try {
+      await resetMutation.mutateAsync({ username: resetting.username, password: resetPassword });
+      notifications.show({
+        title: "Password reset",
+        message: `${resetting.username} must choose a new password on next login`,
+        color: "green",
+      });
+      setResetting(null);
+      setResetPassword("");
+    } catch (err) {
+      fail("Failed to reset password", err);
+    }
+  };
+
+  const handleDelete = async () => {
+    if (!deleting) return;
+    try {
+      await deleteMutation.mutateAsync(deleting.username);
+      notifications.show({ title: "User deleted", message: deleting.username, color: "green" });
+      setDeleting(null);
+    } catch (err) {
+      fail("Failed to delete user", err);
+    }
+  };
+
+  return (
+    <Paper p="md" radius="md" withBorder>
+      <Stack gap="md">
+        <Group gap="xs">
+          <IconUsers size={20} />
+          <Text fw={600}>Users</Text>
+        </Group>
+        <Text size="sm" c="dimmed">
+          Local accounts for basic sign-in. New users and reset passwords are temporary: the user
+          picks their own on next login.
+        </Text>
+
+        <Group align="flex-end">
+          <TextInput
+            label="Username"
+            value={newUsername}
+            onChange={(e) => setNewUsername(e.currentTarget.value)}
+            w={180}
+          />
+          <TextInput
+            label="Email"
+            placeholder="optional"
+            value={newEmail}
+            onChange={(e) => setNewEmail(e.currentTarget.value)}
+            w={220}
+          />
+          <Select
+            label="Role"
+            data={ROLE_OPTIONS}
+            value={newRole}
+            onChange={(v) => v && setNewRole(v)}
+            allowDeselect={false}
+            w={130}
+          />
+          <PasswordInput
+            label="Temporary password"
+            value={newPassword}
+            onChange={(e) => setNewPassword(e.currentTarget.value)}
+            autoComplete="new-password"
+            w={200}
+          />
+          <Button
+            leftSection={<IconUserPlus size={16} />}
+            onClick={handleCreate}
+            loading={createMutation.isPending}
+            disabled={!newUsername.trim() || !newPassword}
+          >
+            Add User
+          </Button>
+        </Group>
+
+        <Table>
+          <Table.Thead>
+            <Table.Tr>
+              <Table.Th>User</Table.Th>
+              <Table.Th>Role</Table.Th>
+              <Table.Th>Status</Table.Th>
+              <Table.Th>Password Changed</Table.Th>
+              <Table.Th />
+            </Table.Tr>
+          </Table.Thead>
+          <Table.Tbody>
+            {(users || []).map((u) => (
+              <Table.Tr key={u.username}>
+                <Table.Td>
+                  <Group gap="xs">
+                    <Text size="sm" fw={500}>
+                      {u.username}
+                    </Text>
+                    {u.source === "seed" && (
+                      <Tooltip label="Imported from AUTH_USERS: disable it, or remove it there before deleting">
+                        <Badge variant="light" color="gray" size="sm">
+                          seed
+                        </Badge>
+                      </Tooltip>
+                    )}
+                    {u.source === "declarative" && (
+                      <Tooltip label={`Managed by ${u.definition}; edit it there`}>
+                        <Badge variant="light" color="gray" size="sm">
+                          managed
+                        </Badge>
+                      </Tooltip>
+                    )}
+                  </Group>
+                  {u.email && (
+                    <Text size="xs" c="dimmed">
+                      {u.email}
+                    </Text>
+                  )}
+                </Table.Td>
+                <Table.Td>
+                  <Select
+                    data={ROLE_OPTIONS}
+                    value={u.role}
+                    onChange={(v) => v && v !== u.role && handleUpdate(u, { role: v })}
+                    allowDeselect={false}
+                    disabled={u.username === me || u.source === "declarative"}
+                    size="xs"
+                    w={120}
+                  />
+                </Table.Td>
+                <Table.Td>
+                  <Group gap={4}>
+                    {u.disabled ? (
+                      <Badge variant="light" color="red" size="sm">
+                        disabled
+                      </Badge>
+                    ) : (
+                      <Badge variant="light" color="green" size="sm">
+                        active
+                      </Badge>
+                    )}
+                    {u.mustChangePassword && (
+                      <Badge variant="light" color="yellow" size="sm">
+                        password change pending
+                      </Badge>
+                    )}
+                  </Group>
+                </Table.Td>
+                <Table.Td>
+                  <Text size="sm">{u.passwordChangedAt ? formatDate(u.passwordChangedAt) : "—"}</Text>
+                </Table.Td>
+                <Table.Td>
+                  {u.username !== me && u.source !== "declarative" && (
+                    <Group gap={4} wrap="nowrap">
+                      <Tooltip label="Reset password">
+                        <ActionIcon variant="subtle" onClick={() => setResetting(u)}>
+                          <IconKey size={16} />
+                        </ActionIcon>
+                      </Tooltip>
+                      <Tooltip label={u.disabled ? "Enable" : "Disable and sign out"}>
+                        <ActionIcon
+                          variant="subtle"
+                          color={u.disabled ? "green" : "orange"}
+                          onClick={() => handleUpdate(u, { disabled: !u.disabled })}
+                        >
+                          {u.disabled ? <IconLockOpen size={16} /> : <IconLock size={16} />}
+                        </ActionIcon>
+                      </Tooltip>
+                      <Tooltip label="Delete">
+                        <ActionIcon variant="subtle" color="red" onClick={() => setDeleting(u)}>
+                          <IconTrash size={16} />
+                        </ActionIcon>
+                      </Tooltip>
+                    </Group>
+                  )}
+                </Table.Td>
+              </Table.Tr>
+            ))}
+          </Table.Tbody>
+        </Table>
+      </Stack>
+
+      <Modal
+        opened={resetting !== null}
+        onClose={() => setResetting(null)}
+        title={`Reset password of ${resetting?.username}`}
+        centered
+      >
+        <Stack>
+          <Text size="sm" c="dimmed">
+            Signs the user out. They must replace this password on next login.
+          </Text>
+          <PasswordInput
+            label="Temporary password"
+            value={resetPassword}
+            onChange={(e) => setResetPassword(e.currentTarget.value)}
+            autoComplete="new-password"
+          />
+          <Group justify="flex-end">
+            <Button variant="default" onClick={() => setResetting(null)}>
+              Cancel
+            </Button>
+            <Button onClick={handleReset} loading={resetMutation.isPending} disabled={!resetPassword}>
+              Reset
+            </Button>
+          </Group>
+        </Stack>
+      </Modal>
+
+      <ConfirmDelete
+        opened={deleting !== null}
+        onClose={() => setDeleting(null)}
+        onConfirm={handleDelete}
+        title="Delete user"
+        message={`Delete ${deleting?.username}? Their sessions end and their personal API tokens are revoked.`}
+        loading={deleteMutation.isPending}
+      />
+    </Paper>
+  );
+}
@@ -28,6 +28,9 @@ const EVENT_TYPES = [
   { value: "backup_partially_failed", label: "Backup Partially Failed" },
   { value: "restore_failed", label: "Restore Failed" },
   { value: "bsl_unavailable", label: "BSL Unavailable" },
+  { value: "cluster_disconnected", label: "Cluster Disconnected" },
+  { value: "cluster_recovered", label: "Cluster Recovered" },
+  { value: "drill_failed", label: "Restore Drill Failed" },
 ];
 
 interface WebhookConfigModalProps {
@@ -2,16 +2,20 @@ import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query";
 import {
   listClusters,
   getCluster,
+  getClusterAccess,
   createCluster,
   updateCluster,
   deleteCluster,
+  importClusters,
 } from "@/lib/api";
 import type {
   Cluster,
   CreateClusterRequest,
   UpdateClusterRequest,
+  ImportClustersRequest,
 } from "@/lib/types";
 import { useClusterStore } from "@/lib/cluster";
+import { hasRole, type Role } from "@/lib/auth";
 
 // List all clusters
 export function useClusters() {
@@ -23,6 +27,22 @@ export function useClusters() {
   });
 }
 
+// Namespaces the caller may select for role on the selected cluster. When
+// restricted, forms must list namespaces explicitly instead of defaulting to
+// the whole cluster.
+export function useNamespaceAccess(role: Exclude<Role, "none">) {
+  const selectedClusterId = useClusterStore((state) => state.selectedClusterId);
+  const { data } = useQuery({
+    queryKey: ["cluster-access", selectedClusterId],
+    queryFn: () => getClusterAccess(selectedClusterId || undefined),
+    enabled: !!selectedClusterId,
+    staleTime: 60000,
+  });
+
+  const restricted = !!data && !hasRole(data.role as Role, role);
+  return { restricted, namespaces: data?.namespaces[role] ?? [] };
+}
+
 // Get single cluster
 export function useCluster(id: string) {
   return useQuery({
@@ -53,6 +73,18 @@ export function useCreateCluster() {
   });
 }
 
+// Import several kubeconfig contexts as clusters
+export function useImportClusters() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (data: ImportClustersRequest) => importClusters(data),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ["clusters"] });
+    },
+  });
+}
+
 // Update cluster mutation
 export function useUpdateCluster() {
   const queryClient = useQueryClient();
@@ -104,3 +136,12 @@ export function useDeleteCluster() {
     },
   });
 }
+
+// Whether the selected cluster's identity may perform verb on a Velero
+// resource. Unknown permissions (preflight not run yet) count as allowed.
+export function useClusterPermission(resource: string, verb: string) {
+  const selectedClusterId = useClusterStore((state) => state.selectedClusterId);
+  const { data: clusters } = useClusters();
+  const cluster = clusters?.find((c) => c.id === selectedClusterId);
+  return cluster?.permissions?.[resource]?.[verb] ?? true;
+}
@@ -0,0 +1,75 @@
+"use client";
+
+import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query";
+import {
+  listDrills,
+  listDrillRuns,
+  createDrill,
+  updateDrill,
+  deleteDrill,
+  runDrill,
+  listSchedules,
+} from "@/lib/api";
+import type { DrillRequest } from "@/lib/types";
+
+// Drills span two clusters, so they are not keyed by the selected cluster
+export function useDrills() {
+  return useQuery({
+    queryKey: ["drills"],
+    queryFn: listDrills,
+    refetchInterval: 30000,
+  });
+}
+
+export function useDrillRuns(id: string) {
+  return useQuery({
+    queryKey: ["drills", id, "runs"],
+    queryFn: () => listDrillRuns(id),
+    enabled: !!id,
+  });
+}
+
+// Schedules of a specific cluster, independent of the selected one
+export function useClusterSchedules(clusterId: string) {
+  return useQuery({
+    queryKey: ["schedules", clusterId],
+    queryFn: () => listSchedules(clusterId),
+    enabled: !!clusterId,
+  });
+}
+
+export function useCreateDrill() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (data: DrillRequest) => createDrill(data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["drills"] }),
+  });
+}
+
+export function useUpdateDrill() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: ({ id, data }: { id: string; data: DrillRequest }) => updateDrill(id, data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["drills"] }),
+  });
+}
+
+export function useDeleteDrill() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (id: string) => deleteDrill(id),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["drills"] }),
+  });
+}
+
+export function useRunDrill() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (id: string) => runDrill(id),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["drills"] }),
+  });
+}
@@ -0,0 +1,32 @@
+"use client";
+
+import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query";
+import { listMigrations, createMigration, cancelMigration } from "@/lib/api";
+import type { CreateMigrationRequest } from "@/lib/types";
+
+// Migrations span two clusters, so they are not keyed by the selected cluster
+export function useMigrations() {
+  return useQuery({
+    queryKey: ["migrations"],
+    queryFn: listMigrations,
+    refetchInterval: 30000,
+  });
+}
+
+export function useCreateMigration() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (data: CreateMigrationRequest) => createMigration(data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["migrations"] }),
+  });
+}
+
+export function useCancelMigration() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: (id: string) => cancelMigration(id),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["migrations"] }),
+  });
+}
@@ -1,7 +1,16 @@
 "use client";
 
 import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query";
-import { listRestores, getRestore, createRestore, deleteRestore, listSharedBackups, createCrossClusterRestore } from "@/lib/api";
+import {
+  listRestores,
+  getRestore,
+  createRestore,
+  deleteRestore,
+  listSharedBackups,
+  createCrossClusterRestore,
+  listBackups,
+  listBackupLocations,
+} from "@/lib/api";
 import type { CreateRestoreRequest, CrossClusterRestoreRequest } from "@/lib/types";
 import { useClusterStore } from "@/lib/cluster";
 
@@ -60,6 +69,23 @@ export function useSharedBackups() {
   });
 }
 
+// Backups and BSLs of a specific cluster, independent of the selected one
+export function useClusterBackups(clusterId: string) {
+  return useQuery({
+    queryKey: ["backups", clusterId],
+    queryFn: () => listBackups(clusterId),
+    enabled: !!clusterId,
+  });
+}
+
+export function useClusterBackupLocations(clusterId: string) {
+  return useQuery({
+    queryKey: ["backup-locations", clusterId],
+    queryFn: () => listBackupLocations(clusterId),
+    enabled: !!clusterId,
+  });
+}
+
 export function useCreateCrossClusterRestore() {
   const queryClient = useQueryClient();
 
@@ -69,6 +95,8 @@ export function useCreateCrossClusterRestore() {
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ["restores"] });
       queryClient.invalidateQueries({ queryKey: ["shared-backups"] });
+      queryClient.invalidateQueries({ queryKey: ["backup-locations"] });
+      queryClient.invalidateQueries({ queryKey: ["migrations"] });
     },
   });
 }
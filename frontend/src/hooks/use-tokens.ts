@@ -0,0 +1,28 @@
+"use client";
+
+import { useQuery, useMutation, useQueryClient } from "@tanstack/react-query";
+import { listApiTokens, createApiToken, revokeApiToken } from "@/lib/api";
+import type { CreateApiTokenRequest } from "@/lib/types";
+
+export function useApiTokens() {
+  return useQuery({
+    queryKey: ["api-tokens"],
+    queryFn: () => listApiTokens(),
+  });
+}
+
+export function useCreateApiToken() {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: (data: CreateApiTokenRequest) => createApiToken(data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["api-tokens"] }),
+  });
+}
+
+export function useRevokeApiToken() {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: (id: string) => revokeApiToken(id),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: ["api-tokens"] }),
+  });
+}
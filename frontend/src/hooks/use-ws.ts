@@ -69,6 +69,15 @@ export function useWebSocket() {
           });
           queryClient.invalidateQueries({ queryKey: ["dashboard", "all"] });
           break;
+        case "cluster":
+          queryClient.invalidateQueries({ queryKey: ["clusters"] });
+          break;
+        case "migration":
+          queryClient.invalidateQueries({ queryKey: ["migrations"] });
+          break;
+        case "drill":
+          queryClient.invalidateQueries({ queryKey: ["drills"] });
+          break;
       }
 
       const clusterLabel = getClusterLabel(event.clusterId, clusters);
@@ -117,6 +126,38 @@ export function useWebSocket() {
         }
       }
 
+      // Migration notifications
+      if (event.type === "migration" && event.action === "modified") {
+        const job = event.resource as { name: string; phase: string; message?: string };
+        if (job.phase === "Succeeded") {
+          notifications.show({
+            title: "Migration completed",
+            message: `Migration "${job.name}" completed successfully`,
+            color: "green",
+          });
+        } else if (job.phase === "Failed") {
+          notifications.show({
+            title: "Migration failed",
+            message: `Migration "${job.name}" failed: ${job.message || "unknown error"}`,
+            color: "red",
+            autoClose: 10000,
+          });
+        }
+      }
+
+      // Restore drill failures (passes are routine and only shown in the table)
+      if (event.type === "drill" && event.action === "modified") {
+        const run = event.resource as { drillName: string; phase: string; message?: string };
+        if (run.phase === "Failed") {
+          notifications.show({
+            title: "Restore drill failed",
+            message: `Drill "${run.drillName}" failed: ${run.message || "unknown error"}`,
+            color: "red",
+            autoClose: 10000,
+          });
+        }
+      }
+
       // BSL health notifications (show when a storage location becomes unavailable)
       if (event.type === "bsl" && event.action === "modified") {
         const bsl = event.resource as { name: string; phase: string };
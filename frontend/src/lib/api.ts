@@ -1,4 +1,14 @@
 import type {
+  AccessInfo,
+  ApiToken,
+  CreateApiTokenRequest,
+  CreateApiTokenResponse,
+  ClusterAccess,
+  LoginSession,
+  RevokeUserSessionsResponse,
+  LocalUser,
+  CreateLocalUserRequest,
+  UpdateLocalUserRequest,
   Backup,
   Restore,
   Schedule,
@@ -15,13 +25,23 @@ import type {
   Cluster,
   CreateClusterRequest,
   UpdateClusterRequest,
+  KubeconfigContext,
+  ImportClustersRequest,
+  ImportResult,
   WebhookConfig,
   CreateWebhookRequest,
   UpdateWebhookRequest,
   CrossClusterBackup,
   CrossClusterRestoreRequest,
   UpdateScheduleRequest,
+  BundleImportReport,
+  MigrationJob,
+  CreateMigrationRequest,
+  Drill,
+  DrillRequest,
+  DrillRun,
 } from "./types";
+import { useAuthStore } from "./auth";
 
 const API_BASE = process.env.NEXT_PUBLIC_API_URL || "";
 
@@ -30,10 +50,53 @@ function getToken(): string | null {
   return localStorage.getItem("velero_token");
 }
 
-async function fetchJSON<T>(path: string, init?: RequestInit): Promise<T> {
+interface SessionResponse {
+  token: string;
+  username: string;
+  role: string;
+}
+
+// Endpoints that answer 401 for bad credentials rather than an expired
+// access token.
+const SESSION_PATHS = ["/auth/login", "/auth/password", "/auth/refresh", "/auth/logout"];
+
+let refreshing: Promise<boolean> | null = null;
+
+// refreshSession trades the HttpOnly refresh cookie for a new access token.
+// Concurrent callers share one request, since every refresh rotates the
+// cookie.
+export function refreshSession(): Promise<boolean> {
+  if (!refreshing) {
+    refreshing = fetch(`${API_BASE}/api/auth/refresh`, { method: "POST", credentials: "include" })
+      .then(async (res) => {
+        if (!res.ok) return false;
+        const session: SessionResponse = await res.json();
+        // Keep the effective role the auth guard resolved; only the token
+        // changes.
+        useAuthStore.getState().setToken(session.token);
+        return true;
+      })
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+}
+
+function sessionExpired(): never {
+  if (typeof window !== "undefined") {
+    useAuthStore.getState().logout();
+    window.location.href = "/login";
+  }
+  throw new Error("Session expired");
+}
+
+// authFetch sends an API request with the access token, refreshing it once
+// when the server rejects it.
+async function authFetch(path: string, init?: RequestInit, retry = true): Promise<Response> {
   const token = getToken();
   const headers: Record<string, string> = {
-    "Content-Type": "application/json",
     ...((init?.headers as Record<string, string>) || {}),
   };
   if (token && token !== "none") {
@@ -43,17 +106,26 @@ async function fetchJSON<T>(path: string, init?: RequestInit): Promise<T> {
   const res = await fetch(`${API_BASE}/api${path}`, {
     ...init,
     headers,
+    credentials: "include",
   });
 
-  if (res.status === 401) {
-    if (typeof window !== "undefined") {
-      localStorage.removeItem("velero_token");
-      localStorage.removeItem("velero_username");
-      localStorage.removeItem("velero_role");
-      window.location.href = "/login";
+  if (res.status === 401 && !SESSION_PATHS.includes(path)) {
+    if (retry && token && token !== "none" && (await refreshSession())) {
+      return authFetch(path, init, false);
     }
-    throw new Error("Session expired");
+    sessionExpired();
   }
+  return res;
+}
+
+async function fetchJSON<T>(path: string, init?: RequestInit): Promise<T> {
+  const res = await authFetch(path, {
+    ...init,
+    headers: {
+      "Content-Type": "application/json",
+      ...((init?.headers as Record<string, string>) || {}),
+    },
+  });
 
   if (!res.ok) {
     const body = await res.json().catch(() => ({}));
@@ -74,23 +146,68 @@ export const getAuthConfig = () =>
   fetchJSON<{ mode: string }>("/auth/config");
 
 export const loginBasic = (username: string, password: string) =>
-  fetchJSON<{ token: string; username: string; role: string }>("/auth/login", {
+  fetchJSON<SessionResponse>("/auth/login", {
     method: "POST",
     body: JSON.stringify({ username, password }),
   });
 
+// loginKubernetes trades a Kubernetes bearer token, e.g. from
+// "kubectl create token", for a dashboard session.
+export const loginKubernetes = (token: string) =>
+  fetchJSON<SessionResponse>("/auth/login", {
+    method: "POST",
+    body: JSON.stringify({ token }),
+  });
+
+// PASSWORD_CHANGE_REQUIRED is the login error of users who must replace a
+// password an admin chose before signing in.
+export const PASSWORD_CHANGE_REQUIRED = "password change required";
+
+// changePassword replaces the caller's password and starts a new session.
+// It works before the first login too, when the current password is the
+// temporary one.
+export const changePassword = (username: string, currentPassword: string, newPassword: string) =>
+  fetchJSON<SessionResponse>("/auth/password", {
+    method: "POST",
+    body: JSON.stringify({ username, currentPassword, newPassword }),
+  });
+
+// logoutSession ends the server-side session; the caller clears local state.
+export const logoutSession = () =>
+  fetchJSON<{ message: string }>("/auth/logout", { method: "POST" }).catch(() => undefined);
+
 export const getMe = () =>
   fetchJSON<{ username: string; email: string; role: string }>("/auth/me");
 
+export const getAccess = () => fetchJSON<AccessInfo>("/auth/access");
+
 // Clusters
 export const listClusters = () => fetchJSON<Cluster[]>("/clusters");
 export const getCluster = (id: string) => fetchJSON<Cluster>(`/clusters/${id}`);
+export const getClusterAccess = (clusterId?: string) =>
+  fetchJSON<ClusterAccess>(addClusterParam("/clusters/access", clusterId));
 export const createCluster = (data: CreateClusterRequest) =>
   fetchJSON<Cluster>("/clusters", { method: "POST", body: JSON.stringify(data) });
 export const updateCluster = (id: string, data: UpdateClusterRequest) =>
   fetchJSON<Cluster>(`/clusters/${id}`, { method: "PATCH", body: JSON.stringify(data) });
 export const deleteCluster = (id: string) =>
   fetchJSON<{ message: string }>(`/clusters/${id}`, { method: "DELETE" });
+export const listKubeconfigContexts = (kubeconfig: string) =>
+  fetchJSON<KubeconfigContext[]>("/clusters/import/contexts", {
+    method: "POST",
+    body: JSON.stringify({ kubeconfig }),
+  });
+export const importClusters = (data: ImportClustersRequest) =>
+  fetchJSON<ImportResult[]>("/clusters/import", { method: "POST", body: JSON.stringify(data) });
+
+// Configuration bundle
+export const exportBundle = (passphrase: string) =>
+  fetchJSON<unknown>("/bundle/export", { method: "POST", body: JSON.stringify({ passphrase }) });
+export const importBundle = (bundle: unknown, passphrase: string, overwrite: boolean) =>
+  fetchJSON<BundleImportReport>("/bundle/import", {
+    method: "POST",
+    body: JSON.stringify({ bundle, passphrase, overwrite }),
+  });
 
 // Dashboard
 export const getDashboardStats = (clusterId?: string) =>
@@ -115,24 +232,7 @@ export const getBackupLogs = async (
   name: string,
   clusterId?: string
 ): Promise<string> => {
-  const token = getToken();
-  const headers: Record<string, string> = {};
-  if (token && token !== "none") {
-    headers["Authorization"] = `Bearer ${token}`;
-  }
-
-  const path = addClusterParam(`/backups/${name}/logs`, clusterId);
-  const res = await fetch(`${API_BASE}/api${path}`, { headers });
-
-  if (res.status === 401) {
-    if (typeof window !== "undefined") {
-      localStorage.removeItem("velero_token");
-      localStorage.removeItem("velero_username");
-      localStorage.removeItem("velero_role");
-      window.location.href = "/login";
-    }
-    throw new Error("Session expired");
-  }
+  const res = await authFetch(addClusterParam(`/backups/${name}/logs`, clusterId));
 
   if (!res.ok) {
     const text = await res.text().catch(() => "");
@@ -169,24 +269,7 @@ export const getRestoreLogs = async (
   name: string,
   clusterId?: string
 ): Promise<string> => {
-  const token = getToken();
-  const headers: Record<string, string> = {};
-  if (token && token !== "none") {
-    headers["Authorization"] = `Bearer ${token}`;
-  }
-
-  const path = addClusterParam(`/restores/${name}/logs`, clusterId);
-  const res = await fetch(`${API_BASE}/api${path}`, { headers });
-
-  if (res.status === 401) {
-    if (typeof window !== "undefined") {
-      localStorage.removeItem("velero_token");
-      localStorage.removeItem("velero_username");
-      localStorage.removeItem("velero_role");
-      window.location.href = "/login";
-    }
-    throw new Error("Session expired");
-  }
+  const res = await authFetch(addClusterParam(`/restores/${name}/logs`, clusterId));
 
   if (!res.ok) {
     const text = await res.text().catch(() => "");
@@ -312,11 +395,94 @@ export const testWebhook = (id: string) =>
     method: "POST",
   });
 
+// Sessions
+export const listSessions = (user?: string) =>
+  fetchJSON<LoginSession[]>(user ? `/sessions?user=${encodeURIComponent(user)}` : "/sessions");
+export const revokeSession = (id: string) =>
+  fetchJSON<{ message: string }>(`/sessions/${id}`, {
+    method: "DELETE",
+  });
+export const revokeUserSessions = (username: string) =>
+  fetchJSON<RevokeUserSessionsResponse>(`/users/${encodeURIComponent(username)}/sessions`, {
+    method: "DELETE",
+  });
+
+// Local users (basic auth mode)
+export const listUsers = () => fetchJSON<LocalUser[]>("/users");
+export const createUser = (data: CreateLocalUserRequest) =>
+  fetchJSON<LocalUser>("/users", {
+    method: "POST",
+    body: JSON.stringify(data),
+  });
+export const updateUser = (username: string, data: UpdateLocalUserRequest) =>
+  fetchJSON<LocalUser>(`/users/${encodeURIComponent(username)}`, {
+    method: "PATCH",
+    body: JSON.stringify(data),
+  });
+export const resetUserPassword = (username: string, password: string) =>
+  fetchJSON<LocalUser>(`/users/${encodeURIComponent(username)}/password`, {
+    method: "POST",
+    body: JSON.stringify({ password }),
+  });
+export const deleteUser = (username: string) =>
+  fetchJSON<{ message: string }>(`/users/${encodeURIComponent(username)}`, {
+    method: "DELETE",
+  });
+
+// API Tokens
+export const listApiTokens = () => fetchJSON<ApiToken[]>("/tokens");
+export const createApiToken = (data: CreateApiTokenRequest) =>
+  fetchJSON<CreateApiTokenResponse>("/tokens", {
+    method: "POST",
+    body: JSON.stringify(data),
+  });
+export const revokeApiToken = (id: string) =>
+  fetchJSON<{ message: string }>(`/tokens/${id}`, {
+    method: "DELETE",
+  });
+
 // Cross-Cluster
 export const listSharedBackups = () =>
   fetchJSON<CrossClusterBackup[]>("/backups/shared");
+// Restores that first provision a storage location on the target run as a
+// background job, which is returned instead of the restore.
 export const createCrossClusterRestore = (data: CrossClusterRestoreRequest) =>
-  fetchJSON<Restore>("/restores/cross-cluster", {
+  fetchJSON<Restore | MigrationJob>("/restores/cross-cluster", {
     method: "POST",
     body: JSON.stringify(data),
   });
+
+// Migrations
+export const listMigrations = () => fetchJSON<MigrationJob[]>("/migrations");
+export const getMigration = (id: string) => fetchJSON<MigrationJob>(`/migrations/${id}`);
+export const createMigration = (data: CreateMigrationRequest) =>
+  fetchJSON<MigrationJob>("/migrations", {
+    method: "POST",
+    body: JSON.stringify(data),
+  });
+export const cancelMigration = (id: string) =>
+  fetchJSON<{ message: string }>(`/migrations/${id}/cancel`, {
+    method: "POST",
+  });
+
+// Restore Drills
+export const listDrills = () => fetchJSON<Drill[]>("/drills");
+export const listDrillRuns = (id: string) => fetchJSON<DrillRun[]>(`/drills/${id}/runs`);
+export const createDrill = (data: DrillRequest) =>
+  fetchJSON<Drill>("/drills", {
+    method: "POST",
+    body: JSON.stringify(data),
+  });
+export const updateDrill = (id: string, data: DrillRequest) =>
+  fetchJSON<Drill>(`/drills/${id}`, {
+    method: "PUT",
+    body: JSON.stringify(data),
+  });
+export const deleteDrill = (id: string) =>
+  fetchJSON<{ message: string }>(`/drills/${id}`, {
+    method: "DELETE",
+  });
+export const runDrill = (id: string) =>
+  fetchJSON<DrillRun>(`/drills/${id}/run`, {
+    method: "POST",
+  });
@@ -1,7 +1,7 @@
 import { create } from "zustand";
 
-export type Role = "viewer" | "operator" | "admin";
-export type AuthMode = "none" | "basic" | "oidc";
+export type Role = "none" | "viewer" | "operator" | "admin";
+export type AuthMode = "none" | "basic" | "oidc" | "kubernetes" | "proxy" | "ldap";
 
 interface AuthState {
   token: string | null;
@@ -11,6 +11,8 @@ interface AuthState {
   isAuthenticated: boolean;
 
   setAuth: (token: string, username: string, role: Role) => void;
+  setToken: (token: string) => void;
+  setRole: (role: Role) => void;
   setAuthMode: (mode: AuthMode) => void;
   logout: () => void;
   initialize: () => void;
@@ -32,6 +34,20 @@ export const useAuthStore = create<AuthState>((set) => ({
     set({ token, username, role, isAuthenticated: true });
   },
 
+  setToken: (token) => {
+    if (typeof window !== "undefined") {
+      localStorage.setItem("velero_token", token);
+    }
+    set({ token });
+  },
+
+  setRole: (role) => {
+    if (typeof window !== "undefined") {
+      localStorage.setItem("velero_role", role);
+    }
+    set({ role });
+  },
+
   setAuthMode: (mode) => set({ authMode: mode }),
 
   logout: () => {
@@ -55,7 +71,19 @@ export const useAuthStore = create<AuthState>((set) => ({
 }));
 
 export function hasRole(userRole: Role | null, requiredRole: Role): boolean {
-  const levels: Record<Role, number> = { viewer: 1, operator: 2, admin: 3 };
+  const levels: Record<Role, number> = { none: 0, viewer: 1, operator: 2, admin: 3 };
   if (!userRole) return false;
   return levels[userRole] >= levels[requiredRole];
 }
+
+// tokenExpiry returns when a JWT expires, in milliseconds since the epoch,
+// or null when it carries no expiry.
+export function tokenExpiry(token: string | null): number | null {
+  if (!token || token === "none") return null;
+  try {
+    const payload = JSON.parse(atob(token.split(".")[1].replace(/-/g, "+").replace(/_/g, "/")));
+    return typeof payload.exp === "number" ? payload.exp * 1000 : null;
+  } catch {
+    return null;
+  }
+}
@@ -103,6 +103,7 @@ export interface CreateBackupRequest {
   ttl?: string;
   snapshotVolumes?: boolean;
   defaultVolumesToFsBackup?: boolean;
+  includeClusterResources?: boolean;
   labelSelector?: string;
 }
 
@@ -114,6 +115,7 @@ export interface CreateRestoreRequest {
   includedResources?: string[];
   excludedResources?: string[];
   restorePVs?: boolean;
+  includeClusterResources?: boolean;
   namespaceMapping?: Record<string, string>;
   existingResourcePolicy?: "none" | "update";
 }
@@ -130,6 +132,7 @@ export interface CreateScheduleRequest {
   ttl?: string;
   snapshotVolumes?: boolean;
   defaultVolumesToFsBackup?: boolean;
+  includeClusterResources?: boolean;
   labelSelector?: string;
   paused?: boolean;
 }
@@ -145,6 +148,7 @@ export interface UpdateScheduleRequest {
   ttl?: string;
   snapshotVolumes?: boolean;
   defaultVolumesToFsBackup?: boolean;
+  includeClusterResources?: boolean;
 }
 
 export interface CreateBackupStorageLocationRequest {
@@ -186,21 +190,66 @@ export interface UpdateVolumeSnapshotLocationRequest {
   config?: Record<string, string>; // Additional provider-specific config
 }
 
+export type ClusterStatus =
+  | "pending"
+  | "connecting"
+  | "connected"
+  | "degraded"
+  | "disconnected"
+  | "error";
+
+export interface ClusterStatusChange {
+  id: string;
+  name: string;
+  status: ClusterStatus;
+  previousStatus?: ClusterStatus;
+  statusMessage?: string;
+  timestamp: string;
+}
+
 export interface Cluster {
   id: string;
   name: string;
   namespace: string;
-  status: "connected" | "disconnected" | "error";
+  status: ClusterStatus;
   statusMessage?: string;
   isDefault: boolean;
+  labels?: Record<string, string>;
   createdAt: string;
   lastHealthCheck: string;
+  metadata?: ClusterMetadata;
+  capabilities?: VeleroCapabilities;
+  // resource -> verb -> allowed, from the cluster's RBAC preflight
+  permissions?: Record<string, Record<string, boolean>>;
+}
+
+export interface ClusterMetadata {
+  kubernetesVersion?: string;
+  veleroVersion?: string;
+  provider?: string;
+  nodeCount: number;
+  collectedAt: string;
+}
+
+export interface VeleroCapabilities {
+  backups: boolean;
+  restores: boolean;
+  schedules: boolean;
+  backupStorageLocations: boolean;
+  volumeSnapshotLocations: boolean;
+  deleteBackupRequests: boolean;
+  downloadRequests: boolean;
+  serverStatusRequests: boolean;
+  podVolumeBackups: boolean;
+  dataUploads: boolean;
+  dataDownloads: boolean;
 }
 
 export interface CreateClusterRequest {
   name: string;
-  namespace: string;
+  namespace?: string;
   setAsDefault: boolean;
+  labels?: Record<string, string>;
 
   // Auth Mode 1: Kubeconfig (traditional)
   kubeconfig?: string;
@@ -217,6 +266,7 @@ export interface UpdateClusterRequest {
   kubeconfig?: string;
   namespace?: string;
   setAsDefault?: boolean;
+  labels?: Record<string, string>;
   // Token-based auth (alternative to kubeconfig)
   apiServer?: string;
   token?: string;
@@ -224,10 +274,168 @@ export interface UpdateClusterRequest {
   insecureSkipTLS?: boolean;
 }
 
+export interface KubeconfigContext {
+  name: string;
+  cluster: string;
+  user: string;
+  server: string;
+  namespace?: string;
+  current: boolean;
+}
+
+export interface ImportContext {
+  context: string;
+  name?: string;
+  namespace?: string;
+  setAsDefault?: boolean;
+  labels?: Record<string, string>;
+}
+
+export interface ImportClustersRequest {
+  kubeconfig: string;
+  contexts: ImportContext[];
+}
+
+export interface ImportResult {
+  context: string;
+  cluster?: Cluster;
+  error?: string;
+}
+
+export interface BundleItemResult {
+  name: string;
+  action: "created" | "updated" | "skipped" | "failed";
+  reason?: string;
+}
+
+export interface BundleImportReport {
+  clusters: BundleItemResult[];
+  webhooks: BundleItemResult[];
+}
+
+export type MigrationPhase = "Pending" | "Running" | "Succeeded" | "Failed" | "Cancelled";
+
+export interface MigrationStep {
+  name: "backup" | "wait-backup" | "provision-location" | "wait-sync" | "restore" | "verify";
+  phase: MigrationPhase;
+  message?: string;
+  startedAt?: string;
+  completedAt?: string;
+}
+
+export interface MigrationSpec {
+  includedNamespaces: string[];
+  includedResources?: string[];
+  excludedResources?: string[];
+  storageLocation?: string;
+  snapshotVolumes?: boolean;
+  defaultVolumesToFsBackup?: boolean;
+  namespaceMapping?: Record<string, string>;
+  restorePVs?: boolean;
+  existingResourcePolicy?: "none" | "update";
+  resourceModifiers?: string;
+  backupTimeout?: string;
+  syncTimeout?: string;
+  restoreTimeout?: string;
+  readyTimeout?: string;
+}
+
+export interface MigrationJob {
+  id: string;
+  name: string;
+  sourceClusterId: string;
+  targetClusterId: string;
+  spec: MigrationSpec;
+  phase: MigrationPhase;
+  message?: string;
+  steps: MigrationStep[];
+  backupName?: string;
+  restoreName?: string;
+  createdBy?: string;
+  createdAt: string;
+  updatedAt: string;
+  // Set on cross-cluster restores of existing backups
+  restore?: CreateRestoreRequest;
+  provisionLocation?: ProvisionLocationRequest;
+}
+
+export interface CreateMigrationRequest extends MigrationSpec {
+  name?: string;
+  sourceClusterId: string;
+  targetClusterId: string;
+}
+
+// Restore Drills
+export type DrillRunPhase = "Running" | "Passed" | "Failed";
+
+export interface DrillCriteria {
+  minItems?: number;
+  allowErrors?: boolean;
+  requirePodsReady: boolean;
+  readyTimeout?: string;
+}
+
+export interface DrillCheck {
+  name: string;
+  passed: boolean;
+  message: string;
+}
+
+export interface DrillRun {
+  id: string;
+  drillId: string;
+  drillName: string;
+  sourceClusterId: string;
+  targetClusterId: string;
+  trigger: "schedule" | "manual";
+  triggeredBy?: string;
+  phase: DrillRunPhase;
+  message?: string;
+  backupName?: string;
+  restoreName?: string;
+  itemsRestored: number;
+  errors: number;
+  warnings: number;
+  checks: DrillCheck[];
+  cleanup?: string;
+  startedAt: string;
+  completedAt?: string;
+}
+
+export interface DrillRequest {
+  name: string;
+  sourceClusterId: string;
+  schedule: string;
+  targetClusterId: string;
+  namespaceMapping: Record<string, string>;
+  cron: string;
+  enabled: boolean;
+  criteria: DrillCriteria;
+  syncTimeout?: string;
+  restoreTimeout?: string;
+}
+
+export interface Drill extends DrillRequest {
+  id: string;
+  createdBy?: string;
+  createdAt: string;
+  updatedAt: string;
+  nextRun?: string;
+  running: boolean;
+  lastRun?: DrillRun;
+}
+
 export interface WSEvent {
-  type: "backup" | "restore" | "schedule" | "bsl";
-  action: "added" | "modified" | "deleted";
-  resource: Backup | Restore | Schedule | BackupStorageLocation;
+  type: "backup" | "restore" | "schedule" | "bsl" | "cluster" | "migration" | "drill";
+  action: "added" | "modified" | "deleted" | "status";
+  resource:
+    | Backup
+    | Restore
+    | Schedule
+    | BackupStorageLocation
+    | ClusterStatusChange
+    | MigrationJob
+    | DrillRun;
   clusterId?: string;
 }
 
@@ -274,7 +482,10 @@ export type NotificationEventType =
   | "backup_failed"
   | "backup_partially_failed"
   | "restore_failed"
-  | "bsl_unavailable";
+  | "bsl_unavailable"
+  | "cluster_disconnected"
+  | "cluster_recovered"
+  | "drill_failed";
 
 export interface WebhookConfig {
   id: string;
@@ -288,6 +499,8 @@ export interface WebhookConfig {
   lastSentAt?: string;
   lastStatus?: string;
   lastError?: string;
+  managed?: boolean; // defined by a labelled Secret/ConfigMap; read-only here
+  source?: string; // e.g. "Secret/ops-slack"
 }
 
 export interface CreateWebhookRequest {
@@ -312,7 +525,109 @@ export interface CrossClusterBackup extends Backup {
   sourceClusterName: string;
 }
 
+export interface ProvisionLocationRequest {
+  name?: string;
+  credential?: string;
+  credentialKey?: string;
+  syncTimeout?: string;
+}
+
 export interface CrossClusterRestoreRequest extends CreateRestoreRequest {
   sourceClusterId: string;
   targetClusterId: string;
+  provisionLocation?: ProvisionLocationRequest;
+}
+
+// Access
+export interface RoleBinding {
+  name: string;
+  role: "viewer" | "operator" | "admin";
+  subjects: { users?: string[]; groups?: string[] };
+  clusters: { ids?: string[]; selector?: string };
+  namespaces?: string[];
+}
+
+export interface AccessInfo {
+  username: string;
+  globalRole: string;
+  role: string;
+  bindings: RoleBinding[];
+}
+
+export interface ClusterAccess {
+  clusterId: string;
+  role: string;
+  namespaces: Record<"viewer" | "operator" | "admin", string[]>;
+}
+
+// API Tokens
+export type ApiTokenKind = "personal" | "service";
+
+export interface ApiToken {
+  id: string;
+  name: string;
+  kind: ApiTokenKind;
+  role: "viewer" | "operator" | "admin";
+  clusters?: string[];
+  owner: { username: string; email?: string; role: string };
+  createdBy: string;
+  createdAt: string;
+  expiresAt?: string;
+  lastUsedAt?: string;
+}
+
+export interface CreateApiTokenRequest {
+  name: string;
+  kind?: ApiTokenKind;
+  role: ApiToken["role"];
+  clusters?: string[];
+  expiresAt?: string;
+}
+
+export interface CreateApiTokenResponse {
+  token: ApiToken;
+  secret: string;
+}
+
+export interface LoginSession {
+  id: string;
+  user: { username: string; email?: string; role: string };
+  userAgent?: string;
+  ip?: string;
+  createdAt: string;
+  refreshedAt: string;
+  expiresAt: string;
+  current: boolean;
+}
+
+export interface RevokeUserSessionsResponse {
+  sessions: number;
+  tokens: number;
+}
+
+export interface LocalUser {
+  username: string;
+  email?: string;
+  role: string;
+  disabled: boolean;
+  mustChangePassword: boolean;
+  source: "seed" | "api" | "declarative";
+  definition?: string;
+  createdAt: string;
+  updatedAt: string;
+  passwordChangedAt?: string;
+}
+
+export interface CreateLocalUserRequest {
+  username: string;
+  email?: string;
+  role: string;
+  password: string;
+  mustChangePassword?: boolean;
+}
+
+export interface UpdateLocalUserRequest {
+  email?: string;
+  role?: string;
+  disabled?: boolean;
 }
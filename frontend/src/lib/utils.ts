@@ -26,6 +26,8 @@ export function phaseColor(
 ): "green" | "red" | "yellow" | "blue" | "gray" | "orange" {
   switch (phase) {
     case "Completed":
+    case "Succeeded":
+    case "Passed":
     case "Available":
     case "Enabled":
       return "green";
@@ -34,6 +36,7 @@ export function phaseColor(
     case "PartiallyFailed":
       return "orange";
     case "InProgress":
+    case "Running":
     case "New":
       return "blue";
     case "Deleting":
@@ -2,6 +2,8 @@ import type { WSEvent } from "./types";
 
 type EventCallback = (event: WSEvent) => void;
 
+const WS_PROTOCOL = "velero-dashboard";
+
 export class WebSocketClient {
   private ws: WebSocket | null = null;
   private url: string;
@@ -13,22 +15,23 @@ export class WebSocketClient {
   constructor(url?: string) {
     const wsProtocol = typeof window !== "undefined" && window.location.protocol === "https:" ? "wss:" : "ws:";
     const wsHost = typeof window !== "undefined" ? window.location.host : "localhost:8080";
-    const base = url || `${wsProtocol}//${wsHost}/ws`;
+    this.url = url || `${wsProtocol}//${wsHost}/ws`;
+  }
 
-    // Append auth token as query param (WebSocket doesn't support custom headers in browsers)
+  // Browsers can't set headers on a WebSocket, so the token travels as a
+  // subprotocol. It is read on every connect, so reconnecting after the
+  // server closes an expired session picks up a refreshed token.
+  private protocols(): string[] | undefined {
     const token = typeof window !== "undefined" ? localStorage.getItem("velero_token") : null;
-    if (token && token !== "none") {
-      this.url = `${base}?token=${encodeURIComponent(token)}`;
-    } else {
-      this.url = base;
-    }
+    if (!token || token === "none") return undefined;
+    return [WS_PROTOCOL, `bearer.${token}`];
   }
 
   connect() {
     if (this.ws?.readyState === WebSocket.OPEN) return;
 
     try {
-      this.ws = new WebSocket(this.url);
+      this.ws = new WebSocket(this.url, this.protocols());
 
       this.ws.onopen = () => {
         this.reconnectDelay = 2000;